@@ -1,15 +1,44 @@
 package main
 
 import (
+	"bikesRentalAPI/internal/analytics"
+	analyticshandler "bikesRentalAPI/internal/analytics/handlers"
+	analyticsrepository "bikesRentalAPI/internal/analytics/repository"
+	audithandler "bikesRentalAPI/internal/audit/handlers"
+	auditrepository "bikesRentalAPI/internal/audit/repository"
 	bikehandler "bikesRentalAPI/internal/bikes/handlers"
 	bikerepository "bikesRentalAPI/internal/bikes/repository"
 	"bikesRentalAPI/internal/database"
+	idempotencyrepository "bikesRentalAPI/internal/idempotency/repository"
+	"bikesRentalAPI/internal/locks"
+	"bikesRentalAPI/internal/payments"
+	paymenthandler "bikesRentalAPI/internal/payments/handlers"
+	paymentrepository "bikesRentalAPI/internal/payments/repository"
+	planhandler "bikesRentalAPI/internal/plans/handlers"
+	planrepository "bikesRentalAPI/internal/plans/repository"
+	privacyhandler "bikesRentalAPI/internal/privacy/handlers"
+	privacyrepository "bikesRentalAPI/internal/privacy/repository"
+	"bikesRentalAPI/internal/promotions"
+	promotionhandler "bikesRentalAPI/internal/promotions/handlers"
+	promotionrepository "bikesRentalAPI/internal/promotions/repository"
+	rebalancinghandler "bikesRentalAPI/internal/rebalancing/handlers"
+	rebalancingrepository "bikesRentalAPI/internal/rebalancing/repository"
+	"bikesRentalAPI/internal/receipts"
+	receipthandler "bikesRentalAPI/internal/receipts/handlers"
+	receiptrepository "bikesRentalAPI/internal/receipts/repository"
+	"bikesRentalAPI/internal/rentals"
 	rentalhanlder "bikesRentalAPI/internal/rentals/handlers"
 	rentalrepository "bikesRentalAPI/internal/rentals/repository"
 	"bikesRentalAPI/internal/router"
 	"bikesRentalAPI/internal/server"
+	stationhandler "bikesRentalAPI/internal/stations/handlers"
+	stationrepository "bikesRentalAPI/internal/stations/repository"
+	surgerepository "bikesRentalAPI/internal/surge/repository"
 	userhandler "bikesRentalAPI/internal/users/handlers"
 	userrepository "bikesRentalAPI/internal/users/repository"
+	wallethandler "bikesRentalAPI/internal/wallet/handlers"
+	walletrepository "bikesRentalAPI/internal/wallet/repository"
+	"context"
 	"flag"
 	"log"
 
@@ -78,12 +107,49 @@ func main() {
 	bikeRepository := bikerepository.New(dbService)
 	bikeHandler := bikehandler.New(bikeRepository)
 
-	rentalRepository := rentalrepository.New(dbService, userRepository, bikeRepository)
+	// Bike locks are simulated locally until a hardware integration is available. Simulated riders lock the bikes they unlocked
+	lockSimulator := locks.NewSimulator()
+	lockSimulator.AutoLock = true
+	lockController := locks.NewRetryController(lockSimulator, locks.ConfigFromEnv())
+
+	stationRepository := stationrepository.New(dbService)
+	stationHandler := stationhandler.New(stationRepository)
+	paymentRepository := paymentrepository.New(dbService)
+	paymentHandler := paymenthandler.New(paymentRepository)
+
+	// Payments are charged through an in-process fake provider until a payment service is integrated
+	paymentProvider := payments.NewFakeProvider()
+	walletRepository := walletrepository.New(dbService, paymentRepository, paymentProvider)
+	walletHandler := wallethandler.New(walletRepository)
+	receiptConfig := receipts.ConfigFromEnv()
+	receiptRepository := receiptrepository.New(dbService, receiptConfig)
+	receiptHandler := receipthandler.New(receiptRepository, receiptConfig.Issuer)
+	planRepository := planrepository.New(dbService, paymentRepository, paymentProvider)
+	planHandler := planhandler.New(planRepository)
+	promotionRepository := promotionrepository.New(dbService, walletRepository, promotions.ReferralCreditFromEnv())
+	promotionHandler := promotionhandler.New(promotionRepository)
+	privacyRepository := privacyrepository.New(dbService)
+	privacyHandler := privacyhandler.New(privacyRepository)
+
+	surgeRepository := surgerepository.New(dbService)
+	pauseConfig := rentals.PauseConfigFromEnv()
+	rentalRepository := rentalrepository.New(dbService, userRepository, bikeRepository, lockController, stationRepository, paymentRepository, paymentProvider, walletRepository, receiptRepository, planRepository, promotionRepository, surgeRepository, pauseConfig)
 	rentalHanlder := rentalhanlder.New(rentalRepository)
 
+	// Pauses over the maximum duration are ended in the background
+	go rentals.EnforceMaxPause(context.Background(), rentalRepository, pauseConfig.CheckInterval)
+	rebalancingRepository := rebalancingrepository.New(dbService)
+	rebalancingHandler := rebalancinghandler.New(rebalancingRepository)
+
+	idempotencyRepository := idempotencyrepository.New(dbService)
+	auditRepository := auditrepository.New(dbService)
+	auditHandler := audithandler.New(auditRepository)
+	analyticsRepository := analyticsrepository.New(dbService, analytics.CacheTTLFromEnv())
+	analyticsHandler := analyticshandler.New(analyticsRepository)
+
 	// Create a new router service and register routes
 	routerService := router.New()
-	handler := routerService.RegisterRoutes(userHandler, bikeHandler, rentalHanlder)
+	handler := routerService.RegisterRoutes(userHandler, bikeHandler, rentalHanlder, rebalancingHandler, stationHandler, paymentHandler, walletHandler, receiptHandler, planHandler, promotionHandler, privacyHandler, auditHandler, analyticsHandler, idempotencyRepository, auditRepository, userRepository)
 
 	server, err := server.NewServerBuilder().
 		WithHanlder(handler).
@@ -0,0 +1,24 @@
+package analytics
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// defaultCacheTTL is how long the admin statistics are served before being computed again
+const defaultCacheTTL = time.Minute
+
+// CacheTTLFromEnv reads how long the admin statistics are cached from ANALYTICS_CACHE_TTL, 1 minute as default
+func CacheTTLFromEnv() time.Duration {
+	value := os.Getenv("ANALYTICS_CACHE_TTL")
+	if value == "" {
+		return defaultCacheTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		log.Printf("invalid ANALYTICS_CACHE_TTL %q. Set to %v as default", value, defaultCacheTTL)
+		return defaultCacheTTL
+	}
+	return ttl
+}
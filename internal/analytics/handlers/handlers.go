@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/analytics/models"
+	"bikesRentalAPI/internal/analytics/repository"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/middlewares"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	// defaultRange is the range of the statistics when none is requested, up to now
+	defaultRange = 30 * 24 * time.Hour
+	// maxRange is the longest range statistics can be requested for
+	maxRange = 366 * 24 * time.Hour
+	// defaultLimit and maxLimit are the number of bikes and users in the rankings
+	defaultLimit = 10
+	maxLimit     = 100
+)
+
+// bikeReportColumns are the columns of the CSV bike report
+var bikeReportColumns = []string{"bike_id", "rentals", "service_minutes", "ridden_minutes", "downtime_minutes", "idle_minutes", "revenue", "deleted"}
+
+// Handler is the interface for analytics handlers
+type Handler interface {
+	GetStats(w http.ResponseWriter, req *http.Request)      // Get the statistics of the fleet and the rentals
+	GetBikeReport(w http.ResponseWriter, req *http.Request) // Export the rentals, time and revenue of every bike
+}
+
+type handler struct {
+	AnalyticsRepo repository.AnalyticsRepository
+}
+
+// New returns a new analytics handler
+func New(analyticsRepository repository.AnalyticsRepository) Handler {
+	return &handler{
+		AnalyticsRepo: analyticsRepository,
+	}
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// GetStats returns the bikes by state, the ongoing rentals and the statistics of the rentals started between the
+// 'from' and 'to' query parameters, the last 30 days as default: rentals and revenue per 'interval' (day or week),
+// average duration and cost, and the 'limit' most ridden bikes and most active users
+func (h *handler) GetStats(w http.ResponseWriter, req *http.Request) {
+	query, err := parseStatsQuery(req.URL.Query(), time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	stats, err := h.AnalyticsRepo.GetStats(query)
+	if err != nil {
+		log.Printf("Error getting stats: %v", err)
+		http.Error(w, "Error getting stats", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, stats)
+}
+
+// GetBikeReport streams, for every bike in the fleet during the range between the 'from' and 'to' query parameters,
+// the last 30 days as default, its rentals, revenue and the minutes it was ridden, idle and out of service.
+// The format is read from the 'format' query parameter, the URL extension (/bikes.csv) or the Accept header. CSV is the default
+func (h *handler) GetBikeReport(w http.ResponseWriter, req *http.Request) {
+	from, to, err := parseRange(req.URL.Query(), time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := reportFormat(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	buffered := bufio.NewWriter(w)
+	var writeBike func(*models.BikeReport) error
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", contentTypeCSV)
+		csvWriter := csv.NewWriter(buffered)
+		if err := csvWriter.Write(bikeReportColumns); err != nil {
+			log.Printf("Error writing bike report: %v", err)
+			return
+		}
+		writeBike = func(bike *models.BikeReport) error {
+			err := csvWriter.Write([]string{
+				strconv.FormatInt(bike.BikeID, 10),
+				strconv.FormatInt(bike.Rentals, 10),
+				strconv.FormatFloat(bike.ServiceMinutes, 'f', -1, 64),
+				strconv.FormatFloat(bike.RiddenMinutes, 'f', -1, 64),
+				strconv.FormatFloat(bike.DowntimeMinutes, 'f', -1, 64),
+				strconv.FormatFloat(bike.IdleMinutes, 'f', -1, 64),
+				strconv.FormatFloat(bike.Revenue, 'f', -1, 64),
+				strconv.FormatBool(bike.Deleted),
+			})
+			if err != nil {
+				return err
+			}
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	default:
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+		encoder := json.NewEncoder(buffered)
+		writeBike = func(bike *models.BikeReport) error {
+			return encoder.Encode(bike)
+		}
+	}
+	filename := fmt.Sprintf("bikes_%s_%s.%s", from.Format("20060102"), to.Format("20060102"), format)
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.WriteHeader(http.StatusOK)
+
+	// Rows are written as they are read from the database and flushed periodically
+	count := 0
+	err = h.AnalyticsRepo.ExportBikeReport(models.ReportQuery{From: from, To: to}, func(bike *models.BikeReport) error {
+		if err := writeBike(bike); err != nil {
+			return err
+		}
+		count++
+		if count%500 == 0 {
+			if err := buffered.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, the truncated body is the only signal left to the client
+		log.Printf("Error exporting bike report: %v", err)
+		return
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Printf("Error exporting bike report: %v", err)
+	}
+}
+
+// parseRange reads the 'from' and 'to' query parameters. The default range is the last 30 days and ends at the
+// end of the current minute, so the requests made within a minute share the cached statistics
+func parseRange(values url.Values, now time.Time) (time.Time, time.Time, error) {
+	to := now.Truncate(time.Minute).Add(time.Minute)
+	var err error
+	if raw := values.Get("to"); raw != "" {
+		if to, err = middlewares.ParseTime(raw, true); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+		}
+	}
+	from := to.Add(-defaultRange)
+	if raw := values.Get("from"); raw != "" {
+		if from, err = middlewares.ParseTime(raw, false); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+		}
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range: from must be before to")
+	}
+	return from, to, nil
+}
+
+// parseStatsQuery reads the range, the interval and the limit of the statistics
+func parseStatsQuery(values url.Values, now time.Time) (models.StatsQuery, error) {
+	query := models.StatsQuery{Interval: models.IntervalDay, Limit: defaultLimit}
+	var err error
+	if query.From, query.To, err = parseRange(values, now); err != nil {
+		return query, err
+	}
+	if query.To.Sub(query.From) > maxRange {
+		return query, fmt.Errorf("invalid range: at most %d days", int(maxRange.Hours()/24))
+	}
+	if raw := values.Get("interval"); raw != "" {
+		if raw != models.IntervalDay && raw != models.IntervalWeek {
+			return query, fmt.Errorf("invalid interval: must be %s or %s", models.IntervalDay, models.IntervalWeek)
+		}
+		query.Interval = raw
+	}
+	if raw := values.Get("limit"); raw != "" {
+		if query.Limit, err = strconv.Atoi(raw); err != nil || query.Limit < 1 || query.Limit > maxLimit {
+			return query, fmt.Errorf("invalid limit: must be between 1 and %d", maxLimit)
+		}
+	}
+	return query, nil
+}
+
+// reportFormat returns the requested report format
+func reportFormat(req *http.Request) (string, error) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format, _ = req.Context().Value(middleware.URLFormatCtxKey).(string)
+	}
+	if format == "" {
+		accept := req.Header.Get("Accept")
+		switch {
+		case strings.Contains(accept, contentTypeNDJSON), strings.Contains(accept, "application/ndjson"):
+			format = formatNDJSON
+		default:
+			format = formatCSV
+		}
+	}
+	switch format {
+	case formatCSV, formatNDJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported report format %q, use %s or %s", format, formatCSV, formatNDJSON)
+}
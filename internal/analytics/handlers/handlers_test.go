@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/analytics/models"
+	"bikesRentalAPI/internal/analytics/repository/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetBikeReport(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAnalyticsRepo := mocks.NewMockAnalyticsRepository(mockCtrl)
+	h := New(mockAnalyticsRepo)
+
+	from := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 9, 23, 59, 59, 999999999, time.UTC)
+	bikes := []*models.BikeReport{
+		{BikeID: 1, Rentals: 2, ServiceMinutes: 10080, RiddenMinutes: 40.5, IdleMinutes: 10039.5, Revenue: 3.5},
+		{BikeID: 3, ServiceMinutes: 1440, IdleMinutes: 1440, Deleted: true},
+	}
+	exportBikes := func() {
+		mockAnalyticsRepo.EXPECT().ExportBikeReport(models.ReportQuery{From: from, To: to}, gomock.Any()).
+			DoAndReturn(func(_ models.ReportQuery, fn func(*models.BikeReport) error) error {
+				for _, bike := range bikes {
+					if err := fn(bike); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+	}
+
+	testCases := []struct {
+		name                string
+		query               string
+		accept              string
+		mockCalls           func()
+		expectedHttpCode    int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "Success - CSV is the default format",
+			query:               "from=2024-06-03&to=2024-06-09",
+			mockCalls:           exportBikes,
+			expectedHttpCode:    http.StatusOK,
+			expectedContentType: contentTypeCSV,
+			expectedBody: "bike_id,rentals,service_minutes,ridden_minutes,downtime_minutes,idle_minutes,revenue,deleted\n" +
+				"1,2,10080,40.5,0,10039.5,3.5,false\n" +
+				"3,0,1440,0,0,1440,0,true\n",
+		},
+		{
+			name:                "Success - NDJSON is negotiated with the Accept header",
+			query:               "from=2024-06-03&to=2024-06-09",
+			accept:              "application/x-ndjson",
+			mockCalls:           exportBikes,
+			expectedHttpCode:    http.StatusOK,
+			expectedContentType: contentTypeNDJSON,
+		},
+		{
+			name:             "Failure - unsupported format",
+			query:            "from=2024-06-03&to=2024-06-09&format=xml",
+			expectedHttpCode: http.StatusNotAcceptable,
+		},
+		{
+			name:             "Failure - the range ends before it starts",
+			query:            "from=2024-06-09&to=2024-06-03",
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:             "Failure - invalid date",
+			query:            "from=yesterday",
+			expectedHttpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockCalls != nil {
+				tc.mockCalls()
+			}
+			req := httptest.NewRequest(http.MethodGet, "/admin/reports/bikes?"+tc.query, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rr := httptest.NewRecorder()
+			h.GetBikeReport(rr, req)
+
+			assert.Equal(t, tc.expectedHttpCode, rr.Code)
+			if tc.expectedHttpCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Contains(t, rr.Header().Get("Content-Disposition"), "bikes_20240603_20240609")
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, rr.Body.String())
+			}
+			if tc.expectedContentType == contentTypeNDJSON {
+				lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+				require.Len(t, lines, len(bikes))
+				var bike models.BikeReport
+				require.NoError(t, json.Unmarshal([]byte(lines[1]), &bike))
+				assert.Equal(t, *bikes[1], bike)
+			}
+		})
+	}
+}
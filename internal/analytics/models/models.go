@@ -0,0 +1,131 @@
+package models
+
+import "time"
+
+const (
+	// IntervalDay groups the rentals by the day they started
+	IntervalDay = "day"
+	// IntervalWeek groups the rentals by the week they started, weeks start on Monday
+	IntervalWeek = "week"
+)
+
+// StatsQuery is the range and grouping the statistics are computed for
+type StatsQuery struct {
+	// The range of the rentals, by the time they started
+	From time.Time
+	To   time.Time
+	// The length of the periods the rentals are grouped by, IntervalDay or IntervalWeek
+	Interval string
+	// The number of bikes and users in the rankings
+	Limit int
+}
+
+// Stats is an overview of the fleet and of the rentals over a range of time
+type Stats struct {
+	// The range of the rentals, by the time they started
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// The length of the periods the rentals are grouped by
+	Interval string `json:"interval" example:"day"`
+	// The bikes by state, now
+	Fleet FleetStats `json:"fleet"`
+	// The number of ongoing rentals, now
+	ActiveRentals int64 `json:"active_rentals" example:"12"`
+	// The rentals started in the range
+	Rentals RentalStats `json:"rentals"`
+	// The number of rentals and the revenue per period of the range, oldest first. Periods without rentals are left out
+	Periods []*PeriodStats `json:"periods"`
+	// The bikes ridden the largest share of the range
+	Utilization []*BikeUtilization `json:"utilization"`
+	// The users with the most rentals in the range
+	TopUsers []*TopUser `json:"top_users"`
+	// When the statistics were computed. They are cached for a short time
+	GeneratedAt time.Time `json:"generated_at"`
+} // @name Stats
+
+// FleetStats counts the bikes by state
+type FleetStats struct {
+	// The bikes not deleted
+	Total int64 `json:"total" example:"100"`
+	// The bikes that can be rented
+	Available int64 `json:"available" example:"80"`
+	// The bikes in an ongoing rental, paused or not
+	Rented int64 `json:"rented" example:"12"`
+	// The rented bikes whose rental is paused
+	Paused int64 `json:"paused" example:"2"`
+	// The bikes neither available nor rented, taken out of service
+	Unavailable int64 `json:"unavailable" example:"8"`
+	// The bikes docked at a station
+	Docked int64 `json:"docked" example:"40"`
+	// The deleted bikes
+	Deleted int64 `json:"deleted" example:"3"`
+} // @name FleetStats
+
+// RentalStats sums up the rentals started in a range
+type RentalStats struct {
+	// The rentals started, ended or not
+	Count int64 `json:"count" example:"340"`
+	// The rentals started that have ended
+	Ended int64 `json:"ended" example:"330"`
+	// The cost of the ended rentals after the adjustments made by the staff
+	Revenue float64 `json:"revenue" example:"1250.4"`
+	// The average duration in minutes and cost of the ended rentals
+	AverageDurationMinutes float64 `json:"average_duration_minutes" example:"18.5"`
+	AverageCost            float64 `json:"average_cost" example:"3.79"`
+} // @name RentalStats
+
+// PeriodStats sums up the rentals started in a day or a week
+type PeriodStats struct {
+	// The first day of the period
+	Period string `json:"period" example:"2024-06-03"`
+	// The rentals started in the period
+	Rentals int64 `json:"rentals" example:"48"`
+	// The cost of the ended rentals started in the period, after adjustments
+	Revenue float64 `json:"revenue" example:"180.25"`
+} // @name PeriodStats
+
+// BikeUtilization is the share of a range a bike was ridden
+type BikeUtilization struct {
+	BikeID int64 `json:"bike_id" example:"7"`
+	// The rentals of the bike overlapping the range
+	Rentals int64 `json:"rentals" example:"25"`
+	// The minutes of the range the bike was rented, paused included
+	RiddenMinutes float64 `json:"ridden_minutes" example:"512.5"`
+	// The ridden minutes over the minutes of the range, between 0 and 1
+	Utilization float64 `json:"utilization" example:"0.012"`
+} // @name BikeUtilization
+
+// TopUser is a user ranked by their rentals in a range
+type TopUser struct {
+	UserID int64  `json:"user_id" example:"2"`
+	Email  string `json:"email" example:"rider@example.com"`
+	// The rentals the user started in the range
+	Rentals int64 `json:"rentals" example:"31"`
+	// The cost of their ended rentals, after adjustments
+	Spent float64 `json:"spent" example:"104.2"`
+} // @name TopUser
+
+// ReportQuery is the range a report is computed for
+type ReportQuery struct {
+	From time.Time
+	To   time.Time
+}
+
+// BikeReport is what a bike earned and how it spent its time over a range
+type BikeReport struct {
+	BikeID int64 `json:"bike_id" example:"7"`
+	// The rentals of the bike started in the range
+	Rentals int64 `json:"rentals" example:"25"`
+	// The minutes of the range the bike was in the fleet, from when it was added until it was deleted
+	ServiceMinutes float64 `json:"service_minutes" example:"10080"`
+	// The minutes of the range the bike was rented, paused included
+	RiddenMinutes float64 `json:"ridden_minutes" example:"512.5"`
+	// The minutes of the range the bike was unavailable without being rented, e.g. under repair
+	DowntimeMinutes float64 `json:"downtime_minutes" example:"1440"`
+	// The minutes of the range the bike was in service but not rented
+	IdleMinutes float64 `json:"idle_minutes" example:"8127.5"`
+	// The cost of the ended rentals started in the range, after adjustments
+	Revenue float64 `json:"revenue" example:"98.3"`
+	// Whether the bike is deleted now
+	Deleted bool `json:"deleted" example:"false"`
+} // @name BikeReport
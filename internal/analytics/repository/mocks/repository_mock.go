@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/analytics/repository/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/analytics/repository/repository.go -destination=internal/analytics/repository/mocks/repository_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "bikesRentalAPI/internal/analytics/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAnalyticsRepository is a mock of AnalyticsRepository interface.
+type MockAnalyticsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnalyticsRepositoryMockRecorder
+}
+
+// MockAnalyticsRepositoryMockRecorder is the mock recorder for MockAnalyticsRepository.
+type MockAnalyticsRepositoryMockRecorder struct {
+	mock *MockAnalyticsRepository
+}
+
+// NewMockAnalyticsRepository creates a new mock instance.
+func NewMockAnalyticsRepository(ctrl *gomock.Controller) *MockAnalyticsRepository {
+	mock := &MockAnalyticsRepository{ctrl: ctrl}
+	mock.recorder = &MockAnalyticsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnalyticsRepository) EXPECT() *MockAnalyticsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ExportBikeReport mocks base method.
+func (m *MockAnalyticsRepository) ExportBikeReport(query models.ReportQuery, fn func(*models.BikeReport) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportBikeReport", query, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportBikeReport indicates an expected call of ExportBikeReport.
+func (mr *MockAnalyticsRepositoryMockRecorder) ExportBikeReport(query, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportBikeReport", reflect.TypeOf((*MockAnalyticsRepository)(nil).ExportBikeReport), query, fn)
+}
+
+// GetStats mocks base method.
+func (m *MockAnalyticsRepository) GetStats(query models.StatsQuery) (*models.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", query)
+	ret0, _ := ret[0].(*models.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockAnalyticsRepositoryMockRecorder) GetStats(query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockAnalyticsRepository)(nil).GetStats), query)
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/analytics/models"
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/helpers"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// periodColumns are the SQL expressions of the first day of the period a rental started in, by interval
+var periodColumns = map[string]string{
+	models.IntervalDay:  "date(start_time)",
+	models.IntervalWeek: "date(start_time, '-6 days', 'weekday 1')",
+}
+
+// rangedRentals selects the rentals started in the range with their revenue, the cost after adjustments of the ended ones
+const rangedRentals = `WITH ranged AS (
+	SELECT r.id, r.user_id, r.bike_id, r.start_time, r.end_time, r.duration_minutes,
+		CASE WHEN r.end_time IS NULL THEN NULL
+			ELSE r.cost + COALESCE((SELECT SUM(a.amount) FROM rental_adjustments a WHERE a.rental_id = r.id), 0) END AS revenue
+	FROM rentals r WHERE r.start_time >= ? AND r.start_time <= ?
+) `
+
+// bikeReport computes the report of every bike in the fleet during the range. The parameters are the start, the end and
+// the past end of the range, and now. Ongoing rentals count until now. Downtime follows the recorded availability changes
+// of the bikes: the time they were unavailable less the time they were rented
+const bikeReport = `WITH params AS (SELECT ? AS range_from, ? AS range_to, ? AS range_end, ? AS now),
+	bike_rentals AS (
+		SELECT r.bike_id,
+			SUM(r.start_time >= p.range_from) AS rentals,
+			SUM(MAX(julianday(MIN(COALESCE(r.end_time, p.now), p.range_end)) - julianday(MAX(r.start_time, p.range_from)), 0) * 1440) AS ridden,
+			SUM(CASE WHEN r.end_time IS NOT NULL AND r.start_time >= p.range_from
+				THEN r.cost + COALESCE((SELECT SUM(a.amount) FROM rental_adjustments a WHERE a.rental_id = r.id), 0) ELSE 0 END) AS revenue
+		FROM rentals r CROSS JOIN params p
+		WHERE r.start_time <= p.range_to AND (r.end_time IS NULL OR r.end_time >= p.range_from)
+		GROUP BY r.bike_id
+	),
+	availability AS (
+		SELECT c.bike_id, c.is_available, c.changed_at AS since,
+			LEAD(c.changed_at) OVER (PARTITION BY c.bike_id ORDER BY c.changed_at, c.id) AS until
+		FROM bike_availability_changes c
+	),
+	bike_unavailable AS (
+		SELECT a.bike_id,
+			SUM(MAX(julianday(MIN(COALESCE(a.until, p.range_end), p.range_end, COALESCE(b.deleted_at, p.range_end))) - julianday(MAX(a.since, p.range_from)), 0) * 1440) AS unavailable
+		FROM availability a CROSS JOIN params p
+		JOIN bikes b ON b.id = a.bike_id
+		WHERE a.is_available = 0 AND a.since <= p.range_end AND (a.until IS NULL OR a.until >= p.range_from)
+		GROUP BY a.bike_id
+	)
+	SELECT b.id, COALESCE(br.rentals, 0), COALESCE(br.ridden, 0), COALESCE(br.revenue, 0),
+		MAX(julianday(MIN(COALESCE(b.deleted_at, p.range_end), p.range_end)) - julianday(MAX(b.created_at, p.range_from)), 0) * 1440,
+		MAX(COALESCE(bu.unavailable, 0) - COALESCE(br.ridden, 0), 0),
+		b.deleted_at IS NOT NULL
+	FROM bikes b CROSS JOIN params p
+	LEFT JOIN bike_rentals br ON br.bike_id = b.id
+	LEFT JOIN bike_unavailable bu ON bu.bike_id = b.id
+	WHERE b.created_at <= p.range_end AND (b.deleted_at IS NULL OR b.deleted_at >= p.range_from)
+	ORDER BY b.id`
+
+type AnalyticsRepository interface {
+	GetStats(query models.StatsQuery) (*models.Stats, error)
+	ExportBikeReport(query models.ReportQuery, fn func(*models.BikeReport) error) error
+}
+
+// cachedStats are statistics kept until they expire
+type cachedStats struct {
+	stats     *models.Stats
+	expiresAt time.Time
+}
+
+type analyticsRepository struct {
+	db database.Database
+	// cacheTTL is how long computed statistics are served before being computed again
+	cacheTTL time.Duration
+	mu       sync.Mutex
+	cache    map[models.StatsQuery]cachedStats
+}
+
+// New initializes a new analytics repository caching the statistics for cacheTTL
+func New(db database.Database, cacheTTL time.Duration) AnalyticsRepository {
+	return &analyticsRepository{db: db, cacheTTL: cacheTTL, cache: make(map[models.StatsQuery]cachedStats)}
+}
+
+// GetStats returns the statistics of the fleet and of the rentals started in the range of the query.
+// They are computed at most once per cache TTL for the same query
+func (r *analyticsRepository) GetStats(query models.StatsQuery) (*models.Stats, error) {
+	query.From, query.To = query.From.UTC(), query.To.UTC()
+	now := time.Now().UTC()
+	r.mu.Lock()
+	cached, ok := r.cache[query]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.stats, nil
+	}
+
+	stats, err := r.computeStats(query, now)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, cached := range r.cache {
+		if !now.Before(cached.expiresAt) {
+			delete(r.cache, key)
+		}
+	}
+	r.cache[query] = cachedStats{stats: stats, expiresAt: now.Add(r.cacheTTL)}
+	return stats, nil
+}
+
+func (r *analyticsRepository) computeStats(query models.StatsQuery, now time.Time) (*models.Stats, error) {
+	periodColumn, ok := periodColumns[query.Interval]
+	if !ok {
+		return nil, fmt.Errorf("unknown interval %q", query.Interval)
+	}
+	stats := &models.Stats{
+		From:        query.From,
+		To:          query.To,
+		Interval:    query.Interval,
+		Periods:     make([]*models.PeriodStats, 0),
+		Utilization: make([]*models.BikeUtilization, 0),
+		TopUsers:    make([]*models.TopUser, 0),
+		GeneratedAt: now,
+	}
+
+	fleetQuery := `SELECT
+			COALESCE(SUM(b.deleted_at IS NULL), 0),
+			COALESCE(SUM(b.deleted_at IS NULL AND b.is_available = 1), 0),
+			COALESCE(SUM(b.deleted_at IS NULL AND r.id IS NOT NULL), 0),
+			COALESCE(SUM(b.deleted_at IS NULL AND p.id IS NOT NULL), 0),
+			COALESCE(SUM(b.deleted_at IS NULL AND b.is_available = 0 AND r.id IS NULL), 0),
+			COALESCE(SUM(b.deleted_at IS NULL AND b.station_id IS NOT NULL), 0),
+			COALESCE(SUM(b.deleted_at IS NOT NULL), 0)
+		FROM bikes b
+		LEFT JOIN rentals r ON r.bike_id = b.id AND r.end_time IS NULL
+		LEFT JOIN rental_pauses p ON p.rental_id = r.id AND p.ended_at IS NULL`
+	fleet := &stats.Fleet
+	if err := r.db.QueryRow(fleetQuery).Scan(&fleet.Total, &fleet.Available, &fleet.Rented, &fleet.Paused,
+		&fleet.Unavailable, &fleet.Docked, &fleet.Deleted); err != nil {
+		return nil, fmt.Errorf("failed to count bikes: %v", err)
+	}
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM rentals WHERE end_time IS NULL").Scan(&stats.ActiveRentals); err != nil {
+		return nil, fmt.Errorf("failed to count active rentals: %v", err)
+	}
+
+	rentalsQuery := rangedRentals + `SELECT COUNT(*), COUNT(end_time), COALESCE(SUM(revenue), 0),
+		COALESCE(AVG(CASE WHEN end_time IS NOT NULL THEN duration_minutes END), 0), COALESCE(AVG(revenue), 0)
+		FROM ranged`
+	rentals := &stats.Rentals
+	if err := r.db.QueryRow(rentalsQuery, query.From, query.To).Scan(&rentals.Count, &rentals.Ended, &rentals.Revenue,
+		&rentals.AverageDurationMinutes, &rentals.AverageCost); err != nil {
+		return nil, fmt.Errorf("failed to sum up rentals: %v", err)
+	}
+	rentals.Revenue = roundAmount(rentals.Revenue)
+	rentals.AverageCost = roundAmount(rentals.AverageCost)
+	rentals.AverageDurationMinutes = round(rentals.AverageDurationMinutes, 2)
+
+	periodsQuery := rangedRentals + fmt.Sprintf(`SELECT %s AS period, COUNT(*), COALESCE(SUM(revenue), 0)
+		FROM ranged GROUP BY period ORDER BY period`, periodColumn)
+	rows, err := r.db.Query(periodsQuery, query.From, query.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to group rentals by %s: %v", query.Interval, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period models.PeriodStats
+		if err := rows.Scan(&period.Period, &period.Rentals, &period.Revenue); err != nil {
+			return nil, err
+		}
+		period.Revenue = roundAmount(period.Revenue)
+		stats.Periods = append(stats.Periods, &period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Only the past of the range can be ridden. Ongoing rentals count until now
+	rangeEnd := query.To
+	if now.Before(rangeEnd) {
+		rangeEnd = now
+	}
+	if rangeMinutes := rangeEnd.Sub(query.From).Minutes(); rangeMinutes > 0 {
+		utilizationQuery := `SELECT bike_id, COUNT(*),
+				SUM((julianday(MIN(COALESCE(end_time, ?), ?)) - julianday(MAX(start_time, ?))) * 1440) AS minutes
+			FROM rentals WHERE start_time <= ? AND (end_time IS NULL OR end_time >= ?)
+			GROUP BY bike_id ORDER BY minutes DESC, bike_id LIMIT ?`
+		rows, err := r.db.Query(utilizationQuery, now, rangeEnd, query.From, rangeEnd, query.From, query.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute bike utilization: %v", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var bike models.BikeUtilization
+			if err := rows.Scan(&bike.BikeID, &bike.Rentals, &bike.RiddenMinutes); err != nil {
+				return nil, err
+			}
+			bike.Utilization = round(bike.RiddenMinutes/rangeMinutes, 4)
+			bike.RiddenMinutes = round(bike.RiddenMinutes, 2)
+			stats.Utilization = append(stats.Utilization, &bike)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	topUsersQuery := rangedRentals + `SELECT r.user_id, COALESCE(u.email, ''), COUNT(*), COALESCE(SUM(r.revenue), 0) AS spent
+		FROM ranged r LEFT JOIN users u ON u.id = r.user_id
+		GROUP BY r.user_id ORDER BY COUNT(*) DESC, spent DESC, r.user_id LIMIT ?`
+	rows, err = r.db.Query(topUsersQuery, query.From, query.To, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank users: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user models.TopUser
+		if err := rows.Scan(&user.UserID, &user.Email, &user.Rentals, &user.Spent); err != nil {
+			return nil, err
+		}
+		user.Spent = roundAmount(user.Spent)
+		stats.TopUsers = append(stats.TopUsers, &user)
+	}
+	return stats, rows.Err()
+}
+
+// ExportBikeReport calls fn with the report of every bike in the fleet during the range of the query, ordered by id,
+// without loading the whole report in memory
+func (r *analyticsRepository) ExportBikeReport(query models.ReportQuery, fn func(*models.BikeReport) error) error {
+	from, to, now := query.From.UTC(), query.To.UTC(), time.Now().UTC()
+	// Only the past of the range can be ridden, idle or out of service
+	rangeEnd := to
+	if now.Before(rangeEnd) {
+		rangeEnd = now
+	}
+	rows, err := r.db.Query(bikeReport, from, to, rangeEnd, now)
+	if err != nil {
+		return fmt.Errorf("failed to compute bike report: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bike models.BikeReport
+		if err := rows.Scan(&bike.BikeID, &bike.Rentals, &bike.RiddenMinutes, &bike.Revenue, &bike.ServiceMinutes,
+			&bike.DowntimeMinutes, &bike.Deleted); err != nil {
+			return err
+		}
+		bike.IdleMinutes = round(math.Max(bike.ServiceMinutes-bike.RiddenMinutes-bike.DowntimeMinutes, 0), 2)
+		bike.ServiceMinutes = round(bike.ServiceMinutes, 2)
+		bike.RiddenMinutes = round(bike.RiddenMinutes, 2)
+		bike.DowntimeMinutes = round(bike.DowntimeMinutes, 2)
+		bike.Revenue = roundAmount(bike.Revenue)
+		if err := fn(&bike); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// roundAmount rounds an amount of money to minor units
+func roundAmount(amount float64) float64 {
+	return helpers.FromMinorUnits(helpers.ToMinorUnits(amount))
+}
+
+// round rounds a value to a number of decimals
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
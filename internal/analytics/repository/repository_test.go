@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/analytics/models"
+	"bikesRentalAPI/internal/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStats(t *testing.T) {
+	// GIVEN: a fleet with available, rented, out of service and deleted bikes
+	t.Setenv("DB_URL", "file:analytics_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	exec := func(query string, args ...interface{}) {
+		_, err := db.Exec(query, args...)
+		require.NoError(t, err)
+	}
+	exec(`INSERT INTO users (id, email, hashed_password) VALUES (1, 'first@example.com', 'x'), (2, 'second@example.com', 'x')`)
+	exec(`INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute) VALUES
+		(1, 1, 40.4, -3.7, 0.1), (2, 1, 40.4, -3.7, 0.1), (3, 0, 40.4, -3.7, 0.1), (4, 0, 40.4, -3.7, 0.1), (5, 1, 40.4, -3.7, 0.1)`)
+	exec("UPDATE bikes SET deleted_at = ? WHERE id = 5", time.Now())
+
+	// GIVEN: two ended rentals and a paused ongoing one in the first week of June, and an ended one after it
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 6, day, hour, minute, 0, 0, time.UTC) }
+	rental := "INSERT INTO rentals (id, user_id, bike_id, start_time, end_time, duration_minutes, cost) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	exec(rental, 1, 1, 1, at(3, 10, 0), at(3, 10, 30), 30, 3.0)
+	exec(rental, 2, 1, 2, at(4, 9, 0), at(4, 9, 10), 10, 1.5)
+	exec(rental, 3, 2, 1, at(12, 9, 0), at(12, 9, 20), 20, 2.0)
+	exec(rental, 4, 2, 3, at(9, 23, 0), nil, nil, 0)
+	exec("INSERT INTO rental_adjustments (rental_id, amount, reason, created_by) VALUES (1, -1.0, 'refund', 'admin')")
+	exec("INSERT INTO rental_pauses (rental_id, started_at) VALUES (4, ?)", at(10, 0, 0))
+
+	repo := New(db, time.Minute)
+	query := models.StatsQuery{From: at(3, 0, 0), To: at(10, 0, 0), Interval: models.IntervalDay, Limit: 10}
+
+	t.Run("Success - the fleet and the rentals of the range are summed up", func(t *testing.T) {
+		stats, err := repo.GetStats(query)
+		require.NoError(t, err)
+		assert.Equal(t, models.FleetStats{Total: 4, Available: 2, Rented: 1, Paused: 1, Unavailable: 1, Docked: 0, Deleted: 1}, stats.Fleet)
+		assert.Equal(t, int64(1), stats.ActiveRentals)
+		assert.Equal(t, models.RentalStats{Count: 3, Ended: 2, Revenue: 3.5, AverageDurationMinutes: 20, AverageCost: 1.75}, stats.Rentals)
+		assert.Equal(t, []*models.PeriodStats{
+			{Period: "2024-06-03", Rentals: 1, Revenue: 2},
+			{Period: "2024-06-04", Rentals: 1, Revenue: 1.5},
+			{Period: "2024-06-09", Rentals: 1, Revenue: 0},
+		}, stats.Periods)
+		assert.Equal(t, []*models.BikeUtilization{
+			{BikeID: 3, Rentals: 1, RiddenMinutes: 60, Utilization: 0.006},
+			{BikeID: 1, Rentals: 1, RiddenMinutes: 30, Utilization: 0.003},
+			{BikeID: 2, Rentals: 1, RiddenMinutes: 10, Utilization: 0.001},
+		}, stats.Utilization)
+		assert.Equal(t, []*models.TopUser{
+			{UserID: 1, Email: "first@example.com", Rentals: 2, Spent: 3.5},
+			{UserID: 2, Email: "second@example.com", Rentals: 1, Spent: 0},
+		}, stats.TopUsers)
+	})
+	t.Run("Success - rentals are grouped by week and rankings are limited", func(t *testing.T) {
+		stats, err := repo.GetStats(models.StatsQuery{From: query.From, To: at(20, 0, 0), Interval: models.IntervalWeek, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []*models.PeriodStats{
+			{Period: "2024-06-03", Rentals: 3, Revenue: 3.5},
+			{Period: "2024-06-10", Rentals: 1, Revenue: 2},
+		}, stats.Periods)
+		require.Len(t, stats.Utilization, 1)
+		require.Len(t, stats.TopUsers, 1)
+		assert.Equal(t, int64(1), stats.TopUsers[0].UserID, "ties are ranked by the amount spent")
+	})
+	t.Run("Success - statistics are cached for the TTL", func(t *testing.T) {
+		// GIVEN: statistics already computed
+		cached, err := repo.GetStats(query)
+		require.NoError(t, err)
+		// WHEN: a rental is started in the range
+		exec(rental, 5, 1, 2, at(5, 8, 0), nil, nil, 0)
+		// THEN: the cached statistics are served until they expire
+		stats, err := repo.GetStats(query)
+		require.NoError(t, err)
+		assert.Same(t, cached, stats)
+		stats, err = New(db, 0).GetStats(query)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), stats.Rentals.Count)
+	})
+	t.Run("Error - unknown interval", func(t *testing.T) {
+		_, err := repo.GetStats(models.StatsQuery{From: query.From, To: query.To, Interval: "month", Limit: 10})
+		assert.Error(t, err)
+	})
+}
+
+func TestExportBikeReport(t *testing.T) {
+	t.Setenv("DB_URL", "file:bike_report_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	exec := func(query string, args ...interface{}) {
+		_, err := db.Exec(query, args...)
+		require.NoError(t, err)
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// GIVEN: bikes added, taken out of service and deleted around the first week of June
+	bike := `INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute, created_at, updated_at, deleted_at)
+		VALUES (?, ?, 40.4, -3.7, 0.1, ?, ?, ?)`
+	exec(bike, 1, 1, at(6, 1, 0, 0), at(6, 1, 0, 0), nil)
+	exec(bike, 2, 0, at(6, 5, 0, 0), at(6, 8, 0, 0), nil)
+	exec(bike, 3, 1, at(5, 1, 0, 0), at(6, 4, 0, 0), at(6, 4, 0, 0))
+	exec(bike, 4, 1, at(6, 20, 0, 0), at(6, 20, 0, 0), nil)
+	exec(bike, 5, 1, at(5, 1, 0, 0), at(5, 20, 0, 0), at(5, 20, 0, 0))
+	exec(bike, 6, 0, at(6, 1, 0, 0), at(6, 9, 12, 0), nil)
+	// GIVEN: the second bike repaired for half a day before being taken out of service, and the sixth rented since the 9th
+	exec("DELETE FROM bike_availability_changes")
+	change := "INSERT INTO bike_availability_changes (bike_id, is_available, changed_at) VALUES (?, ?, ?)"
+	exec(change, 1, 1, at(6, 1, 0, 0))
+	exec(change, 2, 1, at(6, 5, 0, 0))
+	exec(change, 2, 0, at(6, 5, 12, 0))
+	exec(change, 2, 1, at(6, 6, 0, 0))
+	exec(change, 2, 0, at(6, 8, 0, 0))
+	exec(change, 3, 1, at(5, 1, 0, 0))
+	exec(change, 4, 1, at(6, 20, 0, 0))
+	exec(change, 5, 1, at(5, 1, 0, 0))
+	exec(change, 6, 1, at(6, 1, 0, 0))
+	exec(change, 6, 0, at(6, 9, 12, 0))
+	// GIVEN: a rental of the first bike in the range, another started before it and an ongoing one of the sixth bike
+	rental := "INSERT INTO rentals (id, user_id, bike_id, start_time, end_time, duration_minutes, cost) VALUES (?, 1, ?, ?, ?, ?, ?)"
+	exec(rental, 1, 1, at(6, 3, 10, 0), at(6, 3, 10, 30), 30, 3.0)
+	exec(rental, 2, 1, at(6, 2, 23, 50), at(6, 3, 0, 10), 20, 1.0)
+	exec(rental, 3, 6, at(6, 9, 12, 0), nil, nil, 0)
+	exec("INSERT INTO rental_adjustments (rental_id, amount, reason, created_by) VALUES (1, -1.0, 'refund', 'admin')")
+
+	repo := New(db, time.Minute)
+	export := func(query models.ReportQuery) []*models.BikeReport {
+		bikes := make([]*models.BikeReport, 0)
+		require.NoError(t, repo.ExportBikeReport(query, func(bike *models.BikeReport) error {
+			bikes = append(bikes, bike)
+			return nil
+		}))
+		return bikes
+	}
+
+	t.Run("Success - the bikes in the fleet during the range are reported", func(t *testing.T) {
+		bikes := export(models.ReportQuery{From: at(6, 3, 0, 0), To: at(6, 10, 0, 0)})
+		assert.Equal(t, []*models.BikeReport{
+			{BikeID: 1, Rentals: 1, ServiceMinutes: 10080, RiddenMinutes: 40, IdleMinutes: 10040, Revenue: 2},
+			{BikeID: 2, ServiceMinutes: 7200, DowntimeMinutes: 3600, IdleMinutes: 3600},
+			{BikeID: 3, ServiceMinutes: 1440, IdleMinutes: 1440, Deleted: true},
+			{BikeID: 6, Rentals: 1, ServiceMinutes: 10080, RiddenMinutes: 720, IdleMinutes: 9360},
+		}, bikes)
+	})
+	t.Run("Success - ongoing rentals and the fleet are counted until now", func(t *testing.T) {
+		bikes := export(models.ReportQuery{From: at(6, 9, 0, 0), To: time.Now().Add(24 * time.Hour)})
+		require.Len(t, bikes, 4)
+		assert.Equal(t, int64(6), bikes[3].BikeID)
+		assert.InDelta(t, time.Since(at(6, 9, 12, 0)).Minutes(), bikes[3].RiddenMinutes, 1)
+		assert.InDelta(t, time.Since(at(6, 9, 0, 0)).Minutes(), bikes[3].ServiceMinutes, 1)
+		assert.InDelta(t, 0, bikes[3].DowntimeMinutes, 1, "rented time is not downtime")
+	})
+	t.Run("Success - changes of availability are recorded", func(t *testing.T) {
+		// WHEN: the fourth bike is taken out of service
+		exec("UPDATE bikes SET is_available = 0 WHERE id = 4")
+		exec("UPDATE bikes SET latitude = 40.5 WHERE id = 4")
+		// THEN: its downtime is counted since then
+		var changes int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM bike_availability_changes WHERE bike_id = 4").Scan(&changes))
+		assert.Equal(t, 2, changes)
+		bikes := export(models.ReportQuery{From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)})
+		require.Len(t, bikes, 4)
+		assert.Equal(t, int64(4), bikes[2].BikeID)
+		assert.InDelta(t, 0, bikes[2].DowntimeMinutes, 1)
+		assert.InDelta(t, 60, bikes[2].IdleMinutes, 1)
+		assert.InDelta(t, 60, bikes[1].DowntimeMinutes, 1, "the second bike is still out of service")
+	})
+	t.Run("Error - the error of the callback stops the export", func(t *testing.T) {
+		calls := 0
+		err := repo.ExportBikeReport(models.ReportQuery{From: at(6, 3, 0, 0), To: at(6, 10, 0, 0)}, func(*models.BikeReport) error {
+			calls++
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, calls)
+	})
+}
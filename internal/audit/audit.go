@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"bikesRentalAPI/internal/audit/models"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+type contextKey string
+
+const entryKey contextKey = "audit_entry"
+
+// Recorder appends entries to the audit log
+type Recorder interface {
+	Record(entry *models.Entry) error
+}
+
+// NewContext returns a copy of ctx holding the entry of the audited request, completed by the handler
+func NewContext(ctx context.Context, entry *models.Entry) context.Context {
+	return context.WithValue(ctx, entryKey, entry)
+}
+
+// FromContext returns the entry of the audited request, nil when the request is not audited
+func FromContext(ctx context.Context) *models.Entry {
+	entry, _ := ctx.Value(entryKey).(*models.Entry)
+	return entry
+}
+
+// SetActor sets who made the request, for requests that authenticate it themselves like logins
+func SetActor(req *http.Request, actor string) {
+	if entry := FromContext(req.Context()); entry != nil {
+		entry.Actor = actor
+	}
+}
+
+// SetAction names the action of the request in place of its method and route
+func SetAction(req *http.Request, action string) {
+	if entry := FromContext(req.Context()); entry != nil {
+		entry.Action = action
+	}
+}
+
+// SetResource sets the resource acted on, when it is not the one of the route
+func SetResource(req *http.Request, resourceType, resourceID string) {
+	if entry := FromContext(req.Context()); entry != nil {
+		entry.ResourceType, entry.ResourceID = resourceType, resourceID
+	}
+}
+
+// ClearIP leaves the IP address out of the entry, for requests erasing the personal data of the user who makes them
+func ClearIP(req *http.Request) {
+	if entry := FromContext(req.Context()); entry != nil {
+		entry.IP = ""
+	}
+}
+
+// SetChanges records the values of the updated fields before and after an update. current is the resource before the update
+// and fieldsToUpdate the new values by column, whose names are the JSON names of the resource fields
+func SetChanges(req *http.Request, current interface{}, fieldsToUpdate map[string]interface{}) {
+	entry := FromContext(req.Context())
+	if entry == nil {
+		return
+	}
+	entry.Before, entry.After = Changes(current, fieldsToUpdate)
+}
+
+// Changes returns the values of the updated fields before and after an update. Fields left out of the JSON of current are nil before
+func Changes(current interface{}, fieldsToUpdate map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	var values, after map[string]interface{}
+	// Through JSON the pointers of the new values are dereferenced and both sides get the same types
+	if data, err := json.Marshal(current); err == nil {
+		json.Unmarshal(data, &values)
+	}
+	if data, err := json.Marshal(fieldsToUpdate); err == nil {
+		json.Unmarshal(data, &after)
+	}
+	before := make(map[string]interface{}, len(after))
+	for field := range after {
+		before[field] = values[field]
+	}
+	return before, after
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/audit/repository"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/middlewares"
+	"log"
+	"net/http"
+)
+
+// Handler is the interface for audit handlers
+type Handler interface {
+	ListAuditEntries(w http.ResponseWriter, req *http.Request) // List the audit log
+}
+
+type handler struct {
+	AuditRepo repository.AuditRepository
+}
+
+// New returns a new audit handler
+func New(auditRepository repository.AuditRepository) Handler {
+	return &handler{
+		AuditRepo: auditRepository,
+	}
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// ListAuditEntries returns a page of the audit log, filtered by actor, action, resource, request, status code and date
+func (h *handler) ListAuditEntries(w http.ResponseWriter, req *http.Request) {
+	page := middlewares.PageFromContext(req.Context())
+	spec := middlewares.QuerySpecFromContext(req.Context())
+	entries, err := h.AuditRepo.ListEntries(page, spec)
+	if err != nil {
+		log.Printf("Error getting audit entries: %v", err)
+		http.Error(w, "Error getting audit entries", http.StatusInternalServerError)
+		return
+	}
+	entries.Page.SetLinks(req.URL)
+	helpers.WriteJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"bikesRentalAPI/internal/pagination"
+	"time"
+)
+
+// Entry is an action recorded in the audit log
+type Entry struct {
+	// The id of the entry
+	ID int64 `json:"id" example:"1"`
+	// Who made the request, e.g. 'admin:admin' or 'user:2'. Empty for failed logins of unknown users
+	Actor string `json:"actor" example:"admin:admin"`
+	// What was done, the method and route of the request or a named event like 'login'
+	Action string `json:"action" example:"PATCH /admin/rentals/{rental_id}"`
+	// The type and id of the resource acted on
+	ResourceType string `json:"resource_type,omitempty" example:"rentals"`
+	ResourceID   string `json:"resource_id,omitempty" example:"12"`
+	// The values of the updated fields before and after the action
+	Before map[string]interface{} `json:"before,omitempty"`
+	After  map[string]interface{} `json:"after,omitempty"`
+	// The status code of the response
+	StatusCode int `json:"status_code" example:"200"`
+	// The id of the request and the IP address it came from
+	RequestID string `json:"request_id,omitempty"`
+	IP        string `json:"ip,omitempty" example:"127.0.0.1"`
+	// When the action was done
+	CreatedAt time.Time `json:"created_at"`
+} // @name AuditEntry
+
+// EntryList contains a list of audit entries and its pagination
+type EntryList struct {
+	// The list of entries
+	Items []*Entry `json:"items"`
+	// The pagination of the list
+	Page pagination.Info `json:"page"`
+} // @name AuditEntryList
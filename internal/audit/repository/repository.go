@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/audit/models"
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/middlewares"
+	"bikesRentalAPI/internal/pagination"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ListQuery is the whitelist of the filters and sort fields of the audit log
+var ListQuery = middlewares.QueryWhitelist{
+	Filters: map[string]middlewares.FilterField{
+		"actor":         {Column: "actor", Type: middlewares.FieldString, Operator: middlewares.OpEqual},
+		"action":        {Column: "action", Type: middlewares.FieldString, Operator: middlewares.OpContains},
+		"resource_type": {Column: "resource_type", Type: middlewares.FieldString, Operator: middlewares.OpEqual},
+		"resource_id":   {Column: "resource_id", Type: middlewares.FieldString, Operator: middlewares.OpEqual},
+		"request_id":    {Column: "request_id", Type: middlewares.FieldString, Operator: middlewares.OpEqual},
+		"status_code":   {Column: "status_code", Type: middlewares.FieldInt, Operator: middlewares.OpEqual},
+		"from":          {Column: "created_at", Type: middlewares.FieldTime, Operator: middlewares.OpGreaterOrEqual},
+		"to":            {Column: "created_at", Type: middlewares.FieldTime, Operator: middlewares.OpLessOrEqual},
+	},
+	Sorts: map[string]string{"id": "id", "created_at": "created_at"},
+}
+
+type AuditRepository interface {
+	Record(entry *models.Entry) error
+	ListEntries(page *pagination.Page, spec *middlewares.QuerySpec) (*models.EntryList, error)
+}
+
+type auditRepository struct {
+	db database.Database
+}
+
+// New initializes a new audit repository
+func New(db database.Database) AuditRepository {
+	return &auditRepository{db}
+}
+
+// Record appends an entry to the audit log. Entries can't be updated nor deleted
+func (r *auditRepository) Record(entry *models.Entry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	before, err := marshalChanges(entry.Before)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit changes: %v", err)
+	}
+	after, err := marshalChanges(entry.After)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit changes: %v", err)
+	}
+	query := `INSERT INTO audit_log (actor, action, resource_type, resource_id, before, after, status_code, request_id, ip, created_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)`
+	result, err := r.db.Exec(query, entry.Actor, entry.Action, entry.ResourceType, entry.ResourceID, before, after,
+		entry.StatusCode, entry.RequestID, entry.IP, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %v", err)
+	}
+	entry.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListEntries returns a page of the audit log matching the filters of the request, in the requested order
+func (r *auditRepository) ListEntries(page *pagination.Page, spec *middlewares.QuerySpec) (*models.EntryList, error) {
+	where, args := spec.Where()
+	keyset, keysetArgs, orderBy := page.Keyset(spec.SortColumn, spec.Descending)
+	query := fmt.Sprintf(`SELECT id, actor, action, COALESCE(resource_type, ''), COALESCE(resource_id, ''), before, after, status_code,
+		COALESCE(request_id, ''), COALESCE(ip, ''), created_at, %s FROM audit_log WHERE %s AND %s ORDER BY %s LIMIT ?`,
+		pagination.SortKey(spec.SortColumn), where, keyset, orderBy)
+	rows, err := r.db.Query(query, append(append(args, keysetArgs...), page.FetchLimit())...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := &models.EntryList{}
+	entryList := make([]*models.Entry, 0)
+	pageRows := make([]pagination.Row, 0)
+	for rows.Next() {
+		var entry models.Entry
+		var before, after sql.NullString
+		var row pagination.Row
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.ResourceType, &entry.ResourceID, &before, &after,
+			&entry.StatusCode, &entry.RequestID, &entry.IP, &entry.CreatedAt, &row.Key); err != nil {
+			return nil, err
+		}
+		if entry.Before, err = unmarshalChanges(before); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit changes: %v", err)
+		}
+		if entry.After, err = unmarshalChanges(after); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit changes: %v", err)
+		}
+		row.ID = entry.ID
+		entryList = append(entryList, &entry)
+		pageRows = append(pageRows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	entries.Items, entries.Page = pagination.Paginate(page, entryList, pageRows)
+	if page.WithTotal {
+		var total int64
+		if err := r.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM audit_log WHERE %s", where), args...).Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to count audit entries: %v", err)
+		}
+		entries.Page.Total = &total
+	}
+	return entries, nil
+}
+
+// marshalChanges returns the JSON of the values of the updated fields, NULL without changes
+func marshalChanges(changes map[string]interface{}) (sql.NullString, error) {
+	if changes == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// unmarshalChanges reads the values of the updated fields stored by marshalChanges
+func unmarshalChanges(data sql.NullString) (map[string]interface{}, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+	var changes map[string]interface{}
+	if err := json.Unmarshal([]byte(data.String), &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/audit/models"
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/middlewares"
+	"bikesRentalAPI/internal/pagination"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	// GIVEN: an audit log with an update of a rental and a failed login
+	t.Setenv("DB_URL", "file:audit_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	repo := New(db)
+	update := &models.Entry{
+		Actor: "admin:admin", Action: "PATCH /admin/rentals/{rental_id}", ResourceType: "rentals", ResourceID: "12",
+		Before: map[string]interface{}{"user_id": 2.0}, After: map[string]interface{}{"user_id": 7.0},
+		StatusCode: 200, RequestID: "req-1", IP: "10.0.0.1",
+	}
+	require.NoError(t, repo.Record(update))
+	require.NoError(t, repo.Record(&models.Entry{Actor: "user:2", Action: "login", ResourceType: "users", ResourceID: "2", StatusCode: 401}))
+
+	list := func(query string) *models.EntryList {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		page, err := pagination.Parse(values)
+		require.NoError(t, err)
+		spec, err := middlewares.ParseQuerySpec(values, ListQuery)
+		require.NoError(t, err)
+		entries, err := repo.ListEntries(page, spec)
+		require.NoError(t, err)
+		return entries
+	}
+
+	t.Run("Success - entries are listed with their changes", func(t *testing.T) {
+		entries := list("resource_type=rentals&resource_id=12")
+		assert.Len(t, entries.Items, 1)
+		assert.Equal(t, update.ID, entries.Items[0].ID)
+		assert.Equal(t, update.Before, entries.Items[0].Before)
+		assert.Equal(t, update.After, entries.Items[0].After)
+		assert.Equal(t, "req-1", entries.Items[0].RequestID)
+	})
+	t.Run("Success - entries are filtered and paginated", func(t *testing.T) {
+		entries := list("status_code=401")
+		assert.Len(t, entries.Items, 1)
+		assert.Equal(t, "login", entries.Items[0].Action)
+		entries = list("limit=1&order=desc&sort=created_at&total=true")
+		assert.Len(t, entries.Items, 1)
+		assert.Equal(t, "login", entries.Items[0].Action)
+		assert.Equal(t, int64(2), *entries.Page.Total)
+		assert.NotEmpty(t, entries.Page.NextCursor)
+	})
+	t.Run("Failure - entries can't be changed nor deleted", func(t *testing.T) {
+		_, err := db.Exec("UPDATE audit_log SET actor = 'admin:other' WHERE id = ?", update.ID)
+		assert.Error(t, err)
+		_, err = db.Exec("DELETE FROM audit_log WHERE id = ?", update.ID)
+		assert.Error(t, err)
+	})
+}
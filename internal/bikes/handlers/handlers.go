@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"bikesRentalAPI/internal/audit"
 	"bikesRentalAPI/internal/bikes/models"
 	"bikesRentalAPI/internal/bikes/repository"
+	"bikesRentalAPI/internal/database"
 	"bikesRentalAPI/internal/helpers"
 	"bikesRentalAPI/internal/middlewares"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -21,6 +25,10 @@ type Handler interface {
 	GetBikeByID(w http.ResponseWriter, req *http.Request)
 	ListAllBikes(w http.ResponseWriter, req *http.Request)
 	ListAvailableBikes(w http.ResponseWriter, req *http.Request)
+	ImportBikes(w http.ResponseWriter, req *http.Request)
+	ExportBikes(w http.ResponseWriter, req *http.Request)
+	DeleteBike(w http.ResponseWriter, req *http.Request)
+	RestoreBike(w http.ResponseWriter, req *http.Request)
 }
 
 type handler struct {
@@ -38,10 +46,10 @@ func New(BikeRepository repository.BikeRepository) Handler {
 	return handler
 }
 
-// ListAvailableBikes for users returns a list of available bikes with next page ID for pagination
+// ListAvailableBikes for users returns a page of available bikes with the cursors of the pages around it
 func (h *handler) ListAvailableBikes(w http.ResponseWriter, r *http.Request) {
-	pageID := r.Context().Value(middlewares.PageIDKey)
-	bikes, err := h.BikeRepo.ListAvailableBikes(pageID.(int64))
+	page := middlewares.PageFromContext(r.Context())
+	bikes, err := h.BikeRepo.ListAvailableBikes(page)
 	if err != nil {
 		log.Printf("Error getting available bikes: %v", err)
 		http.Error(w, "Error getting available bikes", http.StatusInternalServerError)
@@ -49,9 +57,10 @@ func (h *handler) ListAvailableBikes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	bikesListResponse := models.BikeList{
-		Items:      bikes.Items,
-		NextPageID: bikes.NextPageID,
+		Items: bikes.Items,
+		Page:  bikes.Page,
 	}
+	bikesListResponse.Page.SetLinks(r.URL)
 
 	helpers.WriteJSON(w, http.StatusOK, bikesListResponse)
 
@@ -96,7 +105,7 @@ func (h *handler) AddBike(w http.ResponseWriter, req *http.Request) {
 }
 
 // UpdateBike updates a bike in the database
-// the URL parameters 'bike_id' passed through as the request
+// the URL parameters 'bike_id' passed through as the request. The If-Match header must hold the ETag the bike was read with
 func (h *handler) UpdateBike(w http.ResponseWriter, req *http.Request) {
 	bikeIDStr := chi.URLParam(req, "bike_id")
 	bikeID, err := strconv.ParseInt(bikeIDStr, 10, 64)
@@ -127,10 +136,17 @@ func (h *handler) UpdateBike(w http.ResponseWriter, req *http.Request) {
 
 	bike, err := h.BikeRepo.GetBikeByID(bikeID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Bike not found", http.StatusNotFound)
+			return
+		}
 		log.Printf("Error getting bike: %v", err)
 		http.Error(w, "Error getting bike", http.StatusInternalServerError)
 		return
 	}
+	if !helpers.CheckIfMatch(w, req, bike.Version) {
+		return
+	}
 
 	// Compare attributes to identify modifications
 	fieldsToUpdate, err := getFieldsToUpdate(updateBikeReq, bike)
@@ -139,10 +155,18 @@ func (h *handler) UpdateBike(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	audit.SetChanges(req, bike, fieldsToUpdate)
 	// Update user
-	result, err := h.BikeRepo.UpdateBike(bikeID, fieldsToUpdate)
+	result, err := h.BikeRepo.UpdateBike(bikeID, fieldsToUpdate, bike.Version)
 	if err != nil {
-		http.Error(w, "Error updating user", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Bike not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrVersionConflict):
+			http.Error(w, "The bike was changed since it was read", http.StatusPreconditionFailed)
+		default:
+			http.Error(w, "Error updating user", http.StatusInternalServerError)
+		}
 		return
 	}
 	updateUserResp := models.CreateUpdateBikeResponse{
@@ -180,8 +204,9 @@ func getFieldsToUpdate(updateBikeReq *models.CreateUpdateBikeRequest, bike *mode
 
 // ListAllBikes retrieves all bikes from the database
 func (h *handler) ListAllBikes(w http.ResponseWriter, r *http.Request) {
-	pageID := r.Context().Value(middlewares.PageIDKey)
-	bikes, err := h.BikeRepo.ListAllBikes(pageID.(int64))
+	page := middlewares.PageFromContext(r.Context())
+	spec := middlewares.QuerySpecFromContext(r.Context())
+	bikes, err := h.BikeRepo.ListAllBikes(page, spec)
 	if err != nil {
 		log.Printf("Error getting available bikes: %v", err)
 		http.Error(w, "Error getting available bikes", http.StatusInternalServerError)
@@ -189,13 +214,14 @@ func (h *handler) ListAllBikes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	bikesListResponse := models.BikeList{
-		Items:      bikes.Items,
-		NextPageID: bikes.NextPageID,
+		Items: bikes.Items,
+		Page:  bikes.Page,
 	}
+	bikesListResponse.Page.SetLinks(r.URL)
 	helpers.WriteJSON(w, http.StatusOK, bikesListResponse)
 }
 
-// GetBikeByID retrieves a bike from the database
+// GetBikeByID retrieves a bike from the database with its ETag. Requests with the current ETag in If-None-Match get 304 Not Modified
 func (h *handler) GetBikeByID(w http.ResponseWriter, r *http.Request) {
 	bikeIDStr := chi.URLParam(r, "bike_id")
 	bikeID, err := strconv.ParseInt(bikeIDStr, 10, 64)
@@ -204,6 +230,59 @@ func (h *handler) GetBikeByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	bike, err := h.BikeRepo.GetBikeByID(bikeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Bike not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting bike: %v", err)
+		http.Error(w, "Error getting bike", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSONWithETag(w, r, http.StatusOK, bike.Version, bike)
+}
+
+// DeleteBike soft deletes a bike. Bikes being rented can't be deleted
+func (h *handler) DeleteBike(w http.ResponseWriter, r *http.Request) {
+	bikeIDStr := chi.URLParam(r, "bike_id")
+	bikeID, err := strconv.ParseInt(bikeIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", bikeIDStr, err), http.StatusBadRequest)
+		return
+	}
+	if err := h.BikeRepo.DeleteBike(bikeID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Bike not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrBikeInUse):
+			http.Error(w, "Bike is being rented", http.StatusConflict)
+		default:
+			log.Printf("Error deleting bike: %v", err)
+			http.Error(w, "Error deleting bike", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreBike restores a deleted bike
+func (h *handler) RestoreBike(w http.ResponseWriter, r *http.Request) {
+	bikeIDStr := chi.URLParam(r, "bike_id")
+	bikeID, err := strconv.ParseInt(bikeIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", bikeIDStr, err), http.StatusBadRequest)
+		return
+	}
+	if err := h.BikeRepo.RestoreBike(bikeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Deleted bike not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error restoring bike: %v", err)
+		http.Error(w, "Error restoring bike", http.StatusInternalServerError)
+		return
+	}
+	bike, err := h.BikeRepo.GetBikeByID(bikeID)
 	if err != nil {
 		log.Printf("Error getting bike: %v", err)
 		http.Error(w, "Error getting bike", http.StatusInternalServerError)
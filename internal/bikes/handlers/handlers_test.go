@@ -1,7 +1,22 @@
 package handlers
 
 // TODO Adds tests for the bikes handlers
-import "testing"
+import (
+	"bikesRentalAPI/internal/bikes/models"
+	"bikesRentalAPI/internal/bikes/repository"
+	"bikesRentalAPI/internal/bikes/repository/mocks"
+	"bikesRentalAPI/internal/database"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
 
 func TestListAvailableBikes(t *testing.T) {
 	// GIVEN: a request to list available bikes
@@ -16,15 +31,104 @@ func TestAddBike(t *testing.T) {
 }
 
 func TestUpdateBike(t *testing.T) {
-	// GIVEN: a request to update a bike
-	// WHEN: the request is made
-	// THEN: the bike should be updated
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockBikeRepo := mocks.NewMockBikeRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		ifMatch          string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name:    "Success - the bike is updated when read at its current version",
+			ifMatch: `"4"`,
+			mockCalls: func() {
+				mockBikeRepo.EXPECT().UpdateBike(int64(3), gomock.Any(), int64(4)).Return(int64(3), nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:             "Failure - the If-Match header is missing",
+			expectedHttpCode: http.StatusPreconditionRequired,
+		},
+		{
+			name:             "Failure - the bike was read at an older version",
+			ifMatch:          `"3"`,
+			expectedHttpCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "Failure - the bike is changed by another request before the update",
+			ifMatch: `"4"`,
+			mockCalls: func() {
+				mockBikeRepo.EXPECT().UpdateBike(int64(3), gomock.Any(), int64(4)).Return(int64(0), database.ErrVersionConflict)
+			},
+			expectedHttpCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a request to move a bike at version 4
+			mockBikeRepo.EXPECT().GetBikeByID(int64(3)).Return(&models.Bike{ID: 3, Latitude: 40.1, Longitude: -3.7, PricePerMinute: 0.1, Version: 4}, nil)
+			if tc.mockCalls != nil {
+				tc.mockCalls()
+			}
+			req := httptest.NewRequest(http.MethodPatch, "/admin/bikes/3", strings.NewReader(`{"latitude":40.2}`))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Patch("/admin/bikes/{bike_id}", New(mockBikeRepo).UpdateBike)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the bike is only updated from its current version
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+			if tc.expectedHttpCode == http.StatusPreconditionFailed && tc.mockCalls == nil {
+				assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+			}
+		})
+	}
 }
 
 func TestGetBikeByID(t *testing.T) {
-	// GIVEN: a request to get bike details
-	// WHEN: the request is made
-	// THEN: the bike details should be returned
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockBikeRepo := mocks.NewMockBikeRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		ifNoneMatch      string
+		expectedHttpCode int
+	}{
+		{name: "Success - the bike is returned with its ETag", expectedHttpCode: http.StatusOK},
+		{name: "Success - the bike is not sent again when unchanged", ifNoneMatch: `W/"2", "4"`, expectedHttpCode: http.StatusNotModified},
+		{name: "Success - the bike is sent again when changed", ifNoneMatch: `"3"`, expectedHttpCode: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a request to get a bike at version 4
+			mockBikeRepo.EXPECT().GetBikeByID(int64(3)).Return(&models.Bike{ID: 3, Version: 4}, nil)
+			req := httptest.NewRequest(http.MethodGet, "/admin/bikes/3", nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Get("/admin/bikes/{bike_id}", New(mockBikeRepo).GetBikeByID)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the ETag is the version of the bike
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+			assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+			if tc.expectedHttpCode == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
 }
 
 func TestListAllBikes(t *testing.T) {
@@ -32,3 +136,141 @@ func TestListAllBikes(t *testing.T) {
 	// WHEN: the request is made
 	// THEN: all bikes should be listed
 }
+
+func TestDeleteBike(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockBikeRepo := mocks.NewMockBikeRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the bike is deleted", expectedHttpCode: http.StatusNoContent},
+		{name: "Failure - the bike doesn't exist or is already deleted", repoErr: sql.ErrNoRows, expectedHttpCode: http.StatusNotFound},
+		{name: "Failure - the bike is being rented", repoErr: repository.ErrBikeInUse, expectedHttpCode: http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a request to delete a bike
+			mockBikeRepo.EXPECT().DeleteBike(int64(3)).Return(tc.repoErr)
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Delete("/admin/bikes/{bike_id}", New(mockBikeRepo).DeleteBike)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/bikes/3", nil))
+			// THEN: the bike is deleted unless it is missing or rented
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestImportBikes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockBikeRepo := mocks.NewMockBikeRepository(mockCtrl)
+
+	const csvBody = "latitude,longitude,price_per_minute,is_available\n" +
+		"51.5,-0.16,0.07,true\n" +
+		"95,-0.16,0.07,\n" +
+		"51.5,-0.16,abc,\n" +
+		"51.5,\"-0.16\"x,0.07,\n"
+	const ndjsonBody = `{"latitude":51.5,"longitude":-0.16,"price_per_minute":0.07}` + "\n" +
+		`{"latitude":51.6,"longitude":-0.17,"price_per_minute":0.08}` + "\n"
+
+	testCases := []struct {
+		name             string
+		query            string
+		contentType      string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+		expectedImported int
+		expectedFailed   []int
+	}{
+		{
+			name:             "Success - dry run validates every row without creating bikes",
+			query:            "?dry_run=true",
+			contentType:      "text/csv",
+			body:             csvBody,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusOK,
+			expectedImported: 0,
+			expectedFailed:   []int{2, 3, 4},
+		},
+		{
+			name:             "Success - invalid rows are reported and valid rows are created",
+			contentType:      "text/csv",
+			body:             csvBody,
+			mockCalls:        func() { mockBikeRepo.EXPECT().CreateBike(gomock.Any()).Return(int64(1), nil).Times(1) },
+			expectedHttpCode: http.StatusOK,
+			expectedImported: 1,
+			expectedFailed:   []int{2, 3, 4},
+		},
+		{
+			name:             "Failure - atomic imports create nothing when a row is invalid",
+			query:            "?atomic=true",
+			contentType:      "text/csv",
+			body:             csvBody,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusUnprocessableEntity,
+			expectedImported: 0,
+			expectedFailed:   []int{2, 3, 4},
+		},
+		{
+			name:        "Success - atomic NDJSON import creates every bike in one call",
+			query:       "?atomic=true",
+			contentType: "application/x-ndjson",
+			body:        ndjsonBody,
+			mockCalls: func() {
+				mockBikeRepo.EXPECT().CreateBikes(gomock.Len(2)).Return([]int64{1, 2}, nil).Times(1)
+			},
+			expectedHttpCode: http.StatusCreated,
+			expectedImported: 2,
+			expectedFailed:   []int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: the expected repository calls
+			tc.mockCalls()
+			// GIVEN: an import request
+			req := httptest.NewRequest(http.MethodPost, "/admin/bikes/import"+tc.query, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			rr := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockBikeRepo).ImportBikes(rr, req)
+			// THEN: the status code should be the expected
+			assert.Equal(t, tc.expectedHttpCode, rr.Code)
+			// THEN: the response should report the imported and rejected rows
+			var importResp models.ImportBikesResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &importResp))
+			assert.Equal(t, tc.expectedImported, importResp.Imported)
+			failedRows := make([]int, 0)
+			for _, rowError := range importResp.Errors {
+				failedRows = append(failedRows, rowError.Row)
+			}
+			assert.Equal(t, tc.expectedFailed, failedRows)
+		})
+	}
+
+	t.Run("Failure - files larger than the limit are rejected", func(t *testing.T) {
+		for contentType, row := range map[string]string{
+			"text/csv":             "51.5,-0.16,0.07,true\n",
+			"application/x-ndjson": `{"latitude":51.5,"longitude":-0.16,"price_per_minute":0.07}` + "\n",
+		} {
+			// GIVEN: an import file with more rows than fit in the limit
+			body := "latitude,longitude,price_per_minute,is_available\n" + strings.Repeat(row, maxImportSize/len(row)+1)
+			req := httptest.NewRequest(http.MethodPost, "/admin/bikes/import", strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			rr := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockBikeRepo).ImportBikes(rr, req)
+			// THEN: the import is rejected without creating bikes
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code, contentType)
+		}
+	})
+}
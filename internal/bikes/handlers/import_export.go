@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/bikes/models"
+	"bikesRentalAPI/internal/helpers"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	// maxImportSize is the maximum size in bytes of an import file
+	maxImportSize = 10 << 20
+)
+
+// exportColumns are the columns written by ExportBikes. ImportBikes ignores the ones it doesn't know, so an export can be imported back
+var exportColumns = []string{"id", "is_available", "latitude", "longitude", "price_per_minute", "created_at", "updated_at"}
+
+// ImportBikes creates bikes in bulk from a CSV or NDJSON body.
+// Query parameters:
+//   - dry_run=true only validates the rows
+//   - atomic=true creates no bike at all if any row is invalid
+func (h *handler) ImportBikes(w http.ResponseWriter, req *http.Request) {
+	dryRun, err := parseBoolQuery(req, "dry_run")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	atomic, err := parseBoolQuery(req, "atomic")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format, err := importFormat(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	body := http.MaxBytesReader(w, req.Body, maxImportSize)
+	defer body.Close()
+
+	var rows []models.ImportBikeRequest
+	var rowErrors []models.ImportBikeRowError
+	switch format {
+	case formatCSV:
+		rows, rowErrors, err = readCSVBikes(body)
+	default:
+		rows, rowErrors, err = readNDJSONBikes(body)
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("Import file larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading import file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Validate every row, keeping the valid ones in file order
+	rejected := make(map[int]bool, len(rowErrors))
+	for _, rowError := range rowErrors {
+		rejected[rowError.Row] = true
+	}
+	validBikes := make([]models.CreateUpdateBikeRequest, 0, len(rows))
+	validRows := make([]int, 0, len(rows))
+	for i, row := range rows {
+		rowNumber := i + 1
+		if rejected[rowNumber] {
+			continue
+		}
+		if err := h.validator.Struct(row); err != nil {
+			rowErrors = append(rowErrors, models.ImportBikeRowError{Row: rowNumber, Errors: validationMessages(err)})
+			continue
+		}
+		isAvailable := true // New bikes are available by default
+		if row.IsAvailable != nil {
+			isAvailable = *row.IsAvailable
+		}
+		validBikes = append(validBikes, models.CreateUpdateBikeRequest{
+			IsAvailable:    &isAvailable,
+			Latitude:       row.Latitude,
+			Longitude:      row.Longitude,
+			PricePerMinute: row.PricePerMinute,
+		})
+		validRows = append(validRows, rowNumber)
+	}
+
+	sort.Slice(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+
+	importResp := models.ImportBikesResponse{
+		Received: len(rows),
+		DryRun:   dryRun,
+		Atomic:   atomic,
+		IDs:      make([]int64, 0, len(validBikes)),
+		Errors:   rowErrors,
+	}
+	if importResp.Errors == nil {
+		importResp.Errors = make([]models.ImportBikeRowError, 0)
+	}
+
+	switch {
+	case dryRun:
+		importResp.Failed = len(rowErrors)
+		helpers.WriteJSON(w, http.StatusOK, importResp)
+		return
+	case atomic && len(rowErrors) > 0:
+		importResp.Failed = len(rowErrors)
+		helpers.WriteJSON(w, http.StatusUnprocessableEntity, importResp)
+		return
+	case atomic:
+		ids, err := h.BikeRepo.CreateBikes(validBikes)
+		if err != nil {
+			log.Printf("Error importing bikes: %v", err)
+			http.Error(w, "Error importing bikes", http.StatusInternalServerError)
+			return
+		}
+		importResp.IDs = ids
+	default:
+		for i, bike := range validBikes {
+			id, err := h.BikeRepo.CreateBike(bike)
+			if err != nil {
+				log.Printf("Error importing bike row %d: %v", validRows[i], err)
+				importResp.Errors = append(importResp.Errors, models.ImportBikeRowError{Row: validRows[i], Errors: []string{"failed to create bike"}})
+				continue
+			}
+			importResp.IDs = append(importResp.IDs, id)
+		}
+	}
+	importResp.Imported = len(importResp.IDs)
+	importResp.Failed = len(importResp.Errors)
+
+	status := http.StatusCreated
+	if importResp.Failed > 0 {
+		status = http.StatusOK
+	}
+	helpers.WriteJSON(w, status, importResp)
+}
+
+// ExportBikes streams every bike as CSV or NDJSON.
+// The format is read from the 'format' query parameter, the URL extension (/export.csv) or the Accept header. CSV is the default
+func (h *handler) ExportBikes(w http.ResponseWriter, req *http.Request) {
+	format, err := exportFormat(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	buffered := bufio.NewWriter(w)
+	var writeBike func(*models.Bike) error
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", contentTypeCSV)
+		csvWriter := csv.NewWriter(buffered)
+		if err := csvWriter.Write(exportColumns); err != nil {
+			log.Printf("Error writing bikes export: %v", err)
+			return
+		}
+		writeBike = func(bike *models.Bike) error {
+			err := csvWriter.Write([]string{
+				strconv.FormatInt(bike.ID, 10),
+				strconv.FormatBool(bike.IsAvailable),
+				strconv.FormatFloat(bike.Latitude, 'f', -1, 64),
+				strconv.FormatFloat(bike.Longitude, 'f', -1, 64),
+				strconv.FormatFloat(bike.PricePerMinute, 'f', -1, 64),
+				bike.CreatedAt.UTC().Format(time.RFC3339),
+				bike.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+			if err != nil {
+				return err
+			}
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	default:
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+		encoder := json.NewEncoder(buffered)
+		writeBike = func(bike *models.Bike) error {
+			return encoder.Encode(bike)
+		}
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=bikes.%s", format))
+	w.WriteHeader(http.StatusOK)
+
+	// Rows are written as they are read from the database and flushed periodically
+	count := 0
+	err = h.BikeRepo.ExportBikes(func(bike *models.Bike) error {
+		if err := writeBike(bike); err != nil {
+			return err
+		}
+		count++
+		if count%500 == 0 {
+			if err := buffered.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, the truncated body is the only signal left to the client
+		log.Printf("Error exporting bikes: %v", err)
+		return
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Printf("Error exporting bikes: %v", err)
+	}
+}
+
+// readCSVBikes reads the bikes of a CSV file whose first line is a header with the column names.
+// Malformed rows are reported as row errors, any other read error stops the import
+func readCSVBikes(r io.Reader) ([]models.ImportBikeRequest, []models.ImportBikeRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"latitude", "longitude", "price_per_minute"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	rows := make([]models.ImportBikeRequest, 0)
+	rowErrors := make([]models.ImportBikeRowError, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return nil, nil, err
+		}
+		rowNumber := len(rows) + 1
+		rows = append(rows, models.ImportBikeRequest{})
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportBikeRowError{Row: rowNumber, Errors: []string{err.Error()}})
+			continue
+		}
+		row, fieldErrors := parseCSVBike(record, columns)
+		if len(fieldErrors) > 0 {
+			rowErrors = append(rowErrors, models.ImportBikeRowError{Row: rowNumber, Errors: fieldErrors})
+			continue
+		}
+		rows[len(rows)-1] = row
+	}
+	return rows, rowErrors, nil
+}
+
+// parseCSVBike converts a CSV record into an import row. Empty values are left nil for the validator to report
+func parseCSVBike(record []string, columns map[string]int) (models.ImportBikeRequest, []string) {
+	var row models.ImportBikeRequest
+	var fieldErrors []string
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	parseFloat := func(column string) *float64 {
+		raw := value(column)
+		if raw == "" {
+			return nil
+		}
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			fieldErrors = append(fieldErrors, fmt.Sprintf("%s: %q is not a number", column, raw))
+			return nil
+		}
+		return &parsed
+	}
+	row.Latitude = parseFloat("latitude")
+	row.Longitude = parseFloat("longitude")
+	row.PricePerMinute = parseFloat("price_per_minute")
+	if raw := value("is_available"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			fieldErrors = append(fieldErrors, fmt.Sprintf("is_available: %q is not a boolean", raw))
+		} else {
+			row.IsAvailable = &parsed
+		}
+	}
+	return row, fieldErrors
+}
+
+// readNDJSONBikes reads the bikes of a newline delimited JSON file. Blank lines are skipped
+func readNDJSONBikes(r io.Reader) ([]models.ImportBikeRequest, []models.ImportBikeRowError, error) {
+	scanner := bufio.NewScanner(r)
+	rows := make([]models.ImportBikeRequest, 0)
+	rowErrors := make([]models.ImportBikeRowError, 0)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var row models.ImportBikeRequest
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			rowErrors = append(rowErrors, models.ImportBikeRowError{Row: len(rows) + 1, Errors: []string{fmt.Sprintf("invalid JSON: %v", err)}})
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return rows, rowErrors, nil
+}
+
+// importFormat returns the format of the import body from its Content-Type
+func importFormat(req *http.Request) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return "", fmt.Errorf("Content-Type must be %s or %s", contentTypeCSV, contentTypeNDJSON)
+	}
+	switch mediaType {
+	case contentTypeCSV:
+		return formatCSV, nil
+	case contentTypeNDJSON, "application/ndjson", "application/jsonl":
+		return formatNDJSON, nil
+	}
+	return "", fmt.Errorf("Content-Type must be %s or %s", contentTypeCSV, contentTypeNDJSON)
+}
+
+// exportFormat returns the requested export format
+func exportFormat(req *http.Request) (string, error) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format, _ = req.Context().Value(middleware.URLFormatCtxKey).(string)
+	}
+	if format == "" {
+		accept := req.Header.Get("Accept")
+		switch {
+		case strings.Contains(accept, contentTypeNDJSON), strings.Contains(accept, "application/ndjson"):
+			format = formatNDJSON
+		default:
+			format = formatCSV
+		}
+	}
+	switch format {
+	case formatCSV, formatNDJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q, use %s or %s", format, formatCSV, formatNDJSON)
+}
+
+// parseBoolQuery reads an optional boolean query parameter, false when it is missing
+func parseBoolQuery(req *http.Request, name string) (bool, error) {
+	raw := req.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("couldn't read %s: %v", name, err)
+	}
+	return value, nil
+}
+
+// validationMessages converts validator errors into readable messages
+func validationMessages(err error) []string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []string{err.Error()}
+	}
+	messages := make([]string, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		messages = append(messages, fmt.Sprintf("%s: failed on '%s' validation", fieldError.Field(), fieldError.Tag()))
+	}
+	return messages
+}
@@ -51,6 +51,30 @@ func (mr *MockHandlerMockRecorder) AddBike(w, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBike", reflect.TypeOf((*MockHandler)(nil).AddBike), w, req)
 }
 
+// DeleteBike mocks base method.
+func (m *MockHandler) DeleteBike(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteBike", w, req)
+}
+
+// DeleteBike indicates an expected call of DeleteBike.
+func (mr *MockHandlerMockRecorder) DeleteBike(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBike", reflect.TypeOf((*MockHandler)(nil).DeleteBike), w, req)
+}
+
+// ExportBikes mocks base method.
+func (m *MockHandler) ExportBikes(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ExportBikes", w, req)
+}
+
+// ExportBikes indicates an expected call of ExportBikes.
+func (mr *MockHandlerMockRecorder) ExportBikes(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportBikes", reflect.TypeOf((*MockHandler)(nil).ExportBikes), w, req)
+}
+
 // GetBikeByID mocks base method.
 func (m *MockHandler) GetBikeByID(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
@@ -63,6 +87,18 @@ func (mr *MockHandlerMockRecorder) GetBikeByID(w, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBikeByID", reflect.TypeOf((*MockHandler)(nil).GetBikeByID), w, req)
 }
 
+// ImportBikes mocks base method.
+func (m *MockHandler) ImportBikes(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ImportBikes", w, req)
+}
+
+// ImportBikes indicates an expected call of ImportBikes.
+func (mr *MockHandlerMockRecorder) ImportBikes(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportBikes", reflect.TypeOf((*MockHandler)(nil).ImportBikes), w, req)
+}
+
 // ListAllBikes mocks base method.
 func (m *MockHandler) ListAllBikes(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
@@ -87,6 +123,18 @@ func (mr *MockHandlerMockRecorder) ListAvailableBikes(w, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAvailableBikes", reflect.TypeOf((*MockHandler)(nil).ListAvailableBikes), w, req)
 }
 
+// RestoreBike mocks base method.
+func (m *MockHandler) RestoreBike(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RestoreBike", w, req)
+}
+
+// RestoreBike indicates an expected call of RestoreBike.
+func (mr *MockHandlerMockRecorder) RestoreBike(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBike", reflect.TypeOf((*MockHandler)(nil).RestoreBike), w, req)
+}
+
 // UpdateBike mocks base method.
 func (m *MockHandler) UpdateBike(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"bikesRentalAPI/internal/pagination"
 	"time"
 )
 
@@ -11,15 +12,20 @@ type Bike struct {
 	Latitude       float64   `json:"latitude,omitempty"`
 	Longitude      float64   `json:"longitude,omitempty"`
 	PricePerMinute float64   `json:"price_per_minute,omitempty"`
+	StationID      *int64    `json:"station_id,omitempty"`
 	CreatedAt      time.Time `json:"created_at,omitempty"`
 	UpdatedAt      time.Time `json:"updated_at,omitempty"`
+	// When the bike was deleted, listed to admins with include_deleted=true
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// The version of the bike, changed by every update. Sent as its ETag
+	Version int64 `json:"-"`
 } // @name Bike
 
 // CreateUpdateBikeRequest contains the information to create a bike
 type CreateUpdateBikeRequest struct {
 	IsAvailable    *bool    `json:"is_available" validate:"omitempty"`
 	Latitude       *float64 `json:"latitude" validate:"omitempty,required,latitude"`
-	Longitude      *float64 `json:"longitude" validate:"omitempty,required,latitude"`
+	Longitude      *float64 `json:"longitude" validate:"omitempty,required,longitude"`
 	PricePerMinute *float64 `json:"price_per_minute" validate:"omitempty,required,numeric"`
 } // @name CreateUpdateBikeRequest
 
@@ -27,8 +33,8 @@ type CreateUpdateBikeRequest struct {
 type BikeList struct {
 	// The list of bikes
 	Items []*Bike `json:"items"`
-	// The id to query the next page
-	NextPageID int64 `json:"next_page_id,omitempty" example:"10"`
+	// The pagination of the list
+	Page pagination.Info `json:"page"`
 } // @name BikeList
 
 // CreateUpdateBikeResponse represents the response of creating/updating a bike
@@ -36,3 +42,37 @@ type CreateUpdateBikeResponse struct {
 	ID      int64  `json:"id,omitempty"`
 	Message string `json:"message,omitempty"`
 } // @name CreateUpdateBikeResponse
+
+// ImportBikeRequest contains one row of a bulk bike import
+type ImportBikeRequest struct {
+	IsAvailable    *bool    `json:"is_available" validate:"omitempty"`
+	Latitude       *float64 `json:"latitude" validate:"required,latitude"`
+	Longitude      *float64 `json:"longitude" validate:"required,longitude"`
+	PricePerMinute *float64 `json:"price_per_minute" validate:"required,min=0"`
+} // @name ImportBikeRequest
+
+// ImportBikeRowError contains the validation errors of a row of a bulk bike import
+type ImportBikeRowError struct {
+	// The row number in the file, starting at 1 for the first bike
+	Row int `json:"row"`
+	// The errors found in the row
+	Errors []string `json:"errors"`
+} // @name ImportBikeRowError
+
+// ImportBikesResponse represents the response of a bulk bike import
+type ImportBikesResponse struct {
+	// The number of rows read from the file
+	Received int `json:"received"`
+	// The number of bikes created
+	Imported int `json:"imported"`
+	// The number of rows rejected
+	Failed int `json:"failed"`
+	// Whether the import only validated the rows
+	DryRun bool `json:"dry_run"`
+	// Whether the import was rejected entirely when a row failed
+	Atomic bool `json:"atomic"`
+	// The ids of the created bikes
+	IDs []int64 `json:"ids"`
+	// The errors of the rejected rows
+	Errors []ImportBikeRowError `json:"errors"`
+} // @name ImportBikesResponse
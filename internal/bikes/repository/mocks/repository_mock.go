@@ -11,6 +11,9 @@ package mocks
 
 import (
 	models "bikesRentalAPI/internal/bikes/models"
+	database "bikesRentalAPI/internal/database"
+	middlewares "bikesRentalAPI/internal/middlewares"
+	pagination "bikesRentalAPI/internal/pagination"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -54,6 +57,49 @@ func (mr *MockBikeRepositoryMockRecorder) CreateBike(bike any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBike", reflect.TypeOf((*MockBikeRepository)(nil).CreateBike), bike)
 }
 
+// CreateBikes mocks base method.
+func (m *MockBikeRepository) CreateBikes(bikes []models.CreateUpdateBikeRequest) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBikes", bikes)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBikes indicates an expected call of CreateBikes.
+func (mr *MockBikeRepositoryMockRecorder) CreateBikes(bikes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBikes", reflect.TypeOf((*MockBikeRepository)(nil).CreateBikes), bikes)
+}
+
+// DeleteBike mocks base method.
+func (m *MockBikeRepository) DeleteBike(bikeID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBike", bikeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBike indicates an expected call of DeleteBike.
+func (mr *MockBikeRepositoryMockRecorder) DeleteBike(bikeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBike", reflect.TypeOf((*MockBikeRepository)(nil).DeleteBike), bikeID)
+}
+
+// ExportBikes mocks base method.
+func (m *MockBikeRepository) ExportBikes(fn func(*models.Bike) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportBikes", fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportBikes indicates an expected call of ExportBikes.
+func (mr *MockBikeRepositoryMockRecorder) ExportBikes(fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportBikes", reflect.TypeOf((*MockBikeRepository)(nil).ExportBikes), fn)
+}
+
 // GetBikeByID mocks base method.
 func (m *MockBikeRepository) GetBikeByID(bikeID int64) (*models.Bike, error) {
 	m.ctrl.T.Helper()
@@ -100,60 +146,88 @@ func (mr *MockBikeRepositoryMockRecorder) IsBikeAvailable(bikeID any) *gomock.Ca
 }
 
 // ListAllBikes mocks base method.
-func (m *MockBikeRepository) ListAllBikes(PageID int64) (*models.BikeList, error) {
+func (m *MockBikeRepository) ListAllBikes(page *pagination.Page, spec *middlewares.QuerySpec) (*models.BikeList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAllBikes", PageID)
+	ret := m.ctrl.Call(m, "ListAllBikes", page, spec)
 	ret0, _ := ret[0].(*models.BikeList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListAllBikes indicates an expected call of ListAllBikes.
-func (mr *MockBikeRepositoryMockRecorder) ListAllBikes(PageID any) *gomock.Call {
+func (mr *MockBikeRepositoryMockRecorder) ListAllBikes(page, spec any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllBikes", reflect.TypeOf((*MockBikeRepository)(nil).ListAllBikes), PageID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllBikes", reflect.TypeOf((*MockBikeRepository)(nil).ListAllBikes), page, spec)
 }
 
 // ListAvailableBikes mocks base method.
-func (m *MockBikeRepository) ListAvailableBikes(PageID int64) (*models.BikeList, error) {
+func (m *MockBikeRepository) ListAvailableBikes(page *pagination.Page) (*models.BikeList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAvailableBikes", PageID)
+	ret := m.ctrl.Call(m, "ListAvailableBikes", page)
 	ret0, _ := ret[0].(*models.BikeList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListAvailableBikes indicates an expected call of ListAvailableBikes.
-func (mr *MockBikeRepositoryMockRecorder) ListAvailableBikes(PageID any) *gomock.Call {
+func (mr *MockBikeRepositoryMockRecorder) ListAvailableBikes(page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAvailableBikes", reflect.TypeOf((*MockBikeRepository)(nil).ListAvailableBikes), page)
+}
+
+// RestoreBike mocks base method.
+func (m *MockBikeRepository) RestoreBike(bikeID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBike", bikeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreBike indicates an expected call of RestoreBike.
+func (mr *MockBikeRepositoryMockRecorder) RestoreBike(bikeID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAvailableBikes", reflect.TypeOf((*MockBikeRepository)(nil).ListAvailableBikes), PageID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBike", reflect.TypeOf((*MockBikeRepository)(nil).RestoreBike), bikeID)
 }
 
 // SetBikeAvailability mocks base method.
-func (m *MockBikeRepository) SetBikeAvailability(bikeID int64, isAvailable bool) error {
+func (m *MockBikeRepository) SetBikeAvailability(q database.Querier, bikeID int64, isAvailable bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetBikeAvailability", bikeID, isAvailable)
+	ret := m.ctrl.Call(m, "SetBikeAvailability", q, bikeID, isAvailable)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetBikeAvailability indicates an expected call of SetBikeAvailability.
-func (mr *MockBikeRepositoryMockRecorder) SetBikeAvailability(bikeID, isAvailable any) *gomock.Call {
+func (mr *MockBikeRepositoryMockRecorder) SetBikeAvailability(q, bikeID, isAvailable any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBikeAvailability", reflect.TypeOf((*MockBikeRepository)(nil).SetBikeAvailability), q, bikeID, isAvailable)
+}
+
+// SetBikeLocation mocks base method.
+func (m *MockBikeRepository) SetBikeLocation(q database.Querier, bikeID int64, latitude, longitude float64, stationID *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBikeLocation", q, bikeID, latitude, longitude, stationID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBikeLocation indicates an expected call of SetBikeLocation.
+func (mr *MockBikeRepositoryMockRecorder) SetBikeLocation(q, bikeID, latitude, longitude, stationID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBikeAvailability", reflect.TypeOf((*MockBikeRepository)(nil).SetBikeAvailability), bikeID, isAvailable)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBikeLocation", reflect.TypeOf((*MockBikeRepository)(nil).SetBikeLocation), q, bikeID, latitude, longitude, stationID)
 }
 
 // UpdateBike mocks base method.
-func (m *MockBikeRepository) UpdateBike(bikeID int64, fieldsToUpdate map[string]any) (int64, error) {
+func (m *MockBikeRepository) UpdateBike(bikeID int64, fieldsToUpdate map[string]any, version int64) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBike", bikeID, fieldsToUpdate)
+	ret := m.ctrl.Call(m, "UpdateBike", bikeID, fieldsToUpdate, version)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateBike indicates an expected call of UpdateBike.
-func (mr *MockBikeRepositoryMockRecorder) UpdateBike(bikeID, fieldsToUpdate any) *gomock.Call {
+func (mr *MockBikeRepositoryMockRecorder) UpdateBike(bikeID, fieldsToUpdate, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBike", reflect.TypeOf((*MockBikeRepository)(nil).UpdateBike), bikeID, fieldsToUpdate)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBike", reflect.TypeOf((*MockBikeRepository)(nil).UpdateBike), bikeID, fieldsToUpdate, version)
 }
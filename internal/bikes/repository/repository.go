@@ -3,25 +3,53 @@ package repository
 import (
 	"bikesRentalAPI/internal/bikes/models"
 	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/middlewares"
+	"bikesRentalAPI/internal/pagination"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 )
 
-const (
-	// pageSize is the number of items to return in a page, 10 as default.
-	pageSize = 10
-)
+// ListQuery is the whitelist of the filters and sort fields of the admin bike list
+var ListQuery = middlewares.QueryWhitelist{
+	Filters: map[string]middlewares.FilterField{
+		"is_available": {Column: "is_available", Type: middlewares.FieldBool, Operator: middlewares.OpEqual},
+		"station_id":   {Column: "station_id", Type: middlewares.FieldInt, Operator: middlewares.OpEqual},
+		"price_min":    {Column: "price_per_minute", Type: middlewares.FieldFloat, Operator: middlewares.OpGreaterOrEqual},
+		"price_max":    {Column: "price_per_minute", Type: middlewares.FieldFloat, Operator: middlewares.OpLessOrEqual},
+		"created_from": {Column: "created_at", Type: middlewares.FieldTime, Operator: middlewares.OpGreaterOrEqual},
+		"created_to":   {Column: "created_at", Type: middlewares.FieldTime, Operator: middlewares.OpLessOrEqual},
+		"include_deleted": {
+			Conditions: map[string]string{"false": "deleted_at IS NULL", "true": ""},
+			Default:    "false",
+		},
+	},
+	Sorts: map[string]string{"id": "id", "price_per_minute": "price_per_minute", "created_at": "created_at"},
+}
+
+// ErrBikeInUse is returned when deleting a bike with an ongoing rental
+var ErrBikeInUse = errors.New("bike is in use")
+
+// bikesTable lists the columns of a bike admins can update
+var bikesTable = database.NewTable("bikes", "is_available", "latitude", "longitude", "price_per_minute").Versioned().SoftDeleted()
 
 type BikeRepository interface {
-	ListAvailableBikes(PageID int64) (*models.BikeList, error)
-	ListAllBikes(PageID int64) (*models.BikeList, error)
-	UpdateBike(bikeID int64, fieldsToUpdate map[string]interface{}) (int64, error)
+	ListAvailableBikes(page *pagination.Page) (*models.BikeList, error)
+	ListAllBikes(page *pagination.Page, spec *middlewares.QuerySpec) (*models.BikeList, error)
+	UpdateBike(bikeID int64, fieldsToUpdate map[string]interface{}, version int64) (int64, error)
 	CreateBike(bike models.CreateUpdateBikeRequest) (int64, error)
+	CreateBikes(bikes []models.CreateUpdateBikeRequest) ([]int64, error)
+	ExportBikes(fn func(*models.Bike) error) error
 	GetBikeByID(bikeID int64) (*models.Bike, error)
 	IsBikeAvailable(bikeID int64) (bool, error)
-	SetBikeAvailability(bikeID int64, isAvailable bool) error
+	SetBikeAvailability(q database.Querier, bikeID int64, isAvailable bool) error
+	SetBikeLocation(q database.Querier, bikeID int64, latitude, longitude float64, stationID *int64) error
 	GetBikeCostPerMinute(bikeID int64) (float64, error)
+	DeleteBike(bikeID int64) error
+	RestoreBike(bikeID int64) error
 }
 
 type bikeRepository struct {
@@ -33,21 +61,22 @@ func New(db database.Database) BikeRepository {
 	return &bikeRepository{db}
 }
 
-// GetBikeByID retrieves a bike from the database by its id
+// GetBikeByID retrieves a bike from the database by its id. Deleted bikes are not found
 func (r *bikeRepository) GetBikeByID(bikeID int64) (*models.Bike, error) {
-	query := "SELECT id, is_available, price_per_minute, latitude, longitude, created_at, updated_at FROM bikes WHERE id = ?"
+	query := "SELECT id, is_available, price_per_minute, latitude, longitude, station_id, created_at, updated_at, version FROM bikes WHERE id = ? AND deleted_at IS NULL"
 	row := r.db.QueryRow(query, bikeID)
 	var bike models.Bike
-	if err := row.Scan(&bike.ID, &bike.IsAvailable, &bike.PricePerMinute, &bike.Latitude, &bike.Longitude, &bike.CreatedAt, &bike.UpdatedAt); err != nil {
+	if err := row.Scan(&bike.ID, &bike.IsAvailable, &bike.PricePerMinute, &bike.Latitude, &bike.Longitude, &bike.StationID, &bike.CreatedAt, &bike.UpdatedAt, &bike.Version); err != nil {
 		return nil, err
 	}
 	return &bike, nil
 }
 
 // ListAvailableBikes retrieves all available bikes from the database
-func (r *bikeRepository) ListAvailableBikes(PageID int64) (*models.BikeList, error) {
-	query := "SELECT id, is_available, price_per_minute FROM bikes WHERE is_available = ? AND id > ? ORDER BY id LIMIT ?"
-	rows, err := r.db.Query(query, true, PageID, pageSize)
+func (r *bikeRepository) ListAvailableBikes(page *pagination.Page) (*models.BikeList, error) {
+	keyset, keysetArgs, orderBy := page.Keyset("id", false)
+	query := fmt.Sprintf("SELECT id, is_available, price_per_minute FROM bikes WHERE is_available = ? AND deleted_at IS NULL AND %s ORDER BY %s LIMIT ?", keyset, orderBy)
+	rows, err := r.db.Query(query, append(append([]interface{}{true}, keysetArgs...), page.FetchLimit())...)
 	if err != nil {
 		return nil, err
 	}
@@ -55,6 +84,7 @@ func (r *bikeRepository) ListAvailableBikes(PageID int64) (*models.BikeList, err
 
 	bikes := &models.BikeList{}
 	bikeList := make([]*models.Bike, 0)
+	pageRows := make([]pagination.Row, 0)
 	for rows.Next() {
 		var bike models.Bike
 		if err := rows.Scan(&bike.ID, &bike.IsAvailable, &bike.PricePerMinute); err != nil {
@@ -62,19 +92,25 @@ func (r *bikeRepository) ListAvailableBikes(PageID int64) (*models.BikeList, err
 		}
 
 		bikeList = append(bikeList, &bike)
+		pageRows = append(pageRows, pagination.Row{ID: bike.ID})
 	}
-	if len(bikeList) == pageSize {
-		bikes.NextPageID = bikeList[len(bikeList)-1].ID
+	bikes.Items, bikes.Page = pagination.Paginate(page, bikeList, pageRows)
+	if page.WithTotal {
+		if bikes.Page.Total, err = r.count("is_available = ? AND deleted_at IS NULL", true); err != nil {
+			return nil, err
+		}
 	}
-	bikes.Items = bikeList
 	log.Printf("bikes: %v", bikes)
 	return bikes, nil
 }
 
-// ListAllBikes retrieves all bikes from the database
-func (r *bikeRepository) ListAllBikes(PageID int64) (*models.BikeList, error) {
-	query := "SELECT id, is_available, price_per_minute, latitude, longitude, created_at, updated_at FROM bikes WHERE id > ? ORDER BY id LIMIT ?"
-	rows, err := r.db.Query(query, PageID, pageSize)
+// ListAllBikes retrieves the bikes matching the filters of the spec from the database, in its sort order
+func (r *bikeRepository) ListAllBikes(page *pagination.Page, spec *middlewares.QuerySpec) (*models.BikeList, error) {
+	where, args := spec.Where()
+	keyset, keysetArgs, orderBy := page.Keyset(spec.SortColumn, spec.Descending)
+	query := fmt.Sprintf("SELECT id, is_available, price_per_minute, latitude, longitude, station_id, created_at, updated_at, deleted_at, %s FROM bikes WHERE %s AND %s ORDER BY %s LIMIT ?",
+		pagination.SortKey(spec.SortColumn), where, keyset, orderBy)
+	rows, err := r.db.Query(query, append(append(args, keysetArgs...), page.FetchLimit())...)
 	if err != nil {
 		return nil, err
 	}
@@ -82,47 +118,42 @@ func (r *bikeRepository) ListAllBikes(PageID int64) (*models.BikeList, error) {
 
 	bikes := &models.BikeList{}
 	bikeList := make([]*models.Bike, 0)
+	pageRows := make([]pagination.Row, 0)
 	for rows.Next() {
 		var bike models.Bike
-		if err := rows.Scan(&bike.ID, &bike.IsAvailable, &bike.PricePerMinute, &bike.Latitude, &bike.Longitude, &bike.CreatedAt, &bike.UpdatedAt); err != nil {
+		var row pagination.Row
+		if err := rows.Scan(&bike.ID, &bike.IsAvailable, &bike.PricePerMinute, &bike.Latitude, &bike.Longitude, &bike.StationID, &bike.CreatedAt, &bike.UpdatedAt, &bike.DeletedAt, &row.Key); err != nil {
 			return nil, err
 		}
+		row.ID = bike.ID
 		bikeList = append(bikeList, &bike)
+		pageRows = append(pageRows, row)
 	}
-	if len(bikeList) == pageSize {
-		bikes.NextPageID = bikeList[len(bikeList)-1].ID
+	bikes.Items, bikes.Page = pagination.Paginate(page, bikeList, pageRows)
+	if page.WithTotal {
+		if bikes.Page.Total, err = r.count(where, args...); err != nil {
+			return nil, err
+		}
 	}
-	bikes.Items = bikeList
 	return bikes, nil
 }
 
-// UpdateBike updates a bike in the database
-func (r *bikeRepository) UpdateBike(bikeID int64, fieldsToUpdate map[string]interface{}) (int64, error) {
-	var setFields []string
-	var args []interface{}
-
-	for field, value := range fieldsToUpdate {
-		setFields = append(setFields, fmt.Sprintf("%s = ?", field))
-		args = append(args, value)
+// count returns the number of bikes matching the condition
+func (r *bikeRepository) count(where string, args ...interface{}) (*int64, error) {
+	var total int64
+	if err := r.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM bikes WHERE %s", where), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count bikes: %v", err)
 	}
-	args = append(args, bikeID)
-
-	query := fmt.Sprintf("UPDATE bikes SET %s WHERE id = ?", strings.Join(setFields, ", "))
-	stmt, err := r.db.Prepare(query)
-	if err != nil {
-		return 0, fmt.Errorf("failed to prepare update statement: %v", err)
-	}
-	defer stmt.Close()
+	return &total, nil
+}
 
-	result, err := r.db.Exec(query, args...)
-	if err != nil {
+// UpdateBike updates a bike in the database if it is still at the version it was read at.
+// Returns database.ErrVersionConflict when it was changed since
+func (r *bikeRepository) UpdateBike(bikeID int64, fieldsToUpdate map[string]interface{}, version int64) (int64, error) {
+	if _, err := bikesTable.UpdateVersion(r.db, bikeID, fieldsToUpdate, version); err != nil {
 		return 0, err
 	}
-	lastInsertedID, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
-	}
-	return lastInsertedID, nil
+	return bikeID, nil
 }
 
 // CreateBike creates a bike in the database
@@ -139,8 +170,59 @@ func (r *bikeRepository) CreateBike(bike models.CreateUpdateBikeRequest) (int64,
 	return id, nil
 }
 
+// CreateBikes creates all the bikes in a single transaction. If any insert fails no bike is created
+func (r *bikeRepository) CreateBikes(bikes []models.CreateUpdateBikeRequest) ([]int64, error) {
+	ids := make([]int64, 0, len(bikes))
+	err := r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		query := "INSERT INTO bikes (is_available, price_per_minute, latitude, longitude) VALUES (?, ?, ?, ?)"
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare insert statement: %v", err)
+		}
+		defer stmt.Close()
+
+		for i, bike := range bikes {
+			result, err := stmt.Exec(bike.IsAvailable, bike.PricePerMinute, bike.Latitude, bike.Longitude)
+			if err != nil {
+				return fmt.Errorf("failed to insert bike %d: %v", i+1, err)
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get last insert id: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ExportBikes calls fn for every bike of the fleet ordered by id, without loading the whole fleet in memory
+func (r *bikeRepository) ExportBikes(fn func(*models.Bike) error) error {
+	query := "SELECT id, is_available, price_per_minute, latitude, longitude, station_id, created_at, updated_at FROM bikes WHERE deleted_at IS NULL ORDER BY id"
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bike models.Bike
+		if err := rows.Scan(&bike.ID, &bike.IsAvailable, &bike.PricePerMinute, &bike.Latitude, &bike.Longitude, &bike.StationID, &bike.CreatedAt, &bike.UpdatedAt); err != nil {
+			return err
+		}
+		if err := fn(&bike); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (r *bikeRepository) IsBikeAvailable(bikeID int64) (bool, error) {
-	query := "SELECT is_available FROM bikes WHERE id = ?"
+	query := "SELECT is_available FROM bikes WHERE id = ? AND deleted_at IS NULL"
 	row := r.db.QueryRow(query, bikeID)
 	var isAvailable bool
 	if err := row.Scan(&isAvailable); err != nil {
@@ -149,10 +231,21 @@ func (r *bikeRepository) IsBikeAvailable(bikeID int64) (bool, error) {
 	return isAvailable, nil
 }
 
-// SetBikeAvailability sets the availability of a bike in the database
-func (r *bikeRepository) SetBikeAvailability(bikeID int64, isAvailable bool) error {
+// SetBikeAvailability sets the availability of a bike in the database. q is either the database or an ongoing transaction
+func (r *bikeRepository) SetBikeAvailability(q database.Querier, bikeID int64, isAvailable bool) error {
 	query := "UPDATE bikes SET is_available = ? WHERE id = ?"
-	_, err := r.db.Exec(query, isAvailable, bikeID)
+	_, err := q.Exec(query, isAvailable, bikeID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetBikeLocation sets the location of a bike and the station it is docked at, nil for free-floating bikes.
+// q is either the database or an ongoing transaction
+func (r *bikeRepository) SetBikeLocation(q database.Querier, bikeID int64, latitude, longitude float64, stationID *int64) error {
+	query := "UPDATE bikes SET latitude = ?, longitude = ?, station_id = ? WHERE id = ?"
+	_, err := q.Exec(query, latitude, longitude, stationID, bikeID)
 	if err != nil {
 		return err
 	}
@@ -169,3 +262,41 @@ func (r *bikeRepository) GetBikeCostPerMinute(bikeID int64) (float64, error) {
 	}
 	return pricePerMinute, nil
 }
+
+// DeleteBike soft deletes a bike, it is released from its station and no longer listed nor rented.
+// Bikes with an ongoing rental can't be deleted
+func (r *bikeRepository) DeleteBike(bikeID int64) error {
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		query := "UPDATE bikes SET deleted_at = ?, station_id = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NULL"
+		result, err := tx.Exec(query, now, now, bikeID)
+		if err != nil {
+			return fmt.Errorf("failed to delete bike: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return sql.ErrNoRows
+		}
+		var inUse bool
+		query = "SELECT EXISTS (SELECT 1 FROM rentals WHERE bike_id = ? AND end_time IS NULL)"
+		if err := tx.QueryRow(query, bikeID).Scan(&inUse); err != nil {
+			return fmt.Errorf("failed to check ongoing rentals: %v", err)
+		}
+		if inUse {
+			return ErrBikeInUse
+		}
+		return nil
+	})
+}
+
+// RestoreBike restores a deleted bike, free-floating where it was deleted
+func (r *bikeRepository) RestoreBike(bikeID int64) error {
+	query := "UPDATE bikes SET deleted_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL"
+	result, err := r.db.Exec(query, time.Now().UTC(), bikeID)
+	if err != nil {
+		return fmt.Errorf("failed to restore bike: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
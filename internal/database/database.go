@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -12,10 +14,17 @@ import (
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-var dbUrl = os.Getenv("DB_URL")
+// migrations are embedded so the binary and the tests of any package can migrate a database
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// ErrVersionConflict is returned when updating a row with a version that is no longer its current one,
+// because another request changed the row since it was read
+var ErrVersionConflict = errors.New("the row was changed by another request")
 
 type Database interface {
 	Start() error
@@ -29,18 +38,27 @@ type Database interface {
 	Health() error
 }
 
+// Querier is the set of methods shared by Database and *sql.Tx.
+// Repository methods taking a Querier can run on their own or as part of an ongoing transaction
+type Querier interface {
+	Query(string, ...interface{}) (*sql.Rows, error)
+	QueryRow(string, ...interface{}) *sql.Row
+	Exec(string, ...interface{}) (sql.Result, error)
+}
+
 type database struct {
-	db *sql.DB
+	db  *sql.DB
+	url string
 }
 
-// New initializes a new empty database service
+// New initializes a new empty database service for the DB_URL database
 func New() Database {
-	return &database{db: nil}
+	return &database{db: nil, url: os.Getenv("DB_URL")}
 }
 
 // Start initializes the database connection
 func (d *database) Start() error {
-	db, err := sql.Open("sqlite3", dbUrl)
+	db, err := sql.Open(driverName, d.url)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -58,8 +76,12 @@ func (d *database) Migrate() error {
 	if err != nil {
 		return fmt.Errorf("failed to create driver: %v", err)
 	}
+	source, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %v", err)
+	}
 	// Create a new migration
-	m, err := migrate.NewWithDatabaseInstance("file://internal/database/migrations", dbUrl, driver)
+	m, err := migrate.NewWithInstance("iofs", source, d.url, driver)
 
 	if err != nil {
 		return fmt.Errorf("failed to create migration: %v", err)
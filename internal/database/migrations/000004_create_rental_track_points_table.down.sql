@@ -0,0 +1,3 @@
+ALTER TABLE rentals DROP COLUMN distance_km;
+DROP INDEX IF EXISTS idx_rental_track_points_rental_id;
+DROP TABLE IF EXISTS rental_track_points;
\ No newline at end of file
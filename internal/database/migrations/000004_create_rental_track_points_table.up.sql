@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS rental_track_points (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rental_id INTEGER NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    source TEXT NOT NULL DEFAULT 'app',
+    recorded_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(rental_id) REFERENCES rentals(id)
+);
+CREATE INDEX IF NOT EXISTS idx_rental_track_points_rental_id ON rental_track_points (rental_id, recorded_at);
+ALTER TABLE rentals ADD COLUMN distance_km REAL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_rebalancing_moves_job_id;
+DROP TABLE IF EXISTS rebalancing_moves;
+DROP TABLE IF EXISTS rebalancing_jobs;
\ No newline at end of file
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS rebalancing_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    status TEXT NOT NULL DEFAULT 'pending',
+    cell_size REAL NOT NULL,
+    demand_since TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS rebalancing_moves (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL,
+    bike_id INTEGER NOT NULL,
+    from_cell TEXT NOT NULL,
+    to_cell TEXT NOT NULL,
+    from_latitude REAL NOT NULL,
+    from_longitude REAL NOT NULL,
+    to_latitude REAL NOT NULL,
+    to_longitude REAL NOT NULL,
+    moved BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(job_id) REFERENCES rebalancing_jobs(id),
+    FOREIGN KEY(bike_id) REFERENCES bikes(id)
+);
+CREATE INDEX IF NOT EXISTS idx_rebalancing_moves_job_id ON rebalancing_moves (job_id);
@@ -0,0 +1,5 @@
+ALTER TABLE rentals DROP COLUMN end_station_id;
+ALTER TABLE rentals DROP COLUMN start_station_id;
+DROP INDEX IF EXISTS idx_bikes_station_id;
+ALTER TABLE bikes DROP COLUMN station_id;
+DROP TABLE IF EXISTS stations;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS stations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR (100) NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    capacity INTEGER NOT NULL CHECK (capacity > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE bikes ADD COLUMN station_id INTEGER REFERENCES stations(id);
+CREATE INDEX IF NOT EXISTS idx_bikes_station_id ON bikes (station_id);
+ALTER TABLE rentals ADD COLUMN start_station_id INTEGER REFERENCES stations(id);
+ALTER TABLE rentals ADD COLUMN end_station_id INTEGER REFERENCES stations(id);
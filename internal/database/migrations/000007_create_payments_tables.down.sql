@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_rental_id;
+DROP INDEX IF EXISTS idx_payment_methods_user_id;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS payment_methods;
\ No newline at end of file
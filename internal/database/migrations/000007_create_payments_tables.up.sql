@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS payment_methods (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token TEXT NOT NULL,
+    brand VARCHAR (50),
+    last4 VARCHAR (4),
+    is_default BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    UNIQUE (user_id, token)
+);
+CREATE TABLE IF NOT EXISTS payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rental_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    payment_method_id INTEGER NOT NULL,
+    provider_reference TEXT NOT NULL,
+    amount_authorized REAL NOT NULL DEFAULT 0,
+    amount_captured REAL NOT NULL DEFAULT 0,
+    amount_refunded REAL NOT NULL DEFAULT 0,
+    status TEXT NOT NULL CHECK (status IN ('authorized', 'captured', 'failed', 'refunded')),
+    failure_reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(rental_id) REFERENCES rentals(id),
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(payment_method_id) REFERENCES payment_methods(id)
+);
+CREATE INDEX IF NOT EXISTS idx_payment_methods_user_id ON payment_methods (user_id);
+CREATE INDEX IF NOT EXISTS idx_payments_rental_id ON payments (rental_id);
\ No newline at end of file
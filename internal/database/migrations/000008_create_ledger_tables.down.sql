@@ -0,0 +1,7 @@
+ALTER TABLE rentals DROP COLUMN payment_source;
+DROP INDEX IF EXISTS idx_ledger_transactions_user_id;
+DROP INDEX IF EXISTS idx_ledger_entries_transaction_id;
+DROP INDEX IF EXISTS idx_ledger_entries_account_id;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
+DROP TABLE IF EXISTS ledger_accounts;
\ No newline at end of file
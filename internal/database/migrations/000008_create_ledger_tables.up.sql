@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('wallet', 'cash', 'revenue', 'adjustments')),
+    user_id INTEGER UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('top_up', 'rental', 'adjustment')),
+    user_id INTEGER NOT NULL,
+    rental_id INTEGER,
+    reference TEXT,
+    reason TEXT,
+    created_by TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(rental_id) REFERENCES rentals(id)
+);
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL,
+    account_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(transaction_id) REFERENCES ledger_transactions(id),
+    FOREIGN KEY(account_id) REFERENCES ledger_accounts(id)
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_id ON ledger_entries (account_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_user_id ON ledger_transactions (user_id);
+INSERT INTO ledger_accounts (code, kind) VALUES ('cash', 'cash'), ('revenue', 'revenue'), ('adjustments', 'adjustments');
+ALTER TABLE rentals ADD COLUMN payment_source TEXT CHECK (payment_source IN ('card', 'wallet'));
\ No newline at end of file
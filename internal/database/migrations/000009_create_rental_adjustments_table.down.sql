@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_rental_adjustments_rental_id;
+DROP TABLE IF EXISTS rental_adjustments;
\ No newline at end of file
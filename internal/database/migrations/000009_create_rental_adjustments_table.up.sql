@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS rental_adjustments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rental_id INTEGER NOT NULL,
+    amount REAL NOT NULL CHECK (amount <> 0),
+    reason TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    card_refund REAL NOT NULL DEFAULT 0,
+    wallet_refund REAL NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(rental_id) REFERENCES rentals(id)
+);
+CREATE INDEX IF NOT EXISTS idx_rental_adjustments_rental_id ON rental_adjustments (rental_id);
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS invoice_lines_no_delete;
+DROP TRIGGER IF EXISTS invoice_lines_no_update;
+DROP TRIGGER IF EXISTS invoices_no_delete;
+DROP TRIGGER IF EXISTS invoices_no_update;
+DROP INDEX IF EXISTS idx_invoice_lines_invoice_id;
+DROP INDEX IF EXISTS idx_invoices_user_id_issued_at;
+DROP TABLE IF EXISTS invoice_lines;
+DROP TABLE IF EXISTS invoices;
\ No newline at end of file
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS invoices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sequence INTEGER NOT NULL UNIQUE,
+    number TEXT NOT NULL UNIQUE,
+    rental_id INTEGER NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    tax_rate REAL NOT NULL,
+    subtotal INTEGER NOT NULL,
+    tax INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    issued_at TIMESTAMP NOT NULL,
+    FOREIGN KEY(rental_id) REFERENCES rentals(id),
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    invoice_id INTEGER NOT NULL,
+    code TEXT NOT NULL,
+    description TEXT NOT NULL,
+    quantity REAL NOT NULL,
+    unit_price REAL NOT NULL,
+    amount INTEGER NOT NULL,
+    FOREIGN KEY(invoice_id) REFERENCES invoices(id)
+);
+CREATE INDEX IF NOT EXISTS idx_invoices_user_id_issued_at ON invoices (user_id, issued_at);
+CREATE INDEX IF NOT EXISTS idx_invoice_lines_invoice_id ON invoice_lines (invoice_id);
+CREATE TRIGGER IF NOT EXISTS invoices_no_update BEFORE UPDATE ON invoices
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable');
+END;
+CREATE TRIGGER IF NOT EXISTS invoices_no_delete BEFORE DELETE ON invoices
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable');
+END;
+CREATE TRIGGER IF NOT EXISTS invoice_lines_no_update BEFORE UPDATE ON invoice_lines
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable');
+END;
+CREATE TRIGGER IF NOT EXISTS invoice_lines_no_delete BEFORE DELETE ON invoice_lines
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable');
+END;
\ No newline at end of file
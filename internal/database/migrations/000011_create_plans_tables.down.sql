@@ -0,0 +1,5 @@
+ALTER TABLE rentals DROP COLUMN subscription_id;
+ALTER TABLE rentals DROP COLUMN plan_id;
+DROP INDEX IF EXISTS idx_subscriptions_user_id;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS plans;
\ No newline at end of file
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS plans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR (100) NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('monthly', 'day')),
+    price REAL NOT NULL CHECK (price >= 0),
+    duration_days INTEGER NOT NULL CHECK (duration_days > 0),
+    included_minutes_per_ride INTEGER NOT NULL DEFAULT 0 CHECK (included_minutes_per_ride >= 0),
+    discount_percent REAL NOT NULL DEFAULT 0 CHECK (discount_percent >= 0 AND discount_percent <= 100),
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    plan_id INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('active', 'cancelled', 'expired')),
+    auto_renew BOOLEAN NOT NULL DEFAULT 0,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    provider_reference TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(plan_id) REFERENCES plans(id)
+);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions (user_id, end_time);
+ALTER TABLE rentals ADD COLUMN plan_id INTEGER REFERENCES plans(id);
+ALTER TABLE rentals ADD COLUMN subscription_id INTEGER REFERENCES subscriptions(id);
\ No newline at end of file
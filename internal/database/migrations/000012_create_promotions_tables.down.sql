@@ -0,0 +1,8 @@
+ALTER TABLE rentals DROP COLUMN promo_redemption_id;
+DROP TABLE IF EXISTS referral_rewards;
+DROP INDEX IF EXISTS idx_users_referral_code;
+ALTER TABLE users DROP COLUMN referred_by;
+ALTER TABLE users DROP COLUMN referral_code;
+DROP INDEX IF EXISTS idx_promo_redemptions_user_id;
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
\ No newline at end of file
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code VARCHAR (32) NOT NULL UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('percent_off', 'fixed_credit', 'free_unlocks')),
+    value REAL NOT NULL DEFAULT 0 CHECK (value >= 0),
+    rides INTEGER NOT NULL DEFAULT 1 CHECK (rides > 0),
+    max_redemptions INTEGER CHECK (max_redemptions > 0),
+    redemptions_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    promo_code_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    remaining_rides INTEGER NOT NULL DEFAULT 0 CHECK (remaining_rides >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(promo_code_id, user_id),
+    FOREIGN KEY(promo_code_id) REFERENCES promo_codes(id),
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_user_id ON promo_redemptions (user_id);
+ALTER TABLE users ADD COLUMN referral_code VARCHAR (20);
+ALTER TABLE users ADD COLUMN referred_by INTEGER REFERENCES users(id);
+UPDATE users SET referral_code = upper(hex(randomblob(4))) WHERE referral_code IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_referral_code ON users (referral_code);
+CREATE TABLE IF NOT EXISTS referral_rewards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    referrer_id INTEGER NOT NULL,
+    referee_id INTEGER NOT NULL UNIQUE,
+    rental_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(referrer_id) REFERENCES users(id),
+    FOREIGN KEY(referee_id) REFERENCES users(id),
+    FOREIGN KEY(rental_id) REFERENCES rentals(id)
+);
+ALTER TABLE rentals ADD COLUMN promo_redemption_id INTEGER REFERENCES promo_redemptions(id);
\ No newline at end of file
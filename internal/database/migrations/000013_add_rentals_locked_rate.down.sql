@@ -0,0 +1,2 @@
+ALTER TABLE rentals DROP COLUMN surge_multiplier;
+ALTER TABLE rentals DROP COLUMN price_per_minute;
\ No newline at end of file
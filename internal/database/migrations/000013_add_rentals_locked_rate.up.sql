@@ -0,0 +1,2 @@
+ALTER TABLE rentals ADD COLUMN price_per_minute REAL;
+ALTER TABLE rentals ADD COLUMN surge_multiplier REAL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE rentals DROP COLUMN paused_price_per_minute;
+DROP INDEX IF EXISTS idx_rental_pauses_open;
+DROP INDEX IF EXISTS idx_rental_pauses_rental_id;
+DROP TABLE IF EXISTS rental_pauses;
\ No newline at end of file
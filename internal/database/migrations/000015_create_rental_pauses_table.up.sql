@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS rental_pauses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rental_id INTEGER NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    auto_resumed BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(rental_id) REFERENCES rentals(id)
+);
+CREATE INDEX IF NOT EXISTS idx_rental_pauses_rental_id ON rental_pauses (rental_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rental_pauses_open ON rental_pauses (rental_id) WHERE ended_at IS NULL;
+ALTER TABLE rentals ADD COLUMN paused_price_per_minute REAL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_bikes_deleted_at;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE bikes DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
\ No newline at end of file
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS rental_pauses_update_version;
+DROP TRIGGER IF EXISTS rental_pauses_insert_version;
+DROP TRIGGER IF EXISTS rental_adjustments_version;
+DROP TRIGGER IF EXISTS rentals_version;
+DROP TRIGGER IF EXISTS users_version;
+DROP TRIGGER IF EXISTS bikes_version;
+ALTER TABLE rentals DROP COLUMN version;
+ALTER TABLE users DROP COLUMN version;
+ALTER TABLE bikes DROP COLUMN version;
\ No newline at end of file
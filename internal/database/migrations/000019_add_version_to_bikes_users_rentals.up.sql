@@ -0,0 +1,27 @@
+ALTER TABLE bikes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE rentals ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+CREATE TRIGGER IF NOT EXISTS bikes_version AFTER UPDATE ON bikes WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE bikes SET version = OLD.version + 1 WHERE id = NEW.id;
+END;
+CREATE TRIGGER IF NOT EXISTS users_version AFTER UPDATE ON users WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE users SET version = OLD.version + 1 WHERE id = NEW.id;
+END;
+CREATE TRIGGER IF NOT EXISTS rentals_version AFTER UPDATE ON rentals WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE rentals SET version = OLD.version + 1 WHERE id = NEW.id;
+END;
+CREATE TRIGGER IF NOT EXISTS rental_adjustments_version AFTER INSERT ON rental_adjustments
+BEGIN
+    UPDATE rentals SET version = version + 1 WHERE id = NEW.rental_id;
+END;
+CREATE TRIGGER IF NOT EXISTS rental_pauses_insert_version AFTER INSERT ON rental_pauses
+BEGIN
+    UPDATE rentals SET version = version + 1 WHERE id = NEW.rental_id;
+END;
+CREATE TRIGGER IF NOT EXISTS rental_pauses_update_version AFTER UPDATE ON rental_pauses
+BEGIN
+    UPDATE rentals SET version = version + 1 WHERE id = NEW.rental_id;
+END;
\ No newline at end of file
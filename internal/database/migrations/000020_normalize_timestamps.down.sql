@@ -0,0 +1,58 @@
+DROP TRIGGER IF EXISTS users_timestamps;
+DROP TRIGGER IF EXISTS bikes_timestamps;
+DROP TRIGGER IF EXISTS rentals_timestamps;
+DROP TRIGGER IF EXISTS rental_track_points_timestamps;
+DROP TRIGGER IF EXISTS rebalancing_jobs_timestamps;
+DROP TRIGGER IF EXISTS rebalancing_moves_timestamps;
+DROP TRIGGER IF EXISTS stations_timestamps;
+DROP TRIGGER IF EXISTS payment_methods_timestamps;
+DROP TRIGGER IF EXISTS payments_timestamps;
+DROP TRIGGER IF EXISTS ledger_accounts_timestamps;
+DROP TRIGGER IF EXISTS ledger_transactions_timestamps;
+DROP TRIGGER IF EXISTS ledger_entries_timestamps;
+DROP TRIGGER IF EXISTS rental_adjustments_timestamps;
+DROP TRIGGER IF EXISTS plans_timestamps;
+DROP TRIGGER IF EXISTS subscriptions_timestamps;
+DROP TRIGGER IF EXISTS promo_codes_timestamps;
+DROP TRIGGER IF EXISTS promo_redemptions_timestamps;
+DROP TRIGGER IF EXISTS referral_rewards_timestamps;
+DROP TRIGGER IF EXISTS idempotency_keys_timestamps;
+DROP TRIGGER IF EXISTS rental_pauses_timestamps;
+DROP TRIGGER IF EXISTS rebalancing_jobs_updated_at;
+DROP TRIGGER IF EXISTS stations_updated_at;
+DROP TRIGGER IF EXISTS payment_methods_updated_at;
+DROP TRIGGER IF EXISTS payments_updated_at;
+DROP TRIGGER IF EXISTS plans_updated_at;
+DROP TRIGGER IF EXISTS subscriptions_updated_at;
+DROP TRIGGER IF EXISTS promo_codes_updated_at;
+DROP TRIGGER IF EXISTS bikes_version;
+DROP TRIGGER IF EXISTS users_version;
+DROP TRIGGER IF EXISTS rentals_version;
+DROP TRIGGER IF EXISTS rental_adjustments_version;
+DROP TRIGGER IF EXISTS rental_pauses_insert_version;
+DROP TRIGGER IF EXISTS rental_pauses_update_version;
+
+CREATE TRIGGER bikes_version AFTER UPDATE ON bikes WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE bikes SET version = OLD.version + 1 WHERE id = NEW.id;
+END;
+CREATE TRIGGER users_version AFTER UPDATE ON users WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE users SET version = OLD.version + 1 WHERE id = NEW.id;
+END;
+CREATE TRIGGER rentals_version AFTER UPDATE ON rentals WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE rentals SET version = OLD.version + 1 WHERE id = NEW.id;
+END;
+CREATE TRIGGER rental_adjustments_version AFTER INSERT ON rental_adjustments
+BEGIN
+    UPDATE rentals SET version = version + 1 WHERE id = NEW.rental_id;
+END;
+CREATE TRIGGER rental_pauses_insert_version AFTER INSERT ON rental_pauses
+BEGIN
+    UPDATE rentals SET version = version + 1 WHERE id = NEW.rental_id;
+END;
+CREATE TRIGGER rental_pauses_update_version AFTER UPDATE ON rental_pauses
+BEGIN
+    UPDATE rentals SET version = version + 1 WHERE id = NEW.rental_id;
+END;
\ No newline at end of file
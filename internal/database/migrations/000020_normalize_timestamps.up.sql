@@ -0,0 +1,225 @@
+-- Timestamps are stored in UTC RFC3339 with milliseconds, the format of strftime('%Y-%m-%dT%H:%M:%fZ'),
+-- so they compare and sort as text. The triggers changing rows are dropped while existing rows are normalized
+DROP TRIGGER bikes_version;
+DROP TRIGGER users_version;
+DROP TRIGGER rentals_version;
+DROP TRIGGER rental_adjustments_version;
+DROP TRIGGER rental_pauses_insert_version;
+DROP TRIGGER rental_pauses_update_version;
+DROP TRIGGER invoices_no_update;
+DROP TRIGGER audit_log_no_update;
+
+UPDATE users SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at),
+    deleted_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', deleted_at), deleted_at),
+    anonymized_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', anonymized_at), anonymized_at);
+UPDATE bikes SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at),
+    deleted_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', deleted_at), deleted_at);
+UPDATE rentals SET
+    start_time = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', start_time), start_time),
+    end_time = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', end_time), end_time),
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE rental_track_points SET
+    recorded_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', recorded_at), recorded_at),
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE rebalancing_jobs SET
+    demand_since = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', demand_since), demand_since),
+    completed_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', completed_at), completed_at),
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE rebalancing_moves SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE stations SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE payment_methods SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE payments SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE ledger_accounts SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE ledger_transactions SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE ledger_entries SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE rental_adjustments SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE invoices SET
+    issued_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', issued_at), issued_at);
+UPDATE plans SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE subscriptions SET
+    start_time = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', start_time), start_time),
+    end_time = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', end_time), end_time),
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE promo_codes SET
+    expires_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', expires_at), expires_at),
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    updated_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', updated_at), updated_at);
+UPDATE promo_redemptions SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE referral_rewards SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE idempotency_keys SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at),
+    expires_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', expires_at), expires_at);
+UPDATE rental_pauses SET
+    started_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', started_at), started_at),
+    ended_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', ended_at), ended_at),
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+UPDATE audit_log SET
+    created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', created_at), created_at);
+
+CREATE TRIGGER invoices_no_update BEFORE UPDATE ON invoices
+BEGIN
+    SELECT RAISE(ABORT, 'invoices are immutable');
+END;
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'the audit log is append-only');
+END;
+
+-- The CURRENT_TIMESTAMP defaults of new rows are rewritten in the stored format
+CREATE TRIGGER users_timestamps AFTER INSERT ON users WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE users SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER bikes_timestamps AFTER INSERT ON bikes WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE bikes SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER rentals_timestamps AFTER INSERT ON rentals WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE rentals SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER rental_track_points_timestamps AFTER INSERT ON rental_track_points WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE rental_track_points SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER rebalancing_jobs_timestamps AFTER INSERT ON rebalancing_jobs WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE rebalancing_jobs SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER rebalancing_moves_timestamps AFTER INSERT ON rebalancing_moves WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE rebalancing_moves SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER stations_timestamps AFTER INSERT ON stations WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE stations SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER payment_methods_timestamps AFTER INSERT ON payment_methods WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE payment_methods SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER payments_timestamps AFTER INSERT ON payments WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE payments SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER ledger_accounts_timestamps AFTER INSERT ON ledger_accounts WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE ledger_accounts SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER ledger_transactions_timestamps AFTER INSERT ON ledger_transactions WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE ledger_transactions SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER ledger_entries_timestamps AFTER INSERT ON ledger_entries WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE ledger_entries SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER rental_adjustments_timestamps AFTER INSERT ON rental_adjustments WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE rental_adjustments SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER plans_timestamps AFTER INSERT ON plans WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE plans SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER subscriptions_timestamps AFTER INSERT ON subscriptions WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE subscriptions SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER promo_codes_timestamps AFTER INSERT ON promo_codes WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE promo_codes SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER promo_redemptions_timestamps AFTER INSERT ON promo_redemptions WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE promo_redemptions SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER referral_rewards_timestamps AFTER INSERT ON referral_rewards WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE referral_rewards SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER idempotency_keys_timestamps AFTER INSERT ON idempotency_keys WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE idempotency_keys SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER rental_pauses_timestamps AFTER INSERT ON rental_pauses WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE rental_pauses SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at) WHERE id = NEW.id;
+END;
+
+-- Every change of a row sets its updated_at. Versioned rows get a new version as well, but not when their
+-- timestamps are rewritten after insert
+CREATE TRIGGER bikes_version AFTER UPDATE ON bikes WHEN NEW.version = OLD.version AND NEW.created_at IS OLD.created_at
+BEGIN
+    UPDATE bikes SET version = OLD.version + 1, updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER users_version AFTER UPDATE ON users WHEN NEW.version = OLD.version AND NEW.created_at IS OLD.created_at
+BEGIN
+    UPDATE users SET version = OLD.version + 1, updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER rentals_version AFTER UPDATE ON rentals WHEN NEW.version = OLD.version AND NEW.created_at IS OLD.created_at
+BEGIN
+    UPDATE rentals SET version = OLD.version + 1, updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER rebalancing_jobs_updated_at AFTER UPDATE ON rebalancing_jobs WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE rebalancing_jobs SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER stations_updated_at AFTER UPDATE ON stations WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE stations SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER payment_methods_updated_at AFTER UPDATE ON payment_methods WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE payment_methods SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER payments_updated_at AFTER UPDATE ON payments WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE payments SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER plans_updated_at AFTER UPDATE ON plans WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE plans SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER subscriptions_updated_at AFTER UPDATE ON subscriptions WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE subscriptions SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER promo_codes_updated_at AFTER UPDATE ON promo_codes WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE promo_codes SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER rental_adjustments_version AFTER INSERT ON rental_adjustments
+BEGIN
+    UPDATE rentals SET version = version + 1, updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.rental_id;
+END;
+CREATE TRIGGER rental_pauses_insert_version AFTER INSERT ON rental_pauses
+BEGIN
+    UPDATE rentals SET version = version + 1, updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.rental_id;
+END;
+CREATE TRIGGER rental_pauses_update_version AFTER UPDATE ON rental_pauses WHEN NEW.created_at IS OLD.created_at
+BEGIN
+    UPDATE rentals SET version = version + 1, updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.rental_id;
+END;
\ No newline at end of file
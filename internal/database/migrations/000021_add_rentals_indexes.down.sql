@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_rentals_start_time;
+DROP INDEX IF EXISTS idx_rentals_bike_id_start_time;
\ No newline at end of file
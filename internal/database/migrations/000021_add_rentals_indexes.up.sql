@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_rentals_bike_id_start_time ON rentals (bike_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_rentals_start_time ON rentals (start_time);
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- Audit entries stay append-only, but the personal data they hold can be erased when their user is anonymized:
+-- the values of the changes and the IP address can only be set to NULL
+DROP TRIGGER audit_log_no_update;
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+WHEN NOT (NEW.id IS OLD.id AND NEW.actor IS OLD.actor AND NEW.action IS OLD.action
+    AND NEW.resource_type IS OLD.resource_type AND NEW.resource_id IS OLD.resource_id
+    AND NEW.status_code IS OLD.status_code AND NEW.request_id IS OLD.request_id AND NEW.created_at IS OLD.created_at
+    AND (NEW.before IS OLD.before OR NEW.before IS NULL)
+    AND (NEW.after IS OLD.after OR NEW.after IS NULL)
+    AND (NEW.ip IS OLD.ip OR NEW.ip IS NULL))
+BEGIN
+    SELECT RAISE(ABORT, 'the audit log is append-only');
+END;
\ No newline at end of file
@@ -0,0 +1,30 @@
+-- Authorizations of rides with nothing left to charge are voided instead of captured
+CREATE TABLE payments_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rental_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    payment_method_id INTEGER NOT NULL,
+    provider_reference TEXT NOT NULL,
+    amount_authorized REAL NOT NULL DEFAULT 0,
+    amount_captured REAL NOT NULL DEFAULT 0,
+    amount_refunded REAL NOT NULL DEFAULT 0,
+    status TEXT NOT NULL CHECK (status IN ('authorized', 'captured', 'failed', 'refunded', 'voided')),
+    failure_reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(rental_id) REFERENCES rentals(id),
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(payment_method_id) REFERENCES payment_methods(id)
+);
+INSERT INTO payments_new SELECT * FROM payments;
+DROP TABLE payments;
+ALTER TABLE payments_new RENAME TO payments;
+CREATE INDEX IF NOT EXISTS idx_payments_rental_id ON payments (rental_id);
+CREATE TRIGGER payments_timestamps AFTER INSERT ON payments WHEN NEW.created_at NOT LIKE '%Z'
+BEGIN
+    UPDATE payments SET created_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at), updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at) WHERE id = NEW.id;
+END;
+CREATE TRIGGER payments_updated_at AFTER UPDATE ON payments WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE payments SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS bikes_availability_update;
+DROP TRIGGER IF EXISTS bikes_availability_insert;
+DROP TABLE IF EXISTS bike_availability_changes;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- Every change of the availability of a bike is recorded, to know how long bikes were out of service
+CREATE TABLE IF NOT EXISTS bike_availability_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bike_id INTEGER NOT NULL,
+    is_available BOOLEAN NOT NULL,
+    changed_at TIMESTAMP NOT NULL,
+    FOREIGN KEY(bike_id) REFERENCES bikes(id)
+);
+CREATE INDEX IF NOT EXISTS idx_bike_availability_changes_bike_id_changed_at ON bike_availability_changes (bike_id, changed_at);
+-- Existing bikes are assumed available since they were added, and out of service since their last change when they are now
+INSERT INTO bike_availability_changes (bike_id, is_available, changed_at)
+    SELECT id, 1, created_at FROM bikes;
+INSERT INTO bike_availability_changes (bike_id, is_available, changed_at)
+    SELECT id, 0, MAX(updated_at, created_at) FROM bikes WHERE is_available = 0;
+CREATE TRIGGER bikes_availability_insert AFTER INSERT ON bikes
+BEGIN
+    INSERT INTO bike_availability_changes (bike_id, is_available, changed_at)
+    VALUES (NEW.id, NEW.is_available, strftime('%Y-%m-%dT%H:%M:%fZ', COALESCE(NEW.created_at, 'now')));
+END;
+CREATE TRIGGER bikes_availability_update AFTER UPDATE OF is_available ON bikes WHEN NEW.is_available IS NOT OLD.is_available
+BEGIN
+    INSERT INTO bike_availability_changes (bike_id, is_available, changed_at)
+    VALUES (NEW.id, NEW.is_available, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'));
+END;
\ No newline at end of file
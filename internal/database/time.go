@@ -0,0 +1,59 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// TimeFormat is the layout timestamps are stored in: UTC RFC3339 with milliseconds.
+// Its fixed width keeps the text order of timestamps their time order, so they can be compared and sorted in SQL
+const TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// CurrentTimestamp is the SQL expression of the current time in TimeFormat, used instead of CURRENT_TIMESTAMP
+const CurrentTimestamp = "strftime('%Y-%m-%dT%H:%M:%fZ', 'now')"
+
+// driverName is the name of the sqlite3 driver writing times in TimeFormat
+const driverName = "sqlite3_utc"
+
+func init() {
+	sql.Register(driverName, &utcDriver{})
+}
+
+// FormatTime returns the time as it is stored in the database
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeFormat)
+}
+
+// utcDriver is the sqlite3 driver with connections writing times in TimeFormat.
+// The sqlite3 driver writes them in their own location with nanoseconds, which can't be compared with each other as text
+type utcDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *utcDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &utcConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type utcConn struct {
+	*sqlite3.SQLiteConn
+}
+
+// CheckNamedValue converts the arguments of a statement like the default converter, formatting times with FormatTime
+func (c *utcConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = FormatTime(t)
+	}
+	nv.Value = value
+	return nil
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestDatabase starts a new in-memory database, migrated to the version
+func startTestDatabase(t *testing.T, name string, version uint) (*database, *migrate.Migrate) {
+	t.Setenv("DB_URL", "file:"+name+"?mode=memory&cache=shared")
+	db := New().(*database)
+	require.NoError(t, db.Start())
+	t.Cleanup(func() { db.Close() })
+	driver, err := sqlite3.WithInstance(db.db, &sqlite3.Config{})
+	require.NoError(t, err)
+	source, err := iofs.New(migrations, "migrations")
+	require.NoError(t, err)
+	m, err := migrate.NewWithInstance("iofs", source, db.url, driver)
+	require.NoError(t, err)
+	require.NoError(t, m.Migrate(version))
+	return db, m
+}
+
+// storedText returns the text the column of the row with the id is stored as
+func storedText(t *testing.T, db Database, table, column string, id int64) string {
+	var text string
+	require.NoError(t, db.QueryRow("SELECT CAST("+column+" AS TEXT) FROM "+table+" WHERE id = ?", id).Scan(&text))
+	return text
+}
+
+func TestTimestamps(t *testing.T) {
+	db, _ := startTestDatabase(t, "timestamps_test", 20)
+	madrid := time.FixedZone("CEST", 2*60*60)
+
+	t.Run("Success - times are stored in UTC RFC3339 and read back", func(t *testing.T) {
+		// GIVEN: a rental started at a local time with nanoseconds
+		startTime := time.Date(2024, 6, 1, 10, 30, 15, 123456789, madrid)
+		result, err := db.Exec("INSERT INTO rentals (user_id, bike_id, start_time, start_latitude, start_longitude, cost) VALUES (1, 1, ?, 40.4, -3.7, 0)", startTime)
+		require.NoError(t, err)
+		id, err := result.LastInsertId()
+		require.NoError(t, err)
+		// WHEN: it is read back
+		var read time.Time
+		require.NoError(t, db.QueryRow("SELECT start_time FROM rentals WHERE id = ?", id).Scan(&read))
+		// THEN: it is stored in UTC with milliseconds and is the same instant
+		assert.Equal(t, "2024-06-01T08:30:15.123Z", storedText(t, db, "rentals", "start_time", id))
+		assert.True(t, read.Equal(startTime.Truncate(time.Millisecond)), "read %v", read)
+		assert.Equal(t, time.UTC, read.Location())
+		// THEN: times compare as text in SQL
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM rentals WHERE start_time > ?", startTime.Add(-time.Second)).Scan(&count))
+		assert.Equal(t, 1, count)
+	})
+	t.Run("Success - defaults of new rows are stored in the same format", func(t *testing.T) {
+		result, err := db.Exec("INSERT INTO bikes (is_available, latitude, longitude, price_per_minute) VALUES (1, 40.4, -3.7, 0.1)")
+		require.NoError(t, err)
+		id, err := result.LastInsertId()
+		require.NoError(t, err)
+		for _, column := range []string{"created_at", "updated_at"} {
+			text := storedText(t, db, "bikes", column, id)
+			stored, err := time.Parse(TimeFormat, text)
+			require.NoError(t, err, text)
+			assert.WithinDuration(t, time.Now(), stored, time.Minute)
+		}
+		var version int64
+		require.NoError(t, db.QueryRow("SELECT version FROM bikes WHERE id = ?", id).Scan(&version))
+		assert.Equal(t, int64(1), version, "rewriting the defaults is not a change")
+	})
+	t.Run("Success - updated_at is set by every change", func(t *testing.T) {
+		// GIVEN: a bike and a station last changed long ago
+		_, err := db.Exec("INSERT INTO stations (id, name, latitude, longitude, capacity) VALUES (1, 'Sol', 40.4, -3.7, 10)")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute) VALUES (50, 1, 40.4, -3.7, 0.1)")
+		require.NoError(t, err)
+		longAgo := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		_, err = db.Exec("UPDATE stations SET updated_at = ? WHERE id = 1", longAgo)
+		require.NoError(t, err)
+		assert.Equal(t, "2020-01-01T00:00:00.000Z", storedText(t, db, "stations", "updated_at", 1))
+
+		// WHEN: they are changed without setting updated_at
+		_, err = db.Exec("UPDATE stations SET capacity = 12 WHERE id = 1")
+		require.NoError(t, err)
+		_, err = db.Exec("UPDATE bikes SET is_available = 0 WHERE id = 50")
+		require.NoError(t, err)
+
+		// THEN: their updated_at is now and the bike has a new version
+		var stationUpdatedAt, bikeUpdatedAt time.Time
+		var version int64
+		require.NoError(t, db.QueryRow("SELECT updated_at FROM stations WHERE id = 1").Scan(&stationUpdatedAt))
+		require.NoError(t, db.QueryRow("SELECT updated_at, version FROM bikes WHERE id = 50").Scan(&bikeUpdatedAt, &version))
+		assert.WithinDuration(t, time.Now(), stationUpdatedAt, time.Minute)
+		assert.WithinDuration(t, time.Now(), bikeUpdatedAt, time.Minute)
+		assert.Equal(t, int64(2), version)
+		_, err = time.Parse(TimeFormat, storedText(t, db, "bikes", "updated_at", 50))
+		assert.NoError(t, err)
+	})
+}
+
+func TestNormalizeTimestampsMigration(t *testing.T) {
+	// GIVEN: rows written before the migration with the formats of CURRENT_TIMESTAMP and of the sqlite3 driver
+	db, m := startTestDatabase(t, "normalize_timestamps_test", 19)
+	_, err := db.Exec(`INSERT INTO users (id, email, hashed_password, created_at, updated_at) VALUES
+		(1, 'rider@example.com', 'x', '2024-06-01 08:30:15', '2024-06-01 10:30:15.123456789+02:00')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO rentals (id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, cost) VALUES
+		(1, 1, 1, '2024-06-01 10:30:15.5+02:00', NULL, 40.4, -3.7, 0)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO audit_log (actor, action, resource_type, resource_id, status_code, created_at) VALUES
+		('admin:admin', 'login', 'users', '1', 200, '2024-06-01 10:30:15+02:00')`)
+	require.NoError(t, err)
+
+	// WHEN: the migration runs
+	require.NoError(t, m.Migrate(20))
+
+	// THEN: the timestamps are stored in UTC RFC3339, null ones are left null and versions are unchanged
+	assert.Equal(t, "2024-06-01T08:30:15.000Z", storedText(t, db, "users", "created_at", 1))
+	assert.Equal(t, "2024-06-01T08:30:15.123Z", storedText(t, db, "users", "updated_at", 1))
+	assert.Equal(t, "2024-06-01T08:30:15.500Z", storedText(t, db, "rentals", "start_time", 1))
+	assert.Equal(t, "2024-06-01T08:30:15.000Z", storedText(t, db, "audit_log", "created_at", 1))
+	var endTime *time.Time
+	var version int64
+	require.NoError(t, db.QueryRow("SELECT end_time, version FROM rentals WHERE id = 1").Scan(&endTime, &version))
+	assert.Nil(t, endTime)
+	assert.Equal(t, int64(1), version)
+	// THEN: the audit log is still append-only
+	_, err = db.Exec("UPDATE audit_log SET actor = 'admin:other'")
+	assert.Error(t, err)
+
+	// THEN: the migration can be rolled back and applied again
+	require.NoError(t, m.Migrate(19))
+	require.NoError(t, m.Migrate(20))
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownColumn is returned when an update sets a column that is not one of the updatable columns of the table
+var ErrUnknownColumn = errors.New("unknown column")
+
+// ErrNoFieldsToUpdate is returned when an update sets no column
+var ErrNoFieldsToUpdate = errors.New("no fields to update")
+
+// Table describes the rows of a table that can be updated by id. Only the columns it lists can be set,
+// so column names never reach the SQL from anywhere else
+type Table struct {
+	name    string
+	columns map[string]bool
+	// versioned tables are only updated at the version the row was read at
+	versioned bool
+	// softDeleted tables don't update deleted rows
+	softDeleted bool
+}
+
+// NewTable returns the table with the updatable columns
+func NewTable(name string, columns ...string) *Table {
+	table := &Table{name: name, columns: make(map[string]bool, len(columns))}
+	for _, column := range columns {
+		table.columns[column] = true
+	}
+	return table
+}
+
+// Versioned makes the updates of the table check the version of the row, see UpdateVersion
+func (t *Table) Versioned() *Table {
+	t.versioned = true
+	return t
+}
+
+// SoftDeleted makes the updates of the table skip the rows with deleted_at set
+func (t *Table) SoftDeleted() *Table {
+	t.softDeleted = true
+	return t
+}
+
+// BuildUpdate returns the UPDATE statement setting the fields of the row with the id, and its arguments.
+// Columns are set in alphabetical order and updated_at is always set. The version is checked when it isn't nil
+func (t *Table) BuildUpdate(id int64, fieldsToUpdate map[string]interface{}, version *int64) (string, []interface{}, error) {
+	if len(fieldsToUpdate) == 0 {
+		return "", nil, ErrNoFieldsToUpdate
+	}
+	fields := make([]string, 0, len(fieldsToUpdate))
+	for field := range fieldsToUpdate {
+		if !t.columns[field] {
+			return "", nil, fmt.Errorf("%w %q in %s", ErrUnknownColumn, field, t.name)
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	setFields := make([]string, 0, len(fields)+1)
+	args := make([]interface{}, 0, len(fields)+2)
+	for _, field := range fields {
+		setFields = append(setFields, field+" = ?")
+		args = append(args, fieldsToUpdate[field])
+	}
+	setFields = append(setFields, "updated_at = "+CurrentTimestamp)
+
+	where := []string{"id = ?"}
+	args = append(args, id)
+	if t.softDeleted {
+		where = append(where, "deleted_at IS NULL")
+	}
+	if version != nil {
+		where = append(where, "version = ?")
+		args = append(args, *version)
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", t.name, strings.Join(setFields, ", "), strings.Join(where, " AND "))
+	return query, args, nil
+}
+
+// Update sets the fields of the row with the id and returns the number of rows affected.
+// Returns sql.ErrNoRows when there is no such row
+func (t *Table) Update(q Querier, id int64, fieldsToUpdate map[string]interface{}) (int64, error) {
+	return t.update(q, id, fieldsToUpdate, nil)
+}
+
+// UpdateVersion sets the fields of the row with the id if it is still at the version it was read at, and returns
+// the number of rows affected. Returns sql.ErrNoRows when there is no such row and ErrVersionConflict when it was changed since
+func (t *Table) UpdateVersion(q Querier, id int64, fieldsToUpdate map[string]interface{}, version int64) (int64, error) {
+	if !t.versioned {
+		return 0, fmt.Errorf("table %s has no version", t.name)
+	}
+	return t.update(q, id, fieldsToUpdate, &version)
+}
+
+func (t *Table) update(q Querier, id int64, fieldsToUpdate map[string]interface{}, version *int64) (int64, error) {
+	query, args, err := t.BuildUpdate(id, fieldsToUpdate, version)
+	if err != nil {
+		return 0, err
+	}
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute update statement: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %v", err)
+	}
+	if affected > 0 {
+		return affected, nil
+	}
+	if version == nil {
+		return 0, sql.ErrNoRows
+	}
+
+	// The row is either missing or at another version
+	query = fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = ?", t.name)
+	if t.softDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	var exists bool
+	if err := q.QueryRow(query+")", id).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to check %s: %v", t.name, err)
+	}
+	if !exists {
+		return 0, sql.ErrNoRows
+	}
+	return 0, ErrVersionConflict
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildUpdate(t *testing.T) {
+	table := NewTable("bikes", "latitude", "longitude", "price_per_minute").Versioned().SoftDeleted()
+	version := int64(3)
+
+	t.Run("Success - columns are set in a deterministic order with updated_at", func(t *testing.T) {
+		fields := map[string]interface{}{"price_per_minute": 0.2, "longitude": -3.7, "latitude": 40.4}
+		for i := 0; i < 20; i++ {
+			query, args, err := table.BuildUpdate(7, fields, &version)
+			require.NoError(t, err)
+			assert.Equal(t, "UPDATE bikes SET latitude = ?, longitude = ?, price_per_minute = ?, updated_at = "+CurrentTimestamp+" WHERE id = ? AND deleted_at IS NULL AND version = ?", query)
+			assert.Equal(t, []interface{}{40.4, -3.7, 0.2, int64(7), int64(3)}, args)
+		}
+	})
+	t.Run("Success - the version is only checked when given", func(t *testing.T) {
+		query, args, err := table.BuildUpdate(7, map[string]interface{}{"latitude": 40.4}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE bikes SET latitude = ?, updated_at = "+CurrentTimestamp+" WHERE id = ? AND deleted_at IS NULL", query)
+		assert.Equal(t, []interface{}{40.4, int64(7)}, args)
+	})
+	t.Run("Failure - no fields to update", func(t *testing.T) {
+		_, _, err := table.BuildUpdate(7, map[string]interface{}{}, nil)
+		assert.ErrorIs(t, err, ErrNoFieldsToUpdate)
+	})
+
+	injections := []string{
+		"latitude = 0, is_available",
+		"latitude = 0 WHERE 1 = 1; --",
+		"latitude; DROP TABLE bikes; --",
+		"version",
+		"deleted_at",
+		"updated_at",
+		"id",
+		"LATITUDE",
+		" latitude",
+		`"latitude"`,
+		"",
+	}
+	for _, column := range injections {
+		t.Run("Failure - column "+column+" is refused", func(t *testing.T) {
+			fields := map[string]interface{}{"latitude": 40.4, column: 1}
+			query, args, err := table.BuildUpdate(7, fields, &version)
+			assert.ErrorIs(t, err, ErrUnknownColumn)
+			assert.Empty(t, query)
+			assert.Nil(t, args)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	// GIVEN: a table of items, one of them deleted
+	t.Setenv("DB_URL", "file:update_test?mode=memory&cache=shared")
+	db := New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	_, err := db.Exec(`CREATE TABLE items (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		secret TEXT NOT NULL DEFAULT 'hidden',
+		version INTEGER NOT NULL DEFAULT 1,
+		deleted_at TIMESTAMP,
+		updated_at TIMESTAMP
+	)`)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO items (id, name, deleted_at) VALUES (1, 'first', NULL), (2, 'second', NULL), (3, 'deleted', CURRENT_TIMESTAMP)")
+	require.NoError(t, err)
+	items := NewTable("items", "name").Versioned().SoftDeleted()
+	names := func() map[int64]string {
+		rows, err := db.Query("SELECT id, name FROM items")
+		require.NoError(t, err)
+		defer rows.Close()
+		names := map[int64]string{}
+		for rows.Next() {
+			var id int64
+			var name string
+			require.NoError(t, rows.Scan(&id, &name))
+			names[id] = name
+		}
+		return names
+	}
+
+	t.Run("Success - only the row is updated and its updated_at is set", func(t *testing.T) {
+		affected, err := items.Update(db, 1, map[string]interface{}{"name": "renamed"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+		assert.Equal(t, map[int64]string{1: "renamed", 2: "second", 3: "deleted"}, names())
+		var updatedAt time.Time
+		require.NoError(t, db.QueryRow("SELECT updated_at FROM items WHERE id = 1").Scan(&updatedAt))
+		assert.WithinDuration(t, time.Now(), updatedAt, time.Minute)
+	})
+	t.Run("Success - values are bound, not interpolated", func(t *testing.T) {
+		_, err := items.Update(db, 2, map[string]interface{}{"name": "x', secret = 'leaked"})
+		require.NoError(t, err)
+		var name, secret string
+		require.NoError(t, db.QueryRow("SELECT name, secret FROM items WHERE id = 2").Scan(&name, &secret))
+		assert.Equal(t, "x', secret = 'leaked", name)
+		assert.Equal(t, "hidden", secret)
+	})
+	t.Run("Failure - unknown columns are never executed", func(t *testing.T) {
+		_, err := items.Update(db, 1, map[string]interface{}{"name = 'x', secret": "leaked"})
+		assert.ErrorIs(t, err, ErrUnknownColumn)
+		var secret string
+		require.NoError(t, db.QueryRow("SELECT secret FROM items WHERE id = 1").Scan(&secret))
+		assert.Equal(t, "hidden", secret)
+	})
+	t.Run("Failure - missing and deleted rows are not found", func(t *testing.T) {
+		_, err := items.Update(db, 9, map[string]interface{}{"name": "missing"})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = items.Update(db, 3, map[string]interface{}{"name": "restored"})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = items.UpdateVersion(db, 3, map[string]interface{}{"name": "restored"}, 1)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, "deleted", names()[3])
+	})
+	t.Run("Failure - rows changed since they were read are not updated", func(t *testing.T) {
+		_, err := db.Exec("UPDATE items SET version = 2 WHERE id = 1")
+		require.NoError(t, err)
+		_, err = items.UpdateVersion(db, 1, map[string]interface{}{"name": "stale"}, 1)
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		affected, err := items.UpdateVersion(db, 1, map[string]interface{}{"name": "fresh"}, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+		assert.Equal(t, "fresh", names()[1])
+	})
+	t.Run("Failure - tables without version can't be updated at a version", func(t *testing.T) {
+		_, err := NewTable("items", "name").UpdateVersion(db, 1, map[string]interface{}{"name": "x"}, 1)
+		assert.Error(t, err)
+	})
+}
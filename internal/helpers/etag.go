@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ETag returns the entity tag of a version of a resource
+func ETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// WriteJSONWithETag writes data as JSON with the ETag of its version. Requests already holding it in If-None-Match
+// get 304 Not Modified without body
+func WriteJSONWithETag(rw http.ResponseWriter, req *http.Request, status int, version int64, data interface{}) error {
+	etag := ETag(version)
+	rw.Header().Set("ETag", etag)
+	if matchesETag(req.Header.Get("If-None-Match"), etag, true) {
+		rw.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return WriteJSON(rw, status, data)
+}
+
+// CheckIfMatch checks that the If-Match header of a request updating a resource holds the ETag of its current version,
+// so updates made from an outdated read are refused. It writes 428 Precondition Required when the header is missing
+// and 412 Precondition Failed when it doesn't match
+func CheckIfMatch(rw http.ResponseWriter, req *http.Request, version int64) bool {
+	ifMatch := req.Header.Get("If-Match")
+	if ifMatch == "" {
+		http.Error(rw, "If-Match header with the ETag of the resource is required", http.StatusPreconditionRequired)
+		return false
+	}
+	if !matchesETag(ifMatch, ETag(version), false) {
+		rw.Header().Set("ETag", ETag(version))
+		http.Error(rw, "The resource was changed since it was read", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// matchesETag reports whether a list of entity tags from a conditional header holds etag or is '*'.
+// Weak tags only match with the weak comparison of If-None-Match
+func matchesETag(header, etag string, weak bool) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" {
+			return true
+		}
+		if weak {
+			value = strings.TrimPrefix(value, "W/")
+		}
+		if value == etag {
+			return true
+		}
+	}
+	return false
+}
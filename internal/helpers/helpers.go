@@ -9,7 +9,9 @@ import (
 	"math"
 	"math/rand"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/jwtauth/v5"
 	geo "github.com/kellydunn/golang-geo"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -20,12 +22,17 @@ const (
 )
 
 func WriteJSON(rw http.ResponseWriter, status int, data interface{}) error {
+	return WriteJSONAs(rw, status, "application/json", data)
+}
+
+// WriteJSONAs writes data as JSON using the given content type, e.g. application/geo+json
+func WriteJSONAs(rw http.ResponseWriter, status int, contentType string, data interface{}) error {
 	js, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
-	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Content-Type", contentType)
 	rw.WriteHeader(status)
 	_, err = rw.Write(js)
 	if err != nil {
@@ -85,3 +92,63 @@ func GetRandomLatLon(startLat, startLon float64) (lat, lon float64) {
 	rnadomPoint := startPoint.PointAtDistanceAndBearing(randomDistance, randomAngle)
 	return rnadomPoint.Lat(), rnadomPoint.Lng()
 }
+
+// GetDistanceKm returns the great-circle distance in kilometers between two points using the haversine formula
+func GetDistanceKm(startLat, startLon, endLat, endLon float64) float64 {
+	return geo.NewPoint(startLat, startLon).GreatCircleDistance(geo.NewPoint(endLat, endLon))
+}
+
+// GetPathDistanceKm returns the length in kilometers of the path described by the given [latitude, longitude] points
+func GetPathDistanceKm(path [][2]float64) float64 {
+	distance := 0.0
+	for i := 1; i < len(path); i++ {
+		distance += GetDistanceKm(path[i-1][0], path[i-1][1], path[i][0], path[i][1])
+	}
+	return distance
+}
+
+// GetGridCell returns the row and column of the square grid cell of cellSize degrees containing the point
+func GetGridCell(lat, lon, cellSize float64) (row, col int64) {
+	return int64(math.Floor(lat / cellSize)), int64(math.Floor(lon / cellSize))
+}
+
+// GetGridCellCenter returns the latitude and longitude of the center of a grid cell of cellSize degrees
+func GetGridCellCenter(row, col int64, cellSize float64) (lat, lon float64) {
+	return (float64(row) + 0.5) * cellSize, (float64(col) + 0.5) * cellSize
+}
+
+// GetUserIDFromRequest returns the id of the logged in user from the jwt claims of the request
+func GetUserIDFromRequest(req *http.Request) (int64, error) {
+	_, claims, err := jwtauth.FromContext(req.Context())
+	if err != nil || claims == nil {
+		return 0, fmt.Errorf("failed to get user claims: %v", err)
+	}
+	sub, ok := claims["sub"].(string)
+	if !ok {
+		return 0, fmt.Errorf("sub claim is not a string")
+	}
+	userID, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse user id from jwt claims: %v", err)
+	}
+	return userID, nil
+}
+
+// GetAdminFromRequest returns the username of the administrator authenticated with basic auth
+func GetAdminFromRequest(req *http.Request) string {
+	username, _, ok := req.BasicAuth()
+	if !ok {
+		return ""
+	}
+	return username
+}
+
+// ToMinorUnits converts an amount of money to cents
+func ToMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FromMinorUnits converts cents to an amount of money
+func FromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
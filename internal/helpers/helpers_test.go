@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPathDistanceKm(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     [][2]float64
+		expected float64
+	}{
+		{name: "Success - an empty path has no length", path: nil, expected: 0},
+		{name: "Success - a single point has no length", path: [][2]float64{{40.4, -3.7}}, expected: 0},
+		{name: "Success - a degree of latitude is about 111 km", path: [][2]float64{{40, -3.7}, {41, -3.7}}, expected: 111.19},
+		{name: "Success - the legs of the path are summed", path: [][2]float64{{40, -3.7}, {41, -3.7}, {40, -3.7}}, expected: 222.39},
+		{name: "Success - staying in place adds nothing", path: [][2]float64{{40, -3.7}, {40, -3.7}, {41, -3.7}}, expected: 111.19},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a path of [latitude, longitude] points
+			// WHEN: its length is computed
+			distance := GetPathDistanceKm(tc.path)
+			// THEN: it is the sum of the great-circle distances between consecutive points
+			assert.InDelta(t, tc.expected, distance, 0.01)
+		})
+	}
+}
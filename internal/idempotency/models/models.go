@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Entry is a request made with an Idempotency-Key and the response it got.
+// The response is empty while the first request is still being processed
+type Entry struct {
+	ID int64
+	// The user or admin the key belongs to, e.g. 'user:2' or 'admin:admin'
+	Scope string
+	Key   string
+	// The hash of the method, path and body of the request
+	Fingerprint string
+	StatusCode  *int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the response of the request was stored
+func (e *Entry) Completed() bool {
+	return e.StatusCode != nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/idempotency/models"
+	"fmt"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	Reserve(entry *models.Entry) (*models.Entry, bool, error)
+	Complete(id int64, statusCode int, contentType string, body []byte) error
+	Release(id int64) error
+}
+
+type idempotencyRepository struct {
+	db database.Database
+}
+
+// New initializes a new idempotency repository
+func New(db database.Database) IdempotencyRepository {
+	return &idempotencyRepository{db}
+}
+
+// Reserve stores a new key for the scope and returns true, or returns the entry already stored for it and false.
+// Expired entries are purged first so their keys can be used again
+func (r *idempotencyRepository) Reserve(entry *models.Entry) (*models.Entry, bool, error) {
+	if _, err := r.db.Exec("DELETE FROM idempotency_keys WHERE expires_at <= ?", time.Now().UTC()); err != nil {
+		return nil, false, fmt.Errorf("failed to purge expired idempotency keys: %v", err)
+	}
+	query := `INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (scope, idempotency_key) DO NOTHING`
+	result, err := r.db.Exec(query, entry.Scope, entry.Key, entry.Fingerprint, entry.ExpiresAt.UTC())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert idempotency key: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %v", err)
+	}
+	if affected == 1 {
+		entry.ID, err = result.LastInsertId()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get last insert id: %v", err)
+		}
+		return entry, true, nil
+	}
+
+	var stored models.Entry
+	var contentType *string
+	query = `SELECT id, scope, idempotency_key, fingerprint, status_code, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?`
+	err = r.db.QueryRow(query, entry.Scope, entry.Key).Scan(&stored.ID, &stored.Scope, &stored.Key, &stored.Fingerprint,
+		&stored.StatusCode, &contentType, &stored.Body, &stored.CreatedAt, &stored.ExpiresAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotency key: %v", err)
+	}
+	if contentType != nil {
+		stored.ContentType = *contentType
+	}
+	return &stored, false, nil
+}
+
+// Complete stores the response of the request made with the key
+func (r *idempotencyRepository) Complete(id int64, statusCode int, contentType string, body []byte) error {
+	query := "UPDATE idempotency_keys SET status_code = ?, content_type = ?, response_body = ? WHERE id = ?"
+	if _, err := r.db.Exec(query, statusCode, contentType, body, id); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// Release deletes a key whose request could not be completed, so it can be retried with the same key
+func (r *idempotencyRepository) Release(id int64) error {
+	if _, err := r.db.Exec("DELETE FROM idempotency_keys WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package locks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// LockState is the state reported by the lock of a bike
+type LockState string
+
+const (
+	Locked   LockState = "locked"
+	Unlocked LockState = "unlocked"
+	Unknown  LockState = "unknown"
+)
+
+const (
+	// defaultAttempts is the number of times a lock command is sent before giving up
+	defaultAttempts = 3
+	// defaultTimeout is the time a single lock command is allowed to take
+	defaultTimeout = 5 * time.Second
+	// defaultBackoff is the time waited between two attempts
+	defaultBackoff = 500 * time.Millisecond
+)
+
+// ErrLockUnreachable is returned when the lock of a bike does not answer
+var ErrLockUnreachable = errors.New("lock unreachable")
+
+// LockController sends commands to the physical lock of a bike
+type LockController interface {
+	Unlock(ctx context.Context, bikeID int64) error
+	Lock(ctx context.Context, bikeID int64) error
+	State(ctx context.Context, bikeID int64) (LockState, error)
+}
+
+// Config holds the retry configuration used to talk to the locks
+type Config struct {
+	// Attempts is the number of times a command is sent before giving up
+	Attempts int
+	// Timeout is the time a single attempt is allowed to take
+	Timeout time.Duration
+	// Backoff is the time waited between two attempts
+	Backoff time.Duration
+}
+
+// ConfigFromEnv reads the lock configuration from LOCK_ATTEMPTS, LOCK_TIMEOUT and LOCK_BACKOFF, falling back to defaults
+func ConfigFromEnv() Config {
+	config := Config{Attempts: defaultAttempts, Timeout: defaultTimeout, Backoff: defaultBackoff}
+	if value := os.Getenv("LOCK_ATTEMPTS"); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			log.Printf("invalid LOCK_ATTEMPTS %q. Set to %v as default", value, defaultAttempts)
+		} else {
+			config.Attempts = attempts
+		}
+	}
+	if value := os.Getenv("LOCK_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			log.Printf("invalid LOCK_TIMEOUT %q. Set to %v as default", value, defaultTimeout)
+		} else {
+			config.Timeout = timeout
+		}
+	}
+	if value := os.Getenv("LOCK_BACKOFF"); value != "" {
+		backoff, err := time.ParseDuration(value)
+		if err != nil {
+			log.Printf("invalid LOCK_BACKOFF %q. Set to %v as default", value, defaultBackoff)
+		} else {
+			config.Backoff = backoff
+		}
+	}
+	return config
+}
+
+type retryController struct {
+	next   LockController
+	config Config
+}
+
+// NewRetryController wraps a lock controller so every command is bounded by a timeout and retried on failure
+func NewRetryController(next LockController, config Config) LockController {
+	if config.Attempts < 1 {
+		config.Attempts = 1
+	}
+	return &retryController{next: next, config: config}
+}
+
+// Unlock opens the lock of a bike
+func (c *retryController) Unlock(ctx context.Context, bikeID int64) error {
+	return c.retry(ctx, func(ctx context.Context) error {
+		return c.next.Unlock(ctx, bikeID)
+	})
+}
+
+// Lock closes the lock of a bike
+func (c *retryController) Lock(ctx context.Context, bikeID int64) error {
+	return c.retry(ctx, func(ctx context.Context) error {
+		return c.next.Lock(ctx, bikeID)
+	})
+}
+
+// State queries the state of the lock of a bike
+func (c *retryController) State(ctx context.Context, bikeID int64) (LockState, error) {
+	state := Unknown
+	err := c.retry(ctx, func(ctx context.Context) error {
+		var err error
+		state, err = c.next.State(ctx, bikeID)
+		return err
+	})
+	return state, err
+}
+
+// retry runs the command until it succeeds, the attempts are exhausted or the parent context is done
+func (c *retryController) retry(ctx context.Context, command func(context.Context) error) error {
+	var err error
+	for attempt := 1; attempt <= c.config.Attempts; attempt++ {
+		err = c.attempt(ctx, command)
+		if err == nil {
+			return nil
+		}
+		log.Printf("lock command failed (attempt %d/%d): %v", attempt, c.config.Attempts, err)
+		if attempt == c.config.Attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("lock command cancelled: %w", ctx.Err())
+		case <-time.After(c.config.Backoff):
+		}
+	}
+	return fmt.Errorf("lock command failed after %d attempts: %w", c.config.Attempts, err)
+}
+
+// attempt runs the command once, bounded by the configured timeout
+func (c *retryController) attempt(ctx context.Context, command func(context.Context) error) error {
+	if c.config.Timeout <= 0 {
+		return command(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	return command(ctx)
+}
@@ -0,0 +1,119 @@
+package locks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyController fails the first failures commands and then delegates to the simulator
+type flakyController struct {
+	*Simulator
+	failures int
+	calls    int
+}
+
+func (f *flakyController) Unlock(ctx context.Context, bikeID int64) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return ErrLockUnreachable
+	}
+	return f.Simulator.Unlock(ctx, bikeID)
+}
+
+func TestSimulator(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Success - bikes start locked and follow unlock/lock commands", func(t *testing.T) {
+		// GIVEN: a simulator
+		simulator := NewSimulator()
+		// WHEN: the state is queried before any command
+		state, err := simulator.State(ctx, 1)
+		// THEN: the lock is locked
+		assert.NoError(t, err)
+		assert.Equal(t, Locked, state)
+		// WHEN: the bike is unlocked
+		assert.NoError(t, simulator.Unlock(ctx, 1))
+		state, _ = simulator.State(ctx, 1)
+		// THEN: the lock is unlocked
+		assert.Equal(t, Unlocked, state)
+		// WHEN: the bike is locked again
+		assert.NoError(t, simulator.Lock(ctx, 1))
+		state, _ = simulator.State(ctx, 1)
+		// THEN: the lock is locked
+		assert.Equal(t, Locked, state)
+	})
+	t.Run("Success - auto locking simulates riders closing the locks they opened", func(t *testing.T) {
+		// GIVEN: a simulator whose riders close the locks
+		simulator := NewSimulator()
+		simulator.AutoLock = true
+		// WHEN: a bike is unlocked and its state queried
+		assert.NoError(t, simulator.Unlock(ctx, 1))
+		state, _ := simulator.State(ctx, 1)
+		// THEN: the rider closed the lock
+		assert.Equal(t, Locked, state)
+		// WHEN: a rider leaves a lock open
+		simulator.SetState(2, Unlocked)
+		state, _ = simulator.State(ctx, 2)
+		// THEN: it stays open
+		assert.Equal(t, Unlocked, state)
+	})
+	t.Run("Failure - unreachable locks return ErrLockUnreachable", func(t *testing.T) {
+		// GIVEN: a simulator with an unreachable lock
+		simulator := NewSimulator()
+		simulator.SetReachable(1, false)
+		// WHEN: the bike is unlocked
+		err := simulator.Unlock(ctx, 1)
+		// THEN: the command fails
+		assert.ErrorIs(t, err, ErrLockUnreachable)
+	})
+	t.Run("Failure - commands slower than the context deadline time out", func(t *testing.T) {
+		// GIVEN: a slow simulator
+		simulator := NewSimulator()
+		simulator.Latency = 50 * time.Millisecond
+		timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+		defer cancel()
+		// WHEN: the bike is unlocked
+		err := simulator.Unlock(timeoutCtx, 1)
+		// THEN: the command times out
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestRetryController(t *testing.T) {
+	ctx := context.Background()
+	config := Config{Attempts: 3, Timeout: 10 * time.Millisecond, Backoff: time.Millisecond}
+	t.Run("Success - transient failures are retried", func(t *testing.T) {
+		// GIVEN: a lock failing twice before answering
+		flaky := &flakyController{Simulator: NewSimulator(), failures: 2}
+		controller := NewRetryController(flaky, config)
+		// WHEN: the bike is unlocked
+		err := controller.Unlock(ctx, 1)
+		// THEN: the command succeeds on the third attempt
+		assert.NoError(t, err)
+		assert.Equal(t, 3, flaky.calls)
+	})
+	t.Run("Failure - the last error is returned when attempts are exhausted", func(t *testing.T) {
+		// GIVEN: a lock failing more times than the attempts
+		flaky := &flakyController{Simulator: NewSimulator(), failures: 5}
+		controller := NewRetryController(flaky, config)
+		// WHEN: the bike is unlocked
+		err := controller.Unlock(ctx, 1)
+		// THEN: the command fails after every attempt
+		assert.True(t, errors.Is(err, ErrLockUnreachable))
+		assert.Equal(t, 3, flaky.calls)
+	})
+	t.Run("Failure - every attempt is bounded by the timeout", func(t *testing.T) {
+		// GIVEN: a lock slower than the timeout
+		simulator := NewSimulator()
+		simulator.Latency = 50 * time.Millisecond
+		controller := NewRetryController(simulator, config)
+		// WHEN: the state is queried
+		state, err := controller.State(ctx, 1)
+		// THEN: the command times out
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, Unknown, state)
+	})
+}
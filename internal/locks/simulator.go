@@ -0,0 +1,103 @@
+package locks
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Simulator is an in-memory LockController used for local development and tests.
+// Every bike starts locked. Commands take Latency to complete and fail with FailureRate probability
+type Simulator struct {
+	// Latency is the time a command takes to reach the lock
+	Latency time.Duration
+	// FailureRate is the probability, between 0 and 1, of a command not reaching the lock
+	FailureRate float64
+	// AutoLock simulates riders closing the locks: a lock opened by Unlock reports locked from the next State query.
+	// States forced with SetState are kept
+	AutoLock bool
+
+	mu          sync.Mutex
+	states      map[int64]LockState
+	opened      map[int64]bool
+	unreachable map[int64]bool
+}
+
+// NewSimulator returns a simulator whose commands always succeed immediately
+func NewSimulator() *Simulator {
+	return &Simulator{
+		states:      make(map[int64]LockState),
+		opened:      make(map[int64]bool),
+		unreachable: make(map[int64]bool),
+	}
+}
+
+// SetReachable makes the lock of a bike answer, or stop answering, to every command
+func (s *Simulator) SetReachable(bikeID int64, reachable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unreachable[bikeID] = !reachable
+}
+
+// SetState forces the state of the lock of a bike, e.g. to simulate a rider leaving it open
+func (s *Simulator) SetState(bikeID int64, state LockState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[bikeID] = state
+	delete(s.opened, bikeID)
+}
+
+// Unlock opens the lock of a bike
+func (s *Simulator) Unlock(ctx context.Context, bikeID int64) error {
+	return s.send(ctx, bikeID, func() {
+		s.states[bikeID] = Unlocked
+		s.opened[bikeID] = true
+	})
+}
+
+// Lock closes the lock of a bike
+func (s *Simulator) Lock(ctx context.Context, bikeID int64) error {
+	return s.send(ctx, bikeID, func() {
+		s.states[bikeID] = Locked
+		delete(s.opened, bikeID)
+	})
+}
+
+// State queries the state of the lock of a bike
+func (s *Simulator) State(ctx context.Context, bikeID int64) (LockState, error) {
+	state := Unknown
+	err := s.send(ctx, bikeID, func() {
+		if s.AutoLock && s.opened[bikeID] {
+			s.states[bikeID] = Locked
+			delete(s.opened, bikeID)
+		}
+		var ok bool
+		if state, ok = s.states[bikeID]; !ok {
+			state = Locked
+		}
+	})
+	return state, err
+}
+
+// send simulates the round trip of a command to the lock and applies it when it arrives
+func (s *Simulator) send(ctx context.Context, bikeID int64, apply func()) error {
+	if s.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.Latency):
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unreachable[bikeID] || (s.FailureRate > 0 && rand.Float64() < s.FailureRate) {
+		return ErrLockUnreachable
+	}
+	apply()
+	return nil
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"log"
+	"net/http"
+)
+
+// UserStatusChecker tells whether a user can still use the API
+type UserStatusChecker interface {
+	IsUserActive(userID int64) (bool, error)
+}
+
+// ActiveUser middleware refuses the requests of deleted users, whose tokens are still valid until they expire.
+// It must run after the authentication middleware
+func ActiveUser(users UserStatusChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := helpers.GetUserIDFromRequest(r)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			active, err := users.IsUserActive(userID)
+			if err != nil {
+				log.Printf("Error checking user %d: %v", userID, err)
+				http.Error(w, "Error checking user", http.StatusInternalServerError)
+				return
+			}
+			if !active {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
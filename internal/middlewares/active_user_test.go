@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserStatus reports the users in active as active
+type fakeUserStatus struct {
+	active map[int64]bool
+	err    error
+}
+
+func (f *fakeUserStatus) IsUserActive(userID int64) (bool, error) {
+	return f.active[userID], f.err
+}
+
+func TestActiveUser(t *testing.T) {
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	newRequest := func(sub string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/rentals/start", nil)
+		token, _, err := tokenAuth.Encode(map[string]interface{}{"sub": sub})
+		require.NoError(t, err)
+		return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	testCases := []struct {
+		name             string
+		sub              string
+		users            *fakeUserStatus
+		expectedHttpCode int
+	}{
+		{
+			name:             "Success - active users are served",
+			sub:              "2",
+			users:            &fakeUserStatus{active: map[int64]bool{2: true}},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:             "Failure - deleted users are refused with a token still valid",
+			sub:              "3",
+			users:            &fakeUserStatus{active: map[int64]bool{2: true}},
+			expectedHttpCode: http.StatusUnauthorized,
+		},
+		{
+			name:             "Failure - tokens without a user id are refused",
+			sub:              "admin",
+			users:            &fakeUserStatus{},
+			expectedHttpCode: http.StatusUnauthorized,
+		},
+		{
+			name:             "Failure - the user can't be checked",
+			sub:              "2",
+			users:            &fakeUserStatus{err: errors.New("database is closed")},
+			expectedHttpCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an authenticated request
+			req := newRequest(tc.sub)
+			// WHEN: it is served
+			rec := httptest.NewRecorder()
+			ActiveUser(tc.users)(ok).ServeHTTP(rec, req)
+			// THEN: only the requests of active users reach the handler
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
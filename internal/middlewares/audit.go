@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/audit/models"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Audit middleware records the requests changing data in the append-only audit log: who made them, the route and resource,
+// the response status code, the request id and the IP address. Handlers add the values they changed with audit.SetChanges,
+// and name the requests authenticating their user, like logins, with audit.SetActor and audit.SetAction.
+// It must run after the authentication middleware, reads are not recorded
+func Audit(recorder audit.Recorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			entry := &models.Entry{RequestID: middleware.GetReqID(r.Context()), IP: remoteIP(r)}
+			entry.Actor, _ = requestActor(r)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(audit.NewContext(r.Context(), entry)))
+
+			entry.StatusCode = ww.Status()
+			if entry.StatusCode == 0 {
+				entry.StatusCode = http.StatusOK
+			}
+			setRoute(r, entry)
+			entry.CreatedAt = time.Now().UTC()
+			if err := recorder.Record(entry); err != nil {
+				log.Printf("Error recording audit entry: %v", err)
+			}
+		})
+	}
+}
+
+// setRoute sets the action and resource the handler didn't set from the route of the request: the action is the method and the route,
+// the resource type the first segment of the route after /admin and the resource id the first route parameter, e.g. 'rentals' and '12'
+func setRoute(r *http.Request, entry *models.Entry) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return
+	}
+	pattern := rctx.RoutePattern()
+	if entry.Action == "" {
+		entry.Action = r.Method + " " + pattern
+	}
+	if entry.ResourceType != "" {
+		return
+	}
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(segments) > 1 && segments[0] == "admin" {
+		segments = segments[1:]
+	}
+	entry.ResourceType = segments[0]
+	for i, key := range rctx.URLParams.Keys {
+		if key != "*" {
+			entry.ResourceID = rctx.URLParams.Values[i]
+			break
+		}
+	}
+}
+
+// remoteIP returns the IP address the request came from
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
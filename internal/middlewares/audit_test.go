@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/audit/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorderFunc records the audit entries with a function
+type recorderFunc func(entry *models.Entry) error
+
+func (f recorderFunc) Record(entry *models.Entry) error {
+	return f(entry)
+}
+
+func TestAudit(t *testing.T) {
+	type rental struct {
+		UserID int64 `json:"user_id"`
+		BikeID int64 `json:"bike_id"`
+	}
+	newRouter := func(recorded *[]*models.Entry) http.Handler {
+		r := chi.NewRouter()
+		r.Use(middleware.RequestID)
+		r.Use(Audit(recorderFunc(func(entry *models.Entry) error {
+			*recorded = append(*recorded, entry)
+			return nil
+		})))
+		r.Patch("/admin/rentals/{rental_id}", func(w http.ResponseWriter, req *http.Request) {
+			userID := int64(7)
+			audit.SetChanges(req, rental{UserID: 2, BikeID: 1}, map[string]interface{}{"user_id": &userID})
+			w.WriteHeader(http.StatusOK)
+		})
+		r.Get("/admin/rentals/{rental_id}", func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		r.Post("/users/login", func(w http.ResponseWriter, req *http.Request) {
+			audit.SetAction(req, "login")
+			audit.SetActor(req, "user:2")
+			audit.SetResource(req, "users", "2")
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		return r
+	}
+
+	t.Run("Success - admin mutations are recorded with the changed values", func(t *testing.T) {
+		// GIVEN: an admin updating the user of a rental
+		var recorded []*models.Entry
+		req := httptest.NewRequest(http.MethodPatch, "/admin/rentals/12", nil)
+		req.SetBasicAuth("admin", "password")
+		req.RemoteAddr = "10.0.0.1:5000"
+		// WHEN: the request is served
+		newRouter(&recorded).ServeHTTP(httptest.NewRecorder(), req)
+		// THEN: who did it, on which rental and the previous user are recorded
+		assert.Len(t, recorded, 1)
+		entry := recorded[0]
+		assert.Equal(t, "admin:admin", entry.Actor)
+		assert.Equal(t, "PATCH /admin/rentals/{rental_id}", entry.Action)
+		assert.Equal(t, "rentals", entry.ResourceType)
+		assert.Equal(t, "12", entry.ResourceID)
+		assert.Equal(t, map[string]interface{}{"user_id": 2.0}, entry.Before)
+		assert.Equal(t, map[string]interface{}{"user_id": 7.0}, entry.After)
+		assert.Equal(t, http.StatusOK, entry.StatusCode)
+		assert.Equal(t, "10.0.0.1", entry.IP)
+		assert.NotEmpty(t, entry.RequestID)
+		assert.False(t, entry.CreatedAt.IsZero())
+	})
+	t.Run("Success - reads are not recorded", func(t *testing.T) {
+		var recorded []*models.Entry
+		req := httptest.NewRequest(http.MethodGet, "/admin/rentals/12", nil)
+		req.SetBasicAuth("admin", "password")
+		newRouter(&recorded).ServeHTTP(httptest.NewRecorder(), req)
+		assert.Empty(t, recorded)
+	})
+	t.Run("Success - failed logins are recorded against the user", func(t *testing.T) {
+		// GIVEN: a login with a wrong password
+		var recorded []*models.Entry
+		req := httptest.NewRequest(http.MethodPost, "/users/login", nil)
+		// WHEN: the request is served
+		newRouter(&recorded).ServeHTTP(httptest.NewRecorder(), req)
+		// THEN: the handler names the action and the actor, the status code tells it failed
+		assert.Len(t, recorded, 1)
+		assert.Equal(t, "login", recorded[0].Action)
+		assert.Equal(t, "user:2", recorded[0].Actor)
+		assert.Equal(t, "2", recorded[0].ResourceID)
+		assert.Equal(t, http.StatusUnauthorized, recorded[0].StatusCode)
+	})
+}
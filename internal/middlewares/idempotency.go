@@ -0,0 +1,167 @@
+package middlewares
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/idempotency/models"
+	"bikesRentalAPI/internal/idempotency/repository"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// IdempotencyKeyHeader is the header clients send to retry a request safely
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on the responses replayed from a previous request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength is the longest key accepted
+	maxIdempotencyKeyLength = 255
+	// defaultIdempotencyTTL is how long the response of a request is replayed
+	defaultIdempotencyTTL = 24 * time.Hour
+	// maxIdempotentBodySize is the largest body buffered to fingerprint a request, the size of the largest upload accepted (bike imports)
+	maxIdempotentBodySize = 10 << 20
+)
+
+// IdempotencyTTLFromEnv reads how long responses are replayed from IDEMPOTENCY_KEY_TTL, 24 hours as default
+func IdempotencyTTLFromEnv() time.Duration {
+	value := os.Getenv("IDEMPOTENCY_KEY_TTL")
+	if value == "" {
+		return defaultIdempotencyTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Printf("invalid IDEMPOTENCY_KEY_TTL %q. Set to %v as default", value, defaultIdempotencyTTL)
+		return defaultIdempotencyTTL
+	}
+	return ttl
+}
+
+// Idempotency middleware makes the requests sent with an Idempotency-Key safe to retry. The response of the first request
+// is stored per user or admin and key, and replayed to the requests sent again with the same key until the TTL elapses.
+// Reusing a key for a different request is refused. Responses with server errors are not stored so the request can be retried.
+// It must run after the authentication middleware, requests without the header are not affected
+func Idempotency(repo repository.IdempotencyRepository, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, fmt.Sprintf("%s can't be longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength), http.StatusBadRequest)
+				return
+			}
+			scope, ok := requestActor(r)
+			if !ok {
+				http.Error(w, "Error getting user id", http.StatusBadRequest)
+				return
+			}
+			// The body is buffered to be fingerprinted and read again by the handler, its size is capped
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					http.Error(w, fmt.Sprintf("Request body larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, "Error parsing body request", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			entry, created, err := repo.Reserve(&models.Entry{
+				Scope:       scope,
+				Key:         key,
+				Fingerprint: requestFingerprint(r, body),
+				ExpiresAt:   time.Now().Add(ttl),
+			})
+			if err != nil {
+				log.Printf("Error reserving idempotency key: %v", err)
+				http.Error(w, "Error processing request", http.StatusInternalServerError)
+				return
+			}
+			if !created {
+				replayResponse(w, entry, requestFingerprint(r, body))
+				return
+			}
+
+			var response bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&response)
+			defer func() {
+				// The key is released if the handler panics, before the recoverer answers
+				if rec := recover(); rec != nil {
+					if err := repo.Release(entry.ID); err != nil {
+						log.Printf("Error releasing idempotency key: %v", err)
+					}
+					panic(rec)
+				}
+			}()
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				if err := repo.Release(entry.ID); err != nil {
+					log.Printf("Error releasing idempotency key: %v", err)
+				}
+				return
+			}
+			if err := repo.Complete(entry.ID, status, ww.Header().Get("Content-Type"), response.Bytes()); err != nil {
+				log.Printf("Error storing idempotent response: %v", err)
+			}
+		})
+	}
+}
+
+// replayResponse writes the stored response of a request sent with the same key
+func replayResponse(w http.ResponseWriter, entry *models.Entry, fingerprint string) {
+	if entry.Fingerprint != fingerprint {
+		http.Error(w, fmt.Sprintf("%s was already used for a different request", IdempotencyKeyHeader), http.StatusUnprocessableEntity)
+		return
+	}
+	if !entry.Completed() {
+		http.Error(w, fmt.Sprintf("A request with this %s is still being processed", IdempotencyKeyHeader), http.StatusConflict)
+		return
+	}
+	if entry.ContentType != "" {
+		w.Header().Set("Content-Type", entry.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(*entry.StatusCode)
+	if _, err := w.Write(entry.Body); err != nil {
+		log.Printf("Error writing idempotent response: %v", err)
+	}
+}
+
+// requestActor returns who made the request: the logged in user, e.g. 'user:2', or the admin, e.g. 'admin:admin'.
+// Idempotency keys belong to them
+func requestActor(r *http.Request) (string, bool) {
+	if userID, err := helpers.GetUserIDFromRequest(r); err == nil {
+		return fmt.Sprintf("user:%d", userID), true
+	}
+	if username, _, ok := r.BasicAuth(); ok {
+		return fmt.Sprintf("admin:%s", username), true
+	}
+	return "", false
+}
+
+// requestFingerprint hashes the method, the URL and the body of a request
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,146 @@
+package middlewares
+
+import (
+	"bikesRentalAPI/internal/idempotency/models"
+	"bikesRentalAPI/internal/idempotency/repository/mocks"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdempotency(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/admin/bikes", strings.NewReader(body))
+		req.SetBasicAuth("admin", "password")
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		return req
+	}
+	created := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write(body)
+		})
+	}
+	storedEntry := func(body string, statusCode *int) *models.Entry {
+		return &models.Entry{
+			ID:          1,
+			Scope:       "admin:admin",
+			Key:         "key-1",
+			Fingerprint: requestFingerprint(newRequest(body), []byte(body)),
+			StatusCode:  statusCode,
+			ContentType: "application/json",
+			Body:        []byte(body),
+		}
+	}
+
+	t.Run("Success - requests without a key are not affected", func(t *testing.T) {
+		// GIVEN: a request without Idempotency-Key
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		req := newRequest(`{"id":1}`)
+		req.Header.Del(IdempotencyKeyHeader)
+		// WHEN: it is served
+		rec := httptest.NewRecorder()
+		Idempotency(mockRepo, time.Hour)(created(http.StatusCreated)).ServeHTTP(rec, req)
+		// THEN: the handler answers and nothing is stored
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+	t.Run("Success - the response of the first request is stored", func(t *testing.T) {
+		// GIVEN: a new key
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		mockRepo.EXPECT().Reserve(gomock.Any()).DoAndReturn(func(entry *models.Entry) (*models.Entry, bool, error) {
+			assert.Equal(t, "admin:admin", entry.Scope)
+			entry.ID = 1
+			return entry, true, nil
+		})
+		mockRepo.EXPECT().Complete(int64(1), http.StatusCreated, "application/json", []byte(`{"id":1}`)).Return(nil)
+		// WHEN: the request is served
+		rec := httptest.NewRecorder()
+		Idempotency(mockRepo, time.Hour)(created(http.StatusCreated)).ServeHTTP(rec, newRequest(`{"id":1}`))
+		// THEN: the handler answers
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"id":1}`, rec.Body.String())
+	})
+	t.Run("Success - a retry replays the stored response", func(t *testing.T) {
+		// GIVEN: a key already used for the same request
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		statusCode := http.StatusCreated
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(storedEntry(`{"id":1}`, &statusCode), false, nil)
+		// WHEN: the request is sent again
+		rec := httptest.NewRecorder()
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("the handler must not run again")
+		})
+		Idempotency(mockRepo, time.Hour)(handler).ServeHTTP(rec, newRequest(`{"id":1}`))
+		// THEN: the stored response is replayed
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"id":1}`, rec.Body.String())
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+	})
+	t.Run("Failure - a key reused for a different request", func(t *testing.T) {
+		// GIVEN: a key already used for another body
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		statusCode := http.StatusCreated
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(storedEntry(`{"id":1}`, &statusCode), false, nil)
+		// WHEN: the key is sent with a different body
+		rec := httptest.NewRecorder()
+		Idempotency(mockRepo, time.Hour)(created(http.StatusCreated)).ServeHTTP(rec, newRequest(`{"id":2}`))
+		// THEN: the request is refused
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+	t.Run("Failure - the first request is still being processed", func(t *testing.T) {
+		// GIVEN: a key whose request has no response yet
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(storedEntry(`{"id":1}`, nil), false, nil)
+		// WHEN: the request is sent again
+		rec := httptest.NewRecorder()
+		Idempotency(mockRepo, time.Hour)(created(http.StatusCreated)).ServeHTTP(rec, newRequest(`{"id":1}`))
+		// THEN: it conflicts
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+	t.Run("Success - server errors release the key", func(t *testing.T) {
+		// GIVEN: a new key and a failing handler
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		mockRepo.EXPECT().Reserve(gomock.Any()).DoAndReturn(func(entry *models.Entry) (*models.Entry, bool, error) {
+			entry.ID = 1
+			return entry, true, nil
+		})
+		mockRepo.EXPECT().Release(int64(1)).Return(nil)
+		// WHEN: the request fails
+		rec := httptest.NewRecorder()
+		Idempotency(mockRepo, time.Hour)(created(http.StatusServiceUnavailable)).ServeHTTP(rec, newRequest(`{"id":1}`))
+		// THEN: the key can be retried
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+	t.Run("Failure - keys are scoped to the user", func(t *testing.T) {
+		// GIVEN: an unauthenticated request
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		req := httptest.NewRequest(http.MethodPost, "/rentals/start", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		// WHEN: it is served
+		rec := httptest.NewRecorder()
+		Idempotency(mockRepo, time.Hour)(created(http.StatusOK)).ServeHTTP(rec, req)
+		// THEN: it is refused
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("Failure - bodies over the cap are refused", func(t *testing.T) {
+		// GIVEN: a keyed request with a body over the cap
+		mockRepo := mocks.NewMockIdempotencyRepository(mockCtrl)
+		// WHEN: it is served
+		rec := httptest.NewRecorder()
+		Idempotency(mockRepo, time.Hour)(created(http.StatusCreated)).ServeHTTP(rec, newRequest(strings.Repeat("a", maxIdempotentBodySize+1)))
+		// THEN: it is refused without reserving the key
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
@@ -1,10 +1,9 @@
 package middlewares
 
 import (
+	"bikesRentalAPI/internal/pagination"
 	"context"
-	"fmt"
 	"net/http"
-	"strconv"
 )
 
 type (
@@ -12,23 +11,26 @@ type (
 )
 
 const (
-	PageIDKey pageKey = "page_id"
+	PageKey pageKey = "page"
 )
 
-// Pagination middleware is used to extract the next page id from the url query
+// Pagination middleware is used to extract the limit, the cursor and whether to count the items from the url query
 func Pagination(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		PageID := r.URL.Query().Get(string(PageIDKey))
-		var intPageID int64 = 0
-		var err error
-		if PageID != "" {
-			intPageID, err = strconv.ParseInt(PageID, 10, 64)
-			if err != nil {
-				http.Error(w, fmt.Sprintf("couldn't read %s: %v", PageIDKey, err), http.StatusBadRequest)
-				return
-			}
+		page, err := pagination.Parse(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		ctx := context.WithValue(r.Context(), PageIDKey, intPageID)
+		ctx := context.WithValue(r.Context(), PageKey, page)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// PageFromContext returns the page parsed by Pagination, the first one of the default size when there is none
+func PageFromContext(ctx context.Context) *pagination.Page {
+	if page, ok := ctx.Value(PageKey).(*pagination.Page); ok {
+		return page
+	}
+	return &pagination.Page{Limit: pagination.DefaultLimit}
+}
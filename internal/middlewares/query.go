@@ -0,0 +1,259 @@
+package middlewares
+
+import (
+	"bikesRentalAPI/internal/pagination"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	querySpecKey string
+)
+
+const (
+	QuerySpecKey querySpecKey = "query_spec"
+	// SortParam and OrderParam are the query parameters choosing the sort field and direction
+	SortParam  = "sort"
+	OrderParam = "order"
+)
+
+// FieldType is the type a filter value is parsed as
+type FieldType int
+
+const (
+	FieldInt FieldType = iota
+	FieldFloat
+	FieldString
+	FieldBool
+	// FieldTime values are RFC3339 timestamps or dates. Dates compared with OpLessOrEqual include the whole day
+	FieldTime
+)
+
+// Operator compares a column with a filter value
+type Operator string
+
+const (
+	OpEqual          Operator = "="
+	OpGreaterOrEqual Operator = ">="
+	OpLessOrEqual    Operator = "<="
+	// OpContains matches the columns containing the value, case insensitive
+	OpContains Operator = "LIKE"
+)
+
+// FilterField is a query parameter a resource can be filtered by. Conditions, when set, are the only accepted values
+// and the fixed SQL condition each one filters by, e.g. {"ongoing": "end_time IS NULL"}. An empty condition doesn't filter.
+// Default, when set, is the value applied when the request doesn't have the parameter
+type FilterField struct {
+	Column     string
+	Type       FieldType
+	Operator   Operator
+	Conditions map[string]string
+	Default    string
+}
+
+// QueryWhitelist holds the filters, by query parameter, and the sort fields, by name, a resource accepts with their columns.
+// Columns are never taken from the request
+type QueryWhitelist struct {
+	Filters map[string]FilterField
+	Sorts   map[string]string
+}
+
+// Filter is a parsed filter of a list request
+type Filter struct {
+	Column    string
+	Operator  Operator
+	Value     interface{}
+	Condition string
+}
+
+// QuerySpec holds the filters and the sort of a list request. The sort is applied with the keyset of the page
+type QuerySpec struct {
+	Filters    []Filter
+	SortColumn string
+	Descending bool
+}
+
+// reservedParams are the query parameters handled by other middlewares
+var reservedParams = map[string]bool{
+	pagination.LimitParam:  true,
+	pagination.CursorParam: true,
+	pagination.TotalParam:  true,
+	SortParam:              true,
+	OrderParam:             true,
+}
+
+// QuerySpecParser middleware parses the filters and the sort of a list request against the whitelist of the resource.
+// Unknown parameters and invalid values are refused
+func QuerySpecParser(whitelist QueryWhitelist) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			spec, err := ParseQuerySpec(r.URL.Query(), whitelist)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			ctx := context.WithValue(r.Context(), QuerySpecKey, spec)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// QuerySpecFromContext returns the query spec parsed by QuerySpecParser, sorted by id when there is none
+func QuerySpecFromContext(ctx context.Context) *QuerySpec {
+	if spec, ok := ctx.Value(QuerySpecKey).(*QuerySpec); ok {
+		return spec
+	}
+	return &QuerySpec{SortColumn: "id"}
+}
+
+// ParseQuerySpec parses the filters and the sort of the query parameters against the whitelist.
+// Filters are sorted by parameter so the same request always builds the same query
+func ParseQuerySpec(values url.Values, whitelist QueryWhitelist) (*QuerySpec, error) {
+	spec := &QuerySpec{SortColumn: "id"}
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	for _, param := range params {
+		if reservedParams[param] {
+			continue
+		}
+		field, ok := whitelist.Filters[param]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", param)
+		}
+		filter, err := parseFilter(field, values.Get(param))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", param, err)
+		}
+		spec.add(filter)
+	}
+
+	defaults := make([]string, 0)
+	for param, field := range whitelist.Filters {
+		if _, ok := values[param]; !ok && field.Default != "" {
+			defaults = append(defaults, param)
+		}
+	}
+	sort.Strings(defaults)
+	for _, param := range defaults {
+		filter, err := parseFilter(whitelist.Filters[param], whitelist.Filters[param].Default)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default of %s: %v", param, err)
+		}
+		spec.add(filter)
+	}
+
+	if sortField := values.Get(SortParam); sortField != "" {
+		column, ok := whitelist.Sorts[sortField]
+		if !ok {
+			return nil, fmt.Errorf("can't sort by %q", sortField)
+		}
+		spec.SortColumn = column
+	}
+	switch strings.ToLower(values.Get(OrderParam)) {
+	case "", "asc":
+	case "desc":
+		spec.Descending = true
+	default:
+		return nil, fmt.Errorf("order must be 'asc' or 'desc'")
+	}
+	return spec, nil
+}
+
+// add adds a filter to the spec, unless it is an accepted value without condition
+func (s *QuerySpec) add(filter Filter) {
+	if filter.Column == "" && filter.Condition == "" {
+		return
+	}
+	s.Filters = append(s.Filters, filter)
+}
+
+// parseFilter parses the value of a filter to the type of its field
+func parseFilter(field FilterField, raw string) (Filter, error) {
+	filter := Filter{Column: field.Column, Operator: field.Operator}
+	if field.Conditions != nil {
+		condition, ok := field.Conditions[raw]
+		if !ok {
+			accepted := make([]string, 0, len(field.Conditions))
+			for value := range field.Conditions {
+				accepted = append(accepted, value)
+			}
+			sort.Strings(accepted)
+			return filter, fmt.Errorf("must be one of %s", strings.Join(accepted, ", "))
+		}
+		filter.Condition = condition
+		return filter, nil
+	}
+
+	var err error
+	switch field.Type {
+	case FieldInt:
+		filter.Value, err = strconv.ParseInt(raw, 10, 64)
+	case FieldFloat:
+		filter.Value, err = strconv.ParseFloat(raw, 64)
+	case FieldBool:
+		filter.Value, err = strconv.ParseBool(raw)
+	case FieldTime:
+		filter.Value, err = ParseTime(raw, field.Operator == OpLessOrEqual)
+	default:
+		if raw == "" {
+			err = fmt.Errorf("can't be empty")
+		}
+		filter.Value = raw
+		if field.Operator == OpContains {
+			filter.Value = "%" + escapeLike(raw) + "%"
+		}
+	}
+	return filter, err
+}
+
+// ParseTime parses an RFC3339 timestamp or a date. Dates are the end of the day when they are an upper bound
+func ParseTime(raw string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be a date or an RFC3339 timestamp")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// Where returns the conditions of the filters joined with AND, and their arguments
+func (s *QuerySpec) Where() (string, []interface{}) {
+	conditions := make([]string, 0, len(s.Filters))
+	args := make([]interface{}, 0, len(s.Filters))
+	for _, filter := range s.Filters {
+		switch {
+		case filter.Condition != "":
+			conditions = append(conditions, filter.Condition)
+		case filter.Operator == OpContains:
+			conditions = append(conditions, fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, filter.Column))
+			args = append(args, filter.Value)
+		default:
+			conditions = append(conditions, fmt.Sprintf("%s %s ?", filter.Column, filter.Operator))
+			args = append(args, filter.Value)
+		}
+	}
+	if len(conditions) == 0 {
+		return "1 = 1", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
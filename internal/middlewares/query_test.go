@@ -0,0 +1,172 @@
+package middlewares
+
+import (
+	"bikesRentalAPI/internal/pagination"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testWhitelist = QueryWhitelist{
+	Filters: map[string]FilterField{
+		"user_id":    {Column: "user_id", Type: FieldInt, Operator: OpEqual},
+		"cost_min":   {Column: "cost", Type: FieldFloat, Operator: OpGreaterOrEqual},
+		"start_to":   {Column: "start_time", Type: FieldTime, Operator: OpLessOrEqual},
+		"email":      {Column: "email", Type: FieldString, Operator: OpContains},
+		"status":     {Conditions: map[string]string{"ongoing": "end_time IS NULL", "ended": "end_time IS NOT NULL"}},
+		"is_enabled": {Column: "is_enabled", Type: FieldBool, Operator: OpEqual},
+	},
+	Sorts: map[string]string{"cost": "cost", "start_time": "start_time"},
+}
+
+func TestParseQuerySpec(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		expectedWhere string
+		expectedArgs  []interface{}
+		expectedOrder string
+		wantErr       bool
+	}{
+		{
+			name:          "Success - no filters lists every row by id",
+			query:         "",
+			expectedWhere: "1 = 1",
+			expectedArgs:  []interface{}{},
+			expectedOrder: "id ASC",
+		},
+		{
+			name:          "Success - filters are parameterized and sorted by parameter",
+			query:         "user_id=12&cost_min=10&status=ended",
+			expectedWhere: "cost >= ? AND end_time IS NOT NULL AND user_id = ?",
+			expectedArgs:  []interface{}{10.0, int64(12)},
+			expectedOrder: "id ASC",
+		},
+		{
+			name:          "Success - dates are the end of the day as upper bound",
+			query:         "start_to=2024-05-07&sort=start_time&order=desc",
+			expectedWhere: "start_time <= ?",
+			expectedArgs:  []interface{}{time.Date(2024, 5, 7, 23, 59, 59, 999999999, time.UTC)},
+			expectedOrder: "start_time DESC, id DESC",
+		},
+		{
+			name:          "Success - contains escapes the wildcards",
+			query:         "email=" + url.QueryEscape("a%_b"),
+			expectedWhere: `email LIKE ? ESCAPE '\'`,
+			expectedArgs:  []interface{}{`%a\%\_b%`},
+			expectedOrder: "id ASC",
+		},
+		{
+			name:          "Success - injected values stay arguments",
+			query:         "email=" + url.QueryEscape("x' OR '1'='1"),
+			expectedWhere: `email LIKE ? ESCAPE '\'`,
+			expectedArgs:  []interface{}{`%x' OR '1'='1%`},
+			expectedOrder: "id ASC",
+		},
+		{
+			name:          "Success - pagination parameters are not filters",
+			query:         "limit=5&cursor=abc&total=true",
+			expectedWhere: "1 = 1",
+			expectedArgs:  []interface{}{},
+			expectedOrder: "id ASC",
+		},
+		{
+			name:    "Failure - unknown filter",
+			query:   "password=x",
+			wantErr: true,
+		},
+		{
+			name:    "Failure - sort field out of the whitelist",
+			query:   "sort=" + url.QueryEscape("id; DROP TABLE users"),
+			wantErr: true,
+		},
+		{
+			name:    "Failure - invalid direction",
+			query:   "sort=cost&order=sideways",
+			wantErr: true,
+		},
+		{
+			name:    "Failure - value of the wrong type",
+			query:   "user_id=" + url.QueryEscape("1 OR 1=1"),
+			wantErr: true,
+		},
+		{
+			name:    "Failure - value out of the accepted ones",
+			query:   "status=deleted",
+			wantErr: true,
+		},
+		{
+			name:    "Failure - invalid date",
+			query:   "start_to=last-week",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: the query parameters of a list request
+			values, err := url.ParseQuery(tc.query)
+			assert.NoError(t, err)
+			// WHEN: they are parsed against the whitelist
+			spec, err := ParseQuerySpec(values, testWhitelist)
+			// THEN: the query matches the filters and the sort, or they are refused
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			where, args := spec.Where()
+			assert.Equal(t, tc.expectedWhere, where)
+			assert.Equal(t, tc.expectedArgs, args)
+			_, _, orderBy := (&pagination.Page{}).Keyset(spec.SortColumn, spec.Descending)
+			assert.Equal(t, tc.expectedOrder, orderBy)
+		})
+	}
+}
+
+func TestParseQuerySpecDefaults(t *testing.T) {
+	whitelist := QueryWhitelist{
+		Filters: map[string]FilterField{
+			"user_id": {Column: "user_id", Type: FieldInt, Operator: OpEqual},
+			"include_deleted": {
+				Conditions: map[string]string{"false": "deleted_at IS NULL", "true": ""},
+				Default:    "false",
+			},
+		},
+	}
+	t.Run("Success - defaults apply to the filters without parameter", func(t *testing.T) {
+		spec, err := ParseQuerySpec(url.Values{"user_id": {"12"}}, whitelist)
+		assert.NoError(t, err)
+		where, args := spec.Where()
+		assert.Equal(t, "user_id = ? AND deleted_at IS NULL", where)
+		assert.Equal(t, []interface{}{int64(12)}, args)
+	})
+	t.Run("Success - values without condition don't filter", func(t *testing.T) {
+		spec, err := ParseQuerySpec(url.Values{"include_deleted": {"true"}}, whitelist)
+		assert.NoError(t, err)
+		where, _ := spec.Where()
+		assert.Equal(t, "1 = 1", where)
+	})
+}
+
+func TestQuerySpecParser(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spec := QuerySpecFromContext(r.Context())
+		assert.Equal(t, "cost", spec.SortColumn)
+		w.WriteHeader(http.StatusOK)
+	})
+	t.Run("Success - the spec is set in the context", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		QuerySpecParser(testWhitelist)(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?sort=cost", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("Failure - invalid queries are refused", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		QuerySpecParser(testWhitelist)(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?sort=password", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,195 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// DefaultLimit is the number of items of a page when the request doesn't set one
+	DefaultLimit = 10
+	// MaxLimit is the largest page a request can ask for
+	MaxLimit = 100
+
+	// LimitParam, CursorParam and TotalParam are the query parameters of the page
+	LimitParam  = "limit"
+	CursorParam = "cursor"
+	TotalParam  = "total"
+)
+
+// Cursor points at the row a page starts after, or before when going back. The sort key is kept as text
+// so the row can be found again without reading it, and is nil when the sort column of the row is NULL
+type Cursor struct {
+	ID     int64   `json:"id"`
+	Key    *string `json:"k,omitempty"`
+	Before bool    `json:"b,omitempty"`
+}
+
+// Encode returns the opaque form of the cursor sent to clients
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor encoded with Encode
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// Page is the page a list request asks for
+type Page struct {
+	Limit  int
+	Cursor *Cursor
+	// WithTotal is set when the response counts every item matching the request
+	WithTotal bool
+}
+
+// Parse reads the page of a list request from its query parameters
+func Parse(values url.Values) (*Page, error) {
+	page := &Page{Limit: DefaultLimit}
+	if limit := values.Get(LimitParam); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		page.Limit = value
+	}
+	if cursor := values.Get(CursorParam); cursor != "" {
+		value, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		page.Cursor = value
+	}
+	if total := values.Get(TotalParam); total != "" {
+		value, err := strconv.ParseBool(total)
+		if err != nil {
+			return nil, fmt.Errorf("total must be true or false")
+		}
+		page.WithTotal = value
+	}
+	return page, nil
+}
+
+// FetchLimit is the number of rows to query for the page, one more than its limit to know whether another page follows
+func (p *Page) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// SortKey returns the expression selecting the sort key of a row, read into a Row after the listed columns
+func SortKey(column string) string {
+	return fmt.Sprintf("CAST(%s AS TEXT)", column)
+}
+
+// Keyset returns the condition of the rows of the page and its ORDER BY clause, for a sort on a whitelisted column.
+// Rows are sorted by id to break ties and NULL sort keys come first, like SQLite sorts them
+func (p *Page) Keyset(column string, descending bool) (string, []interface{}, string) {
+	if column == "" {
+		column = "id"
+	}
+	// Going back reads the rows in the reverse order, they are put back in order by Paginate
+	ascending := descending == (p.Cursor != nil && p.Cursor.Before)
+	comparison, direction := "<", "DESC"
+	if ascending {
+		comparison, direction = ">", "ASC"
+	}
+	orderBy := fmt.Sprintf("%s %s, id %s", column, direction, direction)
+	if column == "id" {
+		orderBy = "id " + direction
+	}
+
+	switch {
+	case p.Cursor == nil:
+		return "1 = 1", nil, orderBy
+	case column == "id":
+		return fmt.Sprintf("id %s ?", comparison), []interface{}{p.Cursor.ID}, orderBy
+	case p.Cursor.Key == nil && ascending:
+		return fmt.Sprintf("((%s IS NULL AND id > ?) OR %s IS NOT NULL)", column, column), []interface{}{p.Cursor.ID}, orderBy
+	case p.Cursor.Key == nil:
+		return fmt.Sprintf("(%s IS NULL AND id < ?)", column), []interface{}{p.Cursor.ID}, orderBy
+	case ascending:
+		return fmt.Sprintf("(%s, id) > (?, ?)", column), []interface{}{*p.Cursor.Key, p.Cursor.ID}, orderBy
+	default:
+		return fmt.Sprintf("((%s, id) < (?, ?) OR %s IS NULL)", column, column), []interface{}{*p.Cursor.Key, p.Cursor.ID}, orderBy
+	}
+}
+
+// Row is the id and the sort key of a listed row
+type Row struct {
+	ID  int64
+	Key *string
+}
+
+// Links holds the URLs of the pages around a page
+type Links struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+} // @name PageLinks
+
+// Info is the pagination of a list response, the same for every list
+type Info struct {
+	// The number of items of a page
+	Limit int `json:"limit" example:"10"`
+	// The cursor of the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// The cursor of the previous page, empty on the first page
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// The links to the next and previous pages
+	Links Links `json:"links"`
+	// The number of items matching the request, when asked for with total=true
+	Total *int64 `json:"total,omitempty"`
+} // @name PageInfo
+
+// SetLinks sets the links to the pages around the page from the URL of its request
+func (i *Info) SetLinks(requestURL *url.URL) {
+	link := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		values := requestURL.Query()
+		values.Set(CursorParam, cursor)
+		return requestURL.Path + "?" + values.Encode()
+	}
+	i.Links = Links{Next: link(i.NextCursor), Prev: link(i.PrevCursor)}
+}
+
+// Paginate trims the items queried with the page's keyset to its limit, in the sort order, and returns their pagination.
+// rows holds the id and the sort key of each item
+func Paginate[T any](page *Page, items []T, rows []Row) ([]T, Info) {
+	info := Info{Limit: page.Limit}
+	more := len(items) > page.Limit
+	if more {
+		items, rows = items[:page.Limit], rows[:page.Limit]
+	}
+	back := page.Cursor != nil && page.Cursor.Before
+	if back {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return items, info
+	}
+	first, last := rows[0], rows[len(rows)-1]
+	// Going forward, the next page exists when more rows were read and the previous one when the page started after a row.
+	// Going back it is the other way around
+	if more || back {
+		info.NextCursor = (&Cursor{ID: last.ID, Key: last.Key}).Encode()
+	}
+	if back && more || !back && page.Cursor != nil {
+		info.PrevCursor = (&Cursor{ID: first.ID, Key: first.Key, Before: true}).Encode()
+	}
+	return items, info
+}
@@ -0,0 +1,127 @@
+package pagination
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cursor := (&Cursor{ID: 4}).Encode()
+	testCases := []struct {
+		name     string
+		query    string
+		expected *Page
+		wantErr  bool
+	}{
+		{
+			name:     "Success - the first page of the default size",
+			query:    "",
+			expected: &Page{Limit: DefaultLimit},
+		},
+		{
+			name:     "Success - a limit, a cursor and the total",
+			query:    "limit=25&total=true&cursor=" + cursor,
+			expected: &Page{Limit: 25, Cursor: &Cursor{ID: 4}, WithTotal: true},
+		},
+		{
+			name:    "Failure - a limit over the maximum",
+			query:   fmt.Sprintf("limit=%d", MaxLimit+1),
+			wantErr: true,
+		},
+		{
+			name:    "Failure - a cursor that wasn't sent by the API",
+			query:   "cursor=10",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: the query parameters of a list request
+			values, err := url.ParseQuery(tc.query)
+			assert.NoError(t, err)
+			// WHEN: the page is parsed
+			page, err := Parse(values)
+			// THEN: it matches the parameters, or they are refused
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, page)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	// GIVEN: rows with equal and NULL sort keys
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE rentals (id INTEGER PRIMARY KEY, cost REAL);
+		INSERT INTO rentals (id, cost) VALUES (1, 2.5), (2, NULL), (3, 0.75), (4, 2.5), (5, 10), (6, NULL), (7, 0.75)`)
+	assert.NoError(t, err)
+
+	list := func(page *Page, descending bool) ([]int64, Info) {
+		keyset, args, orderBy := page.Keyset("cost", descending)
+		query := fmt.Sprintf("SELECT id, %s FROM rentals WHERE %s ORDER BY %s LIMIT ?", SortKey("cost"), keyset, orderBy)
+		rows, err := db.Query(query, append(args, page.FetchLimit())...)
+		assert.NoError(t, err)
+		defer rows.Close()
+		ids, keys := []int64{}, []Row{}
+		for rows.Next() {
+			var row Row
+			assert.NoError(t, rows.Scan(&row.ID, &row.Key))
+			ids, keys = append(ids, row.ID), append(keys, row)
+		}
+		ids, info := Paginate(page, ids, keys)
+		return ids, info
+	}
+	cursorPage := func(cursor string) *Page {
+		page, err := Parse(url.Values{LimitParam: {"3"}, CursorParam: {cursor}})
+		assert.NoError(t, err)
+		return page
+	}
+
+	for _, descending := range []bool{false, true} {
+		expected := [][]int64{{2, 6, 3}, {7, 1, 4}, {5}}
+		if descending {
+			expected = [][]int64{{5, 4, 1}, {7, 3, 6}, {2}}
+		}
+		t.Run(fmt.Sprintf("Success - pages follow each other, descending %v", descending), func(t *testing.T) {
+			// WHEN: the pages are read forward, then back
+			ids, info := list(&Page{Limit: 3}, descending)
+			assert.Equal(t, expected[0], ids)
+			assert.Empty(t, info.PrevCursor)
+			for i := 1; i < len(expected); i++ {
+				ids, info = list(cursorPage(info.NextCursor), descending)
+				// THEN: every row is listed once, in the sort order
+				assert.Equal(t, expected[i], ids)
+			}
+			assert.Empty(t, info.NextCursor)
+			for i := len(expected) - 2; i >= 0; i-- {
+				ids, info = list(cursorPage(info.PrevCursor), descending)
+				assert.Equal(t, expected[i], ids)
+				assert.NotEmpty(t, info.NextCursor)
+			}
+			assert.Empty(t, info.PrevCursor)
+		})
+	}
+}
+
+func TestSetLinks(t *testing.T) {
+	// GIVEN: a page with a next page
+	info := Info{NextCursor: "abc"}
+	requestURL, err := url.Parse("/admin/rentals?sort=cost&limit=5&cursor=xyz")
+	assert.NoError(t, err)
+	// WHEN: the links are set
+	info.SetLinks(requestURL)
+	// THEN: the next link keeps the request and replaces its cursor
+	assert.Equal(t, "/admin/rentals?cursor=abc&limit=5&sort=cost", info.Links.Next)
+	assert.Empty(t, info.Links.Prev)
+}
@@ -0,0 +1,111 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DeclinedTokenPrefix marks the payment method tokens the fake provider refuses to authorize
+const DeclinedTokenPrefix = "tok_declined"
+
+// FakeProvider is an in-memory PaymentProvider used for local development and tests.
+// Every authorization succeeds except for tokens starting with DeclinedTokenPrefix
+type FakeProvider struct {
+	mu             sync.Mutex
+	nextID         int64
+	authorizations map[string]*fakeAuthorization
+}
+
+type fakeAuthorization struct {
+	authorized float64
+	captured   float64
+	refunded   float64
+	voided     bool
+}
+
+// NewFakeProvider returns an empty fake provider
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{authorizations: make(map[string]*fakeAuthorization)}
+}
+
+// Authorize holds the amount on the payment method and returns the reference of the authorization
+func (p *FakeProvider) Authorize(ctx context.Context, methodToken string, amount float64) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if methodToken == "" || strings.HasPrefix(methodToken, DeclinedTokenPrefix) {
+		return "", ErrPaymentDeclined
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	reference := fmt.Sprintf("fake_auth_%d", p.nextID)
+	p.authorizations[reference] = &fakeAuthorization{authorized: amount}
+	return reference, nil
+}
+
+// Capture charges the amount on an authorization. An authorization can only be captured once
+func (p *FakeProvider) Capture(ctx context.Context, reference string, amount float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	authorization, ok := p.authorizations[reference]
+	if !ok {
+		return ErrUnknownAuthorization
+	}
+	if authorization.voided || authorization.captured > 0 || amount < 0 {
+		return ErrPaymentDeclined
+	}
+	authorization.captured = amount
+	return nil
+}
+
+// Refund gives back part or all of the captured amount
+func (p *FakeProvider) Refund(ctx context.Context, reference string, amount float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	authorization, ok := p.authorizations[reference]
+	if !ok {
+		return ErrUnknownAuthorization
+	}
+	if amount <= 0 || authorization.refunded+amount > authorization.captured {
+		return ErrPaymentDeclined
+	}
+	authorization.refunded += amount
+	return nil
+}
+
+// Void releases an authorization that was not captured
+func (p *FakeProvider) Void(ctx context.Context, reference string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	authorization, ok := p.authorizations[reference]
+	if !ok {
+		return ErrUnknownAuthorization
+	}
+	if authorization.captured > 0 {
+		return ErrPaymentDeclined
+	}
+	authorization.voided = true
+	return nil
+}
+
+// Captured returns the amount captured and refunded on an authorization
+func (p *FakeProvider) Captured(reference string) (captured float64, refunded float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if authorization, ok := p.authorizations[reference]; ok {
+		return authorization.captured, authorization.refunded
+	}
+	return 0, 0
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/payments/models"
+	"bikesRentalAPI/internal/payments/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// Handler is the interface for payment handlers
+type Handler interface {
+	ListPaymentMethods(w http.ResponseWriter, req *http.Request)      // List the payment methods of the user
+	AddPaymentMethod(w http.ResponseWriter, req *http.Request)        // Add a tokenized payment method
+	SetDefaultPaymentMethod(w http.ResponseWriter, req *http.Request) // Make a payment method the one charged for rentals
+	DeletePaymentMethod(w http.ResponseWriter, req *http.Request)     // Remove a payment method
+	ListRentalPayments(w http.ResponseWriter, req *http.Request)      // List the payments of a rental
+}
+
+type handler struct {
+	PaymentRepo repository.PaymentRepository
+	validator   *validator.Validate
+}
+
+// New returns a new payment handler
+func New(paymentRepository repository.PaymentRepository) Handler {
+	validator := validator.New(validator.WithRequiredStructEnabled())
+	handler := &handler{
+		PaymentRepo: paymentRepository,
+		validator:   validator,
+	}
+	return handler
+}
+
+// ListPaymentMethods returns the payment methods of the user, the default one first
+func (h *handler) ListPaymentMethods(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	methods, err := h.PaymentRepo.ListPaymentMethods(userID)
+	if err != nil {
+		log.Printf("Error getting payment methods: %v", err)
+		http.Error(w, "Error getting payment methods", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, methods)
+}
+
+// AddPaymentMethod stores a payment method token issued by the payment provider
+func (h *handler) AddPaymentMethod(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return
+	}
+	var newMethod models.CreatePaymentMethodRequest
+	if err := json.Unmarshal(body, &newMethod); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return
+	}
+	if err := h.validator.Struct(newMethod); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return
+	}
+	id, err := h.PaymentRepo.CreatePaymentMethod(userID, newMethod)
+	if err != nil {
+		log.Printf("Error adding payment method: %v", err)
+		http.Error(w, "Error adding payment method", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusCreated, models.CreatePaymentMethodResponse{
+		ID:      id,
+		Message: "Payment method added successfully",
+	})
+}
+
+// SetDefaultPaymentMethod makes a payment method of the user the one charged for the next rentals
+func (h *handler) SetDefaultPaymentMethod(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	methodID, ok := parsePaymentMethodID(w, req)
+	if !ok {
+		return
+	}
+	if err := h.PaymentRepo.SetDefaultPaymentMethod(userID, methodID); err != nil {
+		writePaymentMethodError(w, "Error setting default payment method", err)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, models.CreatePaymentMethodResponse{
+		ID:      methodID,
+		Message: "Default payment method updated successfully",
+	})
+}
+
+// DeletePaymentMethod removes a payment method of the user. Methods holding an authorization can't be removed
+func (h *handler) DeletePaymentMethod(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	methodID, ok := parsePaymentMethodID(w, req)
+	if !ok {
+		return
+	}
+	if err := h.PaymentRepo.DeletePaymentMethod(userID, methodID); err != nil {
+		writePaymentMethodError(w, "Error deleting payment method", err)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, models.CreatePaymentMethodResponse{
+		ID:      methodID,
+		Message: "Payment method deleted successfully",
+	})
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// ListRentalPayments returns the payments of a rental
+func (h *handler) ListRentalPayments(w http.ResponseWriter, req *http.Request) {
+	rentalIDStr := chi.URLParam(req, "rental_id")
+	rentalID, err := strconv.ParseInt(rentalIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", rentalIDStr, err), http.StatusBadRequest)
+		return
+	}
+	payments, err := h.PaymentRepo.ListPaymentsByRentalID(rentalID)
+	if err != nil {
+		log.Printf("Error getting rental payments: %v", err)
+		http.Error(w, "Error getting rental payments", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, payments)
+}
+
+// parsePaymentMethodID reads the 'payment_method_id' URL parameter. It writes the error response when it fails
+func parsePaymentMethodID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	methodIDStr := chi.URLParam(req, "payment_method_id")
+	methodID, err := strconv.ParseInt(methodIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", methodIDStr, err), http.StatusBadRequest)
+		return 0, false
+	}
+	return methodID, true
+}
+
+// writePaymentMethodError maps repository errors to http errors
+func writePaymentMethodError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrPaymentMethodNotFound):
+		http.Error(w, "Payment method not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrPaymentMethodInUse):
+		http.Error(w, "Payment method holds the authorization of an ongoing rental", http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
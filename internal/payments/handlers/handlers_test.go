@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/payments/models"
+	"bikesRentalAPI/internal/payments/repository"
+	"bikesRentalAPI/internal/payments/repository/mocks"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// withUser returns the request authenticated as the user
+func withUser(t *testing.T, req *http.Request, userID string) *http.Request {
+	token, _, err := jwtauth.New("HS256", []byte("secret"), nil).Encode(map[string]interface{}{"sub": userID})
+	require.NoError(t, err)
+	return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+}
+
+func TestAddPaymentMethod(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPaymentRepo := mocks.NewMockPaymentRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - the payment method is added to the user",
+			body: `{"token":"tok_visa","brand":"visa","last4":"4242","is_default":true}`,
+			mockCalls: func() {
+				mockPaymentRepo.EXPECT().CreatePaymentMethod(int64(2), models.CreatePaymentMethodRequest{Token: "tok_visa", Brand: "visa", Last4: "4242", IsDefault: true}).Return(int64(5), nil)
+			},
+			expectedHttpCode: http.StatusCreated,
+		},
+		{name: "Failure - the token is required", body: `{"brand":"visa"}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the last digits must be 4 digits", body: `{"token":"tok_visa","last4":"42a"}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the body is not JSON", body: `token=tok_visa`, expectedHttpCode: http.StatusBadRequest},
+		{
+			name: "Failure - the payment method can't be stored",
+			body: `{"token":"tok_visa"}`,
+			mockCalls: func() {
+				mockPaymentRepo.EXPECT().CreatePaymentMethod(int64(2), gomock.Any()).Return(int64(0), errors.New("UNIQUE constraint failed"))
+			},
+			expectedHttpCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider adding a payment method
+			if tc.mockCalls != nil {
+				tc.mockCalls()
+			}
+			req := withUser(t, httptest.NewRequest(http.MethodPost, "/users/payment-methods", strings.NewReader(tc.body)), "2")
+			rec := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockPaymentRepo).AddPaymentMethod(rec, req)
+			// THEN: only valid payment methods are added
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestPaymentMethodChanges(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPaymentRepo := mocks.NewMockPaymentRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		method           string
+		url              string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name:   "Success - the payment method becomes the default one",
+			method: http.MethodPut,
+			url:    "/users/payment-methods/5/default",
+			mockCalls: func() {
+				mockPaymentRepo.EXPECT().SetDefaultPaymentMethod(int64(2), int64(5)).Return(nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:   "Failure - the payment method of another user is not found",
+			method: http.MethodPut,
+			url:    "/users/payment-methods/5/default",
+			mockCalls: func() {
+				mockPaymentRepo.EXPECT().SetDefaultPaymentMethod(int64(2), int64(5)).Return(repository.ErrPaymentMethodNotFound)
+			},
+			expectedHttpCode: http.StatusNotFound,
+		},
+		{
+			name:   "Success - the payment method is deleted",
+			method: http.MethodDelete,
+			url:    "/users/payment-methods/5",
+			mockCalls: func() {
+				mockPaymentRepo.EXPECT().DeletePaymentMethod(int64(2), int64(5)).Return(nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:   "Failure - the payment method of an ongoing rental can't be deleted",
+			method: http.MethodDelete,
+			url:    "/users/payment-methods/5",
+			mockCalls: func() {
+				mockPaymentRepo.EXPECT().DeletePaymentMethod(int64(2), int64(5)).Return(repository.ErrPaymentMethodInUse)
+			},
+			expectedHttpCode: http.StatusConflict,
+		},
+		{
+			name:             "Failure - the payment method id is not a number",
+			method:           http.MethodDelete,
+			url:              "/users/payment-methods/visa",
+			expectedHttpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider changing a payment method
+			if tc.mockCalls != nil {
+				tc.mockCalls()
+			}
+			req := withUser(t, httptest.NewRequest(tc.method, tc.url, nil), "2")
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			handler := New(mockPaymentRepo)
+			router.Put("/users/payment-methods/{payment_method_id}/default", handler.SetDefaultPaymentMethod)
+			router.Delete("/users/payment-methods/{payment_method_id}", handler.DeletePaymentMethod)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: only the payment methods of the rider not holding an authorization are changed
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestListRentalPayments(t *testing.T) {
+	// GIVEN: an administrator listing the payments of rental 7
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPaymentRepo := mocks.NewMockPaymentRepository(mockCtrl)
+	mockPaymentRepo.EXPECT().ListPaymentsByRentalID(int64(7)).Return(&models.PaymentList{Items: []*models.Payment{{ID: 1, RentalID: 7}}}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/admin/rentals/7/payments", nil)
+	rec := httptest.NewRecorder()
+	router := chi.NewRouter()
+	router.Get("/admin/rentals/{rental_id}/payments", New(mockPaymentRepo).ListRentalPayments)
+	// WHEN: the request is made
+	router.ServeHTTP(rec, req)
+	// THEN: the payments are returned
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var payments models.PaymentList
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payments))
+	require.Len(t, payments.Items, 1)
+	assert.Equal(t, int64(7), payments.Items[0].RentalID)
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// PaymentStatus is the state of the payment of a rental
+type PaymentStatus string
+
+const (
+	// PaymentStatusAuthorized is an amount held on the payment method while the rental is ongoing
+	PaymentStatusAuthorized PaymentStatus = "authorized"
+	// PaymentStatusCaptured is a rental cost charged to the rider
+	PaymentStatusCaptured PaymentStatus = "captured"
+	// PaymentStatusFailed is a rental cost the provider refused to charge
+	PaymentStatusFailed PaymentStatus = "failed"
+	// PaymentStatusRefunded is a charged rental cost given back to the rider, in part or in full
+	PaymentStatusRefunded PaymentStatus = "refunded"
+	// PaymentStatusVoided is an authorization released because there was nothing to charge, e.g. the wallet paid the rental
+	PaymentStatusVoided PaymentStatus = "voided"
+)
+
+// PaymentMethod is a tokenized payment method of a user. The token is issued by the payment provider
+type PaymentMethod struct {
+	ID        int64     `json:"id,omitempty"`
+	UserID    int64     `json:"user_id"`
+	Token     string    `json:"-"`
+	Brand     string    `json:"brand,omitempty"`
+	Last4     string    `json:"last4,omitempty"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+} // @name PaymentMethod
+
+// PaymentMethodList contains a list of payment methods
+type PaymentMethodList struct {
+	// The list of payment methods
+	Items []*PaymentMethod `json:"items"`
+} // @name PaymentMethodList
+
+// CreatePaymentMethodRequest contains the information to add a payment method
+type CreatePaymentMethodRequest struct {
+	Token     string `json:"token" validate:"required,max=255"`
+	Brand     string `json:"brand" validate:"omitempty,max=50"`
+	Last4     string `json:"last4" validate:"omitempty,len=4,numeric"`
+	IsDefault bool   `json:"is_default"`
+} // @name CreatePaymentMethodRequest
+
+// CreatePaymentMethodResponse contains the id of the added payment method
+type CreatePaymentMethodResponse struct {
+	ID      int64  `json:"id"`
+	Message string `json:"message"`
+} // @name CreatePaymentMethodResponse
+
+// Payment is the charge of a rental on a payment method
+type Payment struct {
+	ID                int64         `json:"id,omitempty"`
+	RentalID          int64         `json:"rental_id"`
+	UserID            int64         `json:"user_id"`
+	PaymentMethodID   int64         `json:"payment_method_id"`
+	ProviderReference string        `json:"provider_reference"`
+	AmountAuthorized  float64       `json:"amount_authorized"`
+	AmountCaptured    float64       `json:"amount_captured"`
+	AmountRefunded    float64       `json:"amount_refunded"`
+	Status            PaymentStatus `json:"status"`
+	FailureReason     *string       `json:"failure_reason,omitempty"`
+	CreatedAt         time.Time     `json:"created_at,omitempty"`
+	UpdatedAt         time.Time     `json:"updated_at,omitempty"`
+} // @name Payment
+
+// PaymentList contains a list of payments
+type PaymentList struct {
+	// The list of payments
+	Items []*Payment `json:"items"`
+} // @name PaymentList
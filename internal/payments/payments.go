@@ -0,0 +1,47 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+)
+
+// defaultPreAuthAmount is the amount held on the payment method of a rider when a rental starts
+const defaultPreAuthAmount = 10.0
+
+var (
+	// ErrPaymentDeclined is returned when the provider refuses to authorize, capture or refund an amount
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrUnknownAuthorization is returned when the provider has no authorization for the given reference
+	ErrUnknownAuthorization = errors.New("unknown authorization")
+)
+
+// PaymentProvider charges riders through an external payment service.
+// An amount is authorized on the payment method of the rider when a rental starts,
+// the final cost is captured when it ends and captured amounts can be refunded later
+type PaymentProvider interface {
+	// Authorize holds the amount on the payment method and returns the reference of the authorization
+	Authorize(ctx context.Context, methodToken string, amount float64) (string, error)
+	// Capture charges the amount on an authorization
+	Capture(ctx context.Context, reference string, amount float64) error
+	// Refund gives back part or all of the captured amount
+	Refund(ctx context.Context, reference string, amount float64) error
+	// Void releases an authorization that will never be captured
+	Void(ctx context.Context, reference string) error
+}
+
+// PreAuthAmountFromEnv reads the amount authorized when a rental starts from PAYMENT_PREAUTH_AMOUNT, falling back to the default
+func PreAuthAmountFromEnv() float64 {
+	value := os.Getenv("PAYMENT_PREAUTH_AMOUNT")
+	if value == "" {
+		return defaultPreAuthAmount
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount <= 0 {
+		log.Printf("invalid PAYMENT_PREAUTH_AMOUNT %q. Set to %v as default", value, defaultPreAuthAmount)
+		return defaultPreAuthAmount
+	}
+	return amount
+}
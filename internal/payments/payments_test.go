@@ -0,0 +1,64 @@
+package payments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeProvider(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Success - authorize, capture and refund a payment", func(t *testing.T) {
+		// GIVEN: a fake provider
+		provider := NewFakeProvider()
+		// WHEN: an amount is authorized
+		reference, err := provider.Authorize(ctx, "tok_visa", 10)
+		// THEN: a reference is returned
+		assert.NoError(t, err)
+		assert.NotEmpty(t, reference)
+		// WHEN: the final cost is captured and partially refunded
+		assert.NoError(t, provider.Capture(ctx, reference, 4.5))
+		assert.NoError(t, provider.Refund(ctx, reference, 1.5))
+		// THEN: the provider keeps track of both amounts
+		captured, refunded := provider.Captured(reference)
+		assert.Equal(t, 4.5, captured)
+		assert.Equal(t, 1.5, refunded)
+	})
+	t.Run("Failure - declined tokens are not authorized", func(t *testing.T) {
+		// GIVEN: a fake provider
+		provider := NewFakeProvider()
+		// WHEN: a declined token is authorized
+		_, err := provider.Authorize(ctx, DeclinedTokenPrefix+"_visa", 10)
+		// THEN: the payment is declined
+		assert.ErrorIs(t, err, ErrPaymentDeclined)
+	})
+	t.Run("Failure - refunds can't exceed the captured amount", func(t *testing.T) {
+		// GIVEN: a captured payment
+		provider := NewFakeProvider()
+		reference, _ := provider.Authorize(ctx, "tok_visa", 10)
+		assert.NoError(t, provider.Capture(ctx, reference, 2))
+		// WHEN: more than the captured amount is refunded
+		err := provider.Refund(ctx, reference, 3)
+		// THEN: the refund is declined
+		assert.ErrorIs(t, err, ErrPaymentDeclined)
+	})
+	t.Run("Failure - voided authorizations can't be captured", func(t *testing.T) {
+		// GIVEN: a voided authorization
+		provider := NewFakeProvider()
+		reference, _ := provider.Authorize(ctx, "tok_visa", 10)
+		assert.NoError(t, provider.Void(ctx, reference))
+		// WHEN: it is captured
+		err := provider.Capture(ctx, reference, 2)
+		// THEN: the capture is declined
+		assert.ErrorIs(t, err, ErrPaymentDeclined)
+	})
+	t.Run("Failure - unknown references are rejected", func(t *testing.T) {
+		// GIVEN: a fake provider
+		provider := NewFakeProvider()
+		// WHEN: an unknown reference is captured
+		err := provider.Capture(ctx, "missing", 2)
+		// THEN: the authorization is unknown
+		assert.ErrorIs(t, err, ErrUnknownAuthorization)
+	})
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/payments/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrPaymentMethodNotFound is returned when the payment method does not exist or belongs to another user
+	ErrPaymentMethodNotFound = errors.New("payment method not found")
+	// ErrPaymentMethodInUse is returned when removing a payment method holding an authorization of an ongoing rental
+	ErrPaymentMethodInUse = errors.New("payment method in use")
+)
+
+type PaymentRepository interface {
+	CreatePaymentMethod(userID int64, method models.CreatePaymentMethodRequest) (int64, error)
+	ListPaymentMethods(userID int64) (*models.PaymentMethodList, error)
+	GetDefaultPaymentMethod(userID int64) (*models.PaymentMethod, error)
+	SetDefaultPaymentMethod(userID int64, methodID int64) error
+	DeletePaymentMethod(userID int64, methodID int64) error
+	CreatePayment(q database.Querier, payment *models.Payment) (int64, error)
+	GetPaymentByRentalID(q database.Querier, rentalID int64) (*models.Payment, error)
+	ListPaymentsByRentalID(rentalID int64) (*models.PaymentList, error)
+	UpdatePayment(q database.Querier, payment *models.Payment) error
+}
+
+type paymentRepository struct {
+	db database.Database
+}
+
+// New initializes a new empty payment repository
+func New(db database.Database) PaymentRepository {
+	return &paymentRepository{db}
+}
+
+const paymentColumns = "id, rental_id, user_id, payment_method_id, provider_reference, amount_authorized, amount_captured, amount_refunded, status, failure_reason, created_at, updated_at"
+
+// CreatePaymentMethod adds a payment method to a user. The first payment method of a user is always the default one
+func (r *paymentRepository) CreatePaymentMethod(userID int64, method models.CreatePaymentMethodRequest) (int64, error) {
+	var id int64
+	err := r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM payment_methods WHERE user_id = ?", userID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count payment methods: %v", err)
+		}
+		isDefault := method.IsDefault || count == 0
+		if isDefault {
+			if _, err := tx.Exec("UPDATE payment_methods SET is_default = 0 WHERE user_id = ? AND is_default = 1", userID); err != nil {
+				return fmt.Errorf("failed to reset default payment method: %v", err)
+			}
+		}
+		query := "INSERT INTO payment_methods (user_id, token, brand, last4, is_default) VALUES (?, ?, ?, ?, ?)"
+		result, err := tx.Exec(query, userID, method.Token, method.Brand, method.Last4, isDefault)
+		if err != nil {
+			return fmt.Errorf("failed to insert payment method: %v", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// ListPaymentMethods retrieves the payment methods of a user, the default one first
+func (r *paymentRepository) ListPaymentMethods(userID int64) (*models.PaymentMethodList, error) {
+	query := "SELECT id, user_id, token, brand, last4, is_default, created_at, updated_at FROM payment_methods WHERE user_id = ? ORDER BY is_default DESC, id"
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	methods := &models.PaymentMethodList{Items: make([]*models.PaymentMethod, 0)}
+	for rows.Next() {
+		method, err := scanPaymentMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		methods.Items = append(methods.Items, method)
+	}
+	return methods, rows.Err()
+}
+
+// GetDefaultPaymentMethod retrieves the payment method charged for the rentals of a user
+func (r *paymentRepository) GetDefaultPaymentMethod(userID int64) (*models.PaymentMethod, error) {
+	query := "SELECT id, user_id, token, brand, last4, is_default, created_at, updated_at FROM payment_methods WHERE user_id = ? AND is_default = 1"
+	method, err := scanPaymentMethod(r.db.QueryRow(query, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPaymentMethodNotFound
+		}
+		return nil, err
+	}
+	return method, nil
+}
+
+// SetDefaultPaymentMethod makes a payment method the default one of its user
+func (r *paymentRepository) SetDefaultPaymentMethod(userID int64, methodID int64) error {
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		if _, err := tx.Exec("UPDATE payment_methods SET is_default = 0 WHERE user_id = ? AND is_default = 1", userID); err != nil {
+			return fmt.Errorf("failed to reset default payment method: %v", err)
+		}
+		result, err := tx.Exec("UPDATE payment_methods SET is_default = 1 WHERE id = ? AND user_id = ?", methodID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to set default payment method: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return ErrPaymentMethodNotFound
+		}
+		return nil
+	})
+}
+
+// DeletePaymentMethod removes a payment method of a user unless it holds an authorization
+func (r *paymentRepository) DeletePaymentMethod(userID int64, methodID int64) error {
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		var authorized int
+		query := "SELECT COUNT(*) FROM payments WHERE payment_method_id = ? AND status = ?"
+		if err := tx.QueryRow(query, methodID, models.PaymentStatusAuthorized).Scan(&authorized); err != nil {
+			return fmt.Errorf("failed to count authorized payments: %v", err)
+		}
+		if authorized > 0 {
+			return ErrPaymentMethodInUse
+		}
+		result, err := tx.Exec("DELETE FROM payment_methods WHERE id = ? AND user_id = ?", methodID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete payment method: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return ErrPaymentMethodNotFound
+		}
+		return nil
+	})
+}
+
+// CreatePayment stores a payment. q is either the database or an ongoing transaction
+func (r *paymentRepository) CreatePayment(q database.Querier, payment *models.Payment) (int64, error) {
+	query := `INSERT INTO payments (rental_id, user_id, payment_method_id, provider_reference, amount_authorized, amount_captured, amount_refunded, status, failure_reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := q.Exec(query, payment.RentalID, payment.UserID, payment.PaymentMethodID, payment.ProviderReference,
+		payment.AmountAuthorized, payment.AmountCaptured, payment.AmountRefunded, payment.Status, payment.FailureReason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert payment: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %v", err)
+	}
+	return id, nil
+}
+
+// GetPaymentByRentalID retrieves the latest payment of a rental. q is either the database or an ongoing transaction
+func (r *paymentRepository) GetPaymentByRentalID(q database.Querier, rentalID int64) (*models.Payment, error) {
+	query := fmt.Sprintf("SELECT %s FROM payments WHERE rental_id = ? ORDER BY id DESC LIMIT 1", paymentColumns)
+	return scanPayment(q.QueryRow(query, rentalID))
+}
+
+// ListPaymentsByRentalID retrieves every payment of a rental
+func (r *paymentRepository) ListPaymentsByRentalID(rentalID int64) (*models.PaymentList, error) {
+	query := fmt.Sprintf("SELECT %s FROM payments WHERE rental_id = ? ORDER BY id", paymentColumns)
+	rows, err := r.db.Query(query, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := &models.PaymentList{Items: make([]*models.Payment, 0)}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments.Items = append(payments.Items, payment)
+	}
+	return payments, rows.Err()
+}
+
+// UpdatePayment stores the amounts and the status of a payment. q is either the database or an ongoing transaction
+func (r *paymentRepository) UpdatePayment(q database.Querier, payment *models.Payment) error {
+	query := `UPDATE payments SET amount_captured = ?, amount_refunded = ?, status = ?, failure_reason = ?
+		WHERE id = ?`
+	_, err := q.Exec(query, payment.AmountCaptured, payment.AmountRefunded, payment.Status, payment.FailureReason, payment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update payment: %v", err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPaymentMethod(row scanner) (*models.PaymentMethod, error) {
+	var method models.PaymentMethod
+	var brand, last4 sql.NullString
+	err := row.Scan(&method.ID, &method.UserID, &method.Token, &brand, &last4, &method.IsDefault, &method.CreatedAt, &method.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	method.Brand = brand.String
+	method.Last4 = last4.String
+	return &method, nil
+}
+
+func scanPayment(row scanner) (*models.Payment, error) {
+	var payment models.Payment
+	err := row.Scan(
+		&payment.ID,
+		&payment.RentalID,
+		&payment.UserID,
+		&payment.PaymentMethodID,
+		&payment.ProviderReference,
+		&payment.AmountAuthorized,
+		&payment.AmountCaptured,
+		&payment.AmountRefunded,
+		&payment.Status,
+		&payment.FailureReason,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
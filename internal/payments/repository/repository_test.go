@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/payments/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentMethods(t *testing.T) {
+	// GIVEN: two riders
+	t.Setenv("DB_URL", "file:payment_methods_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	_, err := db.Exec("INSERT INTO users (id, email, hashed_password) VALUES (1, 'first@example.com', 'x'), (2, 'second@example.com', 'x')")
+	require.NoError(t, err)
+	repo := New(db)
+	defaultToken := func(userID int64) string {
+		method, err := repo.GetDefaultPaymentMethod(userID)
+		require.NoError(t, err)
+		return method.Token
+	}
+
+	t.Run("Success - the first payment method is the default one", func(t *testing.T) {
+		_, err := repo.GetDefaultPaymentMethod(1)
+		assert.ErrorIs(t, err, ErrPaymentMethodNotFound)
+		_, err = repo.CreatePaymentMethod(1, models.CreatePaymentMethodRequest{Token: "tok_first", Last4: "4242"})
+		require.NoError(t, err)
+		assert.Equal(t, "tok_first", defaultToken(1))
+	})
+	t.Run("Success - a new default payment method replaces the previous one", func(t *testing.T) {
+		_, err := repo.CreatePaymentMethod(1, models.CreatePaymentMethodRequest{Token: "tok_second"})
+		require.NoError(t, err)
+		assert.Equal(t, "tok_first", defaultToken(1), "methods are only default when asked")
+		third, err := repo.CreatePaymentMethod(1, models.CreatePaymentMethodRequest{Token: "tok_third", IsDefault: true})
+		require.NoError(t, err)
+		assert.Equal(t, "tok_third", defaultToken(1))
+
+		methods, err := repo.ListPaymentMethods(1)
+		require.NoError(t, err)
+		require.Len(t, methods.Items, 3)
+		assert.Equal(t, third, methods.Items[0].ID, "the default method is listed first")
+		assert.Equal(t, "4242", methods.Items[1].Last4)
+	})
+	t.Run("Failure - the payment methods of other users can't be used", func(t *testing.T) {
+		methods, err := repo.ListPaymentMethods(1)
+		require.NoError(t, err)
+		assert.ErrorIs(t, repo.SetDefaultPaymentMethod(2, methods.Items[1].ID), ErrPaymentMethodNotFound)
+		assert.ErrorIs(t, repo.DeletePaymentMethod(2, methods.Items[1].ID), ErrPaymentMethodNotFound)
+		assert.Equal(t, "tok_third", defaultToken(1))
+	})
+	t.Run("Failure - a payment method holding an authorization can't be removed", func(t *testing.T) {
+		// GIVEN: a rental authorized on the default payment method
+		method, err := repo.GetDefaultPaymentMethod(1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO bikes (id, is_available, latitude, longitude) VALUES (1, 0, 40.4, -3.7)")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO rentals (id, user_id, bike_id, start_time, cost) VALUES (1, 1, 1, CURRENT_TIMESTAMP, 0)")
+		require.NoError(t, err)
+		payment := &models.Payment{RentalID: 1, UserID: 1, PaymentMethodID: method.ID, ProviderReference: "fake_auth_1",
+			AmountAuthorized: 10, Status: models.PaymentStatusAuthorized}
+		payment.ID, err = repo.CreatePayment(db, payment)
+		require.NoError(t, err)
+
+		// WHEN: the rider removes it
+		// THEN: it is kept until the payment is captured
+		assert.ErrorIs(t, repo.DeletePaymentMethod(1, method.ID), ErrPaymentMethodInUse)
+		payment.Status, payment.AmountCaptured = models.PaymentStatusCaptured, 2.5
+		require.NoError(t, repo.UpdatePayment(db, payment))
+		assert.NoError(t, repo.DeletePaymentMethod(1, method.ID))
+	})
+}
+
+func TestPayments(t *testing.T) {
+	// GIVEN: a rental authorized twice, the first authorization failed
+	t.Setenv("DB_URL", "file:payments_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	_, err := db.Exec("INSERT INTO users (id, email, hashed_password) VALUES (1, 'first@example.com', 'x')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO bikes (id, is_available, latitude, longitude) VALUES (1, 0, 40.4, -3.7)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO rentals (id, user_id, bike_id, start_time, cost) VALUES (1, 1, 1, CURRENT_TIMESTAMP, 0)")
+	require.NoError(t, err)
+	repo := New(db)
+	methodID, err := repo.CreatePaymentMethod(1, models.CreatePaymentMethodRequest{Token: "tok_visa"})
+	require.NoError(t, err)
+	reason := "payment declined"
+	_, err = repo.CreatePayment(db, &models.Payment{RentalID: 1, UserID: 1, PaymentMethodID: methodID, ProviderReference: "fake_auth_1",
+		AmountAuthorized: 10, Status: models.PaymentStatusFailed, FailureReason: &reason})
+	require.NoError(t, err)
+	_, err = repo.CreatePayment(db, &models.Payment{RentalID: 1, UserID: 1, PaymentMethodID: methodID, ProviderReference: "fake_auth_2",
+		AmountAuthorized: 10, Status: models.PaymentStatusAuthorized})
+	require.NoError(t, err)
+
+	t.Run("Success - the latest payment of a rental is the current one", func(t *testing.T) {
+		payment, err := repo.GetPaymentByRentalID(db, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "fake_auth_2", payment.ProviderReference)
+		assert.Nil(t, payment.FailureReason)
+	})
+	t.Run("Success - every payment of a rental is listed, oldest first", func(t *testing.T) {
+		payments, err := repo.ListPaymentsByRentalID(1)
+		require.NoError(t, err)
+		require.Len(t, payments.Items, 2)
+		assert.Equal(t, models.PaymentStatusFailed, payments.Items[0].Status)
+		assert.Equal(t, &reason, payments.Items[0].FailureReason)
+	})
+	t.Run("Success - amounts and status are updated", func(t *testing.T) {
+		payment, err := repo.GetPaymentByRentalID(db, 1)
+		require.NoError(t, err)
+		payment.Status, payment.AmountCaptured, payment.AmountRefunded = models.PaymentStatusRefunded, 4.2, 1.2
+		require.NoError(t, repo.UpdatePayment(db, payment))
+		updated, err := repo.GetPaymentByRentalID(db, 1)
+		require.NoError(t, err)
+		assert.Equal(t, models.PaymentStatusRefunded, updated.Status)
+		assert.Equal(t, 4.2, updated.AmountCaptured)
+		assert.Equal(t, 1.2, updated.AmountRefunded)
+		assert.Equal(t, 10.0, updated.AmountAuthorized)
+	})
+}
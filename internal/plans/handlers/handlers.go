@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/plans/models"
+	"bikesRentalAPI/internal/plans/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// Handler is the interface for plan handlers
+type Handler interface {
+	ListPlans(w http.ResponseWriter, req *http.Request)          // List the plans on sale
+	ListSubscriptions(w http.ResponseWriter, req *http.Request)  // List the subscriptions of the user
+	Subscribe(w http.ResponseWriter, req *http.Request)          // Subscribe to a plan with the default payment method
+	CancelSubscription(w http.ResponseWriter, req *http.Request) // Stop the renewal of a subscription
+	ListAllPlans(w http.ResponseWriter, req *http.Request)       // List every plan
+	CreatePlan(w http.ResponseWriter, req *http.Request)         // Create a plan
+	GetPlan(w http.ResponseWriter, req *http.Request)            // Get a plan
+	UpdatePlan(w http.ResponseWriter, req *http.Request)         // Update a plan
+	RunRenewals(w http.ResponseWriter, req *http.Request)        // Renew or expire the ended subscriptions
+}
+
+type handler struct {
+	PlanRepo  repository.PlanRepository
+	validator *validator.Validate
+}
+
+// New returns a new plan handler
+func New(planRepository repository.PlanRepository) Handler {
+	validator := validator.New(validator.WithRequiredStructEnabled())
+	handler := &handler{
+		PlanRepo:  planRepository,
+		validator: validator,
+	}
+	return handler
+}
+
+// ListPlans returns the plans riders can subscribe to
+func (h *handler) ListPlans(w http.ResponseWriter, req *http.Request) {
+	h.writePlans(w, true)
+}
+
+// ListSubscriptions returns the subscriptions of the logged in user, the latest first
+func (h *handler) ListSubscriptions(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	subscriptions, err := h.PlanRepo.ListSubscriptions(userID)
+	if err != nil {
+		log.Printf("Error getting subscriptions: %v", err)
+		http.Error(w, "Error getting subscriptions", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, subscriptions)
+}
+
+// Subscribe charges the plan on the default payment method of the logged in user and starts the subscription
+func (h *handler) Subscribe(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	var subscribeReq models.SubscribeRequest
+	if !h.parseRequest(w, req, &subscribeReq) {
+		return
+	}
+	subscription, err := h.PlanRepo.Subscribe(userID, &subscribeReq)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPlanUnavailable):
+			http.Error(w, "Plan not available", http.StatusNotFound)
+		case errors.Is(err, repository.ErrAlreadySubscribed):
+			http.Error(w, "A subscription is still valid", http.StatusConflict)
+		case errors.Is(err, repository.ErrPaymentMethodRequired):
+			http.Error(w, "A payment method is required to subscribe", http.StatusPaymentRequired)
+		case errors.Is(err, repository.ErrPaymentDeclined):
+			http.Error(w, "Subscription payment declined", http.StatusPaymentRequired)
+		default:
+			log.Printf("Error subscribing to plan: %v", err)
+			http.Error(w, "Error subscribing to plan", http.StatusInternalServerError)
+		}
+		return
+	}
+	helpers.WriteJSON(w, http.StatusCreated, subscription)
+}
+
+// CancelSubscription stops the renewal of a subscription of the logged in user. It stays valid until the end of the period
+func (h *handler) CancelSubscription(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	subscriptionID, ok := parseID(w, req, "subscription_id")
+	if !ok {
+		return
+	}
+	if err := h.PlanRepo.CancelSubscription(userID, subscriptionID); err != nil {
+		if errors.Is(err, repository.ErrSubscriptionNotFound) {
+			http.Error(w, "Active subscription not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error cancelling subscription: %v", err)
+		http.Error(w, "Error cancelling subscription", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// ListAllPlans returns every plan, including the ones no longer sold
+func (h *handler) ListAllPlans(w http.ResponseWriter, req *http.Request) {
+	h.writePlans(w, false)
+}
+
+// CreatePlan creates a new plan in the database. The duration defaults to 30 days for monthly plans and 1 day for day passes
+func (h *handler) CreatePlan(w http.ResponseWriter, req *http.Request) {
+	var createPlanReq models.CreatePlanRequest
+	if !h.parseRequest(w, req, &createPlanReq) {
+		return
+	}
+	id, err := h.PlanRepo.CreatePlan(createPlanReq)
+	if err != nil {
+		log.Printf("Error creating plan: %v", err)
+		http.Error(w, "Error creating plan", http.StatusInternalServerError)
+		return
+	}
+	createPlanResp := models.CreateUpdatePlanResponse{
+		ID:      id,
+		Message: "Plan created successfully",
+	}
+	helpers.WriteJSON(w, http.StatusCreated, createPlanResp)
+}
+
+// GetPlan retrieves a plan
+func (h *handler) GetPlan(w http.ResponseWriter, req *http.Request) {
+	planID, ok := parseID(w, req, "plan_id")
+	if !ok {
+		return
+	}
+	plan, ok := h.getPlan(w, planID)
+	if !ok {
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, plan)
+}
+
+// UpdatePlan updates a plan. Deactivated plans can't be subscribed to and are not renewed
+func (h *handler) UpdatePlan(w http.ResponseWriter, req *http.Request) {
+	planID, ok := parseID(w, req, "plan_id")
+	if !ok {
+		return
+	}
+	var updatePlanReq models.UpdatePlanRequest
+	if !h.parseRequest(w, req, &updatePlanReq) {
+		return
+	}
+	plan, ok := h.getPlan(w, planID)
+	if !ok {
+		return
+	}
+	fieldsToUpdate, err := getFieldsToUpdate(&updatePlanReq, plan)
+	if err != nil {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+	audit.SetChanges(req, plan, fieldsToUpdate)
+	result, err := h.PlanRepo.UpdatePlan(planID, fieldsToUpdate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Plan not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating plan: %v", err)
+		http.Error(w, "Error updating plan", http.StatusInternalServerError)
+		return
+	}
+	updatePlanResp := models.CreateUpdatePlanResponse{
+		ID:      result,
+		Message: "Plan updated successfully",
+	}
+	helpers.WriteJSON(w, http.StatusOK, updatePlanResp)
+}
+
+// RunRenewals renews the ended subscriptions with auto renew set and expires the others
+func (h *handler) RunRenewals(w http.ResponseWriter, req *http.Request) {
+	result, err := h.PlanRepo.RenewSubscriptions(time.Now().UTC())
+	if err != nil {
+		log.Printf("Error renewing subscriptions: %v", err)
+		http.Error(w, "Error renewing subscriptions", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, result)
+}
+
+// writePlans writes the plans, only the ones on sale when activeOnly is set
+func (h *handler) writePlans(w http.ResponseWriter, activeOnly bool) {
+	plans, err := h.PlanRepo.ListPlans(activeOnly)
+	if err != nil {
+		log.Printf("Error getting plans: %v", err)
+		http.Error(w, "Error getting plans", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, plans)
+}
+
+// getPlan retrieves a plan. It writes the error response when it fails
+func (h *handler) getPlan(w http.ResponseWriter, planID int64) (*models.Plan, bool) {
+	plan, err := h.PlanRepo.GetPlanByID(planID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Plan not found", http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("Error getting plan: %v", err)
+		http.Error(w, "Error getting plan", http.StatusInternalServerError)
+		return nil, false
+	}
+	return plan, true
+}
+
+// parseRequest reads and validates the body request. It writes the error response when it fails
+func (h *handler) parseRequest(w http.ResponseWriter, req *http.Request, dest interface{}) bool {
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return false
+	}
+	if err := json.Unmarshal(body, dest); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return false
+	}
+	if err := h.validator.Struct(dest); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// parseID reads an id URL parameter. It writes the error response when it fails
+func parseID(w http.ResponseWriter, req *http.Request, param string) (int64, bool) {
+	idStr := chi.URLParam(req, param)
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", idStr, err), http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// getFieldsToUpdate compares the fields of the update request with the plan and returns the fields to update as map
+func getFieldsToUpdate(updatePlanReq *models.UpdatePlanRequest, plan *models.Plan) (map[string]interface{}, error) {
+	if updatePlanReq == nil || plan == nil {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	fieldsToUpdate := make(map[string]interface{})
+	if updatePlanReq.Name != nil && *updatePlanReq.Name != plan.Name {
+		fieldsToUpdate["name"] = *updatePlanReq.Name
+	}
+	if updatePlanReq.Price != nil && *updatePlanReq.Price != plan.Price {
+		fieldsToUpdate["price"] = *updatePlanReq.Price
+	}
+	if updatePlanReq.IncludedMinutesPerRide != nil && *updatePlanReq.IncludedMinutesPerRide != plan.IncludedMinutesPerRide {
+		fieldsToUpdate["included_minutes_per_ride"] = *updatePlanReq.IncludedMinutesPerRide
+	}
+	if updatePlanReq.DiscountPercent != nil && *updatePlanReq.DiscountPercent != plan.DiscountPercent {
+		fieldsToUpdate["discount_percent"] = *updatePlanReq.DiscountPercent
+	}
+	if updatePlanReq.IsActive != nil && *updatePlanReq.IsActive != plan.IsActive {
+		fieldsToUpdate["is_active"] = *updatePlanReq.IsActive
+	}
+
+	if len(fieldsToUpdate) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	return fieldsToUpdate, nil
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/plans/models"
+	"bikesRentalAPI/internal/plans/repository"
+	"bikesRentalAPI/internal/plans/repository/mocks"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// withUser returns the request authenticated as the user
+func withUser(t *testing.T, req *http.Request, userID string) *http.Request {
+	token, _, err := jwtauth.New("HS256", []byte("secret"), nil).Encode(map[string]interface{}{"sub": userID})
+	require.NoError(t, err)
+	return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+}
+
+func TestSubscribe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPlanRepo := mocks.NewMockPlanRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		body             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the rider is subscribed to the plan", body: `{"plan_id":1,"auto_renew":true}`, expectedHttpCode: http.StatusCreated},
+		{name: "Failure - the plan is required", body: `{"auto_renew":true}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the plan is not sold", body: `{"plan_id":1}`, repoErr: repository.ErrPlanUnavailable, expectedHttpCode: http.StatusNotFound},
+		{name: "Failure - the rider is already subscribed", body: `{"plan_id":1}`, repoErr: repository.ErrAlreadySubscribed, expectedHttpCode: http.StatusConflict},
+		{name: "Failure - the rider has no payment method", body: `{"plan_id":1}`, repoErr: repository.ErrPaymentMethodRequired, expectedHttpCode: http.StatusPaymentRequired},
+		{name: "Failure - the payment is declined", body: `{"plan_id":1}`, repoErr: repository.ErrPaymentDeclined, expectedHttpCode: http.StatusPaymentRequired},
+		{name: "Failure - the subscription can't be stored", body: `{"plan_id":1}`, repoErr: errors.New("database is locked"), expectedHttpCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider subscribing to a plan
+			if tc.expectedHttpCode != http.StatusBadRequest {
+				var subscription *models.Subscription
+				if tc.repoErr == nil {
+					subscription = &models.Subscription{ID: 3, UserID: 2, PlanID: 1, Status: models.SubscriptionStatusActive}
+				}
+				mockPlanRepo.EXPECT().Subscribe(int64(2), gomock.Any()).Return(subscription, tc.repoErr)
+			}
+			req := withUser(t, httptest.NewRequest(http.MethodPost, "/users/subscriptions", strings.NewReader(tc.body)), "2")
+			rec := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockPlanRepo).Subscribe(rec, req)
+			// THEN: the failures of the subscription are told apart
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestCancelSubscription(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPlanRepo := mocks.NewMockPlanRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the subscription is cancelled", expectedHttpCode: http.StatusNoContent},
+		{name: "Failure - the subscription is not an active one of the rider", repoErr: repository.ErrSubscriptionNotFound, expectedHttpCode: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider cancelling subscription 3
+			mockPlanRepo.EXPECT().CancelSubscription(int64(2), int64(3)).Return(tc.repoErr)
+			req := withUser(t, httptest.NewRequest(http.MethodDelete, "/users/subscriptions/3", nil), "2")
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Delete("/users/subscriptions/{subscription_id}", New(mockPlanRepo).CancelSubscription)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: only active subscriptions of the rider are cancelled
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestUpdatePlan(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPlanRepo := mocks.NewMockPlanRepository(mockCtrl)
+	plan := &models.Plan{ID: 1, Name: "Monthly", Kind: "monthly", Price: 9.99, IsActive: true}
+
+	testCases := []struct {
+		name             string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - only the changed fields are updated",
+			body: `{"price":12.5,"name":"Monthly","is_active":false}`,
+			mockCalls: func() {
+				mockPlanRepo.EXPECT().GetPlanByID(int64(1)).Return(plan, nil)
+				mockPlanRepo.EXPECT().UpdatePlan(int64(1), map[string]interface{}{"price": 12.5, "is_active": false}).Return(int64(1), nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name: "Failure - nothing changes",
+			body: `{"price":9.99}`,
+			mockCalls: func() {
+				mockPlanRepo.EXPECT().GetPlanByID(int64(1)).Return(plan, nil)
+			},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:             "Failure - discounts are percentages",
+			body:             `{"discount_percent":120}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure - the plan doesn't exist",
+			body: `{"price":12.5}`,
+			mockCalls: func() {
+				mockPlanRepo.EXPECT().GetPlanByID(int64(1)).Return(nil, sql.ErrNoRows)
+			},
+			expectedHttpCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an administrator updating plan 1
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodPatch, "/admin/plans/1", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Patch("/admin/plans/{plan_id}", New(mockPlanRepo).UpdatePlan)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the plan is updated with the changed fields
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
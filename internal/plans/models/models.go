@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// SubscriptionStatus is the renewal state of a subscription
+type SubscriptionStatus string
+
+const (
+	// SubscriptionStatusActive subscriptions are valid and renewed at the end of the period when auto renew is set
+	SubscriptionStatusActive SubscriptionStatus = "active"
+	// SubscriptionStatusCancelled subscriptions stay valid until the end of the period and are not renewed
+	SubscriptionStatusCancelled SubscriptionStatus = "cancelled"
+	// SubscriptionStatusExpired subscriptions are over
+	SubscriptionStatusExpired SubscriptionStatus = "expired"
+)
+
+// Plan is a pass sold to riders, e.g. a monthly pass with the first 30 minutes of every ride free
+type Plan struct {
+	ID   int64  `json:"id,omitempty"`
+	Name string `json:"name"`
+	// The kind of pass, 'monthly' or 'day'
+	Kind  string  `json:"kind"`
+	Price float64 `json:"price"`
+	// The number of days a subscription to the plan lasts
+	DurationDays int `json:"duration_days"`
+	// The minutes of every ride not charged
+	IncludedMinutesPerRide int `json:"included_minutes_per_ride"`
+	// The discount, in percent, on the rest of every ride
+	DiscountPercent float64   `json:"discount_percent"`
+	IsActive        bool      `json:"is_active"`
+	CreatedAt       time.Time `json:"created_at,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`
+} // @name Plan
+
+// PlanList contains a list of plans
+type PlanList struct {
+	// The list of plans
+	Items []*Plan `json:"items"`
+} // @name PlanList
+
+// CreatePlanRequest contains the information to create a plan
+type CreatePlanRequest struct {
+	Name                   string  `json:"name" validate:"required,max=100"`
+	Kind                   string  `json:"kind" validate:"required,oneof=monthly day"`
+	Price                  float64 `json:"price" validate:"gte=0"`
+	DurationDays           int     `json:"duration_days" validate:"omitempty,gt=0,lte=366"`
+	IncludedMinutesPerRide int     `json:"included_minutes_per_ride" validate:"gte=0"`
+	DiscountPercent        float64 `json:"discount_percent" validate:"gte=0,lte=100"`
+} // @name CreatePlanRequest
+
+// UpdatePlanRequest contains the information to update a plan. Existing subscriptions follow the updated plan
+type UpdatePlanRequest struct {
+	Name                   *string  `json:"name" validate:"omitempty,max=100"`
+	Price                  *float64 `json:"price" validate:"omitempty,gte=0"`
+	IncludedMinutesPerRide *int     `json:"included_minutes_per_ride" validate:"omitempty,gte=0"`
+	DiscountPercent        *float64 `json:"discount_percent" validate:"omitempty,gte=0,lte=100"`
+	IsActive               *bool    `json:"is_active"`
+} // @name UpdatePlanRequest
+
+// Subscription is the subscription of a user to a plan for a period
+type Subscription struct {
+	ID                int64              `json:"id"`
+	UserID            int64              `json:"user_id"`
+	PlanID            int64              `json:"plan_id"`
+	Status            SubscriptionStatus `json:"status"`
+	AutoRenew         bool               `json:"auto_renew"`
+	StartTime         time.Time          `json:"start_time"`
+	EndTime           time.Time          `json:"end_time"`
+	ProviderReference *string            `json:"-"`
+	CreatedAt         time.Time          `json:"created_at,omitempty"`
+	UpdatedAt         time.Time          `json:"updated_at,omitempty"`
+	// The plan subscribed to
+	Plan *Plan `json:"plan,omitempty"`
+} // @name Subscription
+
+// SubscriptionList contains a list of subscriptions
+type SubscriptionList struct {
+	// The list of subscriptions
+	Items []*Subscription `json:"items"`
+} // @name SubscriptionList
+
+// SubscribeRequest contains the plan a user subscribes to
+type SubscribeRequest struct {
+	PlanID    int64 `json:"plan_id" validate:"required,numeric"`
+	AutoRenew bool  `json:"auto_renew"`
+} // @name SubscribeRequest
+
+// RenewalResult contains the outcome of a renewal run
+type RenewalResult struct {
+	Renewed int `json:"renewed"`
+	Expired int `json:"expired"`
+	Failed  int `json:"failed"`
+} // @name RenewalResult
+
+// CreateUpdatePlanResponse represents the response of creating/updating a plan
+type CreateUpdatePlanResponse struct {
+	ID      int64  `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+} // @name CreateUpdatePlanResponse
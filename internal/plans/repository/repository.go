@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/payments"
+	paymentsrepository "bikesRentalAPI/internal/payments/repository"
+	"bikesRentalAPI/internal/plans/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	// ErrPlanUnavailable is returned when subscribing to a plan that does not exist or is no longer sold
+	ErrPlanUnavailable = errors.New("plan not available")
+	// ErrAlreadySubscribed is returned when subscribing while another subscription is still valid
+	ErrAlreadySubscribed = errors.New("user already has a valid subscription")
+	// ErrSubscriptionNotFound is returned when the subscription does not exist or belongs to another user
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrPaymentMethodRequired is returned when subscribing to a paid plan without a default payment method
+	ErrPaymentMethodRequired = errors.New("payment method required")
+	// ErrPaymentDeclined is returned when the payment provider refuses to charge a plan
+	ErrPaymentDeclined = errors.New("plan payment declined")
+)
+
+// plansTable lists the columns of a plan admins can update
+var plansTable = database.NewTable("plans", "name", "price", "included_minutes_per_ride", "discount_percent", "is_active")
+
+type PlanRepository interface {
+	CreatePlan(plan models.CreatePlanRequest) (int64, error)
+	GetPlanByID(planID int64) (*models.Plan, error)
+	ListPlans(activeOnly bool) (*models.PlanList, error)
+	UpdatePlan(planID int64, fieldsToUpdate map[string]interface{}) (int64, error)
+	Subscribe(userID int64, subscribeReq *models.SubscribeRequest) (*models.Subscription, error)
+	ListSubscriptions(userID int64) (*models.SubscriptionList, error)
+	CancelSubscription(userID int64, subscriptionID int64) error
+	GetActiveSubscription(q database.Querier, userID int64, at time.Time) (*models.Subscription, error)
+	RenewSubscriptions(now time.Time) (*models.RenewalResult, error)
+}
+
+type planRepository struct {
+	db          database.Database
+	paymentRepo paymentsrepository.PaymentRepository
+	provider    payments.PaymentProvider
+}
+
+// New initializes a new plan repository. Subscriptions are charged through the payment provider
+func New(db database.Database, paymentRepo paymentsrepository.PaymentRepository, provider payments.PaymentProvider) PlanRepository {
+	return &planRepository{
+		db:          db,
+		paymentRepo: paymentRepo,
+		provider:    provider,
+	}
+}
+
+// defaultDurationDays is the duration of each kind of plan when it is not given
+var defaultDurationDays = map[string]int{
+	"monthly": 30,
+	"day":     1,
+}
+
+const planColumns = "id, name, kind, price, duration_days, included_minutes_per_ride, discount_percent, is_active, created_at, updated_at"
+
+const subscriptionColumns = "s.id, s.user_id, s.plan_id, s.status, s.auto_renew, s.start_time, s.end_time, s.provider_reference, s.created_at, s.updated_at"
+
+// CreatePlan creates a plan in the database
+func (r *planRepository) CreatePlan(plan models.CreatePlanRequest) (int64, error) {
+	durationDays := plan.DurationDays
+	if durationDays == 0 {
+		durationDays = defaultDurationDays[plan.Kind]
+	}
+	query := "INSERT INTO plans (name, kind, price, duration_days, included_minutes_per_ride, discount_percent) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := r.db.Exec(query, plan.Name, plan.Kind, plan.Price, durationDays, plan.IncludedMinutesPerRide, plan.DiscountPercent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert plan: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %v", err)
+	}
+	return id, nil
+}
+
+// GetPlanByID retrieves a plan from the database by its id
+func (r *planRepository) GetPlanByID(planID int64) (*models.Plan, error) {
+	query := fmt.Sprintf("SELECT %s FROM plans WHERE id = ?", planColumns)
+	return scanPlan(r.db.QueryRow(query, planID))
+}
+
+// ListPlans retrieves the plans, only the ones currently sold when activeOnly is set
+func (r *planRepository) ListPlans(activeOnly bool) (*models.PlanList, error) {
+	query := fmt.Sprintf("SELECT %s FROM plans", planColumns)
+	if activeOnly {
+		query += " WHERE is_active = 1"
+	}
+	query += " ORDER BY id"
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := &models.PlanList{Items: make([]*models.Plan, 0)}
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans.Items = append(plans.Items, plan)
+	}
+	return plans, rows.Err()
+}
+
+// UpdatePlan updates a plan in the database by id
+func (r *planRepository) UpdatePlan(planID int64, fieldsToUpdate map[string]interface{}) (int64, error) {
+	if _, err := plansTable.Update(r.db, planID, fieldsToUpdate); err != nil {
+		return 0, err
+	}
+	return planID, nil
+}
+
+// Subscribe charges the plan on the default payment method of the user and starts a subscription for the duration of the plan
+func (r *planRepository) Subscribe(userID int64, subscribeReq *models.SubscribeRequest) (*models.Subscription, error) {
+	if subscribeReq == nil {
+		return nil, fmt.Errorf("subscribeReq request is nil")
+	}
+	plan, err := r.GetPlanByID(subscribeReq.PlanID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPlanUnavailable
+		}
+		return nil, fmt.Errorf("failed to get plan: %v", err)
+	}
+	if !plan.IsActive {
+		return nil, ErrPlanUnavailable
+	}
+	now := time.Now().UTC()
+	if _, err := r.GetActiveSubscription(r.db, userID, now); err == nil {
+		return nil, ErrAlreadySubscribed
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	reference, err := r.charge(ctx, userID, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &models.Subscription{
+		UserID:            userID,
+		PlanID:            plan.ID,
+		Status:            models.SubscriptionStatusActive,
+		AutoRenew:         subscribeReq.AutoRenew,
+		StartTime:         now,
+		EndTime:           now.AddDate(0, 0, plan.DurationDays),
+		ProviderReference: reference,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Plan:              plan,
+	}
+	query := "INSERT INTO subscriptions (user_id, plan_id, status, auto_renew, start_time, end_time, provider_reference) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := r.db.Exec(query, userID, plan.ID, subscription.Status, subscription.AutoRenew, subscription.StartTime, subscription.EndTime, reference)
+	if err != nil {
+		r.refund(ctx, reference, plan.Price)
+		return nil, fmt.Errorf("failed to insert subscription: %v", err)
+	}
+	subscription.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %v", err)
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions retrieves the subscriptions of a user, the latest first
+func (r *planRepository) ListSubscriptions(userID int64) (*models.SubscriptionList, error) {
+	query := fmt.Sprintf("SELECT %s FROM subscriptions s WHERE s.user_id = ? ORDER BY s.start_time DESC, s.id DESC", subscriptionColumns)
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := &models.SubscriptionList{Items: make([]*models.Subscription, 0)}
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions.Items = append(subscriptions.Items, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+// CancelSubscription stops the renewal of a subscription. It stays valid until the end of the period
+func (r *planRepository) CancelSubscription(userID int64, subscriptionID int64) error {
+	query := `UPDATE subscriptions SET status = ?, auto_renew = 0
+		WHERE id = ? AND user_id = ? AND status = ?`
+	result, err := r.db.Exec(query, models.SubscriptionStatusCancelled, subscriptionID, userID, models.SubscriptionStatusActive)
+	if err != nil {
+		return fmt.Errorf("failed to cancel subscription: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// GetActiveSubscription retrieves the subscription of a user valid at the given time with its plan.
+// It returns sql.ErrNoRows when there is none. q is either the database or an ongoing transaction
+func (r *planRepository) GetActiveSubscription(q database.Querier, userID int64, at time.Time) (*models.Subscription, error) {
+	query := fmt.Sprintf(`SELECT %s, p.id, p.name, p.kind, p.price, p.duration_days, p.included_minutes_per_ride, p.discount_percent, p.is_active, p.created_at, p.updated_at
+		FROM subscriptions s JOIN plans p ON p.id = s.plan_id
+		WHERE s.user_id = ? AND s.status IN (?, ?) AND s.start_time <= ? AND s.end_time > ?
+		ORDER BY s.start_time DESC LIMIT 1`, subscriptionColumns)
+	at = at.UTC()
+	row := q.QueryRow(query, userID, models.SubscriptionStatusActive, models.SubscriptionStatusCancelled, at, at)
+
+	var subscription models.Subscription
+	var plan models.Plan
+	err := row.Scan(
+		&subscription.ID, &subscription.UserID, &subscription.PlanID, &subscription.Status, &subscription.AutoRenew,
+		&subscription.StartTime, &subscription.EndTime, &subscription.ProviderReference, &subscription.CreatedAt, &subscription.UpdatedAt,
+		&plan.ID, &plan.Name, &plan.Kind, &plan.Price, &plan.DurationDays, &plan.IncludedMinutesPerRide, &plan.DiscountPercent,
+		&plan.IsActive, &plan.CreatedAt, &plan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Plan = &plan
+	return &subscription, nil
+}
+
+// RenewSubscriptions renews the subscriptions ended at the given time with auto renew set and expires the others.
+// A subscription whose renewal can't be charged expires
+func (r *planRepository) RenewSubscriptions(now time.Time) (*models.RenewalResult, error) {
+	now = now.UTC()
+	query := fmt.Sprintf(`SELECT %s FROM subscriptions s WHERE s.status IN (?, ?) AND s.end_time <= ? ORDER BY s.id`, subscriptionColumns)
+	rows, err := r.db.Query(query, models.SubscriptionStatusActive, models.SubscriptionStatusCancelled, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ended subscriptions: %v", err)
+	}
+	ended := make([]*models.Subscription, 0)
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ended = append(ended, subscription)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.RenewalResult{}
+	ctx := context.Background()
+	for _, subscription := range ended {
+		if subscription.Status == models.SubscriptionStatusActive && subscription.AutoRenew {
+			renewed, err := r.renew(ctx, subscription, now)
+			if err == nil && renewed {
+				result.Renewed++
+				continue
+			}
+			if err != nil {
+				log.Printf("Error renewing subscription %d: %v", subscription.ID, err)
+			}
+			result.Failed++
+		}
+		if err := r.expire(subscription.ID); err != nil {
+			return nil, err
+		}
+		result.Expired++
+	}
+	return result, nil
+}
+
+// renew charges a new period of a subscription and extends it. It returns false when the plan is no longer sold
+func (r *planRepository) renew(ctx context.Context, subscription *models.Subscription, now time.Time) (bool, error) {
+	plan, err := r.GetPlanByID(subscription.PlanID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get plan: %v", err)
+	}
+	if !plan.IsActive {
+		return false, nil
+	}
+	reference, err := r.charge(ctx, subscription.UserID, plan)
+	if err != nil {
+		return false, err
+	}
+	// The new period starts when the previous one ended, or now if the renewal is late
+	start := subscription.EndTime.UTC()
+	end := start.AddDate(0, 0, plan.DurationDays)
+	if !end.After(now) {
+		start = now
+		end = now.AddDate(0, 0, plan.DurationDays)
+	}
+	query := "UPDATE subscriptions SET start_time = ?, end_time = ?, provider_reference = ? WHERE id = ?"
+	if _, err := r.db.Exec(query, start, end, reference, subscription.ID); err != nil {
+		r.refund(ctx, reference, plan.Price)
+		return false, fmt.Errorf("failed to renew subscription: %v", err)
+	}
+	return true, nil
+}
+
+// expire ends a subscription
+func (r *planRepository) expire(subscriptionID int64) error {
+	query := "UPDATE subscriptions SET status = ?, auto_renew = 0 WHERE id = ?"
+	if _, err := r.db.Exec(query, models.SubscriptionStatusExpired, subscriptionID); err != nil {
+		return fmt.Errorf("failed to expire subscription: %v", err)
+	}
+	return nil
+}
+
+// charge charges the price of a plan on the default payment method of the user. Free plans are not charged
+func (r *planRepository) charge(ctx context.Context, userID int64, plan *models.Plan) (*string, error) {
+	if plan.Price <= 0 {
+		return nil, nil
+	}
+	method, err := r.paymentRepo.GetDefaultPaymentMethod(userID)
+	if err != nil {
+		if errors.Is(err, paymentsrepository.ErrPaymentMethodNotFound) {
+			return nil, ErrPaymentMethodRequired
+		}
+		return nil, fmt.Errorf("failed to get payment method: %v", err)
+	}
+	reference, err := r.provider.Authorize(ctx, method.Token, plan.Price)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+	}
+	if err := r.provider.Capture(ctx, reference, plan.Price); err != nil {
+		if voidErr := r.provider.Void(ctx, reference); voidErr != nil {
+			log.Printf("Error voiding plan authorization %s: %v", reference, voidErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+	}
+	return &reference, nil
+}
+
+// refund gives back the price of a plan that could not be recorded
+func (r *planRepository) refund(ctx context.Context, reference *string, amount float64) {
+	if reference == nil {
+		return
+	}
+	if err := r.provider.Refund(ctx, *reference, amount); err != nil {
+		log.Printf("Error refunding plan payment %s: %v", *reference, err)
+	}
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPlan(row scanner) (*models.Plan, error) {
+	var plan models.Plan
+	err := row.Scan(&plan.ID, &plan.Name, &plan.Kind, &plan.Price, &plan.DurationDays, &plan.IncludedMinutesPerRide,
+		&plan.DiscountPercent, &plan.IsActive, &plan.CreatedAt, &plan.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func scanSubscription(row scanner) (*models.Subscription, error) {
+	var subscription models.Subscription
+	err := row.Scan(&subscription.ID, &subscription.UserID, &subscription.PlanID, &subscription.Status, &subscription.AutoRenew,
+		&subscription.StartTime, &subscription.EndTime, &subscription.ProviderReference, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/payments"
+	paymentsmodels "bikesRentalAPI/internal/payments/models"
+	paymentsrepository "bikesRentalAPI/internal/payments/repository"
+	"bikesRentalAPI/internal/plans/models"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptions(t *testing.T) {
+	// GIVEN: a rider with a payment method, a rider without, and a monthly pass
+	t.Setenv("DB_URL", "file:subscriptions_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	_, err := db.Exec("INSERT INTO users (id, email, hashed_password) VALUES (1, 'first@example.com', 'x'), (2, 'second@example.com', 'x')")
+	require.NoError(t, err)
+	paymentRepo := paymentsrepository.New(db)
+	provider := payments.NewFakeProvider()
+	_, err = paymentRepo.CreatePaymentMethod(1, paymentsmodels.CreatePaymentMethodRequest{Token: "tok_visa"})
+	require.NoError(t, err)
+	repo := New(db, paymentRepo, provider)
+	monthly, err := repo.CreatePlan(models.CreatePlanRequest{Name: "Monthly", Kind: "monthly", Price: 9.99, IncludedMinutesPerRide: 30})
+	require.NoError(t, err)
+
+	t.Run("Success - the plan is charged and valid for its duration", func(t *testing.T) {
+		subscription, err := repo.Subscribe(1, &models.SubscribeRequest{PlanID: monthly, AutoRenew: true})
+		require.NoError(t, err)
+		assert.Equal(t, models.SubscriptionStatusActive, subscription.Status)
+		assert.Equal(t, subscription.StartTime.AddDate(0, 0, 30), subscription.EndTime, "monthly plans last 30 days by default")
+		require.NotNil(t, subscription.ProviderReference)
+		captured, _ := provider.Captured(*subscription.ProviderReference)
+		assert.Equal(t, 9.99, captured)
+
+		active, err := repo.GetActiveSubscription(db, 1, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, subscription.ID, active.ID)
+		assert.Equal(t, 30, active.Plan.IncludedMinutesPerRide)
+		_, err = repo.GetActiveSubscription(db, 1, time.Now().AddDate(0, 0, 31))
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("Failure - a rider can't hold two subscriptions", func(t *testing.T) {
+		_, err := repo.Subscribe(1, &models.SubscribeRequest{PlanID: monthly})
+		assert.ErrorIs(t, err, ErrAlreadySubscribed)
+	})
+	t.Run("Failure - paid plans require a payment method", func(t *testing.T) {
+		_, err := repo.Subscribe(2, &models.SubscribeRequest{PlanID: monthly})
+		assert.ErrorIs(t, err, ErrPaymentMethodRequired)
+	})
+	t.Run("Failure - plans no longer sold can't be subscribed to", func(t *testing.T) {
+		_, err := repo.UpdatePlan(monthly, map[string]interface{}{"is_active": false})
+		require.NoError(t, err)
+		defer repo.UpdatePlan(monthly, map[string]interface{}{"is_active": true})
+		_, err = repo.Subscribe(2, &models.SubscribeRequest{PlanID: monthly})
+		assert.ErrorIs(t, err, ErrPlanUnavailable)
+		_, err = repo.Subscribe(2, &models.SubscribeRequest{PlanID: monthly + 1})
+		assert.ErrorIs(t, err, ErrPlanUnavailable)
+		plans, err := repo.ListPlans(true)
+		require.NoError(t, err)
+		assert.Empty(t, plans.Items)
+	})
+	t.Run("Success - a cancelled subscription stays valid until its end", func(t *testing.T) {
+		subscriptions, err := repo.ListSubscriptions(1)
+		require.NoError(t, err)
+		require.Len(t, subscriptions.Items, 1)
+		assert.ErrorIs(t, repo.CancelSubscription(2, subscriptions.Items[0].ID), ErrSubscriptionNotFound)
+		require.NoError(t, repo.CancelSubscription(1, subscriptions.Items[0].ID))
+		assert.ErrorIs(t, repo.CancelSubscription(1, subscriptions.Items[0].ID), ErrSubscriptionNotFound)
+		active, err := repo.GetActiveSubscription(db, 1, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, models.SubscriptionStatusCancelled, active.Status)
+	})
+	t.Run("Success - ended subscriptions are renewed unless cancelled or not chargeable", func(t *testing.T) {
+		// GIVEN: the cancelled subscription, a renewing free day pass and a renewing one of a rider without payment method, all ended
+		day, err := repo.CreatePlan(models.CreatePlanRequest{Name: "Day", Kind: "day"})
+		require.NoError(t, err)
+		_, err = repo.Subscribe(2, &models.SubscribeRequest{PlanID: day, AutoRenew: true})
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO users (id, email, hashed_password) VALUES (3, 'third@example.com', 'x')")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO subscriptions (user_id, plan_id, status, auto_renew, start_time, end_time) VALUES (3, ?, 'active', 1, ?, ?)",
+			monthly, time.Now().AddDate(0, -1, 0).UTC(), time.Now().UTC())
+		require.NoError(t, err)
+		now := time.Now().AddDate(0, 2, 0).UTC().Truncate(time.Millisecond)
+
+		// WHEN: the renewals run
+		result, err := repo.RenewSubscriptions(now)
+		require.NoError(t, err)
+
+		// THEN: only the free day pass is renewed
+		assert.Equal(t, &models.RenewalResult{Renewed: 1, Expired: 2, Failed: 1}, result)
+		active, err := repo.GetActiveSubscription(db, 2, now)
+		require.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, 1), active.EndTime, "late renewals start now")
+		_, err = repo.GetActiveSubscription(db, 1, now)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
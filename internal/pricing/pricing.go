@@ -0,0 +1,144 @@
+package pricing
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Line codes of the cost breakdown of a rental
+const (
+	// LineUnlockFee is the flat fee charged to unlock a bike
+	LineUnlockFee = "unlock_fee"
+	// LineRideTime is the time the bike was rented
+	LineRideTime = "ride_time"
+	// LinePausedTime is the time the rental was paused with the bike locked
+	LinePausedTime = "paused_time"
+	// LinePlanIncludedMinutes are the minutes of the ride included in the plan of the user
+	LinePlanIncludedMinutes = "plan_included_minutes"
+	// LinePlanDiscount is the discount of the plan of the user on the rest of the ride
+	LinePlanDiscount = "plan_discount"
+	// LinePromotion is the benefit of a promo code redeemed by the user
+	LinePromotion = "promotion"
+)
+
+// Line is an item of the cost breakdown of a rental
+type Line struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+} // @name PricingLine
+
+// Breakdown is the itemized cost of a rental. Amounts are rounded to cents line by line so the total always matches the lines
+type Breakdown struct {
+	Lines []Line  `json:"lines"`
+	Total float64 `json:"total"`
+} // @name PricingBreakdown
+
+// Add appends a line to the breakdown
+func (b *Breakdown) Add(line Line) {
+	line.Amount = helpers.FromMinorUnits(helpers.ToMinorUnits(line.Amount))
+	b.Lines = append(b.Lines, line)
+	b.Total = helpers.FromMinorUnits(helpers.ToMinorUnits(b.Total) + helpers.ToMinorUnits(line.Amount))
+}
+
+// Amount returns the sum of the lines with the given code
+func (b *Breakdown) Amount(code string) float64 {
+	var amount int64
+	for _, line := range b.Lines {
+		if line.Code == code {
+			amount += helpers.ToMinorUnits(line.Amount)
+		}
+	}
+	return helpers.FromMinorUnits(amount)
+}
+
+// UnlockFee returns the line charging the flat fee to unlock a bike
+func UnlockFee(fee float64) Line {
+	return Line{
+		Code:        LineUnlockFee,
+		Description: "Unlock fee",
+		Quantity:    1,
+		UnitPrice:   fee,
+		Amount:      fee,
+	}
+}
+
+// UnlockFeeFromEnv reads the fee charged to unlock a bike from RENTAL_UNLOCK_FEE. No fee is charged by default
+func UnlockFeeFromEnv() float64 {
+	value := os.Getenv("RENTAL_UNLOCK_FEE")
+	if value == "" {
+		return 0
+	}
+	fee, err := strconv.ParseFloat(value, 64)
+	if err != nil || fee < 0 {
+		log.Printf("invalid RENTAL_UNLOCK_FEE %q. No unlock fee is charged", value)
+		return 0
+	}
+	return fee
+}
+
+// RideTime returns the line charging the duration of a rental at the price per minute of the bike
+func RideTime(pricePerMinute float64, duration time.Duration) Line {
+	minutes := duration.Minutes()
+	return Line{
+		Code:        LineRideTime,
+		Description: fmt.Sprintf("Ride time (%.1f min)", minutes),
+		Quantity:    math.Round(minutes*100) / 100,
+		UnitPrice:   pricePerMinute,
+		Amount:      pricePerMinute * minutes,
+	}
+}
+
+// PausedTime returns the line charging the time a rental was paused at the paused price per minute
+func PausedTime(pricePerMinute float64, duration time.Duration) Line {
+	minutes := duration.Minutes()
+	return Line{
+		Code:        LinePausedTime,
+		Description: fmt.Sprintf("Paused time (%.1f min)", minutes),
+		Quantity:    math.Round(minutes*100) / 100,
+		UnitPrice:   pricePerMinute,
+		Amount:      pricePerMinute * minutes,
+	}
+}
+
+// PlanTerms are the terms of the plan a rider is subscribed to
+type PlanTerms struct {
+	Name            string
+	IncludedMinutes int
+	DiscountPercent float64
+}
+
+// ApplyPlan adds the lines crediting the included minutes and the discount of a plan.
+// The included minutes are capped to the duration of the ride and the discount applies to the ride time left to pay,
+// the unlock fee and the paused time are not discounted
+func (b *Breakdown) ApplyPlan(terms PlanTerms, pricePerMinute float64, duration time.Duration) {
+	if terms.IncludedMinutes > 0 {
+		minutes := math.Min(float64(terms.IncludedMinutes), duration.Minutes())
+		if minutes > 0 {
+			b.Add(Line{
+				Code:        LinePlanIncludedMinutes,
+				Description: fmt.Sprintf("%s: included minutes (%.1f min)", terms.Name, minutes),
+				Quantity:    math.Round(minutes*100) / 100,
+				UnitPrice:   -pricePerMinute,
+				Amount:      -pricePerMinute * minutes,
+			})
+		}
+	}
+	rideTimeLeft := b.Amount(LineRideTime) + b.Amount(LinePlanIncludedMinutes)
+	if terms.DiscountPercent > 0 && rideTimeLeft > 0 {
+		b.Add(Line{
+			Code:        LinePlanDiscount,
+			Description: fmt.Sprintf("%s: %.0f%% discount", terms.Name, terms.DiscountPercent),
+			Quantity:    1,
+			UnitPrice:   -rideTimeLeft * terms.DiscountPercent / 100,
+			Amount:      -rideTimeLeft * terms.DiscountPercent / 100,
+		})
+	}
+}
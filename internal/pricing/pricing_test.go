@@ -0,0 +1,107 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakdown(t *testing.T) {
+	t.Run("Success - ride time is charged per minute", func(t *testing.T) {
+		// GIVEN: a 90 seconds ride at 0.2 per minute
+		var breakdown Breakdown
+		// WHEN: the ride time line is added
+		breakdown.Add(RideTime(0.2, 90*time.Second))
+		// THEN: the total is the cost of the ride
+		assert.Len(t, breakdown.Lines, 1)
+		assert.Equal(t, LineRideTime, breakdown.Lines[0].Code)
+		assert.Equal(t, 0.3, breakdown.Total)
+	})
+	t.Run("Success - lines are rounded to cents and the total matches them", func(t *testing.T) {
+		// GIVEN: lines with fractions of cents
+		var breakdown Breakdown
+		// WHEN: they are added
+		breakdown.Add(Line{Code: "a", Amount: 0.104})
+		breakdown.Add(Line{Code: "b", Amount: 0.104})
+		// THEN: every line and the total are rounded to cents
+		assert.Equal(t, 0.1, breakdown.Lines[0].Amount)
+		assert.Equal(t, 0.2, breakdown.Total)
+	})
+}
+
+func TestApplyPlan(t *testing.T) {
+	t.Run("Success - included minutes are capped to the ride", func(t *testing.T) {
+		// GIVEN: a 10 minutes ride at 0.2 per minute
+		var breakdown Breakdown
+		breakdown.Add(RideTime(0.2, 10*time.Minute))
+		// WHEN: a plan with 30 included minutes is applied
+		breakdown.ApplyPlan(PlanTerms{Name: "Monthly", IncludedMinutes: 30}, 0.2, 10*time.Minute)
+		// THEN: the ride is free
+		assert.Len(t, breakdown.Lines, 2)
+		assert.Equal(t, LinePlanIncludedMinutes, breakdown.Lines[1].Code)
+		assert.Equal(t, -2.0, breakdown.Lines[1].Amount)
+		assert.Equal(t, 0.0, breakdown.Total)
+	})
+	t.Run("Success - the discount applies to the minutes not included", func(t *testing.T) {
+		// GIVEN: a 40 minutes ride at 0.2 per minute
+		var breakdown Breakdown
+		breakdown.Add(RideTime(0.2, 40*time.Minute))
+		// WHEN: a plan with 30 included minutes and a 50% discount is applied
+		breakdown.ApplyPlan(PlanTerms{Name: "Monthly", IncludedMinutes: 30, DiscountPercent: 50}, 0.2, 40*time.Minute)
+		// THEN: half of the last 10 minutes is charged
+		assert.Len(t, breakdown.Lines, 3)
+		assert.Equal(t, LinePlanDiscount, breakdown.Lines[2].Code)
+		assert.Equal(t, -1.0, breakdown.Lines[2].Amount)
+		assert.Equal(t, 1.0, breakdown.Total)
+	})
+	t.Run("Success - the discount leaves out the unlock fee and the paused time", func(t *testing.T) {
+		// GIVEN: a ride with an unlock fee of 1, 40 minutes at 0.2 per minute and 10 paused minutes at 0.1 per minute
+		var breakdown Breakdown
+		breakdown.Add(UnlockFee(1))
+		breakdown.Add(RideTime(0.2, 40*time.Minute))
+		breakdown.Add(PausedTime(0.1, 10*time.Minute))
+		// WHEN: a plan with 30 included minutes and a 50% discount is applied
+		breakdown.ApplyPlan(PlanTerms{Name: "Monthly", IncludedMinutes: 30, DiscountPercent: 50}, 0.2, 40*time.Minute)
+		// THEN: only half of the last 10 ridden minutes is discounted, the fee and the paused time are charged in full
+		assert.Equal(t, -1.0, breakdown.Amount(LinePlanDiscount))
+		assert.Equal(t, 3.0, breakdown.Total)
+	})
+	t.Run("Success - a plan without benefits adds no line", func(t *testing.T) {
+		// GIVEN: a ride
+		var breakdown Breakdown
+		breakdown.Add(RideTime(0.2, 10*time.Minute))
+		// WHEN: an empty plan is applied
+		breakdown.ApplyPlan(PlanTerms{Name: "Day"}, 0.2, 10*time.Minute)
+		// THEN: the breakdown is unchanged
+		assert.Len(t, breakdown.Lines, 1)
+		assert.Equal(t, 2.0, breakdown.Total)
+	})
+}
+
+func TestUnlockFee(t *testing.T) {
+	t.Run("Success - the unlock fee is a separate line", func(t *testing.T) {
+		// GIVEN: a ride with an unlock fee
+		var breakdown Breakdown
+		// WHEN: the lines are added
+		breakdown.Add(UnlockFee(1))
+		breakdown.Add(RideTime(0.2, 10*time.Minute))
+		// THEN: the fee can be read back and is part of the total
+		assert.Equal(t, 1.0, breakdown.Amount(LineUnlockFee))
+		assert.Equal(t, 0.0, breakdown.Amount(LinePromotion))
+		assert.Equal(t, 3.0, breakdown.Total)
+	})
+}
+
+func TestPausedTime(t *testing.T) {
+	t.Run("Success - paused time is charged apart from the ride time", func(t *testing.T) {
+		// GIVEN: a 30 minutes ride paused for 10 minutes at half the price
+		var breakdown Breakdown
+		// WHEN: the lines are added
+		breakdown.Add(RideTime(0.2, 20*time.Minute))
+		breakdown.Add(PausedTime(0.1, 10*time.Minute))
+		// THEN: each time is charged at its price
+		assert.Equal(t, 1.0, breakdown.Amount(LinePausedTime))
+		assert.Equal(t, 5.0, breakdown.Total)
+	})
+}
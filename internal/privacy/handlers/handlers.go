@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/privacy/models"
+	"bikesRentalAPI/internal/privacy/repository"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	formatJSON = "json"
+	formatZIP  = "zip"
+
+	contentTypeZIP = "application/zip"
+)
+
+// Handler is the interface for privacy handlers
+type Handler interface {
+	ExportMyData(w http.ResponseWriter, req *http.Request)   // Export the data kept about the logged in user
+	EraseMyData(w http.ResponseWriter, req *http.Request)    // Anonymize the logged in user
+	ExportUserData(w http.ResponseWriter, req *http.Request) // Export the data kept about any user
+	EraseUserData(w http.ResponseWriter, req *http.Request)  // Anonymize any user
+}
+
+type handler struct {
+	PrivacyRepo repository.PrivacyRepository
+}
+
+// New returns a new privacy handler
+func New(privacyRepository repository.PrivacyRepository) Handler {
+	return &handler{
+		PrivacyRepo: privacyRepository,
+	}
+}
+
+// ExportMyData returns the profile, rentals, payments and the other records kept about the logged in user.
+// The format is read from the 'format' parameter, the URL extension (/export.zip) or the Accept header, JSON is the default
+func (h *handler) ExportMyData(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	h.writeExport(w, req, userID)
+}
+
+// EraseMyData anonymizes the logged in user. Their personal data is erased and they can no longer log in,
+// the rentals and payments are kept without it
+func (h *handler) EraseMyData(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	// The audit entry of the erasure can't keep the IP address of the user it erases
+	audit.ClearIP(req)
+	h.anonymize(w, userID)
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// ExportUserData returns the data kept about any user, in the same formats as ExportMyData
+func (h *handler) ExportUserData(w http.ResponseWriter, req *http.Request) {
+	userID, ok := getUserIDFromURL(w, req)
+	if !ok {
+		return
+	}
+	h.writeExport(w, req, userID)
+}
+
+// EraseUserData anonymizes any user, e.g. on a request received outside of the app
+func (h *handler) EraseUserData(w http.ResponseWriter, req *http.Request) {
+	userID, ok := getUserIDFromURL(w, req)
+	if !ok {
+		return
+	}
+	h.anonymize(w, userID)
+}
+
+// anonymize anonymizes a user and writes the response
+func (h *handler) anonymize(w http.ResponseWriter, userID int64) {
+	if err := h.PrivacyRepo.AnonymizeUser(userID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrUserRenting):
+			http.Error(w, "User has an ongoing rental", http.StatusConflict)
+		default:
+			log.Printf("Error anonymizing user: %v", err)
+			http.Error(w, "Error anonymizing user", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeExport writes the data of a user in the requested format
+func (h *handler) writeExport(w http.ResponseWriter, req *http.Request, userID int64) {
+	format, err := exportFormat(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	export, err := h.PrivacyRepo.ExportUserData(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error exporting user data: %v", err)
+		http.Error(w, "Error exporting user data", http.StatusInternalServerError)
+		return
+	}
+	filename := fmt.Sprintf("user-%d-export", userID)
+	if format == formatJSON {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		helpers.WriteJSON(w, http.StatusOK, export)
+		return
+	}
+
+	// Written in memory so an error can still be reported
+	var body bytes.Buffer
+	if err := writeArchive(&body, export); err != nil {
+		log.Printf("Error writing export archive: %v", err)
+		http.Error(w, "Error exporting user data", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeZIP)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// writeArchive writes an export as a ZIP archive with the profile in profile.json and a JSON file per section
+func writeArchive(w *bytes.Buffer, export *models.Export) error {
+	archive := zip.NewWriter(w)
+	files := map[string]interface{}{
+		"profile.json": models.Export{UserID: export.UserID, GeneratedAt: export.GeneratedAt, Profile: export.Profile},
+	}
+	for section, records := range export.Sections {
+		files[section+".json"] = records
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(files[name]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// exportFormat returns the requested export format
+func exportFormat(req *http.Request) (string, error) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format, _ = req.Context().Value(middleware.URLFormatCtxKey).(string)
+	}
+	if format == "" {
+		format = formatJSON
+		if strings.Contains(req.Header.Get("Accept"), contentTypeZIP) {
+			format = formatZIP
+		}
+	}
+	switch format {
+	case formatJSON, formatZIP:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q, use %s or %s", format, formatJSON, formatZIP)
+}
+
+// getUserIDFromURL reads the 'user_id' URL parameter. It writes the error response when it fails
+func getUserIDFromURL(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	userIDStr := chi.URLParam(req, "user_id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", userIDStr, err), http.StatusBadRequest)
+		return 0, false
+	}
+	return userID, true
+}
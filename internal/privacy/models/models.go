@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Record is an exported row by column name
+type Record map[string]interface{}
+
+// Export holds the data kept about a user: the profile and the rows of the other records by section, e.g. "rentals"
+type Export struct {
+	// The id of the user
+	UserID int64 `json:"user_id"`
+	// When the export was generated
+	GeneratedAt time.Time `json:"generated_at"`
+	// The profile of the user
+	Profile Record `json:"profile"`
+	// The records of the user by section
+	Sections map[string][]Record `json:"sections,omitempty"`
+} // @name PersonalDataExport
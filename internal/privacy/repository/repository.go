@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/privacy/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUserRenting is returned when erasing the data of a user with an ongoing rental
+var ErrUserRenting = errors.New("user has an ongoing rental")
+
+// section is a part of the export and the query selecting the rows of a user, whose id is its only argument
+type section struct {
+	name  string
+	query string
+}
+
+// profileQuery selects the profile of a user. Password hashes are never exported
+const profileQuery = `SELECT id, email, first_name, last_name, referral_code, referred_by, created_at, updated_at, deleted_at, anonymized_at
+	FROM users WHERE id = ?`
+
+// exportSections are the records kept about a user. Payment tokens and provider references are not the user's data and are left out
+var exportSections = []section{
+	{"rentals", `SELECT id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude,
+		start_station_id, end_station_id, duration_minutes, distance_km, cost, payment_source, plan_id, subscription_id,
+		promo_redemption_id, price_per_minute, surge_multiplier, paused_price_per_minute, created_at, updated_at
+		FROM rentals WHERE user_id = ? ORDER BY id`},
+	{"rental_pauses", `SELECT p.id, p.rental_id, p.started_at, p.ended_at, p.auto_resumed
+		FROM rental_pauses p JOIN rentals r ON r.id = p.rental_id WHERE r.user_id = ? ORDER BY p.id`},
+	{"rental_track_points", `SELECT t.id, t.rental_id, t.latitude, t.longitude, t.source, t.recorded_at
+		FROM rental_track_points t JOIN rentals r ON r.id = t.rental_id WHERE r.user_id = ? ORDER BY t.id`},
+	{"rental_adjustments", `SELECT a.id, a.rental_id, a.amount, a.reason, a.created_by, a.card_refund, a.wallet_refund, a.created_at
+		FROM rental_adjustments a JOIN rentals r ON r.id = a.rental_id WHERE r.user_id = ? ORDER BY a.id`},
+	{"payment_methods", `SELECT id, brand, last4, is_default, created_at, updated_at FROM payment_methods WHERE user_id = ? ORDER BY id`},
+	{"payments", `SELECT id, rental_id, payment_method_id, amount_authorized, amount_captured, amount_refunded, status, failure_reason, created_at, updated_at
+		FROM payments WHERE user_id = ? ORDER BY id`},
+	{"wallet_transactions", `SELECT t.id, t.kind, t.rental_id, t.reason, t.created_by, e.amount, t.created_at
+		FROM ledger_transactions t JOIN ledger_entries e ON e.transaction_id = t.id JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE a.kind = 'wallet' AND a.user_id = ? ORDER BY t.id`},
+	{"invoices", `SELECT id, number, rental_id, currency, tax_rate, subtotal, tax, total, issued_at FROM invoices WHERE user_id = ? ORDER BY id`},
+	{"subscriptions", `SELECT id, plan_id, status, auto_renew, start_time, end_time, created_at, updated_at FROM subscriptions WHERE user_id = ? ORDER BY id`},
+	{"promo_redemptions", `SELECT r.id, c.code, c.kind, r.remaining_rides, r.created_at
+		FROM promo_redemptions r JOIN promo_codes c ON c.id = r.promo_code_id WHERE r.user_id = ? ORDER BY r.id`},
+	{"referral_rewards", `SELECT id, referrer_id, referee_id, rental_id, amount, created_at FROM referral_rewards WHERE ? IN (referrer_id, referee_id) ORDER BY id`},
+	// The requests made by the user, like logins, and the changes made to their profile by the staff
+	{"audit_log", `SELECT id, actor, action, resource_type, resource_id, before, after, status_code, ip, created_at
+		FROM audit_log WHERE actor = 'user:' || ?1 OR (resource_type = 'users' AND resource_id = CAST(?1 AS TEXT)) ORDER BY id`},
+}
+
+// anonymizeQueries erase the personal data of a user, whose id is their only argument.
+// Rentals, payments, invoices and the wallet ledger are kept for accounting, without the places the user rode to
+var anonymizeQueries = []string{
+	`UPDATE payment_methods SET token = 'erased-' || id, brand = NULL, last4 = NULL, is_default = 0 WHERE user_id = ?`,
+	`DELETE FROM rental_track_points WHERE rental_id IN (SELECT id FROM rentals WHERE user_id = ?)`,
+	`UPDATE rentals SET start_latitude = 0, start_longitude = 0, end_latitude = 0, end_longitude = 0 WHERE user_id = ?`,
+	// Stored responses of idempotent requests may hold the profile
+	`DELETE FROM idempotency_keys WHERE scope = 'user:' || ?`,
+	// The audit log is append-only but the profile changes and the IP addresses of the user can be erased
+	`UPDATE audit_log SET before = NULL, after = NULL WHERE resource_type = 'users' AND resource_id = CAST(? AS TEXT)`,
+	`UPDATE audit_log SET ip = NULL WHERE actor = 'user:' || ?`,
+}
+
+type PrivacyRepository interface {
+	ExportUserData(userID int64) (*models.Export, error)
+	AnonymizeUser(userID int64) error
+}
+
+type privacyRepository struct {
+	db database.Database
+}
+
+// New initializes a new privacy repository
+func New(db database.Database) PrivacyRepository {
+	return &privacyRepository{db}
+}
+
+// ExportUserData returns the data kept about a user, read in a single transaction so the sections are consistent
+func (r *privacyRepository) ExportUserData(userID int64) (*models.Export, error) {
+	export := &models.Export{UserID: userID, GeneratedAt: time.Now().UTC(), Sections: make(map[string][]models.Record)}
+	err := r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		profile, err := queryRecords(tx, profileQuery, userID)
+		if err != nil {
+			return fmt.Errorf("failed to export profile: %v", err)
+		}
+		if len(profile) == 0 {
+			return sql.ErrNoRows
+		}
+		export.Profile = profile[0]
+		for _, section := range exportSections {
+			records, err := queryRecords(tx, section.query, userID)
+			if err != nil {
+				return fmt.Errorf("failed to export %s: %v", section.name, err)
+			}
+			export.Sections[section.name] = records
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// AnonymizeUser erases the personal data of a user, who can no longer log in. The user's id stays on the records
+// kept for accounting but can't be linked to a person anymore. Users with an ongoing rental can't be anonymized
+func (r *privacyRepository) AnonymizeUser(userID int64) error {
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		query := `UPDATE users SET email = 'anonymized-' || id || '@users.invalid', hashed_password = '', first_name = NULL, last_name = NULL,
+			referral_code = NULL, deleted_at = COALESCE(deleted_at, ?), anonymized_at = ?, updated_at = ?
+			WHERE id = ? AND anonymized_at IS NULL`
+		result, err := tx.Exec(query, now, now, now, userID)
+		if err != nil {
+			return fmt.Errorf("failed to anonymize user: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return sql.ErrNoRows
+		}
+		var renting bool
+		query = "SELECT EXISTS (SELECT 1 FROM rentals WHERE user_id = ? AND end_time IS NULL)"
+		if err := tx.QueryRow(query, userID).Scan(&renting); err != nil {
+			return fmt.Errorf("failed to check ongoing rentals: %v", err)
+		}
+		if renting {
+			return ErrUserRenting
+		}
+		for _, query := range anonymizeQueries {
+			if _, err := tx.Exec(query, userID); err != nil {
+				return fmt.Errorf("failed to erase personal data: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// queryRecords returns the rows of a query by column name
+func queryRecords(q database.Querier, query string, args ...interface{}) ([]models.Record, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]models.Record, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		record := make(models.Record, len(columns))
+		for i, column := range columns {
+			if value, ok := values[i].([]byte); ok {
+				values[i] = string(value)
+			}
+			record[column] = values[i]
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// personalData are the values identifying the test user, none of them may be left after the anonymization
+var personalData = []string{"jane.doe@example.com", "Jane", "Doe", "JANE2024", "tok_jane_secret", "9876", "48.8566", "2.3522", "48.8606", "2.3376", "jane.d@example.com", "203.0.113.7"}
+
+func newTestDatabase(t *testing.T) database.Database {
+	t.Setenv("DB_URL", fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_")))
+	db := database.New()
+	require.NoError(t, db.Start())
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate())
+	return db
+}
+
+// seedUser inserts a user with a payment method, a wallet and an ended rental that was paid, invoiced and tracked
+func seedUser(t *testing.T, db database.Database) {
+	_, err := db.Exec(`
+		INSERT INTO users (id, email, hashed_password, first_name, last_name, referral_code) VALUES (1, 'jane.doe@example.com', 'hash', 'Jane', 'Doe', 'JANE2024');
+		INSERT INTO bikes (id, is_available, latitude, longitude) VALUES (1, 1, 40.4168, -3.7038);
+		INSERT INTO rentals (id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude, cost)
+			VALUES (1, 1, 1, '2024-05-07 10:00:00', '2024-05-07 10:20:00', 48.8566, 2.3522, 48.8606, 2.3376, 4.5);
+		INSERT INTO rental_track_points (rental_id, latitude, longitude, recorded_at) VALUES (1, 48.8566, 2.3522, '2024-05-07 10:00:00');
+		INSERT INTO payment_methods (id, user_id, token, brand, last4, is_default) VALUES (1, 1, 'tok_jane_secret', 'visa', '9876', 1);
+		INSERT INTO payments (rental_id, user_id, payment_method_id, provider_reference, amount_authorized, amount_captured, status)
+			VALUES (1, 1, 1, 'ch_1', 4.5, 4.5, 'captured');
+		INSERT INTO ledger_accounts (id, code, kind, user_id) VALUES (10, 'wallet:1', 'wallet', 1);
+		INSERT INTO ledger_transactions (id, kind, user_id, reference) VALUES (1, 'top_up', 1, 'ch_2');
+		INSERT INTO ledger_entries (transaction_id, account_id, amount) VALUES (1, 10, 1000), (1, 1, -1000);
+		INSERT INTO invoices (sequence, number, rental_id, user_id, currency, tax_rate, subtotal, tax, total, issued_at)
+			VALUES (1, 'INV-1', 1, 1, 'EUR', 0.21, 372, 78, 450, '2024-05-07 10:20:00');
+		INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, status_code, response_body, expires_at)
+			VALUES ('user:1', 'key', 'fp', 200, '{"email":"jane.doe@example.com"}', '2099-01-01 00:00:00');
+		INSERT INTO audit_log (actor, action, resource_type, resource_id, before, after, status_code, ip, created_at) VALUES
+			('user:1', 'login', 'users', '1', NULL, NULL, 200, '203.0.113.7', '2024-05-07T09:55:00.000Z'),
+			('admin:admin', 'PATCH /admin/users/{user_id}', 'users', '1', '{"email":"jane.doe@example.com"}', '{"email":"jane.d@example.com"}', 200, '10.0.0.1', '2024-05-08T09:00:00.000Z'),
+			('admin:admin', 'PATCH /admin/rentals/{rental_id}', 'rentals', '1', '{"cost":4.5}', '{"cost":4}', 200, '10.0.0.1', '2024-05-08T09:05:00.000Z');`)
+	require.NoError(t, err)
+}
+
+// findPersonalData returns the columns of every table holding one of the values
+func findPersonalData(t *testing.T, db database.Database, values []string) []string {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'")
+	require.NoError(t, err)
+	var tables []string
+	for rows.Next() {
+		var table string
+		require.NoError(t, rows.Scan(&table))
+		tables = append(tables, table)
+	}
+	rows.Close()
+
+	var found []string
+	for _, table := range tables {
+		columns, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+		require.NoError(t, err)
+		var names []string
+		for columns.Next() {
+			var name string
+			require.NoError(t, columns.Scan(&name))
+			names = append(names, name)
+		}
+		columns.Close()
+		for _, column := range names {
+			for _, value := range values {
+				var count int
+				query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE instr(CAST(%s AS TEXT), ?) > 0", table, column)
+				require.NoError(t, db.QueryRow(query, value).Scan(&count))
+				if count > 0 {
+					found = append(found, fmt.Sprintf("%s.%s: %s", table, column, value))
+				}
+			}
+		}
+	}
+	return found
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	t.Run("Success - no personal data is left and the financial records are kept", func(t *testing.T) {
+		// GIVEN: a user who rode, paid and topped up their wallet
+		db := newTestDatabase(t)
+		seedUser(t, db)
+		repo := New(db)
+		assert.NotEmpty(t, findPersonalData(t, db, personalData))
+
+		// WHEN: the user is anonymized
+		err := repo.AnonymizeUser(1)
+
+		// THEN: none of their personal data is left in any table
+		assert.NoError(t, err)
+		assert.Empty(t, findPersonalData(t, db, personalData))
+		// THEN: the rental, the payment, the invoice and the wallet are kept for accounting
+		var cost, captured float64
+		var invoices, entries int
+		assert.NoError(t, db.QueryRow("SELECT cost FROM rentals WHERE id = 1 AND user_id = 1").Scan(&cost))
+		assert.NoError(t, db.QueryRow("SELECT amount_captured FROM payments WHERE rental_id = 1").Scan(&captured))
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM invoices WHERE user_id = 1").Scan(&invoices))
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM ledger_entries WHERE account_id = 10").Scan(&entries))
+		assert.Equal(t, 4.5, cost)
+		assert.Equal(t, 4.5, captured)
+		assert.Equal(t, 1, invoices)
+		assert.Equal(t, 1, entries)
+		// THEN: the audit trail is kept, with the IP addresses of the staff and the changes to other resources
+		var audited int
+		var adminIP, rentalChange string
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE resource_id = '1'").Scan(&audited))
+		assert.NoError(t, db.QueryRow("SELECT ip FROM audit_log WHERE actor = 'admin:admin' AND resource_type = 'users'").Scan(&adminIP))
+		assert.NoError(t, db.QueryRow("SELECT after FROM audit_log WHERE resource_type = 'rentals'").Scan(&rentalChange))
+		assert.Equal(t, 3, audited)
+		assert.Equal(t, "10.0.0.1", adminIP)
+		assert.Equal(t, `{"cost":4}`, rentalChange)
+		// THEN: the audit log can't be changed otherwise
+		_, err = db.Exec("UPDATE audit_log SET actor = 'admin:other' WHERE id = 1")
+		assert.Error(t, err)
+		_, err = db.Exec("UPDATE audit_log SET after = '{}' WHERE id = 3")
+		assert.Error(t, err)
+		// THEN: the user can't log in nor be anonymized again
+		var deleted, anonymized bool
+		assert.NoError(t, db.QueryRow("SELECT deleted_at IS NOT NULL, anonymized_at IS NOT NULL FROM users WHERE id = 1").Scan(&deleted, &anonymized))
+		assert.True(t, deleted)
+		assert.True(t, anonymized)
+		assert.ErrorIs(t, repo.AnonymizeUser(1), sql.ErrNoRows)
+	})
+	t.Run("Failure - users with an ongoing rental keep their data", func(t *testing.T) {
+		// GIVEN: a user riding a bike
+		db := newTestDatabase(t)
+		seedUser(t, db)
+		_, err := db.Exec("UPDATE rentals SET end_time = NULL WHERE id = 1")
+		require.NoError(t, err)
+
+		// WHEN: the user is anonymized
+		err = New(db).AnonymizeUser(1)
+
+		// THEN: it is refused and nothing is erased
+		assert.ErrorIs(t, err, ErrUserRenting)
+		assert.Len(t, findPersonalData(t, db, []string{"jane.doe@example.com", "tok_jane_secret"}), 4)
+	})
+}
+
+func TestExportUserData(t *testing.T) {
+	t.Run("Success - the export holds the profile and the records of the user", func(t *testing.T) {
+		// GIVEN: a user who rode and paid
+		db := newTestDatabase(t)
+		seedUser(t, db)
+
+		// WHEN: their data is exported
+		export, err := New(db).ExportUserData(1)
+
+		// THEN: every section is exported, without the password hash nor the payment token
+		assert.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", export.Profile["email"])
+		assert.NotContains(t, export.Profile, "hashed_password")
+		assert.Len(t, export.Sections, len(exportSections))
+		assert.Len(t, export.Sections["rentals"], 1)
+		assert.Len(t, export.Sections["rental_track_points"], 1)
+		assert.Equal(t, "9876", export.Sections["payment_methods"][0]["last4"])
+		assert.NotContains(t, export.Sections["payment_methods"][0], "token")
+		assert.Equal(t, int64(1000), export.Sections["wallet_transactions"][0]["amount"])
+		assert.Len(t, export.Sections["invoices"], 1)
+		// THEN: the audit entries of their requests and of the changes to their profile are exported, not the others
+		require.Len(t, export.Sections["audit_log"], 2)
+		assert.Equal(t, "login", export.Sections["audit_log"][0]["action"])
+		assert.Equal(t, `{"email":"jane.d@example.com"}`, export.Sections["audit_log"][1]["after"])
+	})
+	t.Run("Failure - unknown user", func(t *testing.T) {
+		db := newTestDatabase(t)
+		_, err := New(db).ExportUserData(1)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/promotions/models"
+	"bikesRentalAPI/internal/promotions/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxFixedCredit is the highest amount a fixed credit code can give
+const maxFixedCredit = 500
+
+// Handler is the interface for promotion handlers
+type Handler interface {
+	RedeemPromoCode(w http.ResponseWriter, req *http.Request) // Redeem a promo code
+	ListRedemptions(w http.ResponseWriter, req *http.Request) // List the promo codes redeemed by the user
+	ListPromoCodes(w http.ResponseWriter, req *http.Request)  // List every promo code
+	CreatePromoCode(w http.ResponseWriter, req *http.Request) // Create a promo code
+	GetPromoCode(w http.ResponseWriter, req *http.Request)    // Get a promo code
+	UpdatePromoCode(w http.ResponseWriter, req *http.Request) // Update the limits of a promo code
+	DeletePromoCode(w http.ResponseWriter, req *http.Request) // Delete a promo code nobody redeemed
+}
+
+type handler struct {
+	PromotionRepo repository.PromotionRepository
+	validator     *validator.Validate
+}
+
+// New returns a new promotion handler
+func New(promotionRepository repository.PromotionRepository) Handler {
+	validator := validator.New(validator.WithRequiredStructEnabled())
+	handler := &handler{
+		PromotionRepo: promotionRepository,
+		validator:     validator,
+	}
+	return handler
+}
+
+// RedeemPromoCode redeems a promo code for the logged in user. Fixed credit is added to the wallet at once,
+// the other codes apply to the next rides
+func (h *handler) RedeemPromoCode(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	var redeemReq models.RedeemPromoRequest
+	if !h.parseRequest(w, req, &redeemReq) {
+		return
+	}
+	redemption, err := h.PromotionRepo.Redeem(userID, redeemReq.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPromoCodeNotFound):
+			http.Error(w, "Promo code not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrPromoCodeExpired):
+			http.Error(w, "Promo code expired", http.StatusConflict)
+		case errors.Is(err, repository.ErrPromoCodeExhausted):
+			http.Error(w, "Promo code redemption limit reached", http.StatusConflict)
+		case errors.Is(err, repository.ErrPromoCodeAlreadyRedeemed):
+			http.Error(w, "Promo code already redeemed", http.StatusConflict)
+		default:
+			log.Printf("Error redeeming promo code: %v", err)
+			http.Error(w, "Error redeeming promo code", http.StatusInternalServerError)
+		}
+		return
+	}
+	helpers.WriteJSON(w, http.StatusCreated, redemption)
+}
+
+// ListRedemptions returns the promo codes redeemed by the logged in user with the rides they still apply to
+func (h *handler) ListRedemptions(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	redemptions, err := h.PromotionRepo.ListRedemptions(userID)
+	if err != nil {
+		log.Printf("Error getting redemptions: %v", err)
+		http.Error(w, "Error getting redemptions", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, redemptions)
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// ListPromoCodes returns every promo code, the latest first
+func (h *handler) ListPromoCodes(w http.ResponseWriter, req *http.Request) {
+	promoCodes, err := h.PromotionRepo.ListPromoCodes()
+	if err != nil {
+		log.Printf("Error getting promo codes: %v", err)
+		http.Error(w, "Error getting promo codes", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, promoCodes)
+}
+
+// CreatePromoCode creates a new promo code. The value is the percent off for 'percent_off' codes
+// and the credited amount for 'fixed_credit' codes. 'free_unlocks' codes don't have a value
+func (h *handler) CreatePromoCode(w http.ResponseWriter, req *http.Request) {
+	var createReq models.CreatePromoCodeRequest
+	if !h.parseRequest(w, req, &createReq) {
+		return
+	}
+	switch {
+	case createReq.Kind == models.PromoKindPercentOff && (createReq.Value <= 0 || createReq.Value > 100):
+		http.Error(w, "Percent off codes need a value between 0 and 100", http.StatusBadRequest)
+		return
+	case createReq.Kind == models.PromoKindFixedCredit && (createReq.Value <= 0 || createReq.Value > maxFixedCredit):
+		http.Error(w, fmt.Sprintf("Fixed credit codes need a value between 0 and %d", maxFixedCredit), http.StatusBadRequest)
+		return
+	case createReq.ExpiresAt != nil && !createReq.ExpiresAt.After(time.Now()):
+		http.Error(w, "Expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+	id, err := h.PromotionRepo.CreatePromoCode(createReq)
+	if err != nil {
+		if errors.Is(err, repository.ErrPromoCodeExists) {
+			http.Error(w, "Promo code already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Error creating promo code: %v", err)
+		http.Error(w, "Error creating promo code", http.StatusInternalServerError)
+		return
+	}
+	createResp := models.CreateUpdatePromoCodeResponse{
+		ID:      id,
+		Message: "Promo code created successfully",
+	}
+	helpers.WriteJSON(w, http.StatusCreated, createResp)
+}
+
+// GetPromoCode retrieves a promo code
+func (h *handler) GetPromoCode(w http.ResponseWriter, req *http.Request) {
+	promoCodeID, ok := parsePromoCodeID(w, req)
+	if !ok {
+		return
+	}
+	promoCode, ok := h.getPromoCode(w, promoCodeID)
+	if !ok {
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, promoCode)
+}
+
+// UpdatePromoCode updates the redemption limit, the expiry or the activation of a promo code
+func (h *handler) UpdatePromoCode(w http.ResponseWriter, req *http.Request) {
+	promoCodeID, ok := parsePromoCodeID(w, req)
+	if !ok {
+		return
+	}
+	var updateReq models.UpdatePromoCodeRequest
+	if !h.parseRequest(w, req, &updateReq) {
+		return
+	}
+	promoCode, ok := h.getPromoCode(w, promoCodeID)
+	if !ok {
+		return
+	}
+	fieldsToUpdate, err := getFieldsToUpdate(&updateReq, promoCode)
+	if err != nil {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+	if updateReq.MaxRedemptions != nil && *updateReq.MaxRedemptions < promoCode.RedemptionsCount {
+		http.Error(w, fmt.Sprintf("Redemption limit can't be lower than the %d redemptions", promoCode.RedemptionsCount), http.StatusConflict)
+		return
+	}
+	audit.SetChanges(req, promoCode, fieldsToUpdate)
+	result, err := h.PromotionRepo.UpdatePromoCode(promoCodeID, fieldsToUpdate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Promo code not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating promo code: %v", err)
+		http.Error(w, "Error updating promo code", http.StatusInternalServerError)
+		return
+	}
+	updateResp := models.CreateUpdatePromoCodeResponse{
+		ID:      result,
+		Message: "Promo code updated successfully",
+	}
+	helpers.WriteJSON(w, http.StatusOK, updateResp)
+}
+
+// DeletePromoCode deletes a promo code nobody redeemed. Redeemed codes can be deactivated instead
+func (h *handler) DeletePromoCode(w http.ResponseWriter, req *http.Request) {
+	promoCodeID, ok := parsePromoCodeID(w, req)
+	if !ok {
+		return
+	}
+	if err := h.PromotionRepo.DeletePromoCode(promoCodeID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Promo code not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrPromoCodeRedeemed):
+			http.Error(w, "Promo code was redeemed, deactivate it instead", http.StatusConflict)
+		default:
+			log.Printf("Error deleting promo code: %v", err)
+			http.Error(w, "Error deleting promo code", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getPromoCode retrieves a promo code. It writes the error response when it fails
+func (h *handler) getPromoCode(w http.ResponseWriter, promoCodeID int64) (*models.PromoCode, bool) {
+	promoCode, err := h.PromotionRepo.GetPromoCodeByID(promoCodeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Promo code not found", http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("Error getting promo code: %v", err)
+		http.Error(w, "Error getting promo code", http.StatusInternalServerError)
+		return nil, false
+	}
+	return promoCode, true
+}
+
+// parseRequest reads and validates the body request. It writes the error response when it fails
+func (h *handler) parseRequest(w http.ResponseWriter, req *http.Request, dest interface{}) bool {
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return false
+	}
+	if err := json.Unmarshal(body, dest); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return false
+	}
+	if err := h.validator.Struct(dest); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// parsePromoCodeID reads the 'promo_code_id' URL parameter. It writes the error response when it fails
+func parsePromoCodeID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	promoCodeIDStr := chi.URLParam(req, "promo_code_id")
+	promoCodeID, err := strconv.ParseInt(promoCodeIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", promoCodeIDStr, err), http.StatusBadRequest)
+		return 0, false
+	}
+	return promoCodeID, true
+}
+
+// getFieldsToUpdate compares the fields of the update request with the promo code and returns the fields to update as map
+func getFieldsToUpdate(updateReq *models.UpdatePromoCodeRequest, promoCode *models.PromoCode) (map[string]interface{}, error) {
+	if updateReq == nil || promoCode == nil {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	fieldsToUpdate := make(map[string]interface{})
+	if updateReq.MaxRedemptions != nil && (promoCode.MaxRedemptions == nil || *updateReq.MaxRedemptions != *promoCode.MaxRedemptions) {
+		fieldsToUpdate["max_redemptions"] = *updateReq.MaxRedemptions
+	}
+	if updateReq.ExpiresAt != nil && (promoCode.ExpiresAt == nil || !updateReq.ExpiresAt.Equal(*promoCode.ExpiresAt)) {
+		fieldsToUpdate["expires_at"] = updateReq.ExpiresAt.UTC()
+	}
+	if updateReq.IsActive != nil && *updateReq.IsActive != promoCode.IsActive {
+		fieldsToUpdate["is_active"] = *updateReq.IsActive
+	}
+
+	if len(fieldsToUpdate) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	return fieldsToUpdate, nil
+}
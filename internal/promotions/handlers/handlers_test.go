@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/promotions/models"
+	"bikesRentalAPI/internal/promotions/repository"
+	"bikesRentalAPI/internal/promotions/repository/mocks"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// withUser returns the request authenticated as the user
+func withUser(t *testing.T, req *http.Request, userID string) *http.Request {
+	token, _, err := jwtauth.New("HS256", []byte("secret"), nil).Encode(map[string]interface{}{"sub": userID})
+	require.NoError(t, err)
+	return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+}
+
+func TestRedeemPromoCode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPromotionRepo := mocks.NewMockPromotionRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		body             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the code is redeemed", body: `{"code":"SPRING20"}`, expectedHttpCode: http.StatusCreated},
+		{name: "Failure - the code is required", body: `{}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the code is not alphanumeric", body: `{"code":"SPRING-20"}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the code does not exist", body: `{"code":"SPRING20"}`, repoErr: repository.ErrPromoCodeNotFound, expectedHttpCode: http.StatusNotFound},
+		{name: "Failure - the code expired", body: `{"code":"SPRING20"}`, repoErr: repository.ErrPromoCodeExpired, expectedHttpCode: http.StatusConflict},
+		{name: "Failure - the code reached its limit", body: `{"code":"SPRING20"}`, repoErr: repository.ErrPromoCodeExhausted, expectedHttpCode: http.StatusConflict},
+		{name: "Failure - the rider already redeemed the code", body: `{"code":"SPRING20"}`, repoErr: repository.ErrPromoCodeAlreadyRedeemed, expectedHttpCode: http.StatusConflict},
+		{name: "Failure - the redemption can't be stored", body: `{"code":"SPRING20"}`, repoErr: errors.New("database is locked"), expectedHttpCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider redeeming a promo code
+			if tc.expectedHttpCode != http.StatusBadRequest {
+				var redemption *models.Redemption
+				if tc.repoErr == nil {
+					redemption = &models.Redemption{ID: 1, PromoCodeID: 1, UserID: 2, Code: "SPRING20", Kind: models.PromoKindPercentOff, Value: 20, RemainingRides: 2}
+				}
+				mockPromotionRepo.EXPECT().Redeem(int64(2), "SPRING20").Return(redemption, tc.repoErr)
+			}
+			req := withUser(t, httptest.NewRequest(http.MethodPost, "/users/promo", strings.NewReader(tc.body)), "2")
+			rec := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockPromotionRepo).RedeemPromoCode(rec, req)
+			// THEN: the reasons a code can't be redeemed are told apart
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestCreatePromoCode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPromotionRepo := mocks.NewMockPromotionRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - a percent off code is created",
+			body: `{"code":"SPRING20","kind":"percent_off","value":20,"rides":2}`,
+			mockCalls: func() {
+				mockPromotionRepo.EXPECT().CreatePromoCode(gomock.Any()).Return(int64(1), nil)
+			},
+			expectedHttpCode: http.StatusCreated,
+		},
+		{
+			name:             "Failure - percent off codes can't discount more than the cost",
+			body:             `{"code":"SPRING20","kind":"percent_off","value":120}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:             "Failure - fixed credit codes need a value",
+			body:             `{"code":"WELCOME","kind":"fixed_credit"}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:             "Failure - fixed credit codes can't exceed the highest credit",
+			body:             `{"code":"WELCOME","kind":"fixed_credit","value":501}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:             "Failure - the kind is unknown",
+			body:             `{"code":"WELCOME","kind":"cashback","value":5}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:             "Failure - the expiry is in the past",
+			body:             `{"code":"OLD","kind":"free_unlocks","expires_at":"2020-01-01T00:00:00Z"}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure - the code already exists",
+			body: `{"code":"SPRING20","kind":"free_unlocks"}`,
+			mockCalls: func() {
+				mockPromotionRepo.EXPECT().CreatePromoCode(gomock.Any()).Return(int64(0), repository.ErrPromoCodeExists)
+			},
+			expectedHttpCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin creating a promo code
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodPost, "/admin/promo-codes", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockPromotionRepo).CreatePromoCode(rec, req)
+			// THEN: only valid codes are stored
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestUpdatePromoCode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPromotionRepo := mocks.NewMockPromotionRepository(mockCtrl)
+	maxRedemptions := 10
+	promoCode := &models.PromoCode{ID: 1, Code: "SPRING20", Kind: models.PromoKindPercentOff, Value: 20, Rides: 2, MaxRedemptions: &maxRedemptions, RedemptionsCount: 5, IsActive: true}
+
+	testCases := []struct {
+		name             string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - the code is deactivated",
+			body: `{"is_active":false}`,
+			mockCalls: func() {
+				mockPromotionRepo.EXPECT().GetPromoCodeByID(int64(1)).Return(promoCode, nil)
+				mockPromotionRepo.EXPECT().UpdatePromoCode(int64(1), map[string]interface{}{"is_active": false}).Return(int64(1), nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name: "Failure - the limit is lower than the redemptions",
+			body: `{"max_redemptions":4}`,
+			mockCalls: func() {
+				mockPromotionRepo.EXPECT().GetPromoCodeByID(int64(1)).Return(promoCode, nil)
+			},
+			expectedHttpCode: http.StatusConflict,
+		},
+		{
+			name: "Failure - nothing changes",
+			body: `{"is_active":true}`,
+			mockCalls: func() {
+				mockPromotionRepo.EXPECT().GetPromoCodeByID(int64(1)).Return(promoCode, nil)
+			},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure - the code does not exist",
+			body: `{"is_active":false}`,
+			mockCalls: func() {
+				mockPromotionRepo.EXPECT().GetPromoCodeByID(int64(1)).Return(nil, sql.ErrNoRows)
+			},
+			expectedHttpCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin updating promo code 1
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodPatch, "/admin/promo-codes/1", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Patch("/admin/promo-codes/{promo_code_id}", New(mockPromotionRepo).UpdatePromoCode)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the limit never drops below the redemptions already made
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestDeletePromoCode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPromotionRepo := mocks.NewMockPromotionRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the code is deleted", expectedHttpCode: http.StatusNoContent},
+		{name: "Failure - the code does not exist", repoErr: sql.ErrNoRows, expectedHttpCode: http.StatusNotFound},
+		{name: "Failure - the code was redeemed", repoErr: repository.ErrPromoCodeRedeemed, expectedHttpCode: http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin deleting promo code 1
+			mockPromotionRepo.EXPECT().DeletePromoCode(int64(1)).Return(tc.repoErr)
+			req := httptest.NewRequest(http.MethodDelete, "/admin/promo-codes/1", nil)
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Delete("/admin/promo-codes/{promo_code_id}", New(mockPromotionRepo).DeletePromoCode)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: redeemed codes are kept
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// PromoKind is the benefit given by a promo code
+type PromoKind string
+
+const (
+	// PromoKindPercentOff codes discount a percent of the cost of the next rides
+	PromoKindPercentOff PromoKind = "percent_off"
+	// PromoKindFixedCredit codes credit an amount to the wallet when redeemed
+	PromoKindFixedCredit PromoKind = "fixed_credit"
+	// PromoKindFreeUnlocks codes waive the unlock fee of the next rides
+	PromoKindFreeUnlocks PromoKind = "free_unlocks"
+)
+
+// PromoCode is a code marketing hands out to riders
+type PromoCode struct {
+	ID   int64     `json:"id,omitempty"`
+	Code string    `json:"code"`
+	Kind PromoKind `json:"kind"`
+	// The percent off for 'percent_off' codes, the credited amount for 'fixed_credit' codes
+	Value float64 `json:"value"`
+	// The number of rides the benefit applies to for 'percent_off' and 'free_unlocks' codes
+	Rides int `json:"rides"`
+	// The number of users that can redeem the code, nil when unlimited
+	MaxRedemptions   *int `json:"max_redemptions"`
+	RedemptionsCount int  `json:"redemptions_count"`
+	// The code can't be redeemed nor used after this time, nil when it never expires
+	ExpiresAt *time.Time `json:"expires_at"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+} // @name PromoCode
+
+// PromoCodeList contains a list of promo codes
+type PromoCodeList struct {
+	// The list of promo codes
+	Items []*PromoCode `json:"items"`
+} // @name PromoCodeList
+
+// CreatePromoCodeRequest contains the information to create a promo code
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" validate:"required,alphanum,min=3,max=32"`
+	Kind           PromoKind  `json:"kind" validate:"required,oneof=percent_off fixed_credit free_unlocks"`
+	Value          float64    `json:"value" validate:"gte=0"`
+	Rides          int        `json:"rides" validate:"omitempty,gt=0,lte=100"`
+	MaxRedemptions *int       `json:"max_redemptions" validate:"omitempty,gt=0"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+} // @name CreatePromoCodeRequest
+
+// UpdatePromoCodeRequest contains the information to update a promo code
+type UpdatePromoCodeRequest struct {
+	MaxRedemptions *int       `json:"max_redemptions" validate:"omitempty,gt=0"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	IsActive       *bool      `json:"is_active"`
+} // @name UpdatePromoCodeRequest
+
+// CreateUpdatePromoCodeResponse represents the response of creating/updating a promo code
+type CreateUpdatePromoCodeResponse struct {
+	ID      int64  `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+} // @name CreateUpdatePromoCodeResponse
+
+// RedeemPromoRequest contains the code a user redeems
+type RedeemPromoRequest struct {
+	Code string `json:"code" validate:"required,alphanum,max=32"`
+} // @name RedeemPromoRequest
+
+// Redemption is a promo code redeemed by a user
+type Redemption struct {
+	ID          int64     `json:"id"`
+	PromoCodeID int64     `json:"promo_code_id"`
+	UserID      int64     `json:"user_id"`
+	Code        string    `json:"code"`
+	Kind        PromoKind `json:"kind"`
+	Value       float64   `json:"value"`
+	// The number of rides the benefit still applies to
+	RemainingRides int        `json:"remaining_rides"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+} // @name Redemption
+
+// RedemptionList contains a list of redemptions
+type RedemptionList struct {
+	// The list of redemptions
+	Items []*Redemption `json:"items"`
+} // @name RedemptionList
@@ -0,0 +1,60 @@
+package promotions
+
+import (
+	"bikesRentalAPI/internal/pricing"
+	"bikesRentalAPI/internal/promotions/models"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// defaultReferralCredit is the amount credited to the referrer and the referee when the referee ends the first ride
+const defaultReferralCredit = 5
+
+// Apply evaluates the rules of a redeemed promo code against the cost breakdown of a ride and adds the discount line.
+// It returns false when the code gives nothing on this ride, so the ride does not use it up:
+// free unlocks need an unlock fee and percent off needs something left to pay
+func Apply(breakdown *pricing.Breakdown, redemption *models.Redemption) bool {
+	if redemption == nil || redemption.RemainingRides <= 0 {
+		return false
+	}
+	var amount float64
+	var description string
+	switch redemption.Kind {
+	case models.PromoKindFreeUnlocks:
+		amount = breakdown.Amount(pricing.LineUnlockFee)
+		description = fmt.Sprintf("Promo code %s: free unlock", redemption.Code)
+	case models.PromoKindPercentOff:
+		amount = breakdown.Total * min(redemption.Value, 100) / 100
+		description = fmt.Sprintf("Promo code %s: %.0f%% off", redemption.Code, redemption.Value)
+	default:
+		return false
+	}
+	amount = min(amount, breakdown.Total)
+	if amount <= 0 {
+		return false
+	}
+	breakdown.Add(pricing.Line{
+		Code:        pricing.LinePromotion,
+		Description: description,
+		Quantity:    1,
+		UnitPrice:   -amount,
+		Amount:      -amount,
+	})
+	return true
+}
+
+// ReferralCreditFromEnv reads the amount credited to the referrer and the referee from REFERRAL_CREDIT, falling back to the default
+func ReferralCreditFromEnv() float64 {
+	value := os.Getenv("REFERRAL_CREDIT")
+	if value == "" {
+		return defaultReferralCredit
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		log.Printf("invalid REFERRAL_CREDIT %q. Set to %v as default", value, defaultReferralCredit)
+		return defaultReferralCredit
+	}
+	return amount
+}
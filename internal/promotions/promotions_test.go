@@ -0,0 +1,84 @@
+package promotions
+
+import (
+	"bikesRentalAPI/internal/pricing"
+	"bikesRentalAPI/internal/promotions/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name            string
+		unlockFee       float64
+		rideMinutes     int
+		redemption      *models.Redemption
+		expectedApplied bool
+		expectedTotal   float64
+	}{
+		{
+			name:            "Success - percent off discounts the whole ride",
+			unlockFee:       1,
+			rideMinutes:     10,
+			redemption:      &models.Redemption{Code: "HALF", Kind: models.PromoKindPercentOff, Value: 50, RemainingRides: 1},
+			expectedApplied: true,
+			expectedTotal:   1.5,
+		},
+		{
+			name:            "Success - a free unlock waives the unlock fee",
+			unlockFee:       1,
+			rideMinutes:     10,
+			redemption:      &models.Redemption{Code: "UNLOCK", Kind: models.PromoKindFreeUnlocks, RemainingRides: 2},
+			expectedApplied: true,
+			expectedTotal:   2,
+		},
+		{
+			name:            "Failure - a free unlock does not apply without unlock fee",
+			rideMinutes:     10,
+			redemption:      &models.Redemption{Code: "UNLOCK", Kind: models.PromoKindFreeUnlocks, RemainingRides: 2},
+			expectedApplied: false,
+			expectedTotal:   2,
+		},
+		{
+			name:            "Failure - percent off does not apply to a free ride",
+			redemption:      &models.Redemption{Code: "HALF", Kind: models.PromoKindPercentOff, Value: 50, RemainingRides: 1},
+			expectedApplied: false,
+			expectedTotal:   0,
+		},
+		{
+			name:            "Failure - a used up code does not apply",
+			unlockFee:       1,
+			rideMinutes:     10,
+			redemption:      &models.Redemption{Code: "HALF", Kind: models.PromoKindPercentOff, Value: 50},
+			expectedApplied: false,
+			expectedTotal:   3,
+		},
+		{
+			name:            "Failure - fixed credit codes don't discount rides",
+			unlockFee:       1,
+			rideMinutes:     10,
+			redemption:      &models.Redemption{Code: "CREDIT", Kind: models.PromoKindFixedCredit, Value: 5, RemainingRides: 1},
+			expectedApplied: false,
+			expectedTotal:   3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: the cost breakdown of a ride at 0.2 per minute
+			var breakdown pricing.Breakdown
+			if tc.unlockFee > 0 {
+				breakdown.Add(pricing.UnlockFee(tc.unlockFee))
+			}
+			breakdown.Add(pricing.RideTime(0.2, time.Duration(tc.rideMinutes)*time.Minute))
+			// WHEN: the redeemed code is applied
+			applied := Apply(&breakdown, tc.redemption)
+			// THEN: the discount line is added only when the code gives something
+			assert.Equal(t, tc.expectedApplied, applied)
+			assert.Equal(t, tc.expectedTotal, breakdown.Total)
+			assert.Equal(t, tc.expectedApplied, breakdown.Amount(pricing.LinePromotion) < 0)
+		})
+	}
+}
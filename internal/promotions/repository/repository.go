@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/promotions/models"
+	walletrepository "bikesRentalAPI/internal/wallet/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// createdBy is the author recorded on the wallet credits given by promotions
+const createdBy = "promotions"
+
+var (
+	// ErrPromoCodeNotFound is returned when redeeming a code that does not exist
+	ErrPromoCodeNotFound = errors.New("promo code not found")
+	// ErrPromoCodeExpired is returned when redeeming a code that is deactivated or expired
+	ErrPromoCodeExpired = errors.New("promo code expired")
+	// ErrPromoCodeExhausted is returned when redeeming a code that reached its redemption limit
+	ErrPromoCodeExhausted = errors.New("promo code redemption limit reached")
+	// ErrPromoCodeAlreadyRedeemed is returned when a user redeems the same code twice
+	ErrPromoCodeAlreadyRedeemed = errors.New("promo code already redeemed")
+	// ErrPromoCodeExists is returned when creating a code that already exists
+	ErrPromoCodeExists = errors.New("promo code already exists")
+	// ErrPromoCodeRedeemed is returned when deleting a code that was redeemed. It can be deactivated instead
+	ErrPromoCodeRedeemed = errors.New("promo code has redemptions")
+	// ErrRedemptionUsed is returned when a redemption has no rides left to apply to
+	ErrRedemptionUsed = errors.New("promo code used up")
+)
+
+// promoCodesTable lists the columns of a promo code admins can update
+var promoCodesTable = database.NewTable("promo_codes", "max_redemptions", "expires_at", "is_active")
+
+type PromotionRepository interface {
+	CreatePromoCode(promoCode models.CreatePromoCodeRequest) (int64, error)
+	GetPromoCodeByID(promoCodeID int64) (*models.PromoCode, error)
+	ListPromoCodes() (*models.PromoCodeList, error)
+	UpdatePromoCode(promoCodeID int64, fieldsToUpdate map[string]interface{}) (int64, error)
+	DeletePromoCode(promoCodeID int64) error
+	Redeem(userID int64, code string) (*models.Redemption, error)
+	ListRedemptions(userID int64) (*models.RedemptionList, error)
+	ListUsableRedemptions(q database.Querier, userID int64, at time.Time) ([]*models.Redemption, error)
+	UseRedemption(q database.Querier, redemptionID int64) error
+	CreditReferral(q database.Querier, refereeID int64, rentalID int64) (bool, error)
+}
+
+type promotionRepository struct {
+	db             database.Database
+	walletRepo     walletrepository.WalletRepository
+	referralCredit int64
+}
+
+// New initializes a new promotion repository. Promotional credit is given through the wallet,
+// referralCredit is the amount credited to the referrer and the referee when the referee ends the first ride
+func New(db database.Database, walletRepo walletrepository.WalletRepository, referralCredit float64) PromotionRepository {
+	return &promotionRepository{
+		db:             db,
+		walletRepo:     walletRepo,
+		referralCredit: helpers.ToMinorUnits(referralCredit),
+	}
+}
+
+const promoCodeColumns = "id, code, kind, value, rides, max_redemptions, redemptions_count, expires_at, is_active, created_at, updated_at"
+
+const redemptionColumns = "r.id, r.promo_code_id, r.user_id, p.code, p.kind, p.value, r.remaining_rides, p.expires_at, r.created_at"
+
+// CreatePromoCode creates a promo code in the database. Codes are stored upper case
+func (r *promotionRepository) CreatePromoCode(promoCode models.CreatePromoCodeRequest) (int64, error) {
+	code := strings.ToUpper(promoCode.Code)
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM promo_codes WHERE code = ?", code).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to check promo code: %v", err)
+	}
+	if count > 0 {
+		return 0, ErrPromoCodeExists
+	}
+	rides := promoCode.Rides
+	if rides == 0 {
+		rides = 1
+	}
+	var expiresAt *time.Time
+	if promoCode.ExpiresAt != nil {
+		utc := promoCode.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+	query := "INSERT INTO promo_codes (code, kind, value, rides, max_redemptions, expires_at) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := r.db.Exec(query, code, promoCode.Kind, promoCode.Value, rides, promoCode.MaxRedemptions, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert promo code: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %v", err)
+	}
+	return id, nil
+}
+
+// GetPromoCodeByID retrieves a promo code from the database by its id
+func (r *promotionRepository) GetPromoCodeByID(promoCodeID int64) (*models.PromoCode, error) {
+	query := fmt.Sprintf("SELECT %s FROM promo_codes WHERE id = ?", promoCodeColumns)
+	return scanPromoCode(r.db.QueryRow(query, promoCodeID))
+}
+
+// ListPromoCodes retrieves every promo code, the latest first
+func (r *promotionRepository) ListPromoCodes() (*models.PromoCodeList, error) {
+	query := fmt.Sprintf("SELECT %s FROM promo_codes ORDER BY id DESC", promoCodeColumns)
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promoCodes := &models.PromoCodeList{Items: make([]*models.PromoCode, 0)}
+	for rows.Next() {
+		promoCode, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		promoCodes.Items = append(promoCodes.Items, promoCode)
+	}
+	return promoCodes, rows.Err()
+}
+
+// UpdatePromoCode updates a promo code in the database by id
+func (r *promotionRepository) UpdatePromoCode(promoCodeID int64, fieldsToUpdate map[string]interface{}) (int64, error) {
+	if _, err := promoCodesTable.Update(r.db, promoCodeID, fieldsToUpdate); err != nil {
+		return 0, err
+	}
+	return promoCodeID, nil
+}
+
+// DeletePromoCode deletes a promo code nobody redeemed
+func (r *promotionRepository) DeletePromoCode(promoCodeID int64) error {
+	promoCode, err := r.GetPromoCodeByID(promoCodeID)
+	if err != nil {
+		return err
+	}
+	if promoCode.RedemptionsCount > 0 {
+		return ErrPromoCodeRedeemed
+	}
+	result, err := r.db.Exec("DELETE FROM promo_codes WHERE id = ? AND redemptions_count = 0", promoCodeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete promo code: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return ErrPromoCodeRedeemed
+	}
+	return nil
+}
+
+// Redeem redeems a promo code for a user. Fixed credit codes are credited to the wallet at once,
+// the other codes apply to the next rides of the user
+func (r *promotionRepository) Redeem(userID int64, code string) (*models.Redemption, error) {
+	var redemption *models.Redemption
+	err := r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM promo_codes WHERE code = ?", promoCodeColumns)
+		promoCode, err := scanPromoCode(tx.QueryRow(query, strings.ToUpper(code)))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrPromoCodeNotFound
+			}
+			return fmt.Errorf("failed to get promo code: %v", err)
+		}
+		now := time.Now().UTC()
+		if !promoCode.IsActive || (promoCode.ExpiresAt != nil && !promoCode.ExpiresAt.After(now)) {
+			return ErrPromoCodeExpired
+		}
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM promo_redemptions WHERE promo_code_id = ? AND user_id = ?", promoCode.ID, userID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check redemptions: %v", err)
+		}
+		if count > 0 {
+			return ErrPromoCodeAlreadyRedeemed
+		}
+
+		// The limit is checked in the update so concurrent redemptions can't exceed it
+		result, err := tx.Exec(`UPDATE promo_codes SET redemptions_count = redemptions_count + 1
+			WHERE id = ? AND (max_redemptions IS NULL OR redemptions_count < max_redemptions)`, promoCode.ID)
+		if err != nil {
+			return fmt.Errorf("failed to count redemption: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return ErrPromoCodeExhausted
+		}
+
+		remainingRides := promoCode.Rides
+		if promoCode.Kind == models.PromoKindFixedCredit {
+			remainingRides = 0
+		}
+		result, err = tx.Exec("INSERT INTO promo_redemptions (promo_code_id, user_id, remaining_rides) VALUES (?, ?, ?)", promoCode.ID, userID, remainingRides)
+		if err != nil {
+			return fmt.Errorf("failed to insert redemption: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %v", err)
+		}
+		if promoCode.Kind == models.PromoKindFixedCredit {
+			reason := fmt.Sprintf("Promo code %s", promoCode.Code)
+			if _, err := r.walletRepo.Credit(tx, userID, helpers.ToMinorUnits(promoCode.Value), reason, createdBy); err != nil {
+				return fmt.Errorf("failed to credit promo code: %v", err)
+			}
+		}
+		redemption = &models.Redemption{
+			ID:             id,
+			PromoCodeID:    promoCode.ID,
+			UserID:         userID,
+			Code:           promoCode.Code,
+			Kind:           promoCode.Kind,
+			Value:          promoCode.Value,
+			RemainingRides: remainingRides,
+			ExpiresAt:      promoCode.ExpiresAt,
+			CreatedAt:      now,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return redemption, nil
+}
+
+// ListRedemptions retrieves the promo codes redeemed by a user, the latest first
+func (r *promotionRepository) ListRedemptions(userID int64) (*models.RedemptionList, error) {
+	query := fmt.Sprintf(`SELECT %s FROM promo_redemptions r JOIN promo_codes p ON p.id = r.promo_code_id
+		WHERE r.user_id = ? ORDER BY r.id DESC`, redemptionColumns)
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := &models.RedemptionList{Items: make([]*models.Redemption, 0)}
+	for rows.Next() {
+		redemption, err := scanRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		redemptions.Items = append(redemptions.Items, redemption)
+	}
+	return redemptions, rows.Err()
+}
+
+// ListUsableRedemptions retrieves the redemptions of a user with rides left that are still valid at the given time, the oldest first.
+// q is either the database or an ongoing transaction
+func (r *promotionRepository) ListUsableRedemptions(q database.Querier, userID int64, at time.Time) ([]*models.Redemption, error) {
+	query := fmt.Sprintf(`SELECT %s FROM promo_redemptions r JOIN promo_codes p ON p.id = r.promo_code_id
+		WHERE r.user_id = ? AND r.remaining_rides > 0 AND p.is_active = 1 AND (p.expires_at IS NULL OR p.expires_at > ?)
+		ORDER BY r.id`, redemptionColumns)
+	rows, err := q.Query(query, userID, at.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := make([]*models.Redemption, 0)
+	for rows.Next() {
+		redemption, err := scanRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions, rows.Err()
+}
+
+// UseRedemption uses one of the rides left of a redemption. q is either the database or an ongoing transaction
+func (r *promotionRepository) UseRedemption(q database.Querier, redemptionID int64) error {
+	result, err := q.Exec("UPDATE promo_redemptions SET remaining_rides = remaining_rides - 1 WHERE id = ? AND remaining_rides > 0", redemptionID)
+	if err != nil {
+		return fmt.Errorf("failed to use redemption: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return ErrRedemptionUsed
+	}
+	return nil
+}
+
+// CreditReferral credits the referrer and the referee when a referred user ends a ride. Only the first ride is rewarded,
+// the reward is recorded once per referee so it is safe to call on every ride. It returns true when the credit was given.
+// q is either the database or an ongoing transaction
+func (r *promotionRepository) CreditReferral(q database.Querier, refereeID int64, rentalID int64) (bool, error) {
+	query := `INSERT INTO referral_rewards (referrer_id, referee_id, rental_id, amount)
+		SELECT referred_by, id, ?, ? FROM users WHERE id = ? AND referred_by IS NOT NULL
+		ON CONFLICT (referee_id) DO NOTHING`
+	result, err := q.Exec(query, rentalID, r.referralCredit, refereeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record referral reward: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record referral reward: %v", err)
+	}
+	if affected == 0 || r.referralCredit == 0 {
+		return affected > 0, nil
+	}
+
+	var referrerID int64
+	if err := q.QueryRow("SELECT referrer_id FROM referral_rewards WHERE referee_id = ?", refereeID).Scan(&referrerID); err != nil {
+		return false, fmt.Errorf("failed to get referrer: %v", err)
+	}
+	if _, err := r.walletRepo.Credit(q, referrerID, r.referralCredit, fmt.Sprintf("Referral of user %d", refereeID), createdBy); err != nil {
+		return false, fmt.Errorf("failed to credit referrer: %v", err)
+	}
+	if _, err := r.walletRepo.Credit(q, refereeID, r.referralCredit, "Referral welcome credit", createdBy); err != nil {
+		return false, fmt.Errorf("failed to credit referee: %v", err)
+	}
+	return true, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPromoCode(row scanner) (*models.PromoCode, error) {
+	var promoCode models.PromoCode
+	err := row.Scan(&promoCode.ID, &promoCode.Code, &promoCode.Kind, &promoCode.Value, &promoCode.Rides, &promoCode.MaxRedemptions,
+		&promoCode.RedemptionsCount, &promoCode.ExpiresAt, &promoCode.IsActive, &promoCode.CreatedAt, &promoCode.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &promoCode, nil
+}
+
+func scanRedemption(row scanner) (*models.Redemption, error) {
+	var redemption models.Redemption
+	err := row.Scan(&redemption.ID, &redemption.PromoCodeID, &redemption.UserID, &redemption.Code, &redemption.Kind,
+		&redemption.Value, &redemption.RemainingRides, &redemption.ExpiresAt, &redemption.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
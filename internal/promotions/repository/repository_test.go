@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/payments"
+	paymentsrepository "bikesRentalAPI/internal/payments/repository"
+	"bikesRentalAPI/internal/promotions/models"
+	walletrepository "bikesRentalAPI/internal/wallet/repository"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromoCodes(t *testing.T) {
+	// GIVEN: three riders and a percent off code for two rides that one rider can redeem
+	t.Setenv("DB_URL", "file:promo_codes_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	_, err := db.Exec("INSERT INTO users (id, email, hashed_password) VALUES (1, 'first@example.com', 'x'), (2, 'second@example.com', 'x'), (3, 'third@example.com', 'x')")
+	require.NoError(t, err)
+	walletRepo := walletrepository.New(db, paymentsrepository.New(db), payments.NewFakeProvider())
+	repo := New(db, walletRepo, 5)
+	maxRedemptions := 1
+	percentOff, err := repo.CreatePromoCode(models.CreatePromoCodeRequest{Code: "spring20", Kind: models.PromoKindPercentOff, Value: 20, Rides: 2, MaxRedemptions: &maxRedemptions})
+	require.NoError(t, err)
+
+	t.Run("Failure - codes are unique regardless of case", func(t *testing.T) {
+		_, err := repo.CreatePromoCode(models.CreatePromoCodeRequest{Code: "SPRING20", Kind: models.PromoKindFreeUnlocks})
+		assert.ErrorIs(t, err, ErrPromoCodeExists)
+	})
+	t.Run("Failure - unknown codes can't be redeemed", func(t *testing.T) {
+		_, err := repo.Redeem(1, "WINTER")
+		assert.ErrorIs(t, err, ErrPromoCodeNotFound)
+	})
+	t.Run("Success - the code applies to its next rides", func(t *testing.T) {
+		redemption, err := repo.Redeem(1, "Spring20")
+		require.NoError(t, err)
+		assert.Equal(t, "SPRING20", redemption.Code)
+		assert.Equal(t, 2, redemption.RemainingRides)
+
+		usable, err := repo.ListUsableRedemptions(db, 1, time.Now())
+		require.NoError(t, err)
+		require.Len(t, usable, 1)
+		assert.Equal(t, redemption.ID, usable[0].ID)
+		require.NoError(t, repo.UseRedemption(db, usable[0].ID))
+		require.NoError(t, repo.UseRedemption(db, usable[0].ID))
+		assert.ErrorIs(t, repo.UseRedemption(db, usable[0].ID), ErrRedemptionUsed)
+		usable, err = repo.ListUsableRedemptions(db, 1, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, usable)
+	})
+	t.Run("Failure - a rider redeems a code once", func(t *testing.T) {
+		_, err := repo.Redeem(1, "SPRING20")
+		assert.ErrorIs(t, err, ErrPromoCodeAlreadyRedeemed)
+	})
+	t.Run("Failure - codes can't be redeemed past their limit", func(t *testing.T) {
+		_, err := repo.Redeem(2, "SPRING20")
+		assert.ErrorIs(t, err, ErrPromoCodeExhausted)
+	})
+	t.Run("Failure - redeemed codes can't be deleted", func(t *testing.T) {
+		assert.ErrorIs(t, repo.DeletePromoCode(percentOff), ErrPromoCodeRedeemed)
+	})
+	t.Run("Failure - expired and deactivated codes can't be redeemed", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		_, err := repo.CreatePromoCode(models.CreatePromoCodeRequest{Code: "EXPIRED", Kind: models.PromoKindFreeUnlocks, ExpiresAt: &expiresAt})
+		require.NoError(t, err)
+		_, err = repo.Redeem(2, "EXPIRED")
+		assert.ErrorIs(t, err, ErrPromoCodeExpired)
+
+		inactive, err := repo.CreatePromoCode(models.CreatePromoCodeRequest{Code: "INACTIVE", Kind: models.PromoKindFreeUnlocks})
+		require.NoError(t, err)
+		_, err = repo.UpdatePromoCode(inactive, map[string]interface{}{"is_active": false})
+		require.NoError(t, err)
+		_, err = repo.Redeem(2, "INACTIVE")
+		assert.ErrorIs(t, err, ErrPromoCodeExpired)
+	})
+	t.Run("Success - fixed credit is added to the wallet at once", func(t *testing.T) {
+		_, err := repo.CreatePromoCode(models.CreatePromoCodeRequest{Code: "WELCOME", Kind: models.PromoKindFixedCredit, Value: 7.5})
+		require.NoError(t, err)
+		redemption, err := repo.Redeem(2, "WELCOME")
+		require.NoError(t, err)
+		assert.Zero(t, redemption.RemainingRides)
+		balance, err := walletRepo.GetBalance(db, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(750), balance)
+
+		redemptions, err := repo.ListRedemptions(2)
+		require.NoError(t, err)
+		require.Len(t, redemptions.Items, 1)
+		assert.Equal(t, "WELCOME", redemptions.Items[0].Code)
+	})
+	t.Run("Success - codes nobody redeemed can be deleted", func(t *testing.T) {
+		unused, err := repo.CreatePromoCode(models.CreatePromoCodeRequest{Code: "UNUSED", Kind: models.PromoKindFreeUnlocks})
+		require.NoError(t, err)
+		require.NoError(t, repo.DeletePromoCode(unused))
+		_, err = repo.GetPromoCodeByID(unused)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.ErrorIs(t, repo.DeletePromoCode(unused), sql.ErrNoRows)
+	})
+	t.Run("Success - the first ride of a referred rider credits both riders once", func(t *testing.T) {
+		_, err := db.Exec("UPDATE users SET referred_by = 1 WHERE id = 3")
+		require.NoError(t, err)
+		_, err = db.Exec(`INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute) VALUES (1, 1, 40.4, -3.7, 0.1)`)
+		require.NoError(t, err)
+		_, err = db.Exec(`INSERT INTO rentals (id, user_id, bike_id, start_time, start_latitude, start_longitude, cost) VALUES
+			(1, 3, 1, ?, 40.4, -3.7, 0), (2, 3, 1, ?, 40.4, -3.7, 0)`, time.Now(), time.Now())
+		require.NoError(t, err)
+
+		credited, err := repo.CreditReferral(db, 3, 1)
+		require.NoError(t, err)
+		assert.True(t, credited)
+		credited, err = repo.CreditReferral(db, 3, 2)
+		require.NoError(t, err)
+		assert.False(t, credited)
+		credited, err = repo.CreditReferral(db, 2, 2)
+		require.NoError(t, err)
+		assert.False(t, credited, "riders nobody referred aren't credited")
+
+		for _, userID := range []int64{1, 3} {
+			balance, err := walletRepo.GetBalance(db, userID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(500), balance)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package quotes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	// defaultTTL is how long a quote can be used to start a rental
+	defaultTTL = 5 * time.Minute
+	// audience sets quote tokens apart from the login tokens signed with the same key
+	audience = "bike-quote"
+)
+
+var (
+	// ErrInvalidQuote is returned when a quote token is malformed, tampered with or not issued for the rental
+	ErrInvalidQuote = errors.New("invalid quote")
+	// ErrQuoteExpired is returned when a quote token is used after its expiry
+	ErrQuoteExpired = errors.New("quote expired")
+)
+
+// Config holds the quote token configuration
+type Config struct {
+	// Key signs the quote tokens
+	Key []byte
+	// TTL is how long a quote can be used to start a rental
+	TTL time.Duration
+}
+
+// ConfigFromEnv reads the signing key from QUOTE_SIGNING_KEY, falling back to JWT_SECRET_KEY,
+// and the lifetime of the quotes from QUOTE_TTL, 5 minutes as default
+func ConfigFromEnv() Config {
+	config := Config{Key: []byte(os.Getenv("QUOTE_SIGNING_KEY")), TTL: defaultTTL}
+	if len(config.Key) == 0 {
+		config.Key = []byte(os.Getenv("JWT_SECRET_KEY"))
+	}
+	if value := os.Getenv("QUOTE_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			log.Printf("invalid QUOTE_TTL %q. Set to %v as default", value, defaultTTL)
+		} else {
+			config.TTL = ttl
+		}
+	}
+	return config
+}
+
+// Claims are the terms guaranteed by a quote
+type Claims struct {
+	UserID          int64
+	BikeID          int64
+	PricePerMinute  float64
+	SurgeMultiplier float64
+	ExpiresAt       time.Time
+}
+
+// Signer issues and verifies quote tokens
+type Signer struct {
+	auth *jwtauth.JWTAuth
+	ttl  time.Duration
+}
+
+// NewSigner returns a quote token signer
+func NewSigner(config Config) *Signer {
+	return &Signer{
+		auth: jwtauth.New("HS256", config.Key, nil, jwt.WithAudience(audience)),
+		ttl:  config.TTL,
+	}
+}
+
+// Sign returns a token guaranteeing the quoted price to the user until the TTL elapses. The expiry is set on the claims
+func (s *Signer) Sign(claims *Claims) (string, error) {
+	claims.ExpiresAt = time.Now().Add(s.ttl).UTC().Truncate(time.Second)
+	_, token, err := s.auth.Encode(map[string]interface{}{
+		"aud":              audience,
+		"exp":              claims.ExpiresAt.Unix(),
+		"user_id":          claims.UserID,
+		"bike_id":          claims.BikeID,
+		"price_per_minute": claims.PricePerMinute,
+		"surge_multiplier": claims.SurgeMultiplier,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign quote: %v", err)
+	}
+	return token, nil
+}
+
+// Verify checks the signature and the expiry of a quote token and returns its claims
+func (s *Signer) Verify(tokenString string) (*Claims, error) {
+	token, err := jwtauth.VerifyToken(s.auth, tokenString)
+	if err != nil {
+		if errors.Is(err, jwtauth.ErrExpired) {
+			return nil, ErrQuoteExpired
+		}
+		return nil, ErrInvalidQuote
+	}
+	claims := token.PrivateClaims()
+	userID, okUser := claims["user_id"].(float64)
+	bikeID, okBike := claims["bike_id"].(float64)
+	pricePerMinute, okPrice := claims["price_per_minute"].(float64)
+	surgeMultiplier, okMultiplier := claims["surge_multiplier"].(float64)
+	if !okUser || !okBike || !okPrice || !okMultiplier {
+		return nil, ErrInvalidQuote
+	}
+	return &Claims{
+		UserID:          int64(userID),
+		BikeID:          int64(bikeID),
+		PricePerMinute:  pricePerMinute,
+		SurgeMultiplier: surgeMultiplier,
+		ExpiresAt:       token.Expiration(),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package quotes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	signer := NewSigner(Config{Key: []byte("secret"), TTL: time.Minute})
+
+	t.Run("Success - the quoted terms are read back", func(t *testing.T) {
+		// GIVEN: a signed quote
+		claims := &Claims{UserID: 2, BikeID: 7, PricePerMinute: 0.105, SurgeMultiplier: 1.5}
+		token, err := signer.Sign(claims)
+		assert.NoError(t, err)
+		// WHEN: it is verified
+		verified, err := signer.Verify(token)
+		// THEN: the terms and the expiry match
+		assert.NoError(t, err)
+		assert.Equal(t, claims.UserID, verified.UserID)
+		assert.Equal(t, claims.BikeID, verified.BikeID)
+		assert.Equal(t, claims.PricePerMinute, verified.PricePerMinute)
+		assert.Equal(t, claims.SurgeMultiplier, verified.SurgeMultiplier)
+		assert.True(t, claims.ExpiresAt.Equal(verified.ExpiresAt))
+	})
+	t.Run("Failure - expired quote", func(t *testing.T) {
+		// GIVEN: a quote signed with a lifetime already elapsed
+		expired := NewSigner(Config{Key: []byte("secret"), TTL: -time.Minute})
+		token, err := expired.Sign(&Claims{UserID: 2, BikeID: 7, PricePerMinute: 0.1, SurgeMultiplier: 1})
+		assert.NoError(t, err)
+		// WHEN: it is verified
+		_, err = signer.Verify(token)
+		// THEN: it is refused as expired
+		assert.ErrorIs(t, err, ErrQuoteExpired)
+	})
+	t.Run("Failure - quote signed with another key", func(t *testing.T) {
+		// GIVEN: a quote signed with another key
+		other := NewSigner(Config{Key: []byte("other"), TTL: time.Minute})
+		token, err := other.Sign(&Claims{UserID: 2, BikeID: 7, PricePerMinute: 0.01, SurgeMultiplier: 1})
+		assert.NoError(t, err)
+		// WHEN: it is verified
+		_, err = signer.Verify(token)
+		// THEN: it is refused
+		assert.ErrorIs(t, err, ErrInvalidQuote)
+	})
+	t.Run("Failure - login tokens are not quotes", func(t *testing.T) {
+		// GIVEN: a login token signed with the same key
+		_, token, err := jwtauth.New("HS256", []byte("secret"), nil).Encode(map[string]interface{}{
+			"sub": "2",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		assert.NoError(t, err)
+		// WHEN: it is verified as a quote
+		_, err = signer.Verify(token)
+		// THEN: it is refused
+		assert.ErrorIs(t, err, ErrInvalidQuote)
+	})
+	t.Run("Failure - malformed token", func(t *testing.T) {
+		_, err := signer.Verify("not-a-token")
+		assert.ErrorIs(t, err, ErrInvalidQuote)
+	})
+}
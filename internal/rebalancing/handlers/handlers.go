@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/rebalancing/models"
+	"bikesRentalAPI/internal/rebalancing/planner"
+	"bikesRentalAPI/internal/rebalancing/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// defaultCellSize is the default size of the grid cells in degrees, about 1km of latitude
+	defaultCellSize = 0.01
+	// defaultWindowHours is the default number of hours of rental history used to measure demand
+	defaultWindowHours = 7 * 24
+	// defaultMaxMoves is the default maximum number of moves suggested
+	defaultMaxMoves = 50
+)
+
+// Handler is the interface for fleet rebalancing handlers
+type Handler interface {
+	GetRecommendations(w http.ResponseWriter, req *http.Request) // Get suggested moves without saving them
+	CreateJob(w http.ResponseWriter, req *http.Request)          // Save the suggested moves as a job
+	GetJob(w http.ResponseWriter, req *http.Request)             // Get a job and its moves
+	CompleteJob(w http.ResponseWriter, req *http.Request)        // Mark a job done and move its bikes
+}
+
+type handler struct {
+	RebalancingRepo repository.RebalancingRepository
+	validator       *validator.Validate
+}
+
+// New returns a new rebalancing handler
+func New(rebalancingRepository repository.RebalancingRepository) Handler {
+	validator := validator.New(validator.WithRequiredStructEnabled())
+	handler := &handler{
+		RebalancingRepo: rebalancingRepository,
+		validator:       validator,
+	}
+	return handler
+}
+
+// GetRecommendations returns the supply and demand per cell and the suggested moves.
+// Query parameters: cell_size (degrees), window_hours and max_moves
+func (h *handler) GetRecommendations(w http.ResponseWriter, req *http.Request) {
+	recommendationReq := &models.RecommendationRequest{}
+	var err error
+	if recommendationReq.CellSize, err = parseFloatQuery(req, "cell_size"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if recommendationReq.WindowHours, err = parseIntQuery(req, "window_hours"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if recommendationReq.MaxMoves, err = parseIntQuery(req, "max_moves"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recommendation, ok := h.recommend(w, recommendationReq)
+	if !ok {
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, recommendation)
+}
+
+// CreateJob computes the suggested moves and saves them as a pending job.
+// The optional body has the same parameters as GetRecommendations
+func (h *handler) CreateJob(w http.ResponseWriter, req *http.Request) {
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return
+	}
+	recommendationReq := &models.RecommendationRequest{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, recommendationReq); err != nil {
+			http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	recommendation, ok := h.recommend(w, recommendationReq)
+	if !ok {
+		return
+	}
+	if len(recommendation.Moves) == 0 {
+		http.Error(w, "The fleet is already balanced, no job created", http.StatusConflict)
+		return
+	}
+
+	id, err := h.RebalancingRepo.CreateJob(recommendation.CellSize, recommendation.DemandSince, recommendation.Moves)
+	if err != nil {
+		log.Printf("Error creating rebalancing job: %v", err)
+		http.Error(w, "Error creating rebalancing job", http.StatusInternalServerError)
+		return
+	}
+	job, err := h.RebalancingRepo.GetJob(id)
+	if err != nil {
+		log.Printf("Error getting rebalancing job: %v", err)
+		http.Error(w, "Error getting rebalancing job", http.StatusInternalServerError)
+		return
+	}
+	createJobResp := models.CreateUpdateJobResponse{
+		ID:      id,
+		Message: "Rebalancing job created successfully",
+		Job:     job,
+	}
+	helpers.WriteJSON(w, http.StatusCreated, createJobResp)
+}
+
+// GetJob returns a rebalancing job and its moves
+func (h *handler) GetJob(w http.ResponseWriter, req *http.Request) {
+	jobID, err := strconv.ParseInt(chi.URLParam(req, "job_id"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read job_id: %v", err), http.StatusBadRequest)
+		return
+	}
+	job, err := h.RebalancingRepo.GetJob(jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Rebalancing job not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting rebalancing job: %v", err)
+		http.Error(w, "Error getting rebalancing job", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, job)
+}
+
+// CompleteJob marks a pending job as done and updates the position of its bikes
+func (h *handler) CompleteJob(w http.ResponseWriter, req *http.Request) {
+	jobID, err := strconv.ParseInt(chi.URLParam(req, "job_id"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read job_id: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := h.RebalancingRepo.CompleteJob(jobID); err != nil {
+		if errors.Is(err, repository.ErrJobNotPending) {
+			http.Error(w, "Rebalancing job not found or already done", http.StatusConflict)
+			return
+		}
+		log.Printf("Error completing rebalancing job: %v", err)
+		http.Error(w, "Error completing rebalancing job", http.StatusInternalServerError)
+		return
+	}
+	job, err := h.RebalancingRepo.GetJob(jobID)
+	if err != nil {
+		log.Printf("Error getting rebalancing job: %v", err)
+		http.Error(w, "Error getting rebalancing job", http.StatusInternalServerError)
+		return
+	}
+	completeJobResp := models.CreateUpdateJobResponse{
+		ID:      jobID,
+		Message: "Rebalancing job completed successfully",
+		Job:     job,
+	}
+	helpers.WriteJSON(w, http.StatusOK, completeJobResp)
+}
+
+// recommend fills the defaults of the request, validates it and computes the recommendation. It writes the error response when it fails
+func (h *handler) recommend(w http.ResponseWriter, recommendationReq *models.RecommendationRequest) (*models.Recommendation, bool) {
+	if recommendationReq.CellSize == 0 {
+		recommendationReq.CellSize = defaultCellSize
+	}
+	if recommendationReq.WindowHours == 0 {
+		recommendationReq.WindowHours = defaultWindowHours
+	}
+	if recommendationReq.MaxMoves == 0 {
+		recommendationReq.MaxMoves = defaultMaxMoves
+	}
+	if err := h.validator.Struct(recommendationReq); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return nil, false
+	}
+
+	bikes, err := h.RebalancingRepo.ListAvailableBikePositions()
+	if err != nil {
+		log.Printf("Error getting available bikes: %v", err)
+		http.Error(w, "Error getting available bikes", http.StatusInternalServerError)
+		return nil, false
+	}
+	demandSince := time.Now().UTC().Add(-time.Duration(recommendationReq.WindowHours) * time.Hour)
+	demand, err := h.RebalancingRepo.ListRentalStartPoints(demandSince)
+	if err != nil {
+		log.Printf("Error getting rental demand: %v", err)
+		http.Error(w, "Error getting rental demand", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	cells, moves := planner.Plan(bikes, demand, recommendationReq.CellSize, recommendationReq.MaxMoves)
+	return &models.Recommendation{
+		CellSize:    recommendationReq.CellSize,
+		DemandSince: demandSince,
+		Cells:       cells,
+		Moves:       moves,
+	}, true
+}
+
+// parseFloatQuery reads an optional float query parameter, 0 when it is missing
+func parseFloatQuery(req *http.Request, name string) (float64, error) {
+	raw := req.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't read %s: %v", name, err)
+	}
+	return value, nil
+}
+
+// parseIntQuery reads an optional integer query parameter, 0 when it is missing
+func parseIntQuery(req *http.Request, name string) (int, error) {
+	raw := req.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't read %s: %v", name, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/rebalancing/models"
+	"bikesRentalAPI/internal/rebalancing/repository"
+	"bikesRentalAPI/internal/rebalancing/repository/mocks"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRebalancingRepo := mocks.NewMockRebalancingRepository(mockCtrl)
+	// Two bikes parked in a cell while every recent rental starts about 1.5km away
+	bikes := []models.BikePosition{
+		{BikeID: 1, Latitude: 51.505, Longitude: -0.165},
+		{BikeID: 2, Latitude: 51.505, Longitude: -0.165},
+	}
+	demand := []models.DemandPoint{{Latitude: 51.505, Longitude: -0.145}, {Latitude: 51.505, Longitude: -0.145}}
+
+	testCases := []struct {
+		name             string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - the suggested moves are saved as a job",
+			body: `{"max_moves":1}`,
+			mockCalls: func() {
+				mockRebalancingRepo.EXPECT().ListAvailableBikePositions().Return(bikes, nil)
+				mockRebalancingRepo.EXPECT().ListRentalStartPoints(gomock.Any()).Return(demand, nil)
+				mockRebalancingRepo.EXPECT().CreateJob(0.01, gomock.Any(), gomock.Len(1)).Return(int64(1), nil)
+				mockRebalancingRepo.EXPECT().GetJob(int64(1)).Return(&models.Job{ID: 1, Status: "pending"}, nil)
+			},
+			expectedHttpCode: http.StatusCreated,
+		},
+		{
+			name: "Failure - the fleet is already balanced",
+			body: ``,
+			mockCalls: func() {
+				mockRebalancingRepo.EXPECT().ListAvailableBikePositions().Return(bikes, nil)
+				mockRebalancingRepo.EXPECT().ListRentalStartPoints(gomock.Any()).Return(nil, nil)
+			},
+			expectedHttpCode: http.StatusConflict,
+		},
+		{
+			name:             "Failure - the cells are too large",
+			body:             `{"cell_size":2}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin creating a rebalancing job
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodPost, "/admin/rebalancing/jobs", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockRebalancingRepo).CreateJob(rec, req)
+			// THEN: a job is only created when bikes have to move
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestCompleteJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRebalancingRepo := mocks.NewMockRebalancingRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		jobID            string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name:  "Success - the job is done",
+			jobID: "1",
+			mockCalls: func() {
+				mockRebalancingRepo.EXPECT().CompleteJob(int64(1)).Return(nil)
+				mockRebalancingRepo.EXPECT().GetJob(int64(1)).Return(&models.Job{ID: 1, Status: "done"}, nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:  "Failure - the job is not pending",
+			jobID: "1",
+			mockCalls: func() {
+				mockRebalancingRepo.EXPECT().CompleteJob(int64(1)).Return(repository.ErrJobNotPending)
+			},
+			expectedHttpCode: http.StatusConflict,
+		},
+		{
+			name:             "Failure - the job id is not a number",
+			jobID:            "first",
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin completing a rebalancing job
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodPost, "/admin/rebalancing/jobs/"+tc.jobID+"/complete", nil)
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Post("/admin/rebalancing/jobs/{job_id}/complete", New(mockRebalancingRepo).CompleteJob)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: only pending jobs are completed
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestGetJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRebalancingRepo := mocks.NewMockRebalancingRepository(mockCtrl)
+
+	// GIVEN: a job that does not exist
+	mockRebalancingRepo.EXPECT().GetJob(int64(7)).Return(nil, sql.ErrNoRows)
+	req := httptest.NewRequest(http.MethodGet, "/admin/rebalancing/jobs/7", nil)
+	rec := httptest.NewRecorder()
+	router := chi.NewRouter()
+	router.Get("/admin/rebalancing/jobs/{job_id}", New(mockRebalancingRepo).GetJob)
+	// WHEN: the request is made
+	router.ServeHTTP(rec, req)
+	// THEN: the job is not found
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+const (
+	// JobStatusPending is the status of a rebalancing job waiting for the bikes to be moved
+	JobStatusPending = "pending"
+	// JobStatusDone is the status of a rebalancing job whose bikes have been moved
+	JobStatusDone = "done"
+)
+
+// BikePosition is the location of an available bike
+type BikePosition struct {
+	BikeID    int64   `json:"bike_id"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+} // @name BikePosition
+
+// DemandPoint is the start location of a recent rental
+type DemandPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+} // @name DemandPoint
+
+// Cell summarizes the supply and demand of bikes in a grid cell
+type Cell struct {
+	// The id of the cell, "<row>:<col>" of a grid of CellSize degrees
+	ID string `json:"id"`
+	// The latitude of the center of the cell
+	Latitude float64 `json:"latitude"`
+	// The longitude of the center of the cell
+	Longitude float64 `json:"longitude"`
+	// The number of available bikes in the cell
+	Supply int `json:"supply"`
+	// The number of rentals started in the cell during the demand window
+	Demand int `json:"demand"`
+	// The number of bikes the cell should hold according to its share of the demand
+	Target int `json:"target"`
+} // @name Cell
+
+// Move is a suggestion to move a bike from a cell to another
+type Move struct {
+	ID            int64   `json:"id,omitempty"`
+	BikeID        int64   `json:"bike_id"`
+	FromCell      string  `json:"from_cell"`
+	ToCell        string  `json:"to_cell"`
+	FromLatitude  float64 `json:"from_latitude"`
+	FromLongitude float64 `json:"from_longitude"`
+	ToLatitude    float64 `json:"to_latitude"`
+	ToLongitude   float64 `json:"to_longitude"`
+	// The distance of the move in kilometers
+	Distance float64 `json:"distance"`
+	// Whether the bike was moved when the job was completed
+	Moved bool `json:"moved"`
+} // @name Move
+
+// RecommendationRequest contains the parameters used to compute rebalancing recommendations
+type RecommendationRequest struct {
+	// The size of the grid cells in degrees
+	CellSize float64 `json:"cell_size" validate:"gt=0,lte=1"`
+	// The number of hours of rental history used to measure demand
+	WindowHours int `json:"window_hours" validate:"gt=0,lte=2160"`
+	// The maximum number of moves to suggest
+	MaxMoves int `json:"max_moves" validate:"gt=0,lte=1000"`
+} // @name RecommendationRequest
+
+// Recommendation contains the cells of the fleet and the suggested moves
+type Recommendation struct {
+	CellSize    float64   `json:"cell_size"`
+	DemandSince time.Time `json:"demand_since"`
+	Cells       []*Cell   `json:"cells"`
+	Moves       []*Move   `json:"moves"`
+} // @name Recommendation
+
+// Job is a set of moves to be performed by the operations team
+type Job struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	CellSize    float64    `json:"cell_size"`
+	DemandSince time.Time  `json:"demand_since"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Moves       []*Move    `json:"moves"`
+} // @name Job
+
+// CreateUpdateJobResponse represents the response of creating or completing a rebalancing job
+type CreateUpdateJobResponse struct {
+	ID      int64  `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+	Job     *Job   `json:"job,omitempty"`
+} // @name CreateUpdateJobResponse
@@ -0,0 +1,128 @@
+package planner
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/rebalancing/models"
+	"fmt"
+	"math"
+	"sort"
+)
+
+type cellKey struct {
+	row, col int64
+}
+
+type cellState struct {
+	key    cellKey
+	cell   *models.Cell
+	bikes  []models.BikePosition
+	excess int
+}
+
+// Plan groups the available bikes and the rental demand in a grid of cellSize degrees and suggests up to maxMoves moves
+// from the cells holding more bikes than their share of the demand to the cells holding fewer.
+// Each cell target is the fleet size multiplied by the share of the demand started in the cell
+func Plan(bikes []models.BikePosition, demand []models.DemandPoint, cellSize float64, maxMoves int) ([]*models.Cell, []*models.Move) {
+	cells := make(map[cellKey]*cellState)
+	getCell := func(lat, lon float64) *cellState {
+		row, col := helpers.GetGridCell(lat, lon, cellSize)
+		key := cellKey{row, col}
+		state, ok := cells[key]
+		if !ok {
+			centerLat, centerLon := helpers.GetGridCellCenter(row, col, cellSize)
+			state = &cellState{
+				key:  key,
+				cell: &models.Cell{ID: fmt.Sprintf("%d:%d", row, col), Latitude: centerLat, Longitude: centerLon},
+			}
+			cells[key] = state
+		}
+		return state
+	}
+
+	for _, bike := range bikes {
+		state := getCell(bike.Latitude, bike.Longitude)
+		state.bikes = append(state.bikes, bike)
+		state.cell.Supply++
+	}
+	for _, point := range demand {
+		getCell(point.Latitude, point.Longitude).cell.Demand++
+	}
+
+	// Cells are sorted by id so the plan is deterministic
+	states := make([]*cellState, 0, len(cells))
+	for _, state := range cells {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].key.row != states[j].key.row {
+			return states[i].key.row < states[j].key.row
+		}
+		return states[i].key.col < states[j].key.col
+	})
+	summary := make([]*models.Cell, 0, len(states))
+	for _, state := range states {
+		summary = append(summary, state.cell)
+	}
+
+	moves := make([]*models.Move, 0)
+	if len(demand) == 0 || len(bikes) == 0 {
+		for _, state := range states {
+			state.cell.Target = state.cell.Supply
+		}
+		return summary, moves
+	}
+
+	var surplus, deficit []*cellState
+	for _, state := range states {
+		state.cell.Target = int(math.Round(float64(len(bikes)) * float64(state.cell.Demand) / float64(len(demand))))
+		state.excess = state.cell.Supply - state.cell.Target
+		switch {
+		case state.excess > 0:
+			surplus = append(surplus, state)
+		case state.excess < 0:
+			deficit = append(deficit, state)
+		}
+	}
+	// The cells missing the most bikes are served first
+	sort.SliceStable(deficit, func(i, j int) bool { return deficit[i].excess < deficit[j].excess })
+
+	for _, to := range deficit {
+		for to.excess < 0 && len(moves) < maxMoves {
+			from := nearest(to, surplus)
+			if from == nil {
+				return summary, moves
+			}
+			bike := from.bikes[len(from.bikes)-1]
+			from.bikes = from.bikes[:len(from.bikes)-1]
+			from.excess--
+			to.excess++
+			moves = append(moves, &models.Move{
+				BikeID:        bike.BikeID,
+				FromCell:      from.cell.ID,
+				ToCell:        to.cell.ID,
+				FromLatitude:  bike.Latitude,
+				FromLongitude: bike.Longitude,
+				ToLatitude:    to.cell.Latitude,
+				ToLongitude:   to.cell.Longitude,
+				Distance:      helpers.GetDistanceKm(bike.Latitude, bike.Longitude, to.cell.Latitude, to.cell.Longitude),
+			})
+		}
+	}
+	return summary, moves
+}
+
+// nearest returns the closest cell still holding more bikes than its target
+func nearest(to *cellState, surplus []*cellState) *cellState {
+	var closest *cellState
+	closestDistance := math.MaxFloat64
+	for _, from := range surplus {
+		if from.excess <= 0 || len(from.bikes) == 0 {
+			continue
+		}
+		distance := helpers.GetDistanceKm(from.cell.Latitude, from.cell.Longitude, to.cell.Latitude, to.cell.Longitude)
+		if distance < closestDistance {
+			closest, closestDistance = from, distance
+		}
+	}
+	return closest
+}
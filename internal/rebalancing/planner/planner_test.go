@@ -0,0 +1,62 @@
+package planner
+
+import (
+	"bikesRentalAPI/internal/rebalancing/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCellSize = 0.01
+
+func TestPlan(t *testing.T) {
+	// Two cells about 1.5km apart: bikes pile up in the first one while riders start in the second one
+	crowded := models.DemandPoint{Latitude: 51.505, Longitude: -0.165}
+	empty := models.DemandPoint{Latitude: 51.505, Longitude: -0.145}
+
+	t.Run("Success - bikes are moved from crowded cells towards the demand", func(t *testing.T) {
+		// GIVEN: four bikes in the crowded cell and all the demand in the empty cell
+		bikes := []models.BikePosition{
+			{BikeID: 1, Latitude: crowded.Latitude, Longitude: crowded.Longitude},
+			{BikeID: 2, Latitude: crowded.Latitude, Longitude: crowded.Longitude},
+			{BikeID: 3, Latitude: crowded.Latitude, Longitude: crowded.Longitude},
+			{BikeID: 4, Latitude: crowded.Latitude, Longitude: crowded.Longitude},
+		}
+		demand := []models.DemandPoint{crowded, empty, empty, empty}
+		// WHEN: the plan is computed
+		cells, moves := Plan(bikes, demand, testCellSize, 10)
+		// THEN: both cells are summarized with their target
+		assert.Len(t, cells, 2)
+		assert.Equal(t, 1, cells[0].Target)
+		assert.Equal(t, 3, cells[1].Target)
+		// THEN: three bikes are moved to the empty cell
+		assert.Len(t, moves, 3)
+		for _, move := range moves {
+			assert.Equal(t, cells[0].ID, move.FromCell)
+			assert.Equal(t, cells[1].ID, move.ToCell)
+			assert.Equal(t, cells[1].Latitude, move.ToLatitude)
+			assert.Greater(t, move.Distance, 0.0)
+		}
+	})
+	t.Run("Success - the number of moves is capped", func(t *testing.T) {
+		// GIVEN: the same fleet with a cap of one move
+		bikes := []models.BikePosition{
+			{BikeID: 1, Latitude: crowded.Latitude, Longitude: crowded.Longitude},
+			{BikeID: 2, Latitude: crowded.Latitude, Longitude: crowded.Longitude},
+		}
+		demand := []models.DemandPoint{empty, empty}
+		// WHEN: the plan is computed
+		_, moves := Plan(bikes, demand, testCellSize, 1)
+		// THEN: a single move is suggested
+		assert.Len(t, moves, 1)
+	})
+	t.Run("Success - no demand means no moves", func(t *testing.T) {
+		// GIVEN: a fleet without recent rentals
+		bikes := []models.BikePosition{{BikeID: 1, Latitude: crowded.Latitude, Longitude: crowded.Longitude}}
+		// WHEN: the plan is computed
+		cells, moves := Plan(bikes, nil, testCellSize, 10)
+		// THEN: the fleet stays where it is
+		assert.Len(t, moves, 0)
+		assert.Equal(t, 1, cells[0].Target)
+	})
+}
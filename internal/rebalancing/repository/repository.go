@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/rebalancing/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrJobNotPending is returned when completing a rebalancing job that is already done
+var ErrJobNotPending = errors.New("rebalancing job is not pending")
+
+type RebalancingRepository interface {
+	ListAvailableBikePositions() ([]models.BikePosition, error)
+	ListRentalStartPoints(since time.Time) ([]models.DemandPoint, error)
+	CreateJob(cellSize float64, demandSince time.Time, moves []*models.Move) (int64, error)
+	GetJob(jobID int64) (*models.Job, error)
+	CompleteJob(jobID int64) error
+}
+
+type rebalancingRepository struct {
+	db database.Database
+}
+
+// New initializes a new empty rebalancing repository
+func New(db database.Database) RebalancingRepository {
+	return &rebalancingRepository{db}
+}
+
+// ListAvailableBikePositions retrieves the location of every available bike that is not docked at a station.
+// Docked bikes are already where riders look for them and can't be moved without undocking them
+func (r *rebalancingRepository) ListAvailableBikePositions() ([]models.BikePosition, error) {
+	query := "SELECT id, latitude, longitude FROM bikes WHERE is_available = ? AND station_id IS NULL AND deleted_at IS NULL ORDER BY id"
+	rows, err := r.db.Query(query, true)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := make([]models.BikePosition, 0)
+	for rows.Next() {
+		var position models.BikePosition
+		if err := rows.Scan(&position.BikeID, &position.Latitude, &position.Longitude); err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, rows.Err()
+}
+
+// ListRentalStartPoints retrieves the start location of the rentals started since the given time
+func (r *rebalancingRepository) ListRentalStartPoints(since time.Time) ([]models.DemandPoint, error) {
+	query := "SELECT start_latitude, start_longitude FROM rentals WHERE start_time >= ?"
+	rows, err := r.db.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]models.DemandPoint, 0)
+	for rows.Next() {
+		var point models.DemandPoint
+		if err := rows.Scan(&point.Latitude, &point.Longitude); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// CreateJob stores a pending rebalancing job with its moves
+func (r *rebalancingRepository) CreateJob(cellSize float64, demandSince time.Time, moves []*models.Move) (int64, error) {
+	var id int64
+	err := r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		query := "INSERT INTO rebalancing_jobs (status, cell_size, demand_since) VALUES (?, ?, ?)"
+		result, err := tx.Exec(query, models.JobStatusPending, cellSize, demandSince)
+		if err != nil {
+			return fmt.Errorf("failed to insert rebalancing job: %v", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %v", err)
+		}
+
+		query = "INSERT INTO rebalancing_moves (job_id, bike_id, from_cell, to_cell, from_latitude, from_longitude, to_latitude, to_longitude) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+		for _, move := range moves {
+			_, err := tx.Exec(query, id, move.BikeID, move.FromCell, move.ToCell, move.FromLatitude, move.FromLongitude, move.ToLatitude, move.ToLongitude)
+			if err != nil {
+				return fmt.Errorf("failed to insert rebalancing move: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetJob retrieves a rebalancing job with its moves
+func (r *rebalancingRepository) GetJob(jobID int64) (*models.Job, error) {
+	var job models.Job
+	query := "SELECT id, status, cell_size, demand_since, completed_at, created_at, updated_at FROM rebalancing_jobs WHERE id = ?"
+	err := r.db.QueryRow(query, jobID).Scan(&job.ID, &job.Status, &job.CellSize, &job.DemandSince, &job.CompletedAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT id, bike_id, from_cell, to_cell, from_latitude, from_longitude, to_latitude, to_longitude, moved FROM rebalancing_moves WHERE job_id = ? ORDER BY id"
+	rows, err := r.db.Query(query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	job.Moves = make([]*models.Move, 0)
+	for rows.Next() {
+		var move models.Move
+		if err := rows.Scan(&move.ID, &move.BikeID, &move.FromCell, &move.ToCell, &move.FromLatitude, &move.FromLongitude, &move.ToLatitude, &move.ToLongitude, &move.Moved); err != nil {
+			return nil, err
+		}
+		move.Distance = helpers.GetDistanceKm(move.FromLatitude, move.FromLongitude, move.ToLatitude, move.ToLongitude)
+		job.Moves = append(job.Moves, &move)
+	}
+	return &job, rows.Err()
+}
+
+// CompleteJob marks a pending job as done and moves its bikes to the center of their destination cell.
+// Bikes rented or docked since the job was created are left where they are
+func (r *rebalancingRepository) CompleteJob(jobID int64) error {
+	now := time.Now().UTC()
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		query := "UPDATE rebalancing_jobs SET status = ?, completed_at = ?, updated_at = ? WHERE id = ? AND status = ?"
+		result, err := tx.Exec(query, models.JobStatusDone, now, now, jobID, models.JobStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to update rebalancing job: %v", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %v", err)
+		}
+		if affected == 0 {
+			return ErrJobNotPending
+		}
+
+		query = `UPDATE bikes SET latitude = (SELECT to_latitude FROM rebalancing_moves WHERE rebalancing_moves.id = ?),
+			longitude = (SELECT to_longitude FROM rebalancing_moves WHERE rebalancing_moves.id = ?), updated_at = ?
+			WHERE id = ? AND is_available = ? AND station_id IS NULL`
+		moveIDs, err := r.listMoves(tx, jobID)
+		if err != nil {
+			return err
+		}
+		for moveID, bikeID := range moveIDs {
+			result, err := tx.Exec(query, moveID, moveID, now, bikeID, true)
+			if err != nil {
+				return fmt.Errorf("failed to move bike %d: %v", bikeID, err)
+			}
+			moved, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %v", err)
+			}
+			if _, err := tx.Exec("UPDATE rebalancing_moves SET moved = ? WHERE id = ?", moved > 0, moveID); err != nil {
+				return fmt.Errorf("failed to update rebalancing move: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// listMoves returns the bike id of every move of a job indexed by move id
+func (r *rebalancingRepository) listMoves(q database.Querier, jobID int64) (map[int64]int64, error) {
+	rows, err := q.Query("SELECT id, bike_id FROM rebalancing_moves WHERE job_id = ?", jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rebalancing moves: %v", err)
+	}
+	defer rows.Close()
+
+	moves := make(map[int64]int64)
+	for rows.Next() {
+		var moveID, bikeID int64
+		if err := rows.Scan(&moveID, &bikeID); err != nil {
+			return nil, err
+		}
+		moves[moveID] = bikeID
+	}
+	return moves, rows.Err()
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/rebalancing/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockedBikesRebalancing(t *testing.T) {
+	// GIVEN: an available bike, a docked bike, a rented bike and a deleted bike
+	t.Setenv("DB_URL", "file:rebalancing_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	exec := func(query string, args ...interface{}) {
+		_, err := db.Exec(query, args...)
+		require.NoError(t, err)
+	}
+	exec("INSERT INTO stations (id, name, latitude, longitude, capacity) VALUES (1, 'Sol', 40.4, -3.7, 10)")
+	exec(`INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute, station_id) VALUES
+		(1, 1, 40.4, -3.7, 0.1, NULL), (2, 1, 40.4, -3.7, 0.1, 1), (3, 0, 40.4, -3.7, 0.1, NULL), (4, 1, 40.4, -3.7, 0.1, NULL)`)
+	exec("UPDATE bikes SET deleted_at = ? WHERE id = 4", time.Now())
+	repo := New(db)
+
+	t.Run("Success - only undocked available bikes can be rebalanced", func(t *testing.T) {
+		positions, err := repo.ListAvailableBikePositions()
+		require.NoError(t, err)
+		assert.Equal(t, []models.BikePosition{{BikeID: 1, Latitude: 40.4, Longitude: -3.7}}, positions)
+	})
+	t.Run("Success - bikes docked since the job was created are left at their station", func(t *testing.T) {
+		// GIVEN: a job moving the first two bikes
+		moves := []*models.Move{
+			{BikeID: 1, FromCell: "a", ToCell: "b", FromLatitude: 40.4, FromLongitude: -3.7, ToLatitude: 40.5, ToLongitude: -3.6},
+			{BikeID: 2, FromCell: "a", ToCell: "b", FromLatitude: 40.4, FromLongitude: -3.7, ToLatitude: 40.5, ToLongitude: -3.6},
+		}
+		jobID, err := repo.CreateJob(0.01, time.Now().Add(-time.Hour), moves)
+		require.NoError(t, err)
+
+		// WHEN: the job is completed
+		require.NoError(t, repo.CompleteJob(jobID))
+
+		// THEN: the undocked bike is moved and the docked one stays at its station
+		job, err := repo.GetJob(jobID)
+		require.NoError(t, err)
+		assert.Equal(t, models.JobStatusDone, job.Status)
+		assert.True(t, job.Moves[0].Moved)
+		assert.False(t, job.Moves[1].Moved)
+		var latitude float64
+		var stationID *int64
+		require.NoError(t, db.QueryRow("SELECT latitude, station_id FROM bikes WHERE id = 2").Scan(&latitude, &stationID))
+		assert.Equal(t, 40.4, latitude)
+		require.NotNil(t, stationID)
+		assert.Equal(t, int64(1), *stationID)
+		require.NoError(t, db.QueryRow("SELECT latitude FROM bikes WHERE id = 1").Scan(&latitude))
+		assert.Equal(t, 40.5, latitude)
+
+		// THEN: the job can't be completed twice
+		assert.ErrorIs(t, repo.CompleteJob(jobID), ErrJobNotPending)
+	})
+}
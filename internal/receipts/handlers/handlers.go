@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/receipts/models"
+	"bikesRentalAPI/internal/receipts/render"
+	"bikesRentalAPI/internal/receipts/repository"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	formatJSON = "json"
+	formatHTML = "html"
+	formatPDF  = "pdf"
+
+	contentTypeHTML = "text/html; charset=utf-8"
+	contentTypePDF  = "application/pdf"
+)
+
+// Handler is the interface for receipt handlers
+type Handler interface {
+	GetReceipt(w http.ResponseWriter, req *http.Request)          // Get the receipt of a rental of the logged in user
+	GetMonthlyStatement(w http.ResponseWriter, req *http.Request) // Get the receipts of the logged in user for a month
+	GetReceiptDetails(w http.ResponseWriter, req *http.Request)   // Get the receipt of any rental
+}
+
+type handler struct {
+	ReceiptRepo repository.ReceiptRepository
+	issuer      string
+}
+
+// New returns a new receipt handler. The issuer is the name printed on the HTML and PDF receipts
+func New(receiptRepository repository.ReceiptRepository, issuer string) Handler {
+	return &handler{
+		ReceiptRepo: receiptRepository,
+		issuer:      issuer,
+	}
+}
+
+// GetReceipt returns the receipt of a rental of the logged in user.
+// The format is read from the URL extension (/receipt.pdf) or the Accept header, JSON is the default
+func (h *handler) GetReceipt(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	invoice, ok := h.getInvoiceFromURL(w, req)
+	if !ok {
+		return
+	}
+	// Receipts of other users are reported as missing
+	if invoice.UserID != userID {
+		http.Error(w, "Receipt not found", http.StatusNotFound)
+		return
+	}
+	h.writeReceipt(w, req, invoice)
+}
+
+// GetMonthlyStatement returns the receipts issued to the logged in user in the month given as YYYY-MM, the current month by default
+func (h *handler) GetMonthlyStatement(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	month := time.Now().UTC()
+	if monthStr := req.URL.Query().Get("month"); monthStr != "" {
+		month, err = time.Parse("2006-01", monthStr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("couldn't read month %s, use YYYY-MM", monthStr), http.StatusBadRequest)
+			return
+		}
+	}
+	statement, err := h.ReceiptRepo.GetStatement(userID, month)
+	if err != nil {
+		log.Printf("Error getting statement: %v", err)
+		http.Error(w, "Error getting statement", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, statement)
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// GetReceiptDetails returns the receipt of any rental in the requested format
+func (h *handler) GetReceiptDetails(w http.ResponseWriter, req *http.Request) {
+	invoice, ok := h.getInvoiceFromURL(w, req)
+	if !ok {
+		return
+	}
+	h.writeReceipt(w, req, invoice)
+}
+
+// getInvoiceFromURL reads the 'rental_id' URL parameter and retrieves the invoice of the rental. It writes the error response when it fails
+func (h *handler) getInvoiceFromURL(w http.ResponseWriter, req *http.Request) (*models.Invoice, bool) {
+	rentalIDStr := chi.URLParam(req, "rental_id")
+	rentalID, err := strconv.ParseInt(rentalIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", rentalIDStr, err), http.StatusBadRequest)
+		return nil, false
+	}
+	invoice, err := h.ReceiptRepo.GetInvoiceByRentalID(rentalID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Receipt not found", http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("Error getting receipt: %v", err)
+		http.Error(w, "Error getting receipt", http.StatusInternalServerError)
+		return nil, false
+	}
+	return invoice, true
+}
+
+// writeReceipt writes the receipt in the requested format
+func (h *handler) writeReceipt(w http.ResponseWriter, req *http.Request, invoice *models.Invoice) {
+	format, err := receiptFormat(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if format == formatJSON {
+		helpers.WriteJSON(w, http.StatusOK, invoice)
+		return
+	}
+
+	// Rendered in memory so a rendering error can still be reported
+	var body bytes.Buffer
+	contentType := contentTypeHTML
+	if format == formatPDF {
+		contentType = contentTypePDF
+		err = render.PDF(&body, invoice, h.issuer)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", invoice.Number+".pdf"))
+	} else {
+		err = render.HTML(&body, invoice, h.issuer)
+	}
+	if err != nil {
+		log.Printf("Error rendering receipt: %v", err)
+		http.Error(w, "Error rendering receipt", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// receiptFormat returns the requested receipt format
+func receiptFormat(req *http.Request) (string, error) {
+	format, _ := req.Context().Value(middleware.URLFormatCtxKey).(string)
+	if format == "" {
+		accept := req.Header.Get("Accept")
+		switch {
+		case strings.Contains(accept, contentTypePDF):
+			format = formatPDF
+		case strings.Contains(accept, "text/html"):
+			format = formatHTML
+		default:
+			format = formatJSON
+		}
+	}
+	switch format {
+	case formatJSON, formatHTML, formatPDF:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported receipt format %q, use %s, %s or %s", format, formatJSON, formatHTML, formatPDF)
+}
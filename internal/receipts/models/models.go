@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Invoice is the immutable receipt issued when a rental ends. Invoice numbers are sequential and never reused
+type Invoice struct {
+	ID       int64  `json:"id"`
+	Number   string `json:"number"`
+	Sequence int64  `json:"-"`
+	RentalID int64  `json:"rental_id"`
+	UserID   int64  `json:"user_id"`
+	Currency string `json:"currency"`
+	// The tax rate included in the prices, e.g. 0.21
+	TaxRate  float64        `json:"tax_rate"`
+	Subtotal float64        `json:"subtotal"`
+	Tax      float64        `json:"tax"`
+	Total    float64        `json:"total"`
+	IssuedAt time.Time      `json:"issued_at"`
+	Lines    []*InvoiceLine `json:"lines"`
+} // @name Invoice
+
+// InvoiceLine is an item of an invoice, taken from the cost breakdown of the rental. Amounts include taxes
+type InvoiceLine struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+} // @name InvoiceLine
+
+// ReceiptSummary is an invoice listed in a statement
+type ReceiptSummary struct {
+	Number   string    `json:"number"`
+	RentalID int64     `json:"rental_id"`
+	IssuedAt time.Time `json:"issued_at"`
+	Subtotal float64   `json:"subtotal"`
+	Tax      float64   `json:"tax"`
+	Total    float64   `json:"total"`
+} // @name ReceiptSummary
+
+// Statement aggregates the invoices issued to a user in a month
+type Statement struct {
+	UserID int64 `json:"user_id"`
+	// The month of the statement, formatted as YYYY-MM
+	Month    string            `json:"month"`
+	Currency string            `json:"currency"`
+	Count    int               `json:"count"`
+	Subtotal float64           `json:"subtotal"`
+	Tax      float64           `json:"tax"`
+	Total    float64           `json:"total"`
+	Receipts []*ReceiptSummary `json:"receipts"`
+} // @name Statement
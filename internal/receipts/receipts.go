@@ -0,0 +1,46 @@
+package receipts
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+const (
+	// defaultTaxRate is the tax rate included in the rental prices
+	defaultTaxRate = 0.21
+	// defaultCurrency is the currency of the rental prices
+	defaultCurrency = "EUR"
+	// defaultIssuer is the name printed on the receipts
+	defaultIssuer = "bikesRental API"
+)
+
+// Config holds the values used to issue receipts
+type Config struct {
+	// TaxRate is the tax rate included in the rental prices, e.g. 0.21
+	TaxRate float64
+	// Currency is the ISO 4217 code of the rental prices
+	Currency string
+	// Issuer is the name printed on the receipts
+	Issuer string
+}
+
+// ConfigFromEnv reads the receipt configuration from RECEIPT_TAX_RATE, RECEIPT_CURRENCY and RECEIPT_ISSUER, falling back to defaults
+func ConfigFromEnv() Config {
+	config := Config{TaxRate: defaultTaxRate, Currency: defaultCurrency, Issuer: defaultIssuer}
+	if value := os.Getenv("RECEIPT_TAX_RATE"); value != "" {
+		taxRate, err := strconv.ParseFloat(value, 64)
+		if err != nil || taxRate < 0 || taxRate >= 1 {
+			log.Printf("invalid RECEIPT_TAX_RATE %q. Set to %v as default", value, defaultTaxRate)
+		} else {
+			config.TaxRate = taxRate
+		}
+	}
+	if value := os.Getenv("RECEIPT_CURRENCY"); value != "" {
+		config.Currency = value
+	}
+	if value := os.Getenv("RECEIPT_ISSUER"); value != "" {
+		config.Issuer = value
+	}
+	return config
+}
@@ -0,0 +1,156 @@
+package render
+
+import (
+	"bikesRentalAPI/internal/receipts/models"
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+var receiptTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"money":   func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	"percent": func(rate float64) string { return fmt.Sprintf("%.0f%%", rate*100) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.Invoice.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; }
+td.amount, th.amount { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Issuer}}</h1>
+<h2>Receipt {{.Invoice.Number}}</h2>
+<p>Issued on {{.Invoice.IssuedAt.Format "2006-01-02 15:04 UTC"}} for rental #{{.Invoice.RentalID}}</p>
+<table>
+<thead><tr><th>Description</th><th class="amount">Quantity</th><th class="amount">Unit price</th><th class="amount">Amount</th></tr></thead>
+<tbody>
+{{- range .Invoice.Lines}}
+<tr><td>{{.Description}}</td><td class="amount">{{printf "%.2f" .Quantity}}</td><td class="amount">{{money .UnitPrice}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+<tr><td colspan="3">Subtotal</td><td class="amount">{{money .Invoice.Subtotal}} {{.Invoice.Currency}}</td></tr>
+<tr><td colspan="3">Tax ({{percent .Invoice.TaxRate}})</td><td class="amount">{{money .Invoice.Tax}} {{.Invoice.Currency}}</td></tr>
+<tr><th colspan="3">Total</th><th class="amount">{{money .Invoice.Total}} {{.Invoice.Currency}}</th></tr>
+</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// HTML writes the receipt of an invoice as an HTML page
+func HTML(w io.Writer, invoice *models.Invoice, issuer string) error {
+	return receiptTemplate.Execute(w, struct {
+		Invoice *models.Invoice
+		Issuer  string
+	}{invoice, issuer})
+}
+
+// PDF writes the receipt of an invoice as a single page PDF document using the standard Helvetica fonts
+func PDF(w io.Writer, invoice *models.Invoice, issuer string) error {
+	page := &pdfPage{y: 790}
+	page.text(50, 16, true, issuer)
+	page.text(50, 13, true, "Receipt "+invoice.Number)
+	page.text(50, 10, false, fmt.Sprintf("Issued on %s for rental #%d", invoice.IssuedAt.Format("2006-01-02 15:04 UTC"), invoice.RentalID))
+	page.skip(10)
+	page.row(true, "Description", "Quantity", "Unit price", "Amount")
+	for _, line := range invoice.Lines {
+		page.row(false, line.Description, fmt.Sprintf("%.2f", line.Quantity), fmt.Sprintf("%.2f", line.UnitPrice), fmt.Sprintf("%.2f", line.Amount))
+	}
+	page.skip(6)
+	page.row(false, "Subtotal", "", "", fmt.Sprintf("%.2f %s", invoice.Subtotal, invoice.Currency))
+	page.row(false, fmt.Sprintf("Tax (%.0f%%)", invoice.TaxRate*100), "", "", fmt.Sprintf("%.2f %s", invoice.Tax, invoice.Currency))
+	page.row(true, "Total", "", "", fmt.Sprintf("%.2f %s", invoice.Total, invoice.Currency))
+	return page.write(w)
+}
+
+// pdfPage builds the content stream of an A4 page, top to bottom
+type pdfPage struct {
+	content bytes.Buffer
+	y       float64
+}
+
+// text writes a line of text at the current position and moves to the next line
+func (p *pdfPage) text(x float64, size float64, bold bool, value string) {
+	p.at(x, size, bold, value)
+	p.skip(size + 6)
+}
+
+// row writes a table row with a description column and three right aligned columns
+func (p *pdfPage) row(bold bool, description, quantity, unitPrice, amount string) {
+	p.at(50, 10, bold, description)
+	p.atRight(380, 10, bold, quantity)
+	p.atRight(460, 10, bold, unitPrice)
+	p.atRight(545, 10, bold, amount)
+	p.skip(16)
+}
+
+func (p *pdfPage) skip(points float64) {
+	p.y -= points
+}
+
+func (p *pdfPage) at(x float64, size float64, bold bool, value string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, p.y, escapePDF(value))
+}
+
+// atRight approximates right alignment with the average width of a Helvetica character
+func (p *pdfPage) atRight(right float64, size float64, bold bool, value string) {
+	p.at(right-float64(len(value))*size*0.55, size, bold, value)
+}
+
+// write writes the document with the page, its fonts and the cross-reference table
+func (p *pdfPage) write(w io.Writer) error {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 4 0 R /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = document.Len()
+		fmt.Fprintf(&document, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(document.Bytes())
+	return err
+}
+
+// escapePDF escapes a PDF string literal. Characters outside of ASCII are replaced as the standard fonts can't render them
+func escapePDF(value string) string {
+	var escaped strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 126:
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bikesRentalAPI/internal/receipts/models"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testInvoice() *models.Invoice {
+	return &models.Invoice{
+		Number:   "INV-000042",
+		RentalID: 7,
+		Currency: "EUR",
+		TaxRate:  0.21,
+		Subtotal: 1.65,
+		Tax:      0.35,
+		Total:    2,
+		IssuedAt: time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC),
+		Lines: []*models.InvoiceLine{
+			{Code: "ride_time", Description: "Ride time (10.0 min) <b>", Quantity: 10, UnitPrice: 0.2, Amount: 2},
+		},
+	}
+}
+
+func TestHTML(t *testing.T) {
+	t.Run("Success - the receipt lists the lines and totals with escaped values", func(t *testing.T) {
+		// GIVEN: an invoice
+		invoice := testInvoice()
+		var out bytes.Buffer
+		// WHEN: it is rendered as HTML
+		err := HTML(&out, invoice, "Bikes & Co")
+		// THEN: the page contains the invoice values
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "Receipt INV-000042")
+		assert.Contains(t, out.String(), "Bikes &amp; Co")
+		assert.Contains(t, out.String(), "Ride time (10.0 min) &lt;b&gt;")
+		assert.Contains(t, out.String(), "2.00 EUR")
+		assert.Contains(t, out.String(), "Tax (21%)")
+	})
+}
+
+func TestPDF(t *testing.T) {
+	t.Run("Success - the receipt is a well formed PDF document", func(t *testing.T) {
+		// GIVEN: an invoice
+		invoice := testInvoice()
+		var out bytes.Buffer
+		// WHEN: it is rendered as PDF
+		err := PDF(&out, invoice, "Bikes (Co)")
+		// THEN: the document has a header, the invoice values and a trailer
+		assert.NoError(t, err)
+		document := out.String()
+		assert.True(t, strings.HasPrefix(document, "%PDF-1.4\n"))
+		assert.True(t, strings.HasSuffix(document, "%%EOF\n"))
+		assert.Contains(t, document, "(Receipt INV-000042)")
+		assert.Contains(t, document, `(Bikes \(Co\))`)
+		assert.Contains(t, document, "(2.00 EUR)")
+	})
+	t.Run("Success - characters outside of ASCII are replaced", func(t *testing.T) {
+		// GIVEN: a value with accents
+		// WHEN: it is escaped
+		escaped := escapePDF("Café")
+		// THEN: the accented character is replaced
+		assert.Equal(t, "Caf?", escaped)
+	})
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/pricing"
+	"bikesRentalAPI/internal/receipts"
+	"bikesRentalAPI/internal/receipts/models"
+	"fmt"
+	"time"
+)
+
+type ReceiptRepository interface {
+	CreateInvoice(q database.Querier, rentalID int64, userID int64, breakdown pricing.Breakdown, issuedAt time.Time) (*models.Invoice, error)
+	GetInvoiceByRentalID(rentalID int64) (*models.Invoice, error)
+	GetStatement(userID int64, month time.Time) (*models.Statement, error)
+}
+
+type receiptRepository struct {
+	db     database.Database
+	config receipts.Config
+}
+
+// New initializes a new receipt repository issuing invoices with the given configuration
+func New(db database.Database, config receipts.Config) ReceiptRepository {
+	return &receiptRepository{db: db, config: config}
+}
+
+// CreateInvoice issues the invoice of an ended rental with the next invoice number.
+// q must be the transaction ending the rental so numbers are only taken by committed rentals
+func (r *receiptRepository) CreateInvoice(q database.Querier, rentalID int64, userID int64, breakdown pricing.Breakdown, issuedAt time.Time) (*models.Invoice, error) {
+	var sequence int64
+	if err := q.QueryRow("SELECT COALESCE(MAX(sequence), 0) + 1 FROM invoices").Scan(&sequence); err != nil {
+		return nil, fmt.Errorf("failed to get next invoice number: %v", err)
+	}
+
+	var total int64
+	lines := make([]*models.InvoiceLine, 0, len(breakdown.Lines))
+	for _, line := range breakdown.Lines {
+		total += helpers.ToMinorUnits(line.Amount)
+		lines = append(lines, &models.InvoiceLine{
+			Code:        line.Code,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			Amount:      line.Amount,
+		})
+	}
+	subtotal, tax := splitTax(total, r.config.TaxRate)
+
+	invoice := &models.Invoice{
+		Number:   fmt.Sprintf("INV-%06d", sequence),
+		Sequence: sequence,
+		RentalID: rentalID,
+		UserID:   userID,
+		Currency: r.config.Currency,
+		TaxRate:  r.config.TaxRate,
+		Subtotal: helpers.FromMinorUnits(subtotal),
+		Tax:      helpers.FromMinorUnits(tax),
+		Total:    helpers.FromMinorUnits(total),
+		IssuedAt: issuedAt.UTC(),
+		Lines:    lines,
+	}
+
+	query := `INSERT INTO invoices (sequence, number, rental_id, user_id, currency, tax_rate, subtotal, tax, total, issued_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := q.Exec(query, invoice.Sequence, invoice.Number, rentalID, userID, invoice.Currency, invoice.TaxRate, subtotal, tax, total, invoice.IssuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert invoice: %v", err)
+	}
+	invoice.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %v", err)
+	}
+	for _, line := range lines {
+		query := "INSERT INTO invoice_lines (invoice_id, code, description, quantity, unit_price, amount) VALUES (?, ?, ?, ?, ?, ?)"
+		_, err := q.Exec(query, invoice.ID, line.Code, line.Description, line.Quantity, line.UnitPrice, helpers.ToMinorUnits(line.Amount))
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert invoice line: %v", err)
+		}
+	}
+	return invoice, nil
+}
+
+// GetInvoiceByRentalID retrieves the invoice of a rental with its lines
+func (r *receiptRepository) GetInvoiceByRentalID(rentalID int64) (*models.Invoice, error) {
+	query := "SELECT id, sequence, number, rental_id, user_id, currency, tax_rate, subtotal, tax, total, issued_at FROM invoices WHERE rental_id = ?"
+	var invoice models.Invoice
+	var subtotal, tax, total int64
+	err := r.db.QueryRow(query, rentalID).Scan(&invoice.ID, &invoice.Sequence, &invoice.Number, &invoice.RentalID, &invoice.UserID,
+		&invoice.Currency, &invoice.TaxRate, &subtotal, &tax, &total, &invoice.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	invoice.Subtotal = helpers.FromMinorUnits(subtotal)
+	invoice.Tax = helpers.FromMinorUnits(tax)
+	invoice.Total = helpers.FromMinorUnits(total)
+
+	rows, err := r.db.Query("SELECT code, description, quantity, unit_price, amount FROM invoice_lines WHERE invoice_id = ? ORDER BY id", invoice.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice lines: %v", err)
+	}
+	defer rows.Close()
+
+	invoice.Lines = make([]*models.InvoiceLine, 0)
+	for rows.Next() {
+		var line models.InvoiceLine
+		var amount int64
+		if err := rows.Scan(&line.Code, &line.Description, &line.Quantity, &line.UnitPrice, &amount); err != nil {
+			return nil, err
+		}
+		line.Amount = helpers.FromMinorUnits(amount)
+		invoice.Lines = append(invoice.Lines, &line)
+	}
+	return &invoice, rows.Err()
+}
+
+// GetStatement aggregates the invoices issued to a user in the month of the given time
+func (r *receiptRepository) GetStatement(userID int64, month time.Time) (*models.Statement, error) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	query := "SELECT number, rental_id, issued_at, subtotal, tax, total FROM invoices WHERE user_id = ? AND issued_at >= ? AND issued_at < ? ORDER BY sequence"
+	rows, err := r.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %v", err)
+	}
+	defer rows.Close()
+
+	statement := &models.Statement{
+		UserID:   userID,
+		Month:    from.Format("2006-01"),
+		Currency: r.config.Currency,
+		Receipts: make([]*models.ReceiptSummary, 0),
+	}
+	var subtotalSum, taxSum, totalSum int64
+	for rows.Next() {
+		var receipt models.ReceiptSummary
+		var subtotal, tax, total int64
+		if err := rows.Scan(&receipt.Number, &receipt.RentalID, &receipt.IssuedAt, &subtotal, &tax, &total); err != nil {
+			return nil, err
+		}
+		receipt.Subtotal = helpers.FromMinorUnits(subtotal)
+		receipt.Tax = helpers.FromMinorUnits(tax)
+		receipt.Total = helpers.FromMinorUnits(total)
+		subtotalSum += subtotal
+		taxSum += tax
+		totalSum += total
+		statement.Receipts = append(statement.Receipts, &receipt)
+	}
+	statement.Count = len(statement.Receipts)
+	statement.Subtotal = helpers.FromMinorUnits(subtotalSum)
+	statement.Tax = helpers.FromMinorUnits(taxSum)
+	statement.Total = helpers.FromMinorUnits(totalSum)
+	return statement, rows.Err()
+}
+
+// splitTax splits a total including taxes, in cents, into the amount before taxes and the taxes
+func splitTax(total int64, taxRate float64) (subtotal int64, tax int64) {
+	subtotal = helpers.ToMinorUnits(helpers.FromMinorUnits(total) / (1 + taxRate))
+	return subtotal, total - subtotal
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitTax(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int64
+		taxRate      float64
+		wantSubtotal int64
+		wantTax      int64
+	}{
+		{name: "Success - taxes are included in the total", total: 121, taxRate: 0.21, wantSubtotal: 100, wantTax: 21},
+		{name: "Success - subtotal and taxes always sum to the total", total: 1000, taxRate: 0.21, wantSubtotal: 826, wantTax: 174},
+		{name: "Success - no taxes", total: 500, taxRate: 0, wantSubtotal: 500, wantTax: 0},
+		{name: "Success - free rentals", total: 0, taxRate: 0.21, wantSubtotal: 0, wantTax: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN: a total including taxes
+			// WHEN: the taxes are split
+			subtotal, tax := splitTax(tt.total, tt.taxRate)
+			// THEN: the subtotal and the taxes are returned in cents
+			assert.Equal(t, tt.wantSubtotal, subtotal)
+			assert.Equal(t, tt.wantTax, tax)
+			assert.Equal(t, tt.total, subtotal+tax)
+		})
+	}
+}
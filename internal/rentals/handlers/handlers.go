@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/database"
 	"bikesRentalAPI/internal/helpers"
 	"bikesRentalAPI/internal/middlewares"
+	"bikesRentalAPI/internal/quotes"
 	"bikesRentalAPI/internal/rentals/models"
 	"bikesRentalAPI/internal/rentals/repository"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -16,14 +21,25 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// maxQuoteMinutes is the longest ride a fare can be estimated for
+const maxQuoteMinutes = 24 * 60
+
 // Handler is the interface for rental handlers
 type Handler interface {
 	GetRentalHistoryByUserID(w http.ResponseWriter, req *http.Request) // Get rental history by user ID
 	GetRentalList(w http.ResponseWriter, req *http.Request)            // Get rental list
 	GetRentalDetails(w http.ResponseWriter, req *http.Request)         // Get rental details
 	UpdateRentalDetails(w http.ResponseWriter, req *http.Request)      // Update rental details
+	AdjustRental(w http.ResponseWriter, req *http.Request)             // Adjust or refund the cost of a rental
+	GetBikeQuote(w http.ResponseWriter, req *http.Request)             // Get the price of a bike now and estimate a fare
 	StartBikeRental(w http.ResponseWriter, req *http.Request)          // Start bike rental
 	EndBikeRental(w http.ResponseWriter, req *http.Request)            // End bike rental
+	PauseRental(w http.ResponseWriter, req *http.Request)              // Pause the ongoing rental with the bike locked
+	ResumeRental(w http.ResponseWriter, req *http.Request)             // Resume the paused rental and unlock the bike
+	AddRentalTrackPoints(w http.ResponseWriter, req *http.Request)     // Add GPS positions to the logged in user rental
+	AddDeviceTrackPoints(w http.ResponseWriter, req *http.Request)     // Add GPS positions reported by the bike device
+	GetRentalTrack(w http.ResponseWriter, req *http.Request)           // Get the logged in user rental track
+	GetRentalTrackDetails(w http.ResponseWriter, req *http.Request)    // Get rental track
 }
 
 type handler struct {
@@ -43,14 +59,15 @@ func New(RentalRepository repository.RentalRepository) Handler {
 
 // GetRentalList ...
 func (h *handler) GetRentalList(w http.ResponseWriter, req *http.Request) {
-	pageID := req.Context().Value(middlewares.PageIDKey)
-
-	rentals, err := h.RentalRepo.ListAllRentals(pageID.(int64))
+	page := middlewares.PageFromContext(req.Context())
+	spec := middlewares.QuerySpecFromContext(req.Context())
+	rentals, err := h.RentalRepo.ListAllRentals(page, spec)
 	if err != nil {
 		log.Printf("Error getting rental history: %v", err)
 		http.Error(w, "Error getting rental history", http.StatusBadRequest)
 		return
 	}
+	rentals.Page.SetLinks(req.URL)
 	helpers.WriteJSON(w, http.StatusOK, rentals)
 }
 
@@ -69,7 +86,53 @@ func (h *handler) GetRentalDetails(w http.ResponseWriter, req *http.Request) {
 		http.Error(w, "Error getting rental details", http.StatusBadRequest)
 		return
 	}
-	helpers.WriteJSON(w, http.StatusOK, rental)
+	helpers.WriteJSONWithETag(w, req, http.StatusOK, rental.Version, rental)
+}
+
+// AdjustRental records a signed adjustment of the cost of an ended rental on behalf of the authenticated staff member
+func (h *handler) AdjustRental(w http.ResponseWriter, req *http.Request) {
+	rentalIDStr := chi.URLParam(req, "rental_id")
+	rentalID, err := strconv.ParseInt(rentalIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", rentalIDStr, err), http.StatusBadRequest)
+		return
+	}
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return
+	}
+	var adjustReq models.AdjustRentalRequest
+	if err := json.Unmarshal(body, &adjustReq); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return
+	}
+	if err := h.validator.Struct(adjustReq); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return
+	}
+	if helpers.ToMinorUnits(adjustReq.Amount) == 0 {
+		http.Error(w, "Amount must be at least one cent", http.StatusBadRequest)
+		return
+	}
+
+	adjustment, err := h.RentalRepo.AdjustRental(rentalID, &adjustReq, helpers.GetAdminFromRequest(req))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Rental not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrRentalNotEnded):
+			http.Error(w, "Only ended rentals can be adjusted", http.StatusConflict)
+		case errors.Is(err, repository.ErrNegativeCost):
+			http.Error(w, "Adjustment makes the rental cost negative", http.StatusUnprocessableEntity)
+		default:
+			log.Printf("Error adjusting rental: %v", err)
+			http.Error(w, "Error adjusting rental", http.StatusInternalServerError)
+		}
+		return
+	}
+	helpers.WriteJSON(w, http.StatusCreated, adjustment)
 }
 
 // UpdateRentalDetails ...
@@ -109,6 +172,9 @@ func (h *handler) UpdateRentalDetails(w http.ResponseWriter, req *http.Request)
 		http.Error(w, "Error getting rental details", http.StatusBadRequest)
 		return
 	}
+	if !helpers.CheckIfMatch(w, req, rental.Version) {
+		return
+	}
 
 	// Compare attributes to identify modifications
 	fieldsToUpdate, err := getFieldsToUpdate(updateRentalReq, rental)
@@ -134,9 +200,17 @@ func (h *handler) UpdateRentalDetails(w http.ResponseWriter, req *http.Request)
 		}
 	}
 
-	result, err := h.RentalRepo.UpdateRental(rentalID, fieldsToUpdate)
+	audit.SetChanges(req, rental, fieldsToUpdate)
+	result, err := h.RentalRepo.UpdateRental(rentalID, fieldsToUpdate, rental.Version)
 	if err != nil {
-		http.Error(w, "Error updating rental", http.StatusBadRequest)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Rental not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrVersionConflict):
+			http.Error(w, "The rental was changed since it was read", http.StatusPreconditionFailed)
+		default:
+			http.Error(w, "Error updating rental", http.StatusBadRequest)
+		}
 		return
 	}
 	updateRentalResp := models.UpdateRentalResponse{
@@ -147,6 +221,44 @@ func (h *handler) UpdateRentalDetails(w http.ResponseWriter, req *http.Request)
 
 }
 
+// GetBikeQuote returns the price per minute a bike is rented at now. With dynamic pricing it depends on the
+// available bikes and the recent demand around the bike. The 'minutes' query parameter estimates the fare of a ride that long.
+// Starting a rental with the returned token guarantees the quoted price until it expires
+func (h *handler) GetBikeQuote(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	bikeIDStr := chi.URLParam(req, "bike_id")
+	bikeID, err := strconv.ParseInt(bikeIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", bikeIDStr, err), http.StatusBadRequest)
+		return
+	}
+	var minutes *int
+	if minutesStr := req.URL.Query().Get("minutes"); minutesStr != "" {
+		value, err := strconv.Atoi(minutesStr)
+		if err != nil || value < 1 || value > maxQuoteMinutes {
+			http.Error(w, fmt.Sprintf("minutes must be between 1 and %d", maxQuoteMinutes), http.StatusBadRequest)
+			return
+		}
+		minutes = &value
+	}
+	quote, err := h.RentalRepo.QuoteBike(userID, bikeID, minutes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Bike not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error quoting bike: %v", err)
+		http.Error(w, "Error quoting bike", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, quote)
+}
+
 // StartBikeRental ...
 func (h *handler) StartBikeRental(w http.ResponseWriter, req *http.Request) {
 	_, claims, err := jwtauth.FromContext(req.Context())
@@ -192,6 +304,34 @@ func (h *handler) StartBikeRental(w http.ResponseWriter, req *http.Request) {
 	rental, err := h.RentalRepo.StartRental(userId, startBikeRentalReq)
 	if err != nil {
 		log.Printf("Error starting bike rental: %v", err)
+		if errors.Is(err, repository.ErrNegativeBalance) {
+			http.Error(w, "Wallet balance is negative, please top up before renting", http.StatusPaymentRequired)
+			return
+		}
+		if errors.Is(err, repository.ErrPaymentMethodRequired) {
+			http.Error(w, "A payment method is required to rent a bike", http.StatusPaymentRequired)
+			return
+		}
+		if errors.Is(err, repository.ErrPaymentDeclined) {
+			http.Error(w, "Payment pre-authorization declined", http.StatusPaymentRequired)
+			return
+		}
+		if errors.Is(err, repository.ErrStationMismatch) {
+			http.Error(w, "Bike is not docked at the given station", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, repository.ErrUnlockFailed) {
+			http.Error(w, "Bike could not be unlocked, please try again", http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, quotes.ErrInvalidQuote) {
+			http.Error(w, "Invalid quote token", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, quotes.ErrQuoteExpired) {
+			http.Error(w, "Quote expired, please request a new one", http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error starting bike rental", http.StatusBadRequest)
 		return
 	}
@@ -250,6 +390,18 @@ func (h *handler) EndBikeRental(w http.ResponseWriter, req *http.Request) {
 	rental, err := h.RentalRepo.EndRental(userId, startBikeRentalReq)
 	if err != nil {
 		log.Printf("Error ending bike rental: %v", err)
+		if errors.Is(err, repository.ErrStationRequired) {
+			http.Error(w, "Bike must be returned to a station", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, repository.ErrStationUnavailable) {
+			http.Error(w, "Station not found or has no free docks", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, repository.ErrBikeNotLocked) {
+			http.Error(w, "Bike must be locked to end the rental", http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error ending bike rental", http.StatusBadRequest)
 		return
 	}
@@ -257,6 +409,59 @@ func (h *handler) EndBikeRental(w http.ResponseWriter, req *http.Request) {
 
 }
 
+// PauseRental pauses the ongoing rental of the logged in user. The bike is locked and held for the rider,
+// the paused time is charged at the paused price until the rider resumes or the maximum pause duration is reached
+func (h *handler) PauseRental(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	pause, err := h.RentalRepo.PauseRental(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "No ongoing rental", http.StatusNotFound)
+		case errors.Is(err, repository.ErrRentalPaused):
+			http.Error(w, "Rental is already paused", http.StatusConflict)
+		case errors.Is(err, repository.ErrBikeNotLocked):
+			http.Error(w, "Bike must be locked to pause the rental", http.StatusConflict)
+		default:
+			log.Printf("Error pausing rental: %v", err)
+			http.Error(w, "Error pausing rental", http.StatusInternalServerError)
+		}
+		return
+	}
+	helpers.WriteJSON(w, http.StatusCreated, pause)
+}
+
+// ResumeRental ends the pause of the ongoing rental of the logged in user and unlocks the bike
+func (h *handler) ResumeRental(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	pause, err := h.RentalRepo.ResumeRental(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "No ongoing rental", http.StatusNotFound)
+		case errors.Is(err, repository.ErrRentalNotPaused):
+			http.Error(w, "Rental is not paused", http.StatusConflict)
+		case errors.Is(err, repository.ErrUnlockFailed):
+			http.Error(w, "Bike could not be unlocked, please try again", http.StatusServiceUnavailable)
+		default:
+			log.Printf("Error resuming rental: %v", err)
+			http.Error(w, "Error resuming rental", http.StatusInternalServerError)
+		}
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, pause)
+}
+
 // GetRentalHistoryByUserID retrieves the rental history of a user
 func (h *handler) GetRentalHistoryByUserID(w http.ResponseWriter, req *http.Request) {
 	_, claims, err := jwtauth.FromContext(req.Context())
@@ -270,17 +475,138 @@ func (h *handler) GetRentalHistoryByUserID(w http.ResponseWriter, req *http.Requ
 		http.Error(w, "Error getting user id", http.StatusBadRequest)
 		return
 	}
-	pageID := req.Context().Value(middlewares.PageIDKey)
+	page := middlewares.PageFromContext(req.Context())
 
-	rentals, err := h.RentalRepo.GetRentalHistoryByUserID(userId, pageID.(int64))
+	rentals, err := h.RentalRepo.GetRentalHistoryByUserID(userId, page)
 	if err != nil {
 		log.Printf("Error getting rental history: %v", err)
 		http.Error(w, "Error getting rental history", http.StatusBadRequest)
 		return
 	}
+	rentals.Page.SetLinks(req.URL)
 	helpers.WriteJSON(w, http.StatusOK, rentals)
 }
 
+// AddRentalTrackPoints appends GPS positions submitted by the app to a rental of the logged in user
+func (h *handler) AddRentalTrackPoints(w http.ResponseWriter, req *http.Request) {
+	userId, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	rental, ok := h.getRentalFromURL(w, req)
+	if !ok {
+		return
+	}
+	if rental.UserID != userId {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	h.addTrackPoints(w, req, rental, "app")
+}
+
+// AddDeviceTrackPoints appends GPS positions reported by the bike device telemetry to a rental
+func (h *handler) AddDeviceTrackPoints(w http.ResponseWriter, req *http.Request) {
+	rental, ok := h.getRentalFromURL(w, req)
+	if !ok {
+		return
+	}
+	h.addTrackPoints(w, req, rental, "device")
+}
+
+// GetRentalTrack returns the GeoJSON track of a rental of the logged in user
+func (h *handler) GetRentalTrack(w http.ResponseWriter, req *http.Request) {
+	userId, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	rental, ok := h.getRentalFromURL(w, req)
+	if !ok {
+		return
+	}
+	if rental.UserID != userId {
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return
+	}
+	h.writeTrack(w, rental)
+}
+
+// GetRentalTrackDetails returns the GeoJSON track of any rental
+func (h *handler) GetRentalTrackDetails(w http.ResponseWriter, req *http.Request) {
+	rental, ok := h.getRentalFromURL(w, req)
+	if !ok {
+		return
+	}
+	h.writeTrack(w, rental)
+}
+
+// getRentalFromURL reads the 'rental_id' URL parameter and retrieves the rental. It writes the error response when it fails
+func (h *handler) getRentalFromURL(w http.ResponseWriter, req *http.Request) (*models.Rental, bool) {
+	rentalIDStr := chi.URLParam(req, "rental_id")
+	rentalID, err := strconv.ParseInt(rentalIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", rentalIDStr, err), http.StatusBadRequest)
+		return nil, false
+	}
+	rental, err := h.RentalRepo.GetRentalDetails(rentalID)
+	if err != nil {
+		log.Printf("Error getting rental details: %v", err)
+		http.Error(w, "Rental not found", http.StatusNotFound)
+		return nil, false
+	}
+	return rental, true
+}
+
+// addTrackPoints validates the positions in the request body and appends them to the track of an ongoing rental
+func (h *handler) addTrackPoints(w http.ResponseWriter, req *http.Request, rental *models.Rental, source string) {
+	if rental.EndTime != nil {
+		http.Error(w, "Rental has already ended", http.StatusBadRequest)
+		return
+	}
+
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return
+	}
+	var addTrackPointsReq *models.AddTrackPointsRequest
+	if err := json.Unmarshal(body, &addTrackPointsReq); err != nil {
+		http.Error(w, "Error decoding body request", http.StatusBadRequest)
+		return
+	}
+	if err := h.validator.Struct(addTrackPointsReq); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.RentalRepo.AddTrackPoints(rental.ID, source, addTrackPointsReq.Points); err != nil {
+		log.Printf("Error adding track points: %v", err)
+		http.Error(w, "Error adding track points", http.StatusInternalServerError)
+		return
+	}
+	addTrackPointsResp := models.AddTrackPointsResponse{
+		RentalID: rental.ID,
+		Count:    len(addTrackPointsReq.Points),
+		Message:  "Track points added successfully",
+	}
+	helpers.WriteJSON(w, http.StatusCreated, addTrackPointsResp)
+}
+
+// writeTrack writes the rental track as a GeoJSON LineString feature
+func (h *handler) writeTrack(w http.ResponseWriter, rental *models.Rental) {
+	points, err := h.RentalRepo.GetTrackPoints(rental.ID)
+	if err != nil {
+		log.Printf("Error getting rental track: %v", err)
+		http.Error(w, "Error getting rental track", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSONAs(w, http.StatusOK, "application/geo+json", models.NewRentalTrack(rental, points))
+}
+
 // getFieldsToUpdate compares the fields of the update request with the rental and returns the fields to update as map
 func getFieldsToUpdate(updateRentalReq *models.UpdateRentalRequest, rental *models.Rental) (map[string]interface{}, error) {
 	if updateRentalReq == nil || rental == nil {
@@ -1,6 +1,23 @@
 package handlers
 
-import "testing"
+import (
+	"bikesRentalAPI/internal/rentals/models"
+	"bikesRentalAPI/internal/rentals/repository/mocks"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
 
 func TestListRental(t *testing.T) {
 	// GIVEN: a request to list all rentals
@@ -19,3 +36,272 @@ func TestUpdateRentalDetails(t *testing.T) {
 	// WHEN: the request is made
 	// THEN: the rental details should be updated
 }
+
+// withUser returns the request authenticated as the user
+func withUser(t *testing.T, req *http.Request, userID string) *http.Request {
+	token, _, err := jwtauth.New("HS256", []byte("secret"), nil).Encode(map[string]interface{}{"sub": userID})
+	require.NoError(t, err)
+	return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+}
+
+func TestAddRentalTrackPoints(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRentalRepo := mocks.NewMockRentalRepository(mockCtrl)
+	startTime := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	ongoing := &models.Rental{ID: 7, UserID: 2, StartTime: &startTime, StartLatitude: 40.4, StartLongitude: -3.7}
+	ended := &models.Rental{ID: 7, UserID: 2, StartTime: &startTime, EndTime: &startTime}
+
+	testCases := []struct {
+		name             string
+		userID           string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name:   "Success - the points are added to the ongoing rental of the user",
+			userID: "2",
+			body:   `{"points":[{"latitude":40.41,"longitude":-3.71},{"latitude":40.42,"longitude":-3.72,"recorded_at":"2024-06-03T10:05:00Z"}]}`,
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(ongoing, nil)
+				mockRentalRepo.EXPECT().AddTrackPoints(int64(7), "app", gomock.Len(2)).Return(nil)
+			},
+			expectedHttpCode: http.StatusCreated,
+		},
+		{
+			name:   "Failure - the rental of another user is not found",
+			userID: "3",
+			body:   `{"points":[{"latitude":40.41,"longitude":-3.71}]}`,
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(ongoing, nil)
+			},
+			expectedHttpCode: http.StatusNotFound,
+		},
+		{
+			name:   "Failure - points can't be added to an ended rental",
+			userID: "2",
+			body:   `{"points":[{"latitude":40.41,"longitude":-3.71}]}`,
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(ended, nil)
+			},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Failure - positions out of range are refused",
+			userID: "2",
+			body:   `{"points":[{"latitude":91,"longitude":-3.71}]}`,
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(ongoing, nil)
+			},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Failure - at least one point is required",
+			userID: "2",
+			body:   `{"points":[]}`,
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(ongoing, nil)
+			},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Failure - the points can't be stored",
+			userID: "2",
+			body:   `{"points":[{"latitude":40.41,"longitude":-3.71}]}`,
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(ongoing, nil)
+				mockRentalRepo.EXPECT().AddTrackPoints(int64(7), "app", gomock.Any()).Return(errors.New("database is locked"))
+			},
+			expectedHttpCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider submitting positions for rental 7
+			tc.mockCalls()
+			req := withUser(t, httptest.NewRequest(http.MethodPost, "/rentals/7/track", strings.NewReader(tc.body)), tc.userID)
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Post("/rentals/{rental_id}/track", New(mockRentalRepo).AddRentalTrackPoints)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: only valid positions of the ongoing rental of the rider are added
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+			if tc.expectedHttpCode == http.StatusCreated {
+				var resp models.AddTrackPointsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, 2, resp.Count)
+			}
+		})
+	}
+}
+
+func TestAddDeviceTrackPoints(t *testing.T) {
+	// GIVEN: the bike device reporting a position for rental 7
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRentalRepo := mocks.NewMockRentalRepository(mockCtrl)
+	mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(&models.Rental{ID: 7, UserID: 2}, nil)
+	mockRentalRepo.EXPECT().AddTrackPoints(int64(7), "device", gomock.Len(1)).Return(nil)
+	req := httptest.NewRequest(http.MethodPost, "/admin/rentals/7/track", strings.NewReader(`{"points":[{"latitude":40.41,"longitude":-3.71}]}`))
+	rec := httptest.NewRecorder()
+	router := chi.NewRouter()
+	router.Post("/admin/rentals/{rental_id}/track", New(mockRentalRepo).AddDeviceTrackPoints)
+	// WHEN: the request is made
+	router.ServeHTTP(rec, req)
+	// THEN: the position is added as reported by the device
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestGetRentalTrack(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRentalRepo := mocks.NewMockRentalRepository(mockCtrl)
+	startTime := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(20 * time.Minute)
+	endLatitude, endLongitude := 40.43, -3.73
+	ended := &models.Rental{ID: 7, UserID: 2, StartTime: &startTime, EndTime: &endTime, StartLatitude: 40.4, StartLongitude: -3.7,
+		EndLatitude: &endLatitude, EndLongitude: &endLongitude, Distance: 4.2}
+	ongoing := &models.Rental{ID: 7, UserID: 2, StartTime: &startTime, StartLatitude: 40.4, StartLongitude: -3.7}
+	points := []*models.TrackPoint{
+		{ID: 1, RentalID: 7, Latitude: 40.41, Longitude: -3.71, RecordedAt: startTime.Add(5 * time.Minute)},
+		{ID: 2, RentalID: 7, Latitude: 40.42, Longitude: -3.72, RecordedAt: startTime.Add(10 * time.Minute)},
+	}
+
+	testCases := []struct {
+		name                string
+		userID              string
+		rental              *models.Rental
+		points              []*models.TrackPoint
+		expectedHttpCode    int
+		expectedCoordinates [][2]float64
+		expectedTimestamps  []time.Time
+	}{
+		{
+			name:                "Success - the track of an ended rental goes from the start through the points to the end",
+			userID:              "2",
+			rental:              ended,
+			points:              points,
+			expectedHttpCode:    http.StatusOK,
+			expectedCoordinates: [][2]float64{{-3.7, 40.4}, {-3.71, 40.41}, {-3.72, 40.42}, {-3.73, 40.43}},
+			expectedTimestamps:  []time.Time{startTime, points[0].RecordedAt, points[1].RecordedAt, endTime},
+		},
+		{
+			name:                "Success - the track of an ongoing rental ends at the last point",
+			userID:              "2",
+			rental:              ongoing,
+			points:              points[:1],
+			expectedHttpCode:    http.StatusOK,
+			expectedCoordinates: [][2]float64{{-3.7, 40.4}, {-3.71, 40.41}},
+			expectedTimestamps:  []time.Time{startTime, points[0].RecordedAt},
+		},
+		{
+			name:                "Success - a rental without points only has its start",
+			userID:              "2",
+			rental:              ongoing,
+			points:              []*models.TrackPoint{},
+			expectedHttpCode:    http.StatusOK,
+			expectedCoordinates: [][2]float64{{-3.7, 40.4}},
+			expectedTimestamps:  []time.Time{startTime},
+		},
+		{
+			name:             "Failure - the rental of another user is not found",
+			userID:           "3",
+			rental:           ended,
+			expectedHttpCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider asking for the track of rental 7
+			mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(tc.rental, nil)
+			if tc.points != nil {
+				mockRentalRepo.EXPECT().GetTrackPoints(int64(7)).Return(tc.points, nil)
+			}
+			req := withUser(t, httptest.NewRequest(http.MethodGet, "/rentals/7/track", nil), tc.userID)
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Get("/rentals/{rental_id}/track", New(mockRentalRepo).GetRentalTrack)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the track is returned as a GeoJSON line string
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+			if tc.expectedHttpCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "application/geo+json", rec.Header().Get("Content-Type"))
+			var track models.RentalTrack
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &track))
+			assert.Equal(t, "Feature", track.Type)
+			assert.Equal(t, "LineString", track.Geometry.Type)
+			assert.Equal(t, tc.expectedCoordinates, track.Geometry.Coordinates)
+			assert.Equal(t, tc.expectedTimestamps, track.Properties.Timestamps)
+			assert.Equal(t, int64(7), track.Properties.RentalID)
+			assert.Equal(t, tc.rental.Distance, track.Properties.Distance)
+		})
+	}
+}
+
+func TestGetRentalTrackDetails(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRentalRepo := mocks.NewMockRentalRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		url              string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - the track of any rental is returned",
+			url:  "/admin/rentals/7/track",
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(&models.Rental{ID: 7, UserID: 2}, nil)
+				mockRentalRepo.EXPECT().GetTrackPoints(int64(7)).Return([]*models.TrackPoint{}, nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:             "Failure - the rental id is not a number",
+			url:              "/admin/rentals/seven/track",
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure - the rental doesn't exist",
+			url:  "/admin/rentals/7/track",
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(nil, errors.New("sql: no rows in result set"))
+			},
+			expectedHttpCode: http.StatusNotFound,
+		},
+		{
+			name: "Failure - the track can't be read",
+			url:  "/admin/rentals/7/track",
+			mockCalls: func() {
+				mockRentalRepo.EXPECT().GetRentalDetails(int64(7)).Return(&models.Rental{ID: 7, UserID: 2}, nil)
+				mockRentalRepo.EXPECT().GetTrackPoints(int64(7)).Return(nil, context.DeadlineExceeded)
+			},
+			expectedHttpCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an administrator asking for the track of a rental
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Get("/admin/rentals/{rental_id}/track", New(mockRentalRepo).GetRentalTrackDetails)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the track is returned when the rental exists
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
@@ -39,6 +39,42 @@ func (m *MockHandler) EXPECT() *MockHandlerMockRecorder {
 	return m.recorder
 }
 
+// AddDeviceTrackPoints mocks base method.
+func (m *MockHandler) AddDeviceTrackPoints(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AddDeviceTrackPoints", w, req)
+}
+
+// AddDeviceTrackPoints indicates an expected call of AddDeviceTrackPoints.
+func (mr *MockHandlerMockRecorder) AddDeviceTrackPoints(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDeviceTrackPoints", reflect.TypeOf((*MockHandler)(nil).AddDeviceTrackPoints), w, req)
+}
+
+// AddRentalTrackPoints mocks base method.
+func (m *MockHandler) AddRentalTrackPoints(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AddRentalTrackPoints", w, req)
+}
+
+// AddRentalTrackPoints indicates an expected call of AddRentalTrackPoints.
+func (mr *MockHandlerMockRecorder) AddRentalTrackPoints(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRentalTrackPoints", reflect.TypeOf((*MockHandler)(nil).AddRentalTrackPoints), w, req)
+}
+
+// AdjustRental mocks base method.
+func (m *MockHandler) AdjustRental(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AdjustRental", w, req)
+}
+
+// AdjustRental indicates an expected call of AdjustRental.
+func (mr *MockHandlerMockRecorder) AdjustRental(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustRental", reflect.TypeOf((*MockHandler)(nil).AdjustRental), w, req)
+}
+
 // EndBikeRental mocks base method.
 func (m *MockHandler) EndBikeRental(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
@@ -51,6 +87,18 @@ func (mr *MockHandlerMockRecorder) EndBikeRental(w, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndBikeRental", reflect.TypeOf((*MockHandler)(nil).EndBikeRental), w, req)
 }
 
+// GetBikeQuote mocks base method.
+func (m *MockHandler) GetBikeQuote(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetBikeQuote", w, req)
+}
+
+// GetBikeQuote indicates an expected call of GetBikeQuote.
+func (mr *MockHandlerMockRecorder) GetBikeQuote(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBikeQuote", reflect.TypeOf((*MockHandler)(nil).GetBikeQuote), w, req)
+}
+
 // GetRentalDetails mocks base method.
 func (m *MockHandler) GetRentalDetails(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
@@ -87,6 +135,54 @@ func (mr *MockHandlerMockRecorder) GetRentalList(w, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRentalList", reflect.TypeOf((*MockHandler)(nil).GetRentalList), w, req)
 }
 
+// GetRentalTrack mocks base method.
+func (m *MockHandler) GetRentalTrack(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetRentalTrack", w, req)
+}
+
+// GetRentalTrack indicates an expected call of GetRentalTrack.
+func (mr *MockHandlerMockRecorder) GetRentalTrack(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRentalTrack", reflect.TypeOf((*MockHandler)(nil).GetRentalTrack), w, req)
+}
+
+// GetRentalTrackDetails mocks base method.
+func (m *MockHandler) GetRentalTrackDetails(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetRentalTrackDetails", w, req)
+}
+
+// GetRentalTrackDetails indicates an expected call of GetRentalTrackDetails.
+func (mr *MockHandlerMockRecorder) GetRentalTrackDetails(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRentalTrackDetails", reflect.TypeOf((*MockHandler)(nil).GetRentalTrackDetails), w, req)
+}
+
+// PauseRental mocks base method.
+func (m *MockHandler) PauseRental(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PauseRental", w, req)
+}
+
+// PauseRental indicates an expected call of PauseRental.
+func (mr *MockHandlerMockRecorder) PauseRental(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseRental", reflect.TypeOf((*MockHandler)(nil).PauseRental), w, req)
+}
+
+// ResumeRental mocks base method.
+func (m *MockHandler) ResumeRental(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResumeRental", w, req)
+}
+
+// ResumeRental indicates an expected call of ResumeRental.
+func (mr *MockHandlerMockRecorder) ResumeRental(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeRental", reflect.TypeOf((*MockHandler)(nil).ResumeRental), w, req)
+}
+
 // StartBikeRental mocks base method.
 func (m *MockHandler) StartBikeRental(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
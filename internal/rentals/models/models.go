@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"bikesRentalAPI/internal/pagination"
+	"bikesRentalAPI/internal/pricing"
+	"time"
+)
 
 // Rental model represents a rental operation of a bike
 type Rental struct {
@@ -30,18 +34,70 @@ type Rental struct {
 	DurationMinutes int64 `json:"duration_minutes"`
 	// The cost of the rental
 	Cost float64 `json:"cost"`
+	// The travelled distance of the rental in kilometers
+	Distance float64 `json:"distance"`
+	// The station the bike was picked up from, nil for free-floating bikes
+	StartStationID *int64 `json:"start_station_id"`
+	// The station the bike was returned to, nil for free-floating bikes
+	EndStationID *int64 `json:"end_station_id"`
+	// How the rental is paid, 'card' or 'wallet'. nil for rentals started before payments
+	PaymentSource *string `json:"payment_source"`
+	// The plan applied to the cost of the rental, nil when the user had no subscription
+	PlanID *int64 `json:"plan_id"`
+	// The subscription of the user the plan was applied through
+	SubscriptionID *int64 `json:"subscription_id"`
+	// The price per minute quoted and locked when the rental started. nil for rentals started before dynamic pricing
+	PricePerMinute *float64 `json:"price_per_minute"`
+	// The dynamic pricing multiplier applied to the price of the bike when the rental started
+	SurgeMultiplier *float64 `json:"surge_multiplier"`
+	// The price per minute charged while the rental is paused, locked when the rental started
+	PausedPricePerMinute *float64 `json:"paused_price_per_minute"`
+	// The intervals the rental was paused, oldest first
+	Pauses []*RentalPause `json:"pauses,omitempty"`
+	// The cost after the adjustments made by the staff. Only set in the rental details
+	EffectiveCost *float64 `json:"effective_cost,omitempty"`
+	// The adjustments made by the staff, oldest first. Only set in the rental details
+	Adjustments []*RentalAdjustment `json:"adjustments,omitempty"`
+	// The version of the rental, changed by every update of the rental, its pauses and adjustments. Sent as its ETag
+	Version int64 `json:"-"`
 } // @name Rental
 
+// RentalPause is an interval a rental was paused with the bike locked, charged at the paused price
+type RentalPause struct {
+	ID        int64      `json:"id"`
+	RentalID  int64      `json:"rental_id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	// True when the pause was ended by the maximum pause duration instead of the rider
+	AutoResumed bool `json:"auto_resumed"`
+	// The price per minute charged and when the pause ends if the rider doesn't resume before. Only set when pausing
+	PausedPricePerMinute *float64   `json:"paused_price_per_minute,omitempty"`
+	ResumesAt            *time.Time `json:"resumes_at,omitempty"`
+} // @name RentalPause
+
+const (
+	// PaymentSourceCard rentals are pre-authorized on the payment method of the user, wallet credit is used first when they end
+	PaymentSourceCard = "card"
+	// PaymentSourceWallet rentals are fully debited from the wallet of the user
+	PaymentSourceWallet = "wallet"
+)
+
 // StartBikeRentalRequest contains the request to start a rental
 type StartBikeRentalRequest struct {
 	BikeID    int64   `json:"bike_id" validate:"required,numeric"`
 	Latitude  float64 `json:"latitude" validate:"required,latitude"`
 	Longitude float64 `json:"longitude" validate:"required,longitude"`
+	// The station the bike is picked up from. Required when the bike is docked
+	StationID *int64 `json:"station_id" validate:"omitempty,numeric"`
+	// The token of a quote of the bike. The quoted price is charged instead of the current one while the quote is valid
+	QuoteToken *string `json:"quote_token" validate:"omitempty"`
 } // @name StartBikeRentalRequest
 
 // StopBikeRentalRequest contains the request to stop a rental
 type StopBikeRentalRequest struct {
 	RentalID int64 `json:"rental_id" validate:"required,numeric"`
+	// The station the bike is returned to. Required when the bike was picked up from a station
+	StationID *int64 `json:"station_id" validate:"omitempty,numeric"`
 } // @name StopBikeRentalRequest
 
 // StartRentalResponse contains the response of starting a rental
@@ -50,8 +106,39 @@ type StartRentalResponse struct {
 	StartTime time.Time `json:"start_time"`
 	Latitude  float64   `json:"latitude"`
 	Longitude float64   `json:"longitude"`
+	StationID *int64    `json:"station_id,omitempty"`
+	// How the rental is paid, 'card' or 'wallet'
+	PaymentSource string `json:"payment_source"`
+	// The price per minute charged for the whole rental
+	PricePerMinute float64 `json:"price_per_minute"`
+	// The dynamic pricing multiplier applied to the price of the bike
+	SurgeMultiplier float64 `json:"surge_multiplier"`
 } // @name StartRentalResponse
 
+// BikeQuote is the price per minute a bike is rented at now. Starting a rental locks the quoted price,
+// starting it with the quote token guarantees it until the token expires
+type BikeQuote struct {
+	BikeID int64 `json:"bike_id"`
+	// The price per minute of the bike before dynamic pricing
+	BasePricePerMinute float64 `json:"base_price_per_minute"`
+	// The multiplier applied by dynamic pricing, 1 when there is no surge or dynamic pricing is disabled
+	SurgeMultiplier float64 `json:"surge_multiplier"`
+	PricePerMinute  float64 `json:"price_per_minute"`
+	// The price per minute charged while the rental is paused
+	PausedPricePerMinute float64 `json:"paused_price_per_minute"`
+	// The available bikes and the rentals recently started around the bike. Only set when dynamic pricing is enabled
+	AvailableBikes *int      `json:"available_bikes,omitempty"`
+	RecentDemand   *int      `json:"recent_demand,omitempty"`
+	QuotedAt       time.Time `json:"quoted_at"`
+	// The minutes the fare is estimated for and the estimated cost breakdown, with the fees, plan and promo codes of the user.
+	// Only set when minutes are given
+	Minutes  *int               `json:"minutes,omitempty"`
+	Estimate *pricing.Breakdown `json:"estimate,omitempty"`
+	// The signed token to start the rental with, and its expiry
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+} // @name BikeQuote
+
 // StopRentalResponse contains the response of stopping a rental
 type StopRentalResponse struct {
 	BikeID          int64     `json:"bike_id"`
@@ -61,14 +148,26 @@ type StopRentalResponse struct {
 	Cost            float64   `json:"cost"`
 	DurationMinutes int       `json:"duration"`
 	Distance        float64   `json:"distance,omitempty"`
+	StationID       *int64    `json:"station_id,omitempty"`
+	PaymentStatus   string    `json:"payment_status,omitempty"`
+	// The part of the cost paid with wallet credit
+	WalletAmount float64 `json:"wallet_amount,omitempty"`
+	// The part of the cost that could not be charged on the payment method, debited from the wallet until the rider pays it
+	OutstandingAmount float64 `json:"outstanding_amount,omitempty"`
+	// The number of the invoice issued for the rental, its receipt is available at /rentals/{id}/receipt
+	InvoiceNumber string `json:"invoice_number"`
+	// The plan applied to the cost, nil when the user has no subscription
+	PlanID *int64 `json:"plan_id,omitempty"`
+	// The minutes the rental was paused, charged at the paused price
+	PausedMinutes int `json:"paused_minutes,omitempty"`
 } // @name StopRentalResponse
 
-// RentalList contains a list of rentals and the next page id
+// RentalList contains a list of rentals and its pagination
 type RentalList struct {
 	// The list of rentals
 	Items []*Rental `json:"items"`
-	// The id to query the next page
-	NextPageID int64 `json:"next_page_id"`
+	// The pagination of the list
+	Page pagination.Info `json:"page"`
 } // @name RentalList
 
 // UpdateRentalRequest contains the request to update a rental
@@ -86,3 +185,117 @@ type UpdateRentalResponse struct {
 	ID      int64  `json:"id,omitempty"`
 	Message string `json:"message,omitempty"`
 } // @name UpdateRentalResponse
+
+// TrackPoint is a GPS position recorded while a rental is ongoing
+type TrackPoint struct {
+	// The id of the track point
+	ID int64 `json:"id"`
+	// The id of the rental
+	RentalID int64 `json:"rental_id"`
+	// The latitude of the position
+	Latitude float64 `json:"latitude"`
+	// The longitude of the position
+	Longitude float64 `json:"longitude"`
+	// Where the position comes from (app, device)
+	Source string `json:"source"`
+	// The time the position was recorded
+	RecordedAt time.Time `json:"recorded_at"`
+} // @name TrackPoint
+
+// TrackPointRequest contains a single GPS position submitted for a rental
+type TrackPointRequest struct {
+	Latitude   float64    `json:"latitude" validate:"required,latitude"`
+	Longitude  float64    `json:"longitude" validate:"required,longitude"`
+	RecordedAt *time.Time `json:"recorded_at" validate:"omitempty"`
+} // @name TrackPointRequest
+
+// AddTrackPointsRequest contains the request to append GPS positions to a rental track
+type AddTrackPointsRequest struct {
+	Points []TrackPointRequest `json:"points" validate:"required,min=1,max=500,dive"`
+} // @name AddTrackPointsRequest
+
+// AddTrackPointsResponse represents the response of appending GPS positions to a rental track
+type AddTrackPointsResponse struct {
+	RentalID int64  `json:"rental_id"`
+	Count    int    `json:"count"`
+	Message  string `json:"message,omitempty"`
+} // @name AddTrackPointsResponse
+
+// RentalTrack is the GeoJSON Feature representation of a rental track
+type RentalTrack struct {
+	Type       string                `json:"type"`
+	Geometry   RentalTrackGeometry   `json:"geometry"`
+	Properties RentalTrackProperties `json:"properties"`
+} // @name RentalTrack
+
+// RentalTrackGeometry is a GeoJSON LineString. Coordinates are [longitude, latitude] pairs
+type RentalTrackGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+} // @name RentalTrackGeometry
+
+// RentalTrackProperties contains the properties of a rental track feature
+type RentalTrackProperties struct {
+	RentalID   int64       `json:"rental_id"`
+	Distance   float64     `json:"distance"`
+	Timestamps []time.Time `json:"timestamps"`
+} // @name RentalTrackProperties
+
+// NewRentalTrack builds the GeoJSON track of a rental from its start location, the recorded points and its end location
+func NewRentalTrack(rental *Rental, points []*TrackPoint) *RentalTrack {
+	coordinates := make([][2]float64, 0, len(points)+2)
+	timestamps := make([]time.Time, 0, len(points)+2)
+	if rental.StartTime != nil {
+		coordinates = append(coordinates, [2]float64{rental.StartLongitude, rental.StartLatitude})
+		timestamps = append(timestamps, *rental.StartTime)
+	}
+	for _, point := range points {
+		coordinates = append(coordinates, [2]float64{point.Longitude, point.Latitude})
+		timestamps = append(timestamps, point.RecordedAt)
+	}
+	if rental.EndTime != nil && rental.EndLatitude != nil && rental.EndLongitude != nil {
+		coordinates = append(coordinates, [2]float64{*rental.EndLongitude, *rental.EndLatitude})
+		timestamps = append(timestamps, *rental.EndTime)
+	}
+	return &RentalTrack{
+		Type: "Feature",
+		Geometry: RentalTrackGeometry{
+			Type:        "LineString",
+			Coordinates: coordinates,
+		},
+		Properties: RentalTrackProperties{
+			RentalID:   rental.ID,
+			Distance:   rental.Distance,
+			Timestamps: timestamps,
+		},
+	}
+}
+
+// RentalAdjustment is a change of the cost of an ended rental made by the staff.
+// Negative amounts are refunds, given back to the card and then to the wallet the rental was paid with.
+// Positive amounts are charged to the wallet, the rider owes them until the balance is topped up
+type RentalAdjustment struct {
+	ID        int64   `json:"id"`
+	RentalID  int64   `json:"rental_id"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+	CreatedBy string  `json:"created_by"`
+	// The part of the refund given back to the payment method
+	CardRefund float64 `json:"card_refund"`
+	// The part of the refund credited to the wallet
+	WalletRefund float64   `json:"wallet_refund"`
+	CreatedAt    time.Time `json:"created_at"`
+} // @name RentalAdjustment
+
+// AdjustRentalRequest contains a signed change of the cost of a rental. Negative amounts are refunds, positive amounts are charged to the wallet
+type AdjustRentalRequest struct {
+	Amount float64 `json:"amount" validate:"required,ne=0"`
+	Reason string  `json:"reason" validate:"required,max=255"`
+} // @name AdjustRentalRequest
+
+// AdjustRentalResponse contains the recorded adjustment and the new effective cost of the rental
+type AdjustRentalResponse struct {
+	Adjustment    *RentalAdjustment `json:"adjustment"`
+	EffectiveCost float64           `json:"effective_cost"`
+	Message       string            `json:"message"`
+} // @name AdjustRentalResponse
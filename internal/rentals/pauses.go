@@ -0,0 +1,107 @@
+package rentals
+
+import (
+	"bikesRentalAPI/internal/rentals/models"
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultPauseRate is the share of the price per minute charged while a rental is paused
+	defaultPauseRate = 0.5
+	// defaultMaxPause is the longest a rental can stay paused before the regular price applies again
+	defaultMaxPause = 30 * time.Minute
+	// defaultPauseCheckInterval is how often the pauses over the maximum are looked for
+	defaultPauseCheckInterval = time.Minute
+)
+
+// PauseConfig holds the configuration of rental pauses
+type PauseConfig struct {
+	// Rate is the share of the price per minute charged while paused, between 0 and 1
+	Rate float64
+	// MaxDuration is the longest a pause lasts. Longer pauses are ended and charged the regular price
+	MaxDuration time.Duration
+	// CheckInterval is how often the pauses over the maximum are ended
+	CheckInterval time.Duration
+}
+
+// PauseConfigFromEnv reads the pause configuration from RENTAL_PAUSE_RATE, RENTAL_MAX_PAUSE and RENTAL_PAUSE_CHECK_INTERVAL,
+// falling back to defaults
+func PauseConfigFromEnv() PauseConfig {
+	config := PauseConfig{Rate: defaultPauseRate, MaxDuration: defaultMaxPause, CheckInterval: defaultPauseCheckInterval}
+	if value := os.Getenv("RENTAL_PAUSE_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			log.Printf("invalid RENTAL_PAUSE_RATE %q. Set to %v as default", value, defaultPauseRate)
+		} else {
+			config.Rate = rate
+		}
+	}
+	if value := os.Getenv("RENTAL_MAX_PAUSE"); value != "" {
+		maxPause, err := time.ParseDuration(value)
+		if err != nil || maxPause <= 0 {
+			log.Printf("invalid RENTAL_MAX_PAUSE %q. Set to %v as default", value, defaultMaxPause)
+		} else {
+			config.MaxDuration = maxPause
+		}
+	}
+	if value := os.Getenv("RENTAL_PAUSE_CHECK_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			log.Printf("invalid RENTAL_PAUSE_CHECK_INTERVAL %q. Set to %v as default", value, defaultPauseCheckInterval)
+		} else {
+			config.CheckInterval = interval
+		}
+	}
+	return config
+}
+
+// PausedPricePerMinute returns the price per minute charged while paused, rounded like the dynamic prices
+func (c PauseConfig) PausedPricePerMinute(pricePerMinute float64) float64 {
+	return math.Round(pricePerMinute*c.Rate*10000) / 10000
+}
+
+// PausedDuration returns how long a rental ending at the given time was paused. Pauses still open end with the rental
+func PausedDuration(pauses []*models.RentalPause, end time.Time) time.Duration {
+	var paused time.Duration
+	for _, pause := range pauses {
+		pauseEnd := end
+		if pause.EndedAt != nil && pause.EndedAt.Before(end) {
+			pauseEnd = *pause.EndedAt
+		}
+		if pauseEnd.After(pause.StartedAt) {
+			paused += pauseEnd.Sub(pause.StartedAt)
+		}
+	}
+	return paused
+}
+
+// PauseResumer ends the pauses that lasted longer than the maximum
+type PauseResumer interface {
+	ResumeExpiredPauses(now time.Time) (int64, error)
+}
+
+// EnforceMaxPause ends the pauses over the maximum duration every interval until the context is done
+func EnforceMaxPause(ctx context.Context, resumer PauseResumer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			resumed, err := resumer.ResumeExpiredPauses(now)
+			if err != nil {
+				log.Printf("Error resuming expired pauses: %v", err)
+				continue
+			}
+			if resumed > 0 {
+				log.Printf("Resumed %d rentals paused over the maximum", resumed)
+			}
+		}
+	}
+}
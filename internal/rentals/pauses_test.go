@@ -0,0 +1,67 @@
+package rentals
+
+import (
+	"bikesRentalAPI/internal/rentals/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPausedDuration(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	ended := func(minutes int) *time.Time { end := at(minutes); return &end }
+
+	testCases := []struct {
+		name     string
+		pauses   []*models.RentalPause
+		end      time.Time
+		expected time.Duration
+	}{
+		{
+			name:     "Success - a rental without pauses",
+			end:      at(30),
+			expected: 0,
+		},
+		{
+			name: "Success - ended pauses add up",
+			pauses: []*models.RentalPause{
+				{StartedAt: at(5), EndedAt: ended(10)},
+				{StartedAt: at(20), EndedAt: ended(27)},
+			},
+			end:      at(30),
+			expected: 12 * time.Minute,
+		},
+		{
+			name:     "Success - an open pause ends with the rental",
+			pauses:   []*models.RentalPause{{StartedAt: at(20)}},
+			end:      at(30),
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "Success - pauses are cut at the end of the rental",
+			pauses:   []*models.RentalPause{{StartedAt: at(20), EndedAt: ended(40)}},
+			end:      at(30),
+			expected: 10 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: the pauses of a rental
+			// WHEN: the paused duration is computed
+			paused := PausedDuration(tc.pauses, tc.end)
+			// THEN: it matches the time spent paused
+			assert.Equal(t, tc.expected, paused)
+		})
+	}
+}
+
+func TestPausedPricePerMinute(t *testing.T) {
+	// GIVEN: a pause rate of 40%
+	config := PauseConfig{Rate: 0.4}
+	// WHEN: it applies to a price per minute
+	// THEN: the paused price is the share of it
+	assert.Equal(t, 0.042, config.PausedPricePerMinute(0.105))
+}
@@ -1,51 +1,158 @@
 package repository
 
 import (
+	bikesmodels "bikesRentalAPI/internal/bikes/models"
 	bikesrepository "bikesRentalAPI/internal/bikes/repository"
 	"bikesRentalAPI/internal/database"
 	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/locks"
+	"bikesRentalAPI/internal/middlewares"
+	"bikesRentalAPI/internal/pagination"
+	"bikesRentalAPI/internal/payments"
+	paymentsmodels "bikesRentalAPI/internal/payments/models"
+	paymentsrepository "bikesRentalAPI/internal/payments/repository"
+	plansrepository "bikesRentalAPI/internal/plans/repository"
+	"bikesRentalAPI/internal/pricing"
+	"bikesRentalAPI/internal/promotions"
+	promotionsrepository "bikesRentalAPI/internal/promotions/repository"
+	"bikesRentalAPI/internal/quotes"
+	receiptsmodels "bikesRentalAPI/internal/receipts/models"
+	receiptsrepository "bikesRentalAPI/internal/receipts/repository"
+	"bikesRentalAPI/internal/rentals"
 	"bikesRentalAPI/internal/rentals/models"
+	stationsmodels "bikesRentalAPI/internal/stations/models"
+	stationsrepository "bikesRentalAPI/internal/stations/repository"
+	"bikesRentalAPI/internal/surge"
+	surgerepository "bikesRentalAPI/internal/surge/repository"
 	usersrepository "bikesRentalAPI/internal/users/repository"
+	walletrepository "bikesRentalAPI/internal/wallet/repository"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 )
 
-const (
-	// pageSize is the number of items to return in a page, 10 as default.
-	pageSize = 10
+// ListQuery is the whitelist of the filters and sort fields of the admin rental list
+var ListQuery = middlewares.QueryWhitelist{
+	Filters: map[string]middlewares.FilterField{
+		"user_id":        {Column: "user_id", Type: middlewares.FieldInt, Operator: middlewares.OpEqual},
+		"bike_id":        {Column: "bike_id", Type: middlewares.FieldInt, Operator: middlewares.OpEqual},
+		"status":         {Conditions: map[string]string{"ongoing": "end_time IS NULL", "ended": "end_time IS NOT NULL"}},
+		"payment_source": {Column: "payment_source", Type: middlewares.FieldString, Operator: middlewares.OpEqual},
+		"start_from":     {Column: "start_time", Type: middlewares.FieldTime, Operator: middlewares.OpGreaterOrEqual},
+		"start_to":       {Column: "start_time", Type: middlewares.FieldTime, Operator: middlewares.OpLessOrEqual},
+		"cost_min":       {Column: "cost", Type: middlewares.FieldFloat, Operator: middlewares.OpGreaterOrEqual},
+		"cost_max":       {Column: "cost", Type: middlewares.FieldFloat, Operator: middlewares.OpLessOrEqual},
+	},
+	Sorts: map[string]string{"id": "id", "start_time": "start_time", "cost": "cost", "duration_minutes": "duration_minutes"},
+}
+
+var (
+	// ErrUnlockFailed is returned when the lock of the bike could not be opened to start a rental
+	ErrUnlockFailed = errors.New("failed to unlock bike")
+	// ErrBikeNotLocked is returned when the lock of the bike does not report locked to end a rental
+	ErrBikeNotLocked = errors.New("bike is not locked")
+	// ErrStationMismatch is returned when a docked bike is rented from another station than the one it is docked at
+	ErrStationMismatch = errors.New("bike is not docked at the station")
+	// ErrStationRequired is returned when a bike picked up from a station is not returned to a station
+	ErrStationRequired = errors.New("bike must be returned to a station")
+	// ErrStationUnavailable is returned when the return station does not exist or has no free docks
+	ErrStationUnavailable = errors.New("station not found or full")
+	// ErrPaymentMethodRequired is returned when starting a rental without a default payment method
+	ErrPaymentMethodRequired = errors.New("payment method required")
+	// ErrPaymentDeclined is returned when the pre-authorization of a rental is refused by the payment provider
+	ErrPaymentDeclined = errors.New("payment pre-authorization declined")
+	// ErrNegativeBalance is returned when starting a rental while the wallet of the user is in debt
+	ErrNegativeBalance = errors.New("wallet balance is negative")
+	// ErrRentalNotEnded is returned when adjusting the cost of an ongoing rental
+	ErrRentalNotEnded = errors.New("rental has not ended")
+	// ErrNegativeCost is returned when an adjustment would make the cost of a rental negative
+	ErrNegativeCost = errors.New("adjustment makes the rental cost negative")
+	// ErrRentalPaused is returned when pausing a rental already paused
+	ErrRentalPaused = errors.New("rental is already paused")
+	// ErrRentalNotPaused is returned when resuming a rental that is not paused
+	ErrRentalNotPaused = errors.New("rental is not paused")
 )
 
+// rentalsTable lists the columns of a rental admins can update
+var rentalsTable = database.NewTable("rentals", "user_id", "bike_id", "start_latitude", "start_longitude").Versioned()
+
 type RentalRepository interface {
 	IsBikeAvailable(bikeID int64) bool
 	IsUserRentingBike(userID int64) bool
+	QuoteBike(userID, bikeID int64, minutes *int) (*models.BikeQuote, error)
 	StartRental(userID int64, startReq *models.StartBikeRentalRequest) (*models.StartRentalResponse, error)
 	EndRental(userID int64, endReq *models.StopBikeRentalRequest) (*models.StopRentalResponse, error)
 	GetOngoingRental(userID int64) (*models.Rental, error)
-	GetRentalHistoryByUserID(userID int64, PageID int64) (*models.RentalList, error)
+	PauseRental(userID int64) (*models.RentalPause, error)
+	ResumeRental(userID int64) (*models.RentalPause, error)
+	ResumeExpiredPauses(now time.Time) (int64, error)
+	GetRentalHistoryByUserID(userID int64, page *pagination.Page) (*models.RentalList, error)
 	GetRentalDetails(rentalID int64) (*models.Rental, error)
-	UpdateRental(rentalID int64, fieldsToUpdate map[string]interface{}) (int64, error)
-	ListAllRentals(pageID int64) (*models.RentalList, error)
+	UpdateRental(rentalID int64, fieldsToUpdate map[string]interface{}, version int64) (int64, error)
+	AdjustRental(rentalID int64, adjustReq *models.AdjustRentalRequest, createdBy string) (*models.AdjustRentalResponse, error)
+	ListAdjustments(rentalID int64) ([]*models.RentalAdjustment, error)
+	ListAllRentals(page *pagination.Page, spec *middlewares.QuerySpec) (*models.RentalList, error)
+	AddTrackPoints(rentalID int64, source string, points []models.TrackPointRequest) error
+	GetTrackPoints(rentalID int64) ([]*models.TrackPoint, error)
 }
 
 type rentalRepository struct {
-	db       database.Database
-	userRepo usersrepository.UserRepository
-	bikeRepo bikesrepository.BikeRepository
+	db             database.Database
+	userRepo       usersrepository.UserRepository
+	bikeRepo       bikesrepository.BikeRepository
+	lockController locks.LockController
+	stationRepo    stationsrepository.StationRepository
+	paymentRepo    paymentsrepository.PaymentRepository
+	provider       payments.PaymentProvider
+	walletRepo     walletrepository.WalletRepository
+	receiptRepo    receiptsrepository.ReceiptRepository
+	planRepo       plansrepository.PlanRepository
+	promoRepo      promotionsrepository.PromotionRepository
+	surgeRepo      surgerepository.SurgeRepository
+	preAuthAmount  float64
+	unlockFee      float64
+	surgeConfig    surge.Config
+	quoteSigner    *quotes.Signer
+	pauseConfig    rentals.PauseConfig
 }
 
 // New initializes a new empty rental repository
 func New(db database.Database,
 	userRepo usersrepository.UserRepository,
 	bikeRepo bikesrepository.BikeRepository,
+	lockController locks.LockController,
+	stationRepo stationsrepository.StationRepository,
+	paymentRepo paymentsrepository.PaymentRepository,
+	provider payments.PaymentProvider,
+	walletRepo walletrepository.WalletRepository,
+	receiptRepo receiptsrepository.ReceiptRepository,
+	planRepo plansrepository.PlanRepository,
+	promoRepo promotionsrepository.PromotionRepository,
+	surgeRepo surgerepository.SurgeRepository,
+	pauseConfig rentals.PauseConfig,
 ) RentalRepository {
 	return &rentalRepository{
-		db:       db,
-		userRepo: userRepo,
-		bikeRepo: bikeRepo,
+		db:             db,
+		userRepo:       userRepo,
+		bikeRepo:       bikeRepo,
+		lockController: lockController,
+		stationRepo:    stationRepo,
+		paymentRepo:    paymentRepo,
+		provider:       provider,
+		walletRepo:     walletRepo,
+		receiptRepo:    receiptRepo,
+		planRepo:       planRepo,
+		promoRepo:      promoRepo,
+		surgeRepo:      surgeRepo,
+		preAuthAmount:  payments.PreAuthAmountFromEnv(),
+		unlockFee:      pricing.UnlockFeeFromEnv(),
+		surgeConfig:    surge.ConfigFromEnv(),
+		quoteSigner:    quotes.NewSigner(quotes.ConfigFromEnv()),
+		pauseConfig:    pauseConfig,
 	}
 }
 
@@ -69,50 +176,401 @@ func (r *rentalRepository) IsUserRentingBike(userID int64) bool {
 	return count > 0
 }
 
+// QuoteBike returns the price per minute a bike is rented at now for the user, with a token guaranteeing it.
+// When minutes are given, the fare of a ride that long is estimated the same way it is charged when the rental ends
+func (r *rentalRepository) QuoteBike(userID, bikeID int64, minutes *int) (*models.BikeQuote, error) {
+	bike, err := r.bikeRepo.GetBikeByID(bikeID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := r.quoteBike(bike)
+	if err != nil {
+		return nil, err
+	}
+	if minutes != nil {
+		ride, err := r.priceRide(userID, quote.PricePerMinute, quote.PausedPricePerMinute, quote.QuotedAt, time.Duration(*minutes)*time.Minute, 0)
+		if err != nil {
+			return nil, err
+		}
+		quote.Minutes, quote.Estimate = minutes, &ride.breakdown
+	}
+	claims := &quotes.Claims{
+		UserID:          userID,
+		BikeID:          bike.ID,
+		PricePerMinute:  quote.PricePerMinute,
+		SurgeMultiplier: quote.SurgeMultiplier,
+	}
+	quote.Token, err = r.quoteSigner.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+	quote.ExpiresAt = claims.ExpiresAt
+	return quote, nil
+}
+
+// quoteBike applies dynamic pricing to the price of a bike from the available bikes and the recent demand around it
+func (r *rentalRepository) quoteBike(bike *bikesmodels.Bike) (*models.BikeQuote, error) {
+	quote := &models.BikeQuote{
+		BikeID:             bike.ID,
+		BasePricePerMinute: bike.PricePerMinute,
+		SurgeMultiplier:    1,
+		PricePerMinute:     bike.PricePerMinute,
+		QuotedAt:           time.Now().UTC(),
+	}
+	if !r.surgeConfig.Enabled {
+		quote.PausedPricePerMinute = r.pauseConfig.PausedPricePerMinute(quote.PricePerMinute)
+		return quote, nil
+	}
+	since := quote.QuotedAt.Add(-r.surgeConfig.Window)
+	availableBikes, recentDemand, err := r.surgeRepo.GetCellActivity(bike.Latitude, bike.Longitude, r.surgeConfig.CellSize, since)
+	if err != nil {
+		return nil, err
+	}
+	quote.AvailableBikes, quote.RecentDemand = &availableBikes, &recentDemand
+	quote.SurgeMultiplier = r.surgeConfig.Multiplier(availableBikes, recentDemand)
+	quote.PricePerMinute = r.surgeConfig.PricePerMinute(bike.PricePerMinute, quote.SurgeMultiplier)
+	quote.PausedPricePerMinute = r.pauseConfig.PausedPricePerMinute(quote.PricePerMinute)
+	return quote, nil
+}
+
+// quoteRental returns the price a rental of the bike starts at. A quote token must have been issued to the user for the bike
+// and not be expired, its price is used instead of the current one
+func (r *rentalRepository) quoteRental(userID int64, bike *bikesmodels.Bike, quoteToken *string) (*models.BikeQuote, error) {
+	if quoteToken == nil {
+		quote, err := r.quoteBike(bike)
+		if err != nil {
+			return nil, fmt.Errorf("failed to quote bike: %v", err)
+		}
+		return quote, nil
+	}
+	claims, err := r.quoteSigner.Verify(*quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID != userID || claims.BikeID != bike.ID {
+		return nil, quotes.ErrInvalidQuote
+	}
+	return &models.BikeQuote{
+		BikeID:               bike.ID,
+		BasePricePerMinute:   bike.PricePerMinute,
+		SurgeMultiplier:      claims.SurgeMultiplier,
+		PricePerMinute:       claims.PricePerMinute,
+		PausedPricePerMinute: r.pauseConfig.PausedPricePerMinute(claims.PricePerMinute),
+		ExpiresAt:            claims.ExpiresAt,
+	}, nil
+}
+
 func (r *rentalRepository) StartRental(userID int64, startReq *models.StartBikeRentalRequest) (*models.StartRentalResponse, error) {
 	if startReq == nil {
 		return nil, fmt.Errorf("startReq request is nil")
 	}
+	bike, err := r.bikeRepo.GetBikeByID(startReq.BikeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bike: %v", err)
+	}
+	// Docked bikes can only be picked up from the station they are docked at
+	if bike.StationID != nil && (startReq.StationID == nil || *startReq.StationID != *bike.StationID) {
+		return nil, ErrStationMismatch
+	}
+	// The price quoted now is charged for the whole rental, unless the user started it with a valid quote
+	quote, err := r.quoteRental(userID, bike, startReq.QuoteToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Riders in debt must top up their wallet before renting again
+	ctx := context.Background()
+	balance, err := r.walletRepo.GetBalance(r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance < 0 {
+		return nil, ErrNegativeBalance
+	}
+
+	// The rental is paid with wallet credit when it covers the pre-authorization amount.
+	// Otherwise the bike is only unlocked once the amount is held on the payment method of the user
+	paymentSource := models.PaymentSourceWallet
+	var method *paymentsmodels.PaymentMethod
+	var reference string
+	if balance < helpers.ToMinorUnits(r.preAuthAmount) {
+		paymentSource = models.PaymentSourceCard
+		method, err = r.paymentRepo.GetDefaultPaymentMethod(userID)
+		if err != nil {
+			if errors.Is(err, paymentsrepository.ErrPaymentMethodNotFound) {
+				return nil, ErrPaymentMethodRequired
+			}
+			return nil, fmt.Errorf("failed to get payment method: %v", err)
+		}
+		reference, err = r.provider.Authorize(ctx, method.Token, r.preAuthAmount)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+		}
+	}
+
 	now := time.Now().UTC()
 	initialCost := 0.0
 	var id int64
 
-	r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
-		query := "INSERT INTO rentals (user_id, bike_id, start_time, start_latitude, start_longitude, cost) VALUES (?, ?, ?, ?, ?, ?)"
-		result, err := r.db.Exec(query, userID, startReq.BikeID, now, startReq.Latitude, startReq.Longitude, initialCost)
+	err = r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		query := "INSERT INTO rentals (user_id, bike_id, start_time, start_latitude, start_longitude, start_station_id, payment_source, price_per_minute, surge_multiplier, paused_price_per_minute, cost) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		result, err := tx.Exec(query, userID, startReq.BikeID, now, startReq.Latitude, startReq.Longitude, bike.StationID, paymentSource, quote.PricePerMinute, quote.SurgeMultiplier, quote.PausedPricePerMinute, initialCost)
 		if err != nil {
 			return fmt.Errorf("failed to insert rental: %v", err)
 		}
-		err = r.bikeRepo.SetBikeAvailability(startReq.BikeID, false)
+		err = r.bikeRepo.SetBikeAvailability(tx, startReq.BikeID, false)
 		if err != nil {
 			return fmt.Errorf("failed to set bike availability: %v", err)
 		}
+		if bike.StationID != nil {
+			// The bike leaves its dock
+			if err := r.bikeRepo.SetBikeLocation(tx, bike.ID, bike.Latitude, bike.Longitude, nil); err != nil {
+				return fmt.Errorf("failed to undock bike: %v", err)
+			}
+		}
 		id, err = result.LastInsertId()
 		if err != nil {
 			return fmt.Errorf("failed to get last insert id: %v", err)
 		}
+		if method != nil {
+			_, err = r.paymentRepo.CreatePayment(tx, &paymentsmodels.Payment{
+				RentalID:          id,
+				UserID:            userID,
+				PaymentMethodID:   method.ID,
+				ProviderReference: reference,
+				AmountAuthorized:  r.preAuthAmount,
+				Status:            paymentsmodels.PaymentStatusAuthorized,
+			})
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
+	if err != nil {
+		r.voidAuthorization(ctx, reference)
+		return nil, err
+	}
+
+	// The lock is driven once the rental is committed, a slow lock must not hold the database.
+	// The rental is undone when the lock does not confirm it is open
+	if err := r.lockController.Unlock(ctx, startReq.BikeID); err != nil {
+		if cancelErr := r.cancelRentalStart(ctx, id, bike); cancelErr != nil {
+			// The rental stays ongoing and is charged as usual when the rider ends it
+			log.Printf("Error cancelling rental %d after the bike could not be unlocked: %v", id, cancelErr)
+		} else {
+			r.voidAuthorization(ctx, reference)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnlockFailed, err)
+	}
 
 	return &models.StartRentalResponse{
-		ID:        id,
-		StartTime: now,
-		Latitude:  startReq.Latitude,
-		Longitude: startReq.Longitude,
+		ID:              id,
+		StartTime:       now,
+		Latitude:        startReq.Latitude,
+		Longitude:       startReq.Longitude,
+		StationID:       bike.StationID,
+		PaymentSource:   paymentSource,
+		PricePerMinute:  quote.PricePerMinute,
+		SurgeMultiplier: quote.SurgeMultiplier,
 	}, nil
 
 }
 
+// cancelRentalStart deletes a rental whose bike could not be unlocked and its payment, and puts the bike back where it was
+func (r *rentalRepository) cancelRentalStart(ctx context.Context, rentalID int64, bike *bikesmodels.Bike) error {
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM payments WHERE rental_id = ?", rentalID); err != nil {
+			return fmt.Errorf("failed to delete payment: %v", err)
+		}
+		if _, err := tx.Exec("DELETE FROM rentals WHERE id = ? AND end_time IS NULL", rentalID); err != nil {
+			return fmt.Errorf("failed to delete rental: %v", err)
+		}
+		if bike.StationID != nil {
+			// The bike goes back to its dock
+			if err := r.bikeRepo.SetBikeLocation(tx, bike.ID, bike.Latitude, bike.Longitude, bike.StationID); err != nil {
+				return fmt.Errorf("failed to dock bike: %v", err)
+			}
+		}
+		if err := r.bikeRepo.SetBikeAvailability(tx, bike.ID, true); err != nil {
+			return fmt.Errorf("failed to set bike availability: %v", err)
+		}
+		return nil
+	})
+}
+
+// voidAuthorization releases the amount held on the payment method for a rental that did not start. Nothing is held for wallet rentals
+func (r *rentalRepository) voidAuthorization(ctx context.Context, reference string) {
+	if reference == "" {
+		return
+	}
+	if err := r.provider.Void(ctx, reference); err != nil {
+		log.Printf("Error voiding payment authorization %s after failed rental start: %v", reference, err)
+	}
+}
+
 func (r *rentalRepository) GetOngoingRental(userID int64) (*models.Rental, error) {
 	var rental models.Rental
-	query := "SELECT id, user_id, bike_id, start_time, start_latitude, start_longitude, cost FROM rentals WHERE user_id = ? AND end_time IS NULL ORDER BY start_time DESC LIMIT 1"
-	err := r.db.QueryRow(query, userID).Scan(&rental.ID, &rental.UserID, &rental.BikeID, &rental.StartTime, &rental.StartLatitude, &rental.StartLongitude, &rental.Cost)
+	query := "SELECT id, user_id, bike_id, start_time, start_latitude, start_longitude, start_station_id, payment_source, price_per_minute, surge_multiplier, paused_price_per_minute, cost FROM rentals WHERE user_id = ? AND end_time IS NULL ORDER BY start_time DESC LIMIT 1"
+	err := r.db.QueryRow(query, userID).Scan(&rental.ID, &rental.UserID, &rental.BikeID, &rental.StartTime, &rental.StartLatitude, &rental.StartLongitude, &rental.StartStationID, &rental.PaymentSource, &rental.PricePerMinute, &rental.SurgeMultiplier, &rental.PausedPricePerMinute, &rental.Cost)
 	if err != nil {
 		return nil, err
 	}
 	return &rental, nil
 }
 
+// PauseRental pauses the ongoing rental of the user. The bike is locked and held for the rider, the paused time is charged
+// at the paused price until the rider resumes or the maximum pause duration is reached
+func (r *rentalRepository) PauseRental(userID int64) (*models.RentalPause, error) {
+	rental, err := r.GetOngoingRental(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ongoing rental: %w", err)
+	}
+	// The rental is only paused once the lock reports the rider locked the bike. The lock is queried before the transaction,
+	// a slow lock must not hold the database
+	ctx := context.Background()
+	if err := r.requireLocked(ctx, rental.BikeID); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	pause := &models.RentalPause{RentalID: rental.ID, StartedAt: now}
+	err = r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		var open int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM rental_pauses WHERE rental_id = ? AND ended_at IS NULL", rental.ID).Scan(&open); err != nil {
+			return fmt.Errorf("failed to check open pauses: %v", err)
+		}
+		if open > 0 {
+			return ErrRentalPaused
+		}
+		result, err := tx.Exec("INSERT INTO rental_pauses (rental_id, started_at) VALUES (?, ?)", rental.ID, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert pause: %v", err)
+		}
+		pause.ID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pausedPricePerMinute := rental.PausedPricePerMinute
+	if pausedPricePerMinute == nil && rental.PricePerMinute != nil {
+		price := r.pauseConfig.PausedPricePerMinute(*rental.PricePerMinute)
+		pausedPricePerMinute = &price
+	}
+	resumesAt := now.Add(r.pauseConfig.MaxDuration)
+	pause.PausedPricePerMinute, pause.ResumesAt = pausedPricePerMinute, &resumesAt
+	return pause, nil
+}
+
+// ResumeRental ends the pause of the ongoing rental of the user and unlocks the bike.
+// Pauses ended by the maximum pause duration are already charged the regular price, resuming them only unlocks the bike
+func (r *rentalRepository) ResumeRental(userID int64) (*models.RentalPause, error) {
+	rental, err := r.GetOngoingRental(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ongoing rental: %w", err)
+	}
+	pauses, err := r.listPauses(rental.ID)
+	if err != nil {
+		return nil, err
+	}
+	rentalPauses := pauses[rental.ID]
+	if len(rentalPauses) == 0 {
+		return nil, ErrRentalNotPaused
+	}
+	pause := rentalPauses[len(rentalPauses)-1]
+	if pause.EndedAt != nil && !pause.AutoResumed {
+		return nil, ErrRentalNotPaused
+	}
+
+	// The pause is only ended once the lock confirms it is open. The lock is driven outside any transaction,
+	// a slow lock must not hold the database
+	ctx := context.Background()
+	if err := r.lockController.Unlock(ctx, rental.BikeID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnlockFailed, err)
+	}
+	if pause.EndedAt == nil {
+		now := time.Now().UTC()
+		if _, err := r.db.Exec("UPDATE rental_pauses SET ended_at = ? WHERE id = ? AND ended_at IS NULL", now, pause.ID); err != nil {
+			// The pause goes on, do not leave the bike open
+			if lockErr := r.lockController.Lock(ctx, rental.BikeID); lockErr != nil {
+				log.Printf("Error locking bike %d after failed rental resume: %v", rental.BikeID, lockErr)
+			}
+			return nil, fmt.Errorf("failed to end pause: %v", err)
+		}
+		pause.EndedAt = &now
+	}
+	return pause, nil
+}
+
+// ResumeExpiredPauses ends the pauses open for longer than the maximum pause duration and returns how many were ended.
+// They end when the maximum was reached and the regular price applies from then on. The bike stays locked until the rider resumes
+func (r *rentalRepository) ResumeExpiredPauses(now time.Time) (int64, error) {
+	deadline := now.UTC().Add(-r.pauseConfig.MaxDuration)
+	rows, err := r.db.Query("SELECT id, started_at FROM rental_pauses WHERE ended_at IS NULL AND started_at <= ?", deadline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired pauses: %v", err)
+	}
+	expired := make([]*models.RentalPause, 0)
+	for rows.Next() {
+		var pause models.RentalPause
+		if err := rows.Scan(&pause.ID, &pause.StartedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan pause: %v", err)
+		}
+		expired = append(expired, &pause)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get expired pauses: %v", err)
+	}
+
+	var resumed int64
+	for _, pause := range expired {
+		endedAt := pause.StartedAt.UTC().Add(r.pauseConfig.MaxDuration)
+		result, err := r.db.Exec("UPDATE rental_pauses SET ended_at = ?, auto_resumed = 1 WHERE id = ? AND ended_at IS NULL", endedAt, pause.ID)
+		if err != nil {
+			return resumed, fmt.Errorf("failed to end pause: %v", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return resumed, fmt.Errorf("failed to get rows affected: %v", err)
+		}
+		resumed += affected
+	}
+	return resumed, nil
+}
+
+// listPauses returns the pauses of the rentals by rental id, oldest first
+func (r *rentalRepository) listPauses(rentalIDs ...int64) (map[int64][]*models.RentalPause, error) {
+	pauses := make(map[int64][]*models.RentalPause)
+	if len(rentalIDs) == 0 {
+		return pauses, nil
+	}
+	args := make([]interface{}, len(rentalIDs))
+	for i, id := range rentalIDs {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(rentalIDs)), ", ")
+	query := fmt.Sprintf("SELECT id, rental_id, started_at, ended_at, auto_resumed FROM rental_pauses WHERE rental_id IN (%s) ORDER BY started_at, id", placeholders)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pauses: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pause models.RentalPause
+		if err := rows.Scan(&pause.ID, &pause.RentalID, &pause.StartedAt, &pause.EndedAt, &pause.AutoResumed); err != nil {
+			return nil, fmt.Errorf("failed to scan pause: %v", err)
+		}
+		pauses[pause.RentalID] = append(pauses[pause.RentalID], &pause)
+	}
+	return pauses, rows.Err()
+}
+
 func (r *rentalRepository) EndRental(userID int64, endReq *models.StopBikeRentalRequest) (*models.StopRentalResponse, error) {
 	if endReq == nil {
 		return nil, fmt.Errorf("endReq request is nil")
@@ -122,53 +580,321 @@ func (r *rentalRepository) EndRental(userID int64, endReq *models.StopBikeRental
 		return nil, fmt.Errorf("failed to get ongoing rental: %v", err)
 	}
 
-	bikeCostPerMin, err := r.bikeRepo.GetBikeCostPerMinute(rental.BikeID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get bike cost per minute: %v", err)
+	// Bikes picked up from a station must be returned to a station with a free dock
+	var endStation *stationsmodels.Station
+	if endReq.StationID != nil {
+		endStation, err = r.getReturnStation(r.db, *endReq.StationID)
+		if err != nil {
+			return nil, err
+		}
+	} else if rental.StartStationID != nil {
+		return nil, ErrStationRequired
+	}
+
+	// The rental can only end once the lock reports the rider locked the bike
+	ctx := context.Background()
+	if err := r.requireLocked(ctx, rental.BikeID); err != nil {
+		return nil, err
+	}
+
+	// Rentals are charged the price locked when they started, the current price of the bike for older rentals
+	var bikeCostPerMin float64
+	if rental.PricePerMinute != nil {
+		bikeCostPerMin = *rental.PricePerMinute
+	} else {
+		bikeCostPerMin, err = r.bikeRepo.GetBikeCostPerMinute(rental.BikeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get bike cost per minute: %v", err)
+		}
 	}
 
 	// For this example, we will generate random latitude and longitude for the end location.
+	// Bikes returned to a station end at the station location
 	finalLat, finalLon := helpers.GetRandomLatLon(rental.StartLatitude, rental.StartLongitude)
+	if endStation != nil {
+		finalLat, finalLon = endStation.Latitude, endStation.Longitude
+	}
+
+	// The travelled distance follows the recorded track from the start to the end location
+	trackPoints, err := r.GetTrackPoints(rental.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rental track: %v", err)
+	}
+	distance := calculateRentalDistance(rental, trackPoints, finalLat, finalLon)
+
+	// Paused time is charged at the paused price locked when the rental started, a share of the current price for older rentals
+	pausedCostPerMin := r.pauseConfig.PausedPricePerMinute(bikeCostPerMin)
+	if rental.PausedPricePerMinute != nil {
+		pausedCostPerMin = *rental.PausedPricePerMinute
+	}
+	pauses, err := r.listPauses(rental.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	now := time.Now().UTC()
 	duration := now.Sub(rental.StartTime.UTC())
 	durationInMinutes := int(duration.Round(time.Minute).Minutes())
-	cost := calculateRentalCost(bikeCostPerMin, duration)
+	paused := rentals.PausedDuration(pauses[rental.ID], now)
+	ride, err := r.priceRide(rental.UserID, bikeCostPerMin, pausedCostPerMin, rental.StartTime.UTC(), duration, paused)
+	if err != nil {
+		return nil, err
+	}
+	breakdown := ride.breakdown
+	cost := breakdown.Total
+	var walletAmount int64
+	var invoice *receiptsmodels.Invoice
 
-	r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+	err = r.db.Transaction(ctx, func(tx *sql.Tx) error {
 
-		query := "UPDATE rentals SET end_time = ?, end_latitude = ?, end_longitude = ?, duration_minutes = ?, cost = ? WHERE id = ?"
-		_, err = r.db.Exec(query, now, finalLat, finalLon, durationInMinutes, cost, rental.ID)
+		if endStation != nil {
+			// Checked again in the transaction, the last dock may have been taken meanwhile
+			if _, err := r.getReturnStation(tx, endStation.ID); err != nil {
+				return err
+			}
+		}
+		query := "UPDATE rentals SET end_time = ?, end_latitude = ?, end_longitude = ?, end_station_id = ?, duration_minutes = ?, cost = ?, distance_km = ?, plan_id = ?, subscription_id = ?, promo_redemption_id = ? WHERE id = ?"
+		_, err := tx.Exec(query, now, finalLat, finalLon, endReq.StationID, durationInMinutes, cost, distance, ride.planID, ride.subscriptionID, ride.redemptionID, rental.ID)
 		if err != nil {
 			return fmt.Errorf("failed to update rental: %v", err)
 		}
-		err = r.bikeRepo.SetBikeAvailability(rental.BikeID, true)
+		// A pause still open ends with the rental
+		if _, err := tx.Exec("UPDATE rental_pauses SET ended_at = ? WHERE rental_id = ? AND ended_at IS NULL", now, rental.ID); err != nil {
+			return fmt.Errorf("failed to end pause: %v", err)
+		}
+		err = r.bikeRepo.SetBikeAvailability(tx, rental.BikeID, true)
 		if err != nil {
 			return fmt.Errorf("failed to set bike availability: %v", err)
 		}
+		err = r.bikeRepo.SetBikeLocation(tx, rental.BikeID, finalLat, finalLon, endReq.StationID)
+		if err != nil {
+			return fmt.Errorf("failed to set bike location: %v", err)
+		}
+		walletAmount, err = r.debitWallet(tx, rental, helpers.ToMinorUnits(cost))
+		if err != nil {
+			return err
+		}
+		if ride.redemptionID != nil {
+			if err := r.promoRepo.UseRedemption(tx, *ride.redemptionID); err != nil {
+				return err
+			}
+		}
+		// Referred users reward their referrer when their first ride ends
+		if _, err := r.promoRepo.CreditReferral(tx, rental.UserID, rental.ID); err != nil {
+			return err
+		}
+		invoice, err = r.receiptRepo.CreateInvoice(tx, rental.ID, rental.UserID, breakdown, now)
+		if err != nil {
+			return fmt.Errorf("failed to issue invoice: %v", err)
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The part of the cost not paid with wallet credit is charged on the payment method, what can't be charged is owed by the rider
+	paymentStatus, debt, err := r.capturePayment(ctx, rental, max(0, helpers.ToMinorUnits(cost)-walletAmount))
+	if err != nil {
+		// The rental is over even if the rider could not be charged
+		log.Printf("Error capturing payment of rental %d: %v", rental.ID, err)
+	}
 
 	return &models.StopRentalResponse{
-		BikeID:          rental.BikeID,
-		EndTime:         now,
-		Latitude:        finalLat,
-		Longitude:       finalLon,
-		Cost:            cost,
-		DurationMinutes: durationInMinutes,
+		BikeID:            rental.BikeID,
+		EndTime:           now,
+		Latitude:          finalLat,
+		Longitude:         finalLon,
+		Cost:              cost,
+		DurationMinutes:   durationInMinutes,
+		Distance:          distance,
+		StationID:         endReq.StationID,
+		PaymentStatus:     paymentStatus,
+		WalletAmount:      helpers.FromMinorUnits(walletAmount),
+		OutstandingAmount: helpers.FromMinorUnits(debt),
+		InvoiceNumber:     invoice.Number,
+		PlanID:            ride.planID,
+		PausedMinutes:     int(paused.Round(time.Minute).Minutes()),
 	}, nil
 }
 
-// calculateRentalCost calculates the cost of a rental based on the cost per minute and the duration of the rental
-func calculateRentalCost(costPerMin float64, duration time.Duration) float64 {
-	return costPerMin * duration.Minutes()
+// requireLocked returns ErrBikeNotLocked unless the lock of the bike reports it is locked.
+// Bikes are locked by the rider, the lock is only queried
+func (r *rentalRepository) requireLocked(ctx context.Context, bikeID int64) error {
+	state, err := r.lockController.State(ctx, bikeID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBikeNotLocked, err)
+	}
+	if state != locks.Locked {
+		return fmt.Errorf("%w: lock reported %s", ErrBikeNotLocked, state)
+	}
+	return nil
 }
 
-// GetRentalHistoryByUserID returns a list of rentals for a user, paginated
-func (r *rentalRepository) GetRentalHistoryByUserID(userID int64, PageID int64) (*models.RentalList, error) {
+// pricedRide is the cost breakdown of a ride with the plan and promo code applied to it
+type pricedRide struct {
+	breakdown      pricing.Breakdown
+	planID         *int64
+	subscriptionID *int64
+	redemptionID   *int64
+}
+
+// priceRide computes the cost breakdown of a ride started at the given time: the unlock fee, the ride time and the paused time,
+// less the benefits of the plan the user was subscribed to at the start and of the oldest promo code giving something on the ride.
+// The plan only applies to the ride time, the unlock fee and the paused time are charged in full
+func (r *rentalRepository) priceRide(userID int64, pricePerMinute, pausedPricePerMinute float64, startTime time.Time, duration, paused time.Duration) (*pricedRide, error) {
+	ride := &pricedRide{}
+	if r.unlockFee > 0 {
+		ride.breakdown.Add(pricing.UnlockFee(r.unlockFee))
+	}
+	rideTime := duration - paused
+	ride.breakdown.Add(pricing.RideTime(pricePerMinute, rideTime))
+	if paused > 0 {
+		ride.breakdown.Add(pricing.PausedTime(pausedPricePerMinute, paused))
+	}
+
+	subscription, err := r.planRepo.GetActiveSubscription(r.db, userID, startTime)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get subscription: %v", err)
+	}
+	if subscription != nil {
+		ride.planID, ride.subscriptionID = &subscription.PlanID, &subscription.ID
+		ride.breakdown.ApplyPlan(pricing.PlanTerms{
+			Name:            subscription.Plan.Name,
+			IncludedMinutes: subscription.Plan.IncludedMinutesPerRide,
+			DiscountPercent: subscription.Plan.DiscountPercent,
+		}, pricePerMinute, rideTime)
+	}
+
+	// Codes giving nothing on this ride, like free unlocks without an unlock fee, are kept for later rides
+	redemptions, err := r.promoRepo.ListUsableRedemptions(r.db, userID, startTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo codes: %v", err)
+	}
+	for _, redemption := range redemptions {
+		if promotions.Apply(&ride.breakdown, redemption) {
+			ride.redemptionID = &redemption.ID
+			break
+		}
+	}
+	return ride, nil
+}
+
+// debitWallet debits the cost of a rental, in cents, from the wallet of the user in the transaction ending it and returns the debited amount.
+// Wallet rentals are fully debited, even when the balance is not enough. Card rentals only use the available credit
+func (r *rentalRepository) debitWallet(tx *sql.Tx, rental *models.Rental, cost int64) (int64, error) {
+	if rental.PaymentSource == nil || cost <= 0 {
+		return 0, nil
+	}
+	amount := cost
+	if *rental.PaymentSource == models.PaymentSourceCard {
+		balance, err := r.walletRepo.GetBalance(tx, rental.UserID)
+		if err != nil {
+			return 0, err
+		}
+		if balance < amount {
+			amount = balance
+		}
+	}
+	if amount <= 0 {
+		return 0, nil
+	}
+	if _, err := r.walletRepo.DebitRental(tx, rental.UserID, rental.ID, amount); err != nil {
+		return 0, fmt.Errorf("failed to debit wallet: %v", err)
+	}
+	return amount, nil
+}
+
+// capturePayment charges the cost of a rental, in cents, on the authorization taken when it started and returns the payment status
+// and the amount left owed by the rider. The authorization is voided when there is nothing to charge.
+// At most the authorized amount is captured: the rest of the cost, or all of it when the capture fails, is debited from the wallet
+// of the rider, whose negative balance keeps them from renting until it is paid.
+// Rentals started before payments were introduced have no payment and are not charged
+func (r *rentalRepository) capturePayment(ctx context.Context, rental *models.Rental, cost int64) (string, int64, error) {
+	payment, err := r.paymentRepo.GetPaymentByRentalID(r.db, rental.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", 0, nil
+		}
+		return "", 0, fmt.Errorf("failed to get payment: %v", err)
+	}
+	if payment.Status != paymentsmodels.PaymentStatusAuthorized {
+		return string(payment.Status), 0, nil
+	}
+
+	if cost <= 0 {
+		if err := r.provider.Void(ctx, payment.ProviderReference); err != nil {
+			return string(payment.Status), 0, fmt.Errorf("failed to void authorization: %v", err)
+		}
+		payment.Status = paymentsmodels.PaymentStatusVoided
+		if err := r.paymentRepo.UpdatePayment(r.db, payment); err != nil {
+			return "", 0, err
+		}
+		return string(payment.Status), 0, nil
+	}
+
+	capture := min(cost, helpers.ToMinorUnits(payment.AmountAuthorized))
+	captureErr := r.provider.Capture(ctx, payment.ProviderReference, helpers.FromMinorUnits(capture))
+	if captureErr != nil {
+		reason := captureErr.Error()
+		payment.Status = paymentsmodels.PaymentStatusFailed
+		payment.FailureReason = &reason
+		capture = 0
+	} else {
+		payment.Status = paymentsmodels.PaymentStatusCaptured
+		payment.AmountCaptured = helpers.FromMinorUnits(capture)
+	}
+	if err := r.paymentRepo.UpdatePayment(r.db, payment); err != nil {
+		return "", 0, err
+	}
+
+	debt := cost - capture
+	if debt > 0 {
+		if _, err := r.walletRepo.DebitRental(r.db, rental.UserID, rental.ID, debt); err != nil {
+			return string(payment.Status), 0, fmt.Errorf("failed to record outstanding balance of %d cents: %v", debt, err)
+		}
+	}
+	if captureErr != nil {
+		return string(payment.Status), debt, captureErr
+	}
+	return string(payment.Status), debt, nil
+}
 
-	query := "SELECT id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude, duration_minutes, cost, created_at, updated_at FROM rentals WHERE user_id = ? AND id > ? ORDER BY id LIMIT ?"
-	rows, err := r.db.Query(query, userID, PageID, pageSize)
+// getReturnStation returns the station a bike is returned to, if it exists and has a free dock. q is either the database or an ongoing transaction
+func (r *rentalRepository) getReturnStation(q database.Querier, stationID int64) (*stationsmodels.Station, error) {
+	station, err := r.stationRepo.GetStationByID(stationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStationUnavailable
+		}
+		return nil, fmt.Errorf("failed to get station: %v", err)
+	}
+	docked, err := r.stationRepo.CountDockedBikes(q, stationID)
+	if err != nil {
+		return nil, err
+	}
+	if docked >= station.Capacity {
+		return nil, ErrStationUnavailable
+	}
+	return station, nil
+}
+
+// calculateRentalDistance calculates the travelled distance in kilometers from the start location, through the recorded track, to the end location
+func calculateRentalDistance(rental *models.Rental, trackPoints []*models.TrackPoint, endLat, endLon float64) float64 {
+	path := make([][2]float64, 0, len(trackPoints)+2)
+	path = append(path, [2]float64{rental.StartLatitude, rental.StartLongitude})
+	for _, point := range trackPoints {
+		path = append(path, [2]float64{point.Latitude, point.Longitude})
+	}
+	path = append(path, [2]float64{endLat, endLon})
+	return helpers.GetPathDistanceKm(path)
+}
+
+// GetRentalHistoryByUserID returns a list of rentals for a user, paginated
+func (r *rentalRepository) GetRentalHistoryByUserID(userID int64, page *pagination.Page) (*models.RentalList, error) {
+	keyset, keysetArgs, orderBy := page.Keyset("id", false)
+	query := fmt.Sprintf("SELECT id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude, start_station_id, end_station_id, payment_source, plan_id, subscription_id, price_per_minute, surge_multiplier, paused_price_per_minute, duration_minutes, cost, distance_km, created_at, updated_at FROM rentals WHERE user_id = ? AND %s ORDER BY %s LIMIT ?", keyset, orderBy)
+	rows, err := r.db.Query(query, append(append([]interface{}{userID}, keysetArgs...), page.FetchLimit())...)
 	if err != nil {
 		return nil, err
 	}
@@ -188,8 +914,17 @@ func (r *rentalRepository) GetRentalHistoryByUserID(userID int64, PageID int64)
 			&rental.StartLongitude,
 			&rental.EndLatitude,
 			&rental.EndLongitude,
+			&rental.StartStationID,
+			&rental.EndStationID,
+			&rental.PaymentSource,
+			&rental.PlanID,
+			&rental.SubscriptionID,
+			&rental.PricePerMinute,
+			&rental.SurgeMultiplier,
+			&rental.PausedPricePerMinute,
 			&rental.DurationMinutes,
 			&rental.Cost,
+			&rental.Distance,
 			&rental.CreatedAt,
 			&rental.UpdatedAt,
 		); err != nil {
@@ -197,16 +932,34 @@ func (r *rentalRepository) GetRentalHistoryByUserID(userID int64, PageID int64)
 		}
 		rentalList = append(rentalList, &rental)
 	}
-	if len(rentalList) == pageSize {
-		rentals.NextPageID = rentalList[len(rentalList)-1].ID
+	// Pause segments are shown in the history
+	rentalIDs := make([]int64, len(rentalList))
+	for i, rental := range rentalList {
+		rentalIDs[i] = rental.ID
+	}
+	pauses, err := r.listPauses(rentalIDs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, rental := range rentalList {
+		rental.Pauses = pauses[rental.ID]
+	}
+	pageRows := make([]pagination.Row, len(rentalList))
+	for i, rental := range rentalList {
+		pageRows[i] = pagination.Row{ID: rental.ID}
+	}
+	rentals.Items, rentals.Page = pagination.Paginate(page, rentalList, pageRows)
+	if page.WithTotal {
+		if rentals.Page.Total, err = r.count("user_id = ?", userID); err != nil {
+			return nil, err
+		}
 	}
-	rentals.Items = rentalList
 	return rentals, nil
 }
 
 // GetRentalDetails returns the details of a rental
 func (r *rentalRepository) GetRentalDetails(rentalID int64) (*models.Rental, error) {
-	query := "SELECT id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude, duration_minutes, cost, created_at, updated_at FROM rentals WHERE id = ?"
+	query := "SELECT id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude, start_station_id, end_station_id, payment_source, plan_id, subscription_id, price_per_minute, surge_multiplier, paused_price_per_minute, duration_minutes, cost, distance_km, created_at, updated_at, version FROM rentals WHERE id = ?"
 	row := r.db.QueryRow(query, rentalID)
 	var rental models.Rental
 	if err := row.Scan(&rental.ID,
@@ -218,54 +971,59 @@ func (r *rentalRepository) GetRentalDetails(rentalID int64) (*models.Rental, err
 		&rental.StartLongitude,
 		&rental.EndLatitude,
 		&rental.EndLongitude,
+		&rental.StartStationID,
+		&rental.EndStationID,
+		&rental.PaymentSource,
+		&rental.PlanID,
+		&rental.SubscriptionID,
+		&rental.PricePerMinute,
+		&rental.SurgeMultiplier,
+		&rental.PausedPricePerMinute,
 		&rental.DurationMinutes,
 		&rental.Cost,
+		&rental.Distance,
 		&rental.CreatedAt,
 		&rental.UpdatedAt,
+		&rental.Version,
 	); err != nil {
 		return nil, fmt.Errorf("failed to get rental details: %v", err)
 	}
-	return &rental, nil
-}
-
-// UpdateRental updates a rental in the database by id. Returns the id of the updated rental. If no fields are updated, returns 0 and an error
-func (r *rentalRepository) UpdateRental(rentalID int64, fieldsToUpdate map[string]interface{}) (int64, error) {
-	var setFields []string
-	var args []interface{}
-	var id int64
 
-	for field, value := range fieldsToUpdate {
-		setFields = append(setFields, fmt.Sprintf("%s = ?", field))
-		args = append(args, value)
+	adjustments, err := r.ListAdjustments(rentalID)
+	if err != nil {
+		return nil, err
 	}
-	args = append(args, rentalID)
-
-	r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
-
-		query := fmt.Sprintf("UPDATE rentals SET %s WHERE id = ?", strings.Join(setFields, ", "))
-		stmt, err := r.db.Prepare(query)
-		if err != nil {
-			return fmt.Errorf("failed to prepare update statement: %v", err)
-		}
-		defer stmt.Close()
+	effectiveCost := rental.Cost
+	for _, adjustment := range adjustments {
+		effectiveCost += adjustment.Amount
+	}
+	rental.Adjustments = adjustments
+	rental.EffectiveCost = &effectiveCost
 
-		result, err := stmt.Exec(args...)
-		if err != nil {
-			return fmt.Errorf("failed to execute update statement: %v", err)
-		}
+	pauses, err := r.listPauses(rentalID)
+	if err != nil {
+		return nil, err
+	}
+	rental.Pauses = pauses[rentalID]
+	return &rental, nil
+}
 
-		id, err = result.LastInsertId()
-		if err != nil {
-			return fmt.Errorf("failed to get last insert id: %v", err)
-		}
-		return nil
-	})
-	return id, nil
+// UpdateRental updates a rental in the database by id if it is still at the version it was read at. Returns the id of the updated rental.
+// If no fields are updated, returns 0 and an error, database.ErrVersionConflict when the rental was changed since
+func (r *rentalRepository) UpdateRental(rentalID int64, fieldsToUpdate map[string]interface{}, version int64) (int64, error) {
+	if _, err := rentalsTable.UpdateVersion(r.db, rentalID, fieldsToUpdate, version); err != nil {
+		return 0, err
+	}
+	return rentalID, nil
 }
 
-func (r *rentalRepository) ListAllRentals(pageID int64) (*models.RentalList, error) {
-	query := "SELECT id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude, duration_minutes, cost, created_at, updated_at FROM rentals WHERE id > ? ORDER BY id LIMIT ?"
-	rows, err := r.db.Query(query, pageID, pageSize)
+// ListAllRentals returns the rentals matching the filters of the spec, in its sort order
+func (r *rentalRepository) ListAllRentals(page *pagination.Page, spec *middlewares.QuerySpec) (*models.RentalList, error) {
+	where, args := spec.Where()
+	keyset, keysetArgs, orderBy := page.Keyset(spec.SortColumn, spec.Descending)
+	query := fmt.Sprintf("SELECT id, user_id, bike_id, start_time, end_time, start_latitude, start_longitude, end_latitude, end_longitude, start_station_id, end_station_id, payment_source, plan_id, subscription_id, price_per_minute, surge_multiplier, paused_price_per_minute, duration_minutes, cost, distance_km, created_at, updated_at, %s FROM rentals WHERE %s AND %s ORDER BY %s LIMIT ?",
+		pagination.SortKey(spec.SortColumn), where, keyset, orderBy)
+	rows, err := r.db.Query(query, append(append(args, keysetArgs...), page.FetchLimit())...)
 	if err != nil {
 		return nil, err
 	}
@@ -273,9 +1031,11 @@ func (r *rentalRepository) ListAllRentals(pageID int64) (*models.RentalList, err
 
 	rentals := &models.RentalList{}
 	rentalList := make([]*models.Rental, 0)
+	pageRows := make([]pagination.Row, 0)
 
 	for rows.Next() {
 		var rental models.Rental
+		var row pagination.Row
 		if err := rows.Scan(&rental.ID,
 			&rental.UserID,
 			&rental.BikeID,
@@ -285,18 +1045,254 @@ func (r *rentalRepository) ListAllRentals(pageID int64) (*models.RentalList, err
 			&rental.StartLongitude,
 			&rental.EndLatitude,
 			&rental.EndLongitude,
+			&rental.StartStationID,
+			&rental.EndStationID,
+			&rental.PaymentSource,
+			&rental.PlanID,
+			&rental.SubscriptionID,
+			&rental.PricePerMinute,
+			&rental.SurgeMultiplier,
+			&rental.PausedPricePerMinute,
 			&rental.DurationMinutes,
 			&rental.Cost,
+			&rental.Distance,
 			&rental.CreatedAt,
 			&rental.UpdatedAt,
+			&row.Key,
 		); err != nil {
 			return nil, err
 		}
+		row.ID = rental.ID
 		rentalList = append(rentalList, &rental)
+		pageRows = append(pageRows, row)
 	}
-	if len(rentalList) == pageSize {
-		rentals.NextPageID = rentalList[len(rentalList)-1].ID
+	rentals.Items, rentals.Page = pagination.Paginate(page, rentalList, pageRows)
+	if page.WithTotal {
+		if rentals.Page.Total, err = r.count(where, args...); err != nil {
+			return nil, err
+		}
 	}
-	rentals.Items = rentalList
 	return rentals, nil
 }
+
+// count returns the number of rentals matching the condition
+func (r *rentalRepository) count(where string, args ...interface{}) (*int64, error) {
+	var total int64
+	if err := r.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM rentals WHERE %s", where), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count rentals: %v", err)
+	}
+	return &total, nil
+}
+
+// AddTrackPoints appends GPS positions to the track of a rental. Points without a recorded time are stamped with the current time
+func (r *rentalRepository) AddTrackPoints(rentalID int64, source string, points []models.TrackPointRequest) error {
+	now := time.Now().UTC()
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		query := "INSERT INTO rental_track_points (rental_id, latitude, longitude, source, recorded_at) VALUES (?, ?, ?, ?, ?)"
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare insert statement: %v", err)
+		}
+		defer stmt.Close()
+
+		for _, point := range points {
+			recordedAt := now
+			if point.RecordedAt != nil {
+				recordedAt = point.RecordedAt.UTC()
+			}
+			if _, err := stmt.Exec(rentalID, point.Latitude, point.Longitude, source, recordedAt); err != nil {
+				return fmt.Errorf("failed to insert track point: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetTrackPoints returns the GPS positions recorded for a rental ordered by time
+func (r *rentalRepository) GetTrackPoints(rentalID int64) ([]*models.TrackPoint, error) {
+	query := "SELECT id, rental_id, latitude, longitude, source, recorded_at FROM rental_track_points WHERE rental_id = ? ORDER BY recorded_at, id"
+	rows, err := r.db.Query(query, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]*models.TrackPoint, 0)
+	for rows.Next() {
+		var point models.TrackPoint
+		if err := rows.Scan(&point.ID, &point.RentalID, &point.Latitude, &point.Longitude, &point.Source, &point.RecordedAt); err != nil {
+			return nil, err
+		}
+		points = append(points, &point)
+	}
+	return points, rows.Err()
+}
+
+// AdjustRental records a signed change of the cost of an ended rental made by a staff member.
+// Refunds are given back to the payment method first and the rest is credited to the wallet, up to what the rider paid with each.
+// The payment method is refunded once the adjustment is committed, a declined refund is credited to the wallet instead.
+// Increases of the cost are debited from the wallet
+func (r *rentalRepository) AdjustRental(rentalID int64, adjustReq *models.AdjustRentalRequest, createdBy string) (*models.AdjustRentalResponse, error) {
+	if adjustReq == nil {
+		return nil, fmt.Errorf("adjustReq request is nil")
+	}
+	ctx := context.Background()
+	adjustment := &models.RentalAdjustment{
+		RentalID:  rentalID,
+		Amount:    adjustReq.Amount,
+		Reason:    adjustReq.Reason,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	var effectiveCost float64
+	var userID int64
+	var payment *paymentsmodels.Payment
+
+	err := r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		var endTime *time.Time
+		var cost, adjusted float64
+		query := `SELECT r.user_id, r.end_time, r.cost, COALESCE((SELECT SUM(a.amount) FROM rental_adjustments a WHERE a.rental_id = r.id), 0)
+			FROM rentals r WHERE r.id = ?`
+		if err := tx.QueryRow(query, rentalID).Scan(&userID, &endTime, &cost, &adjusted); err != nil {
+			return fmt.Errorf("failed to get rental: %w", err)
+		}
+		if endTime == nil {
+			return ErrRentalNotEnded
+		}
+		effectiveCost = cost + adjusted + adjustReq.Amount
+		if helpers.ToMinorUnits(effectiveCost) < 0 {
+			return ErrNegativeCost
+		}
+
+		if adjustReq.Amount < 0 {
+			refund := helpers.ToMinorUnits(-adjustReq.Amount)
+			var err error
+			payment, err = r.splitRefund(tx, userID, adjustment, refund)
+			if err != nil {
+				return err
+			}
+		} else {
+			_, err := r.walletRepo.ChargeRental(tx, userID, rentalID, helpers.ToMinorUnits(adjustReq.Amount), adjustment.Reason, adjustment.CreatedBy)
+			if err != nil {
+				return fmt.Errorf("failed to charge wallet: %v", err)
+			}
+		}
+
+		query = "INSERT INTO rental_adjustments (rental_id, amount, reason, created_by, card_refund, wallet_refund, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+		result, err := tx.Exec(query, rentalID, adjustment.Amount, adjustment.Reason, adjustment.CreatedBy, adjustment.CardRefund, adjustment.WalletRefund, adjustment.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert rental adjustment: %v", err)
+		}
+		adjustment.ID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The provider is only called once the adjustment is recorded, so that a rollback never leaves an untracked refund
+	message := "Rental adjusted successfully"
+	if payment != nil {
+		if refundErr := r.provider.Refund(ctx, payment.ProviderReference, adjustment.CardRefund); refundErr != nil {
+			log.Printf("Error refunding payment %d of rental %d: %v", payment.ID, rentalID, refundErr)
+			if err := r.moveRefundToWallet(ctx, userID, payment, adjustment); err != nil {
+				return nil, err
+			}
+			message = "Rental adjusted, the refund declined by the payment provider was credited to the wallet"
+		}
+	}
+
+	return &models.AdjustRentalResponse{
+		Adjustment:    adjustment,
+		EffectiveCost: effectiveCost,
+		Message:       message,
+	}, nil
+}
+
+// moveRefundToWallet credits to the wallet the part of an adjustment the payment provider refused to refund to the card
+func (r *rentalRepository) moveRefundToWallet(ctx context.Context, userID int64, payment *paymentsmodels.Payment, adjustment *models.RentalAdjustment) error {
+	return r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		payment.AmountRefunded = helpers.FromMinorUnits(helpers.ToMinorUnits(payment.AmountRefunded - adjustment.CardRefund))
+		if helpers.ToMinorUnits(payment.AmountRefunded) == 0 {
+			payment.Status = paymentsmodels.PaymentStatusCaptured
+		}
+		if err := r.paymentRepo.UpdatePayment(tx, payment); err != nil {
+			return err
+		}
+		refund := helpers.ToMinorUnits(adjustment.CardRefund)
+		if _, err := r.walletRepo.RefundRental(tx, userID, adjustment.RentalID, refund, adjustment.Reason, adjustment.CreatedBy); err != nil {
+			return fmt.Errorf("failed to refund wallet: %v", err)
+		}
+		adjustment.WalletRefund = helpers.FromMinorUnits(helpers.ToMinorUnits(adjustment.WalletRefund) + refund)
+		adjustment.CardRefund = 0
+		query := "UPDATE rental_adjustments SET card_refund = ?, wallet_refund = ? WHERE id = ?"
+		if _, err := tx.Exec(query, adjustment.CardRefund, adjustment.WalletRefund, adjustment.ID); err != nil {
+			return fmt.Errorf("failed to update rental adjustment: %v", err)
+		}
+		return nil
+	})
+}
+
+// splitRefund gives back the refund, in cents, to the card the rental was charged on and credits the rest to the wallet.
+// It returns the payment to refund on the provider, nil when nothing goes back to the card
+func (r *rentalRepository) splitRefund(tx *sql.Tx, userID int64, adjustment *models.RentalAdjustment, refund int64) (*paymentsmodels.Payment, error) {
+	var cardPayment *paymentsmodels.Payment
+	payment, err := r.paymentRepo.GetPaymentByRentalID(tx, adjustment.RentalID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get payment: %v", err)
+	}
+	if payment != nil && (payment.Status == paymentsmodels.PaymentStatusCaptured || payment.Status == paymentsmodels.PaymentStatusRefunded) {
+		refundable := helpers.ToMinorUnits(payment.AmountCaptured - payment.AmountRefunded)
+		cardRefund := min(refund, refundable)
+		if cardRefund > 0 {
+			adjustment.CardRefund = helpers.FromMinorUnits(cardRefund)
+			payment.AmountRefunded += adjustment.CardRefund
+			payment.Status = paymentsmodels.PaymentStatusRefunded
+			if err := r.paymentRepo.UpdatePayment(tx, payment); err != nil {
+				return nil, err
+			}
+			refund -= cardRefund
+			cardPayment = payment
+		}
+	}
+
+	if refund > 0 {
+		paid, err := r.walletRepo.GetRentalPaidAmount(tx, userID, adjustment.RentalID)
+		if err != nil {
+			return nil, err
+		}
+		walletRefund := min(refund, paid)
+		if walletRefund > 0 {
+			_, err := r.walletRepo.RefundRental(tx, userID, adjustment.RentalID, walletRefund, adjustment.Reason, adjustment.CreatedBy)
+			if err != nil {
+				return nil, fmt.Errorf("failed to refund wallet: %v", err)
+			}
+			adjustment.WalletRefund = helpers.FromMinorUnits(walletRefund)
+		}
+	}
+	return cardPayment, nil
+}
+
+// ListAdjustments returns the adjustments of a rental, oldest first
+func (r *rentalRepository) ListAdjustments(rentalID int64) ([]*models.RentalAdjustment, error) {
+	query := "SELECT id, rental_id, amount, reason, created_by, card_refund, wallet_refund, created_at FROM rental_adjustments WHERE rental_id = ? ORDER BY id"
+	rows, err := r.db.Query(query, rentalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rental adjustments: %v", err)
+	}
+	defer rows.Close()
+
+	adjustments := make([]*models.RentalAdjustment, 0)
+	for rows.Next() {
+		var adjustment models.RentalAdjustment
+		err := rows.Scan(&adjustment.ID, &adjustment.RentalID, &adjustment.Amount, &adjustment.Reason, &adjustment.CreatedBy, &adjustment.CardRefund, &adjustment.WalletRefund, &adjustment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, &adjustment)
+	}
+	return adjustments, rows.Err()
+}
@@ -0,0 +1,452 @@
+package repository
+
+import (
+	bikesrepository "bikesRentalAPI/internal/bikes/repository"
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/locks"
+	"bikesRentalAPI/internal/payments"
+	paymentsmodels "bikesRentalAPI/internal/payments/models"
+	paymentsrepository "bikesRentalAPI/internal/payments/repository"
+	plansrepository "bikesRentalAPI/internal/plans/repository"
+	"bikesRentalAPI/internal/promotions"
+	promotionsmodels "bikesRentalAPI/internal/promotions/models"
+	promotionsrepository "bikesRentalAPI/internal/promotions/repository"
+	"bikesRentalAPI/internal/receipts"
+	receiptsrepository "bikesRentalAPI/internal/receipts/repository"
+	"bikesRentalAPI/internal/rentals"
+	"bikesRentalAPI/internal/rentals/models"
+	stationsrepository "bikesRentalAPI/internal/stations/repository"
+	surgerepository "bikesRentalAPI/internal/surge/repository"
+	usersrepository "bikesRentalAPI/internal/users/repository"
+	walletrepository "bikesRentalAPI/internal/wallet/repository"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRepository is a rental repository over an in-memory database, with simulated locks and payments
+type testRepository struct {
+	*rentalRepository
+	locks    *locks.Simulator
+	provider *payments.FakeProvider
+}
+
+// newTestRepository starts a new in-memory database with a rider and a bike, and returns a rental repository using it
+func newTestRepository(t *testing.T, name string) *testRepository {
+	t.Setenv("DB_URL", "file:"+name+"?mode=memory&cache=shared")
+	t.Setenv("PAYMENT_PREAUTH_AMOUNT", "10")
+	t.Setenv("RENTAL_UNLOCK_FEE", "0")
+	t.Setenv("SURGE_PRICING_ENABLED", "false")
+	db := database.New()
+	require.NoError(t, db.Start())
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate())
+
+	simulator := locks.NewSimulator()
+	provider := payments.NewFakeProvider()
+	paymentRepo := paymentsrepository.New(db)
+	walletRepo := walletrepository.New(db, paymentRepo, provider)
+	repo := New(db,
+		usersrepository.New(db),
+		bikesrepository.New(db),
+		simulator,
+		stationsrepository.New(db),
+		paymentRepo,
+		provider,
+		walletRepo,
+		receiptsrepository.New(db, receipts.ConfigFromEnv()),
+		plansrepository.New(db, paymentRepo, provider),
+		promotionsrepository.New(db, walletRepo, promotions.ReferralCreditFromEnv()),
+		surgerepository.New(db),
+		rentals.PauseConfigFromEnv(),
+	).(*rentalRepository)
+
+	_, err := db.Exec("INSERT INTO users (id, email, hashed_password) VALUES (1, 'rider@example.com', 'x')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute) VALUES (1, 1, 40.4, -3.7, 0.1)")
+	require.NoError(t, err)
+	_, err = paymentRepo.CreatePaymentMethod(1, paymentsmodels.CreatePaymentMethodRequest{Token: "tok_visa", IsDefault: true})
+	require.NoError(t, err)
+	return &testRepository{rentalRepository: repo, locks: simulator, provider: provider}
+}
+
+// startRental starts a rental of the first bike by the rider
+func (r *testRepository) startRental(t *testing.T) *models.StartRentalResponse {
+	started, err := r.StartRental(1, &models.StartBikeRentalRequest{BikeID: 1, Latitude: 40.4, Longitude: -3.7})
+	require.NoError(t, err)
+	return started
+}
+
+func TestLockedRentals(t *testing.T) {
+	testCases := []struct {
+		name      string
+		lockState locks.LockState
+		reachable bool
+		wantErr   error
+	}{
+		{name: "Success - the rider locked the bike", lockState: locks.Locked, reachable: true},
+		{name: "Failure - the rider left the bike unlocked", lockState: locks.Unlocked, reachable: true, wantErr: ErrBikeNotLocked},
+		{name: "Failure - the lock doesn't answer", lockState: locks.Locked, reachable: false, wantErr: ErrBikeNotLocked},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an ongoing rental whose lock reports the state
+			repo := newTestRepository(t, "locked_rentals_test_"+string(rune('a'+i)))
+			started := repo.startRental(t)
+			repo.locks.SetState(1, tc.lockState)
+			repo.locks.SetReachable(1, tc.reachable)
+
+			// WHEN: the rider pauses the rental
+			_, err := repo.PauseRental(1)
+			// THEN: the rental is only paused when the lock reports locked
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// WHEN: the rider ends the rental
+			_, err = repo.EndRental(1, &models.StopBikeRentalRequest{RentalID: started.ID})
+			// THEN: the rental is only ended when the lock reports locked, the server never locks the bike itself
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				_, err := repo.GetOngoingRental(1)
+				assert.NoError(t, err, "the rental is still ongoing")
+				repo.locks.SetReachable(1, true)
+				state, _ := repo.locks.State(context.Background(), 1)
+				assert.Equal(t, tc.lockState, state)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// writingLocks is a lock controller writing to the database on every command,
+// it fails to write when the command is sent while a transaction holds the database
+type writingLocks struct {
+	*locks.Simulator
+	db        database.Database
+	writeErrs []error
+}
+
+func (l *writingLocks) write() {
+	_, err := l.db.Exec("UPDATE users SET email = email WHERE id = 1")
+	l.writeErrs = append(l.writeErrs, err)
+}
+
+func (l *writingLocks) Unlock(ctx context.Context, bikeID int64) error {
+	l.write()
+	return l.Simulator.Unlock(ctx, bikeID)
+}
+
+func (l *writingLocks) State(ctx context.Context, bikeID int64) (locks.LockState, error) {
+	l.write()
+	return l.Simulator.State(ctx, bikeID)
+}
+
+func TestLockCommandsOutsideTransactions(t *testing.T) {
+	t.Run("Success - the database is free while the lock is driven", func(t *testing.T) {
+		// GIVEN: a lock controller writing to the database on every command
+		repo := newTestRepository(t, "lock_commands_test_a")
+		lockController := &writingLocks{Simulator: repo.locks, db: repo.db}
+		repo.lockController = lockController
+
+		// WHEN: the rider starts, pauses and resumes a rental
+		repo.startRental(t)
+		repo.locks.SetState(1, locks.Locked)
+		_, err := repo.PauseRental(1)
+		require.NoError(t, err)
+		_, err = repo.ResumeRental(1)
+		require.NoError(t, err)
+
+		// THEN: no command was sent while a transaction held the database
+		require.Len(t, lockController.writeErrs, 3)
+		for _, err := range lockController.writeErrs {
+			assert.NoError(t, err)
+		}
+	})
+	t.Run("Failure - the rental is undone when the bike can't be unlocked", func(t *testing.T) {
+		// GIVEN: a bike whose lock doesn't answer
+		repo := newTestRepository(t, "lock_commands_test_b")
+		repo.locks.SetReachable(1, false)
+
+		// WHEN: the rider starts a rental
+		_, err := repo.StartRental(1, &models.StartBikeRentalRequest{BikeID: 1, Latitude: 40.4, Longitude: -3.7})
+
+		// THEN: no rental nor payment is left and the bike can be rented again
+		assert.ErrorIs(t, err, ErrUnlockFailed)
+		_, err = repo.GetOngoingRental(1)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		var payments int
+		require.NoError(t, repo.db.QueryRow("SELECT COUNT(*) FROM payments").Scan(&payments))
+		assert.Zero(t, payments)
+		bike, err := repo.bikeRepo.GetBikeByID(1)
+		require.NoError(t, err)
+		assert.True(t, bike.IsAvailable)
+		repo.locks.SetReachable(1, true)
+		repo.startRental(t)
+	})
+}
+
+// rideFor moves the start of the ongoing rental back so that it lasts the given time, and lets the rider lock the bike
+func (r *testRepository) rideFor(t *testing.T, rentalID int64, duration time.Duration) {
+	_, err := r.db.Exec("UPDATE rentals SET start_time = ? WHERE id = ?", time.Now().Add(-duration).UTC(), rentalID)
+	require.NoError(t, err)
+	r.locks.SetState(1, locks.Locked)
+}
+
+func TestCapturePayment(t *testing.T) {
+	t.Run("Success - the cost above the authorized amount is owed by the rider", func(t *testing.T) {
+		// GIVEN: a card rental authorized for 10 lasting 200 minutes at 0.1 per minute
+		repo := newTestRepository(t, "capture_payment_test_a")
+		started := repo.startRental(t)
+		repo.rideFor(t, started.ID, 200*time.Minute)
+
+		// WHEN: the rental ends
+		stopped, err := repo.EndRental(1, &models.StopBikeRentalRequest{RentalID: started.ID})
+		require.NoError(t, err)
+
+		// THEN: only the authorized amount is captured and the rest is debited from the wallet
+		payment, err := repo.paymentRepo.GetPaymentByRentalID(repo.db, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, paymentsmodels.PaymentStatusCaptured, payment.Status)
+		assert.Equal(t, 10.0, payment.AmountCaptured)
+		captured, _ := repo.provider.Captured(payment.ProviderReference)
+		assert.Equal(t, 10.0, captured)
+		assert.InDelta(t, stopped.Cost-10, stopped.OutstandingAmount, 0.001)
+		balance, err := repo.walletRepo.GetBalance(repo.db, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, -stopped.OutstandingAmount, float64(balance)/100, 0.001)
+
+		// THEN: the rider can't rent again until the debt is paid
+		_, err = repo.StartRental(1, &models.StartBikeRentalRequest{BikeID: 1, Latitude: 40.4, Longitude: -3.7})
+		assert.ErrorIs(t, err, ErrNegativeBalance)
+	})
+	t.Run("Success - the authorization is voided when the wallet paid the ride", func(t *testing.T) {
+		// GIVEN: a card rental of 10 minutes whose rider got wallet credit meanwhile
+		repo := newTestRepository(t, "capture_payment_test_b")
+		started := repo.startRental(t)
+		_, err := repo.walletRepo.Credit(repo.db, 1, 500, "welcome", "admin")
+		require.NoError(t, err)
+		repo.rideFor(t, started.ID, 10*time.Minute)
+
+		// WHEN: the rental ends
+		stopped, err := repo.EndRental(1, &models.StopBikeRentalRequest{RentalID: started.ID})
+		require.NoError(t, err)
+
+		// THEN: the wallet pays the ride and the held amount is released
+		assert.Equal(t, string(paymentsmodels.PaymentStatusVoided), stopped.PaymentStatus)
+		assert.Equal(t, stopped.Cost, stopped.WalletAmount)
+		payment, err := repo.paymentRepo.GetPaymentByRentalID(repo.db, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, paymentsmodels.PaymentStatusVoided, payment.Status)
+		assert.Error(t, repo.provider.Capture(context.Background(), payment.ProviderReference, 1), "a voided authorization can't be captured")
+	})
+	t.Run("Failure - the cost of a declined capture is owed by the rider", func(t *testing.T) {
+		// GIVEN: a card rental whose authorization can't be captured anymore
+		repo := newTestRepository(t, "capture_payment_test_c")
+		started := repo.startRental(t)
+		repo.rideFor(t, started.ID, 10*time.Minute)
+		payment, err := repo.paymentRepo.GetPaymentByRentalID(repo.db, started.ID)
+		require.NoError(t, err)
+		require.NoError(t, repo.provider.Void(context.Background(), payment.ProviderReference))
+
+		// WHEN: the rental ends
+		stopped, err := repo.EndRental(1, &models.StopBikeRentalRequest{RentalID: started.ID})
+		require.NoError(t, err, "the rental ends even if the rider could not be charged")
+
+		// THEN: the payment is failed and the whole cost is debited from the wallet
+		assert.Equal(t, string(paymentsmodels.PaymentStatusFailed), stopped.PaymentStatus)
+		assert.Equal(t, stopped.Cost, stopped.OutstandingAmount)
+		balance, err := repo.walletRepo.GetBalance(repo.db, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, -stopped.Cost, float64(balance)/100, 0.001)
+	})
+}
+
+// decliningRefunds is a payment provider refusing every refund
+type decliningRefunds struct {
+	payments.PaymentProvider
+}
+
+func (decliningRefunds) Refund(ctx context.Context, reference string, amount float64) error {
+	return payments.ErrPaymentDeclined
+}
+
+func TestAdjustRental(t *testing.T) {
+	// endRental ends a rental of 10 minutes charged on the card
+	endRental := func(t *testing.T, repo *testRepository) (*models.StopRentalResponse, *paymentsmodels.Payment) {
+		started := repo.startRental(t)
+		repo.rideFor(t, started.ID, 10*time.Minute)
+		stopped, err := repo.EndRental(1, &models.StopBikeRentalRequest{RentalID: started.ID})
+		require.NoError(t, err)
+		payment, err := repo.paymentRepo.GetPaymentByRentalID(repo.db, started.ID)
+		require.NoError(t, err)
+		return stopped, payment
+	}
+	balance := func(t *testing.T, repo *testRepository) float64 {
+		balance, err := repo.walletRepo.GetBalance(repo.db, 1)
+		require.NoError(t, err)
+		return float64(balance) / 100
+	}
+
+	t.Run("Success - refunds are given back to the card", func(t *testing.T) {
+		repo := newTestRepository(t, "adjust_rental_test_a")
+		stopped, payment := endRental(t, repo)
+
+		adjusted, err := repo.AdjustRental(1, &models.AdjustRentalRequest{Amount: -0.5, Reason: "bumpy ride"}, "admin")
+		require.NoError(t, err)
+
+		assert.Equal(t, 0.5, adjusted.Adjustment.CardRefund)
+		assert.InDelta(t, stopped.Cost-0.5, adjusted.EffectiveCost, 0.001)
+		_, refunded := repo.provider.Captured(payment.ProviderReference)
+		assert.Equal(t, 0.5, refunded)
+	})
+	t.Run("Success - refunds declined by the provider are credited to the wallet", func(t *testing.T) {
+		// GIVEN: a payment provider refusing refunds
+		repo := newTestRepository(t, "adjust_rental_test_b")
+		_, payment := endRental(t, repo)
+		repo.rentalRepository.provider = decliningRefunds{repo.provider}
+
+		// WHEN: the rental is refunded
+		adjusted, err := repo.AdjustRental(1, &models.AdjustRentalRequest{Amount: -0.5, Reason: "bumpy ride"}, "admin")
+		require.NoError(t, err)
+
+		// THEN: the refund goes to the wallet and the payment is left as captured
+		assert.Equal(t, 0.0, adjusted.Adjustment.CardRefund)
+		assert.Equal(t, 0.5, adjusted.Adjustment.WalletRefund)
+		assert.Equal(t, 0.5, balance(t, repo))
+		payment, err = repo.paymentRepo.GetPaymentByRentalID(repo.db, payment.RentalID)
+		require.NoError(t, err)
+		assert.Equal(t, paymentsmodels.PaymentStatusCaptured, payment.Status)
+		assert.Equal(t, 0.0, payment.AmountRefunded)
+		adjustments, err := repo.ListAdjustments(1)
+		require.NoError(t, err)
+		require.Len(t, adjustments, 1)
+		assert.Equal(t, 0.0, adjustments[0].CardRefund)
+		assert.Equal(t, 0.5, adjustments[0].WalletRefund)
+	})
+	t.Run("Success - increases of the cost are charged to the wallet", func(t *testing.T) {
+		repo := newTestRepository(t, "adjust_rental_test_c")
+		stopped, _ := endRental(t, repo)
+
+		adjusted, err := repo.AdjustRental(1, &models.AdjustRentalRequest{Amount: 2, Reason: "damaged bike"}, "admin")
+		require.NoError(t, err)
+
+		assert.InDelta(t, stopped.Cost+2, adjusted.EffectiveCost, 0.001)
+		assert.Equal(t, -2.0, balance(t, repo), "the rider owes the increase")
+
+		// THEN: a full refund gives the card payment back to the card and the charge back to the wallet
+		refunded, err := repo.AdjustRental(1, &models.AdjustRentalRequest{Amount: -adjusted.EffectiveCost, Reason: "damage was already there"}, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, stopped.Cost, refunded.Adjustment.CardRefund)
+		assert.Equal(t, 2.0, refunded.Adjustment.WalletRefund)
+		assert.Equal(t, 0.0, balance(t, repo))
+	})
+	t.Run("Failure - the cost can't become negative", func(t *testing.T) {
+		repo := newTestRepository(t, "adjust_rental_test_d")
+		stopped, _ := endRental(t, repo)
+
+		_, err := repo.AdjustRental(1, &models.AdjustRentalRequest{Amount: -(stopped.Cost + 1), Reason: "too much"}, "admin")
+		assert.ErrorIs(t, err, ErrNegativeCost)
+	})
+}
+
+func TestPriceRide(t *testing.T) {
+	t.Run("Success - promo codes giving nothing on the ride don't hide the next ones", func(t *testing.T) {
+		// GIVEN: no unlock fee and a rider who redeemed a free unlocks code and then a 50% off code
+		repo := newTestRepository(t, "price_ride_test_a")
+		for _, code := range []promotionsmodels.CreatePromoCodeRequest{
+			{Code: "FREEUNLOCK", Kind: promotionsmodels.PromoKindFreeUnlocks},
+			{Code: "HALFOFF", Kind: promotionsmodels.PromoKindPercentOff, Value: 50},
+		} {
+			_, err := repo.promoRepo.CreatePromoCode(code)
+			require.NoError(t, err)
+			_, err = repo.promoRepo.Redeem(1, code.Code)
+			require.NoError(t, err)
+		}
+		started := repo.startRental(t)
+		repo.rideFor(t, started.ID, 10*time.Minute)
+
+		// WHEN: the rental ends
+		stopped, err := repo.EndRental(1, &models.StopBikeRentalRequest{RentalID: started.ID})
+		require.NoError(t, err)
+
+		// THEN: the ride is discounted by the percent off code, which is used up, and the free unlock is kept
+		assert.InDelta(t, 0.5, stopped.Cost, 0.01)
+		redemptions, err := repo.promoRepo.ListUsableRedemptions(repo.db, 1, time.Now())
+		require.NoError(t, err)
+		require.Len(t, redemptions, 1)
+		assert.Equal(t, "FREEUNLOCK", redemptions[0].Code)
+	})
+}
+
+func TestCalculateRentalDistance(t *testing.T) {
+	rental := &models.Rental{StartLatitude: 40, StartLongitude: -3.7}
+	testCases := []struct {
+		name     string
+		points   []*models.TrackPoint
+		expected float64
+	}{
+		{name: "Success - without track the distance is straight from the start to the end", expected: 111.19},
+		{
+			name:     "Success - the distance follows the track from the start to the end",
+			points:   []*models.TrackPoint{{Latitude: 41, Longitude: -3.7}, {Latitude: 40, Longitude: -3.7}},
+			expected: 333.58,
+		},
+		{
+			name:     "Success - points at the same position add nothing",
+			points:   []*models.TrackPoint{{Latitude: 40, Longitude: -3.7}, {Latitude: 41, Longitude: -3.7}},
+			expected: 111.19,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rental started a degree of latitude south of where it ends
+			// WHEN: the travelled distance is computed
+			distance := calculateRentalDistance(rental, tc.points, 41, -3.7)
+			// THEN: it goes through every recorded point
+			assert.InDelta(t, tc.expected, distance, 0.01)
+		})
+	}
+}
+
+func TestTrackPoints(t *testing.T) {
+	// GIVEN: an ongoing rental
+	repo := newTestRepository(t, "track_points_test")
+	started := repo.startRental(t)
+	recordedAt := time.Date(2024, 6, 3, 10, 5, 0, 0, time.UTC)
+
+	// WHEN: positions are recorded, one of them late with the time it was taken
+	require.NoError(t, repo.AddTrackPoints(started.ID, "app", []models.TrackPointRequest{{Latitude: 40.41, Longitude: -3.71}}))
+	require.NoError(t, repo.AddTrackPoints(started.ID, "device", []models.TrackPointRequest{{Latitude: 40.42, Longitude: -3.72, RecordedAt: &recordedAt}}))
+
+	// THEN: the track is ordered by the time the positions were taken
+	points, err := repo.GetTrackPoints(started.ID)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, "device", points[0].Source)
+	assert.Equal(t, recordedAt, points[0].RecordedAt)
+	assert.Equal(t, "app", points[1].Source)
+	other, err := repo.GetTrackPoints(started.ID + 1)
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	// THEN: the distance of the ended rental follows the track
+	repo.locks.SetState(1, locks.Locked)
+	stopped, err := repo.EndRental(1, &models.StopBikeRentalRequest{RentalID: started.ID})
+	require.NoError(t, err)
+	rental, err := repo.GetRentalDetails(started.ID)
+	require.NoError(t, err)
+	expected := calculateRentalDistance(rental, points, stopped.Latitude, stopped.Longitude)
+	assert.InDelta(t, expected, stopped.Distance, 0.0001)
+	assert.Greater(t, stopped.Distance, helpers.GetDistanceKm(40.4, -3.7, stopped.Latitude, stopped.Longitude)-0.0001)
+}
@@ -6,11 +6,26 @@ import (
 	"os"
 	"strings"
 
+	analytics "bikesRentalAPI/internal/analytics/handlers"
+	audit "bikesRentalAPI/internal/audit/handlers"
+	auditrepository "bikesRentalAPI/internal/audit/repository"
 	bikes "bikesRentalAPI/internal/bikes/handlers"
+	bikesrepository "bikesRentalAPI/internal/bikes/repository"
 	"bikesRentalAPI/internal/helpers"
+	idempotency "bikesRentalAPI/internal/idempotency/repository"
 	"bikesRentalAPI/internal/middlewares"
+	payments "bikesRentalAPI/internal/payments/handlers"
+	plans "bikesRentalAPI/internal/plans/handlers"
+	privacy "bikesRentalAPI/internal/privacy/handlers"
+	promotions "bikesRentalAPI/internal/promotions/handlers"
+	rebalancing "bikesRentalAPI/internal/rebalancing/handlers"
+	receipts "bikesRentalAPI/internal/receipts/handlers"
 	rentals "bikesRentalAPI/internal/rentals/handlers"
+	rentalsrepository "bikesRentalAPI/internal/rentals/repository"
+	stations "bikesRentalAPI/internal/stations/handlers"
 	users "bikesRentalAPI/internal/users/handlers"
+	usersrepository "bikesRentalAPI/internal/users/repository"
+	wallet "bikesRentalAPI/internal/wallet/handlers"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,7 +33,7 @@ import (
 )
 
 type Router interface {
-	RegisterRoutes(userHandler users.Handler, bikeHandler bikes.Handler, rentalHandler rentals.Handler) http.Handler
+	RegisterRoutes(userHandler users.Handler, bikeHandler bikes.Handler, rentalHandler rentals.Handler, rebalancingHandler rebalancing.Handler, stationHandler stations.Handler, paymentHandler payments.Handler, walletHandler wallet.Handler, receiptHandler receipts.Handler, planHandler plans.Handler, promotionHandler promotions.Handler, privacyHandler privacy.Handler, auditHandler audit.Handler, analyticsHandler analytics.Handler, idempotencyRepo idempotency.IdempotencyRepository, auditRepo auditrepository.AuditRepository, userRepo usersrepository.UserRepository) http.Handler
 }
 
 type chiRouter struct {
@@ -54,20 +69,48 @@ func New() Router {
 }
 
 // RegisterRoutes registers all routes for the application
-func (r *chiRouter) RegisterRoutes(userHandler users.Handler, bikeHandler bikes.Handler, rentalHandler rentals.Handler) http.Handler {
+func (r *chiRouter) RegisterRoutes(userHandler users.Handler, bikeHandler bikes.Handler, rentalHandler rentals.Handler, rebalancingHandler rebalancing.Handler, stationHandler stations.Handler, paymentHandler payments.Handler, walletHandler wallet.Handler, receiptHandler receipts.Handler, planHandler plans.Handler, promotionHandler promotions.Handler, privacyHandler privacy.Handler, auditHandler audit.Handler, analyticsHandler analytics.Handler, idempotencyRepo idempotency.IdempotencyRepository, auditRepo auditrepository.AuditRepository, userRepo usersrepository.UserRepository) http.Handler {
+	// Retries of the requests sent with an Idempotency-Key get the response of the first one
+	idempotent := middlewares.Idempotency(idempotencyRepo, middlewares.IdempotencyTTLFromEnv())
+	// Admin mutations and security-relevant user actions are recorded in the audit log. Replayed responses are not recorded again
+	audited := middlewares.Audit(auditRepo)
+	// Tokens of deleted users stay valid until they expire, their requests are refused
+	activeUser := middlewares.ActiveUser(userRepo)
+
 	// Add routes here
 	r.Route("/users", func(r chi.Router) {
 		// User authentication
 		r.Post("/register", userHandler.RegisterUser)
-		r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		r.With(audited).Post("/login", func(w http.ResponseWriter, r *http.Request) {
 			userHandler.LoginUser(tokenAuth, w, r)
 		})
 		r.Group(func(r chi.Router) {
 			r.Use(jwtauth.Verifier(tokenAuth))
 			r.Use(jwtauth.Authenticator(tokenAuth))
+			r.Use(activeUser)
 			// User profile operations
 			r.Get("/profile", userHandler.GetUserProfile)
 			r.Patch("/profile", userHandler.UpdateUserProfile)
+			// Payment methods
+			r.Get("/payment-methods", paymentHandler.ListPaymentMethods)
+			r.Post("/payment-methods", paymentHandler.AddPaymentMethod)
+			r.Post("/payment-methods/{payment_method_id}/default", paymentHandler.SetDefaultPaymentMethod)
+			r.Delete("/payment-methods/{payment_method_id}", paymentHandler.DeletePaymentMethod)
+			// Wallet
+			r.Get("/wallet", walletHandler.GetWallet)
+			r.Post("/wallet/top-up", walletHandler.TopUpWallet)
+			// Receipts
+			r.Get("/receipts/statement", receiptHandler.GetMonthlyStatement)
+			// Subscriptions
+			r.Get("/subscriptions", planHandler.ListSubscriptions)
+			r.Post("/subscriptions", planHandler.Subscribe)
+			r.Delete("/subscriptions/{subscription_id}", planHandler.CancelSubscription)
+			// Promo codes
+			r.Get("/promo", promotionHandler.ListRedemptions)
+			r.Post("/promo", promotionHandler.RedeemPromoCode)
+			// Personal data
+			r.Get("/me/export", privacyHandler.ExportMyData)
+			r.With(audited).Delete("/me", privacyHandler.EraseMyData)
 		})
 	})
 
@@ -75,18 +118,45 @@ func (r *chiRouter) RegisterRoutes(userHandler users.Handler, bikeHandler bikes.
 		r.Group(func(r chi.Router) {
 			r.Use(jwtauth.Verifier(tokenAuth))
 			r.Use(jwtauth.Authenticator(tokenAuth))
+			r.Use(activeUser)
 			// Bike general operations
 			r.With(middlewares.Pagination).Get("/available", bikeHandler.ListAvailableBikes)
+			r.Get("/{bike_id}/quote", rentalHandler.GetBikeQuote)
+		})
+	})
+	r.Route("/stations", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(jwtauth.Verifier(tokenAuth))
+			r.Use(jwtauth.Authenticator(tokenAuth))
+			r.Use(activeUser)
+			// Station availability
+			r.Get("/", stationHandler.ListStations)
+			r.Get("/{station_id}", stationHandler.GetStation)
+		})
+	})
+	r.Route("/plans", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(jwtauth.Verifier(tokenAuth))
+			r.Use(jwtauth.Authenticator(tokenAuth))
+			r.Use(activeUser)
+			// Plans on sale
+			r.Get("/", planHandler.ListPlans)
 		})
 	})
 	r.Route("/rentals", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
 			r.Use(jwtauth.Verifier(tokenAuth))
 			r.Use(jwtauth.Authenticator(tokenAuth))
+			r.Use(activeUser)
 			// Rental operations
-			r.Post("/start", rentalHandler.StartBikeRental)
-			r.Post("/end", rentalHandler.EndBikeRental)
+			r.With(idempotent).Post("/start", rentalHandler.StartBikeRental)
+			r.With(idempotent).Post("/end", rentalHandler.EndBikeRental)
+			r.Post("/pause", rentalHandler.PauseRental)
+			r.Post("/resume", rentalHandler.ResumeRental)
 			r.With(middlewares.Pagination).Get("/history", rentalHandler.GetRentalHistoryByUserID)
+			r.Post("/{rental_id}/track", rentalHandler.AddRentalTrackPoints)
+			r.Get("/{rental_id}/track", rentalHandler.GetRentalTrack)
+			r.Get("/{rental_id}/receipt", receiptHandler.GetReceipt)
 		})
 	})
 
@@ -94,24 +164,78 @@ func (r *chiRouter) RegisterRoutes(userHandler users.Handler, bikeHandler bikes.
 		// Administrative endpoints
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.BasicAuth("bikesRental API Administration", adminCredentials))
+			r.Use(idempotent)
+			r.Use(audited)
 
 			r.Route("/bikes", func(r chi.Router) {
 				r.Post("/", bikeHandler.AddBike)
+				r.Post("/import", bikeHandler.ImportBikes)
+				r.Get("/export", bikeHandler.ExportBikes)
 				r.Patch("/{bike_id}", bikeHandler.UpdateBike)
 				r.Get("/{bike_id}", bikeHandler.GetBikeByID)
-				r.With(middlewares.Pagination).Get("/", bikeHandler.ListAllBikes)
+				r.Delete("/{bike_id}", bikeHandler.DeleteBike)
+				r.Post("/{bike_id}/restore", bikeHandler.RestoreBike)
+				r.With(middlewares.Pagination, middlewares.QuerySpecParser(bikesrepository.ListQuery)).Get("/", bikeHandler.ListAllBikes)
 			})
 
 			r.Route("/users", func(r chi.Router) {
-				r.With(middlewares.Pagination).Get("/", userHandler.ListAllUsers)
+				r.With(middlewares.Pagination, middlewares.QuerySpecParser(usersrepository.ListQuery)).Get("/", userHandler.ListAllUsers)
 				r.Get("/{user_id}", userHandler.GetUserDetails)
 				r.Patch("/{user_id}", userHandler.UpdateUserDetails)
+				r.Delete("/{user_id}", userHandler.DeleteUser)
+				r.Post("/{user_id}/restore", userHandler.RestoreUser)
+				r.Get("/{user_id}/export", privacyHandler.ExportUserData)
+				r.Post("/{user_id}/anonymize", privacyHandler.EraseUserData)
+				r.Get("/{user_id}/wallet", walletHandler.GetUserWallet)
+				r.Post("/{user_id}/wallet/adjustments", walletHandler.AdjustUserWallet)
 			})
 
 			r.Route("/rentals", func(r chi.Router) {
-				r.With(middlewares.Pagination).Get("/", rentalHandler.GetRentalList)
+				r.With(middlewares.Pagination, middlewares.QuerySpecParser(rentalsrepository.ListQuery)).Get("/", rentalHandler.GetRentalList)
 				r.Get("/{rental_id}", rentalHandler.GetRentalDetails)
 				r.Patch("/{rental_id}", rentalHandler.UpdateRentalDetails)
+				r.Post("/{rental_id}/adjustments", rentalHandler.AdjustRental)
+				r.Get("/{rental_id}/track", rentalHandler.GetRentalTrackDetails)
+				r.Post("/{rental_id}/track", rentalHandler.AddDeviceTrackPoints)
+				r.Get("/{rental_id}/payments", paymentHandler.ListRentalPayments)
+				r.Get("/{rental_id}/receipt", receiptHandler.GetReceiptDetails)
+			})
+
+			r.Route("/stations", func(r chi.Router) {
+				r.Get("/", stationHandler.ListStations)
+				r.Post("/", stationHandler.CreateStation)
+				r.Get("/{station_id}", stationHandler.GetStation)
+				r.Patch("/{station_id}", stationHandler.UpdateStation)
+				r.Delete("/{station_id}", stationHandler.DeleteStation)
+				r.Post("/{station_id}/bikes", stationHandler.DockBike)
+				r.Delete("/{station_id}/bikes/{bike_id}", stationHandler.UndockBike)
+			})
+
+			r.Route("/plans", func(r chi.Router) {
+				r.Get("/", planHandler.ListAllPlans)
+				r.Post("/", planHandler.CreatePlan)
+				r.Post("/renewals", planHandler.RunRenewals)
+				r.Get("/{plan_id}", planHandler.GetPlan)
+				r.Patch("/{plan_id}", planHandler.UpdatePlan)
+			})
+
+			r.Route("/promo-codes", func(r chi.Router) {
+				r.Get("/", promotionHandler.ListPromoCodes)
+				r.Post("/", promotionHandler.CreatePromoCode)
+				r.Get("/{promo_code_id}", promotionHandler.GetPromoCode)
+				r.Patch("/{promo_code_id}", promotionHandler.UpdatePromoCode)
+				r.Delete("/{promo_code_id}", promotionHandler.DeletePromoCode)
+			})
+
+			r.With(middlewares.Pagination, middlewares.QuerySpecParser(auditrepository.ListQuery)).Get("/audit", auditHandler.ListAuditEntries)
+			r.Get("/stats", analyticsHandler.GetStats)
+			r.Get("/reports/bikes", analyticsHandler.GetBikeReport)
+
+			r.Route("/rebalancing", func(r chi.Router) {
+				r.Get("/recommendations", rebalancingHandler.GetRecommendations)
+				r.Post("/jobs", rebalancingHandler.CreateJob)
+				r.Get("/jobs/{job_id}", rebalancingHandler.GetJob)
+				r.Post("/jobs/{job_id}/complete", rebalancingHandler.CompleteJob)
 			})
 		})
 	})
@@ -1,13 +1,29 @@
 package router
 
 import (
+	analyticsmocks "bikesRentalAPI/internal/analytics/handlers/mocks"
+	auditmocks "bikesRentalAPI/internal/audit/handlers/mocks"
+	auditrepomocks "bikesRentalAPI/internal/audit/repository/mocks"
 	bikemocks "bikesRentalAPI/internal/bikes/handlers/mocks"
+	idempotencymocks "bikesRentalAPI/internal/idempotency/repository/mocks"
+	paymentmocks "bikesRentalAPI/internal/payments/handlers/mocks"
+	planmocks "bikesRentalAPI/internal/plans/handlers/mocks"
+	privacymocks "bikesRentalAPI/internal/privacy/handlers/mocks"
+	promotionmocks "bikesRentalAPI/internal/promotions/handlers/mocks"
+	rebalancingmocks "bikesRentalAPI/internal/rebalancing/handlers/mocks"
+	receiptmocks "bikesRentalAPI/internal/receipts/handlers/mocks"
 	rentalmocks "bikesRentalAPI/internal/rentals/handlers/mocks"
+	stationmocks "bikesRentalAPI/internal/stations/handlers/mocks"
 	usermocks "bikesRentalAPI/internal/users/handlers/mocks"
+	userrepomocks "bikesRentalAPI/internal/users/repository/mocks"
+	walletmocks "bikesRentalAPI/internal/wallet/handlers/mocks"
+
+	"bikesRentalAPI/internal/middlewares"
 
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -26,6 +42,19 @@ func TestRouter(t *testing.T) {
 	mockUserHandler := usermocks.NewMockHandler(mockCtrl)
 	mockBikeHandler := bikemocks.NewMockHandler(mockCtrl)
 	mockRentalHandler := rentalmocks.NewMockHandler(mockCtrl)
+	mockRebalancingHandler := rebalancingmocks.NewMockHandler(mockCtrl)
+	mockStationHandler := stationmocks.NewMockHandler(mockCtrl)
+	mockPaymentHandler := paymentmocks.NewMockHandler(mockCtrl)
+	mockWalletHandler := walletmocks.NewMockHandler(mockCtrl)
+	mockReceiptHandler := receiptmocks.NewMockHandler(mockCtrl)
+	mockPlanHandler := planmocks.NewMockHandler(mockCtrl)
+	mockPromotionHandler := promotionmocks.NewMockHandler(mockCtrl)
+	mockPrivacyHandler := privacymocks.NewMockHandler(mockCtrl)
+	mockAuditHandler := auditmocks.NewMockHandler(mockCtrl)
+	mockAnalyticsHandler := analyticsmocks.NewMockHandler(mockCtrl)
+	mockIdempotencyRepo := idempotencymocks.NewMockIdempotencyRepository(mockCtrl)
+	mockAuditRepo := auditrepomocks.NewMockAuditRepository(mockCtrl)
+	mockUserRepo := userrepomocks.NewMockUserRepository(mockCtrl)
 
 	t.Run("Success - New returns a new router interface using the chi router", func(t *testing.T) {
 		// WHEN: the New function is called
@@ -37,7 +66,7 @@ func TestRouter(t *testing.T) {
 		// GIVEN: a router
 		router := New()
 		// WHEN: the routes are registered
-		handler := router.RegisterRoutes(mockUserHandler, mockBikeHandler, mockRentalHandler)
+		handler := router.RegisterRoutes(mockUserHandler, mockBikeHandler, mockRentalHandler, mockRebalancingHandler, mockStationHandler, mockPaymentHandler, mockWalletHandler, mockReceiptHandler, mockPlanHandler, mockPromotionHandler, mockPrivacyHandler, mockAuditHandler, mockAnalyticsHandler, mockIdempotencyRepo, mockAuditRepo, mockUserRepo)
 		// THEN: the handler should be created successfully
 		assert.NotNil(t, handler)
 	})
@@ -45,7 +74,7 @@ func TestRouter(t *testing.T) {
 		// GIVEN: a router, a test server and expected message
 		router := New()
 		// WHEN: the routes are registered
-		handler := router.RegisterRoutes(mockUserHandler, mockBikeHandler, mockRentalHandler)
+		handler := router.RegisterRoutes(mockUserHandler, mockBikeHandler, mockRentalHandler, mockRebalancingHandler, mockStationHandler, mockPaymentHandler, mockWalletHandler, mockReceiptHandler, mockPlanHandler, mockPromotionHandler, mockPrivacyHandler, mockAuditHandler, mockAnalyticsHandler, mockIdempotencyRepo, mockAuditRepo, mockUserRepo)
 		// GIVEN: a test server
 		server := httptest.NewServer(handler)
 		defer server.Close()
@@ -63,4 +92,20 @@ func TestRouter(t *testing.T) {
 		assert.Contains(t, string(body), expectedMessage)
 
 	})
+	t.Run("Failure - keyed imports over the size cap are refused", func(t *testing.T) {
+		// GIVEN: the registered routes with an admin. The credentials are read when the package is loaded
+		defer func(credentials map[string]string) { adminCredentials = credentials }(adminCredentials)
+		adminCredentials = map[string]string{"test": "test"}
+		router := New()
+		handler := router.RegisterRoutes(mockUserHandler, mockBikeHandler, mockRentalHandler, mockRebalancingHandler, mockStationHandler, mockPaymentHandler, mockWalletHandler, mockReceiptHandler, mockPlanHandler, mockPromotionHandler, mockPrivacyHandler, mockAuditHandler, mockAnalyticsHandler, mockIdempotencyRepo, mockAuditRepo, mockUserRepo)
+		// WHEN: an import larger than 10MB is sent with an Idempotency-Key
+		req := httptest.NewRequest(http.MethodPost, "/admin/bikes/import", strings.NewReader(strings.Repeat("a", 10<<20+1)))
+		req.SetBasicAuth("test", "test")
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set(middlewares.IdempotencyKeyHeader, "import-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		// THEN: it is refused before reaching the import handler
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
 }
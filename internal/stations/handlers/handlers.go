@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/stations/models"
+	"bikesRentalAPI/internal/stations/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// Handler is the interface for station handlers
+type Handler interface {
+	ListStations(w http.ResponseWriter, req *http.Request)  // List stations with availability
+	CreateStation(w http.ResponseWriter, req *http.Request) // Create a station
+	GetStation(w http.ResponseWriter, req *http.Request)    // Get a station with availability
+	UpdateStation(w http.ResponseWriter, req *http.Request) // Update a station
+	DeleteStation(w http.ResponseWriter, req *http.Request) // Delete an empty station
+	DockBike(w http.ResponseWriter, req *http.Request)      // Dock a free-floating bike at a station
+	UndockBike(w http.ResponseWriter, req *http.Request)    // Release a bike from a station
+}
+
+type handler struct {
+	StationRepo repository.StationRepository
+	validator   *validator.Validate
+}
+
+// New returns a new station handler
+func New(stationRepository repository.StationRepository) Handler {
+	validator := validator.New(validator.WithRequiredStructEnabled())
+	handler := &handler{
+		StationRepo: stationRepository,
+		validator:   validator,
+	}
+	return handler
+}
+
+// ListStations returns every station with its docked bikes, available bikes and free docks
+func (h *handler) ListStations(w http.ResponseWriter, req *http.Request) {
+	stations, err := h.StationRepo.ListStations()
+	if err != nil {
+		log.Printf("Error getting stations: %v", err)
+		http.Error(w, "Error getting stations", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, stations)
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// CreateStation creates a new station in the database
+func (h *handler) CreateStation(w http.ResponseWriter, req *http.Request) {
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return
+	}
+	var newStation models.CreateStationRequest
+	if err := json.Unmarshal(body, &newStation); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return
+	}
+	if err := h.validator.Struct(newStation); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return
+	}
+	id, err := h.StationRepo.CreateStation(newStation)
+	if err != nil {
+		log.Printf("Error creating station: %v", err)
+		http.Error(w, "Error creating station", http.StatusInternalServerError)
+		return
+	}
+	createStationResp := models.CreateUpdateStationResponse{
+		ID:      id,
+		Message: "Station created successfully",
+	}
+	helpers.WriteJSON(w, http.StatusCreated, createStationResp)
+}
+
+// GetStation retrieves a station with its availability
+func (h *handler) GetStation(w http.ResponseWriter, req *http.Request) {
+	stationID, ok := parseStationID(w, req)
+	if !ok {
+		return
+	}
+	station, err := h.StationRepo.GetStationAvailability(stationID)
+	if err != nil {
+		writeStationError(w, "Error getting station", err)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, station)
+}
+
+// UpdateStation updates a station. The capacity can't go below the number of docked bikes
+func (h *handler) UpdateStation(w http.ResponseWriter, req *http.Request) {
+	stationID, ok := parseStationID(w, req)
+	if !ok {
+		return
+	}
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return
+	}
+	var updateStationReq *models.UpdateStationRequest
+	if err := json.Unmarshal(body, &updateStationReq); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return
+	}
+	if err := h.validator.Struct(updateStationReq); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return
+	}
+
+	station, err := h.StationRepo.GetStationAvailability(stationID)
+	if err != nil {
+		writeStationError(w, "Error getting station", err)
+		return
+	}
+	fieldsToUpdate, err := getFieldsToUpdate(updateStationReq, station)
+	if err != nil {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+	if updateStationReq.Capacity != nil && *updateStationReq.Capacity < station.DockedBikes {
+		http.Error(w, fmt.Sprintf("Capacity can't be lower than the %d docked bikes", station.DockedBikes), http.StatusConflict)
+		return
+	}
+
+	audit.SetChanges(req, station, fieldsToUpdate)
+	result, err := h.StationRepo.UpdateStation(stationID, fieldsToUpdate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Station not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating station: %v", err)
+		http.Error(w, "Error updating station", http.StatusInternalServerError)
+		return
+	}
+	updateStationResp := models.CreateUpdateStationResponse{
+		ID:      result,
+		Message: "Station updated successfully",
+	}
+	helpers.WriteJSON(w, http.StatusOK, updateStationResp)
+}
+
+// DeleteStation deletes a station without docked bikes
+func (h *handler) DeleteStation(w http.ResponseWriter, req *http.Request) {
+	stationID, ok := parseStationID(w, req)
+	if !ok {
+		return
+	}
+	if err := h.StationRepo.DeleteStation(stationID); err != nil {
+		writeStationError(w, "Error deleting station", err)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, models.CreateUpdateStationResponse{
+		ID:      stationID,
+		Message: "Station deleted successfully",
+	})
+}
+
+// DockBike docks an available free-floating bike at the station
+func (h *handler) DockBike(w http.ResponseWriter, req *http.Request) {
+	stationID, ok := parseStationID(w, req)
+	if !ok {
+		return
+	}
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return
+	}
+	var dockBikeReq models.DockBikeRequest
+	if err := json.Unmarshal(body, &dockBikeReq); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return
+	}
+	if err := h.validator.Struct(dockBikeReq); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return
+	}
+	if err := h.StationRepo.DockBike(stationID, dockBikeReq.BikeID); err != nil {
+		writeStationError(w, "Error docking bike", err)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, models.CreateUpdateStationResponse{
+		ID:      stationID,
+		Message: "Bike docked successfully",
+	})
+}
+
+// UndockBike releases a bike from the station, it becomes free-floating
+func (h *handler) UndockBike(w http.ResponseWriter, req *http.Request) {
+	stationID, ok := parseStationID(w, req)
+	if !ok {
+		return
+	}
+	bikeIDStr := chi.URLParam(req, "bike_id")
+	bikeID, err := strconv.ParseInt(bikeIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", bikeIDStr, err), http.StatusBadRequest)
+		return
+	}
+	if err := h.StationRepo.UndockBike(stationID, bikeID); err != nil {
+		writeStationError(w, "Error undocking bike", err)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, models.CreateUpdateStationResponse{
+		ID:      stationID,
+		Message: "Bike undocked successfully",
+	})
+}
+
+// parseStationID reads the 'station_id' URL parameter. It writes the error response when it fails
+func parseStationID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	stationIDStr := chi.URLParam(req, "station_id")
+	stationID, err := strconv.ParseInt(stationIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", stationIDStr, err), http.StatusBadRequest)
+		return 0, false
+	}
+	return stationID, true
+}
+
+// writeStationError maps repository errors to http errors
+func writeStationError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Station or bike not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrStationFull),
+		errors.Is(err, repository.ErrStationNotEmpty),
+		errors.Is(err, repository.ErrBikeNotDockable):
+		http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// getFieldsToUpdate compares the fields of the update request with the station and returns the fields to update as map
+func getFieldsToUpdate(updateStationReq *models.UpdateStationRequest, station *models.StationAvailability) (map[string]interface{}, error) {
+	if updateStationReq == nil || station == nil {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	fieldsToUpdate := make(map[string]interface{})
+	if updateStationReq.Name != nil && *updateStationReq.Name != station.Name {
+		fieldsToUpdate["name"] = *updateStationReq.Name
+	}
+	if updateStationReq.Latitude != nil && *updateStationReq.Latitude != station.Latitude {
+		fieldsToUpdate["latitude"] = *updateStationReq.Latitude
+	}
+	if updateStationReq.Longitude != nil && *updateStationReq.Longitude != station.Longitude {
+		fieldsToUpdate["longitude"] = *updateStationReq.Longitude
+	}
+	if updateStationReq.Capacity != nil && *updateStationReq.Capacity != station.Capacity {
+		fieldsToUpdate["capacity"] = *updateStationReq.Capacity
+	}
+
+	if len(fieldsToUpdate) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	return fieldsToUpdate, nil
+}
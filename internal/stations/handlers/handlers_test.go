@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/stations/models"
+	"bikesRentalAPI/internal/stations/repository"
+	"bikesRentalAPI/internal/stations/repository/mocks"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUpdateStation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStationRepo := mocks.NewMockStationRepository(mockCtrl)
+	station := &models.StationAvailability{
+		Station:     models.Station{ID: 1, Name: "Sol", Latitude: 40.4168, Longitude: -3.7038, Capacity: 10},
+		DockedBikes: 4,
+		FreeDocks:   6,
+	}
+
+	testCases := []struct {
+		name             string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - the station is renamed",
+			body: `{"name":"Puerta del Sol"}`,
+			mockCalls: func() {
+				mockStationRepo.EXPECT().GetStationAvailability(int64(1)).Return(station, nil)
+				mockStationRepo.EXPECT().UpdateStation(int64(1), map[string]interface{}{"name": "Puerta del Sol"}).Return(int64(1), nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name: "Failure - the capacity is lower than the docked bikes",
+			body: `{"capacity":3}`,
+			mockCalls: func() {
+				mockStationRepo.EXPECT().GetStationAvailability(int64(1)).Return(station, nil)
+			},
+			expectedHttpCode: http.StatusConflict,
+		},
+		{
+			name:             "Failure - the latitude is out of range",
+			body:             `{"latitude":91}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure - the station does not exist",
+			body: `{"capacity":12}`,
+			mockCalls: func() {
+				mockStationRepo.EXPECT().GetStationAvailability(int64(1)).Return(nil, sql.ErrNoRows)
+			},
+			expectedHttpCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin updating station 1
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodPatch, "/admin/stations/1", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Patch("/admin/stations/{station_id}", New(mockStationRepo).UpdateStation)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the capacity never drops below the docked bikes
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestDockBike(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStationRepo := mocks.NewMockStationRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		body             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the bike is docked", body: `{"bike_id":5}`, expectedHttpCode: http.StatusOK},
+		{name: "Failure - the bike is required", body: `{}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the station does not exist", body: `{"bike_id":5}`, repoErr: sql.ErrNoRows, expectedHttpCode: http.StatusNotFound},
+		{name: "Failure - the station is full", body: `{"bike_id":5}`, repoErr: repository.ErrStationFull, expectedHttpCode: http.StatusConflict},
+		{name: "Failure - the bike is rented or docked elsewhere", body: `{"bike_id":5}`, repoErr: repository.ErrBikeNotDockable, expectedHttpCode: http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin docking a bike at station 1
+			if tc.expectedHttpCode != http.StatusBadRequest {
+				mockStationRepo.EXPECT().DockBike(int64(1), int64(5)).Return(tc.repoErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/admin/stations/1/bikes", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Post("/admin/stations/{station_id}/bikes", New(mockStationRepo).DockBike)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: the reasons a bike can't be docked are told apart
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestDeleteStation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStationRepo := mocks.NewMockStationRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the station is deleted", expectedHttpCode: http.StatusOK},
+		{name: "Failure - the station does not exist", repoErr: sql.ErrNoRows, expectedHttpCode: http.StatusNotFound},
+		{name: "Failure - the station has docked bikes", repoErr: repository.ErrStationNotEmpty, expectedHttpCode: http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin deleting station 1
+			mockStationRepo.EXPECT().DeleteStation(int64(1)).Return(tc.repoErr)
+			req := httptest.NewRequest(http.MethodDelete, "/admin/stations/1", nil)
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Delete("/admin/stations/{station_id}", New(mockStationRepo).DeleteStation)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: stations with docked bikes are kept
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Station is a docking station where bikes can be picked up and returned
+type Station struct {
+	ID        int64     `json:"id,omitempty"`
+	Name      string    `json:"name"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Capacity  int       `json:"capacity"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+} // @name Station
+
+// StationAvailability contains a station and the state of its docks
+type StationAvailability struct {
+	Station
+	// The number of bikes docked at the station
+	DockedBikes int `json:"docked_bikes"`
+	// The number of docked bikes available for rent
+	AvailableBikes int `json:"available_bikes"`
+	// The number of empty docks
+	FreeDocks int `json:"free_docks"`
+} // @name StationAvailability
+
+// StationList contains a list of stations with their availability
+type StationList struct {
+	// The list of stations
+	Items []*StationAvailability `json:"items"`
+} // @name StationList
+
+// CreateStationRequest contains the information to create a station
+type CreateStationRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Latitude  *float64 `json:"latitude" validate:"required,latitude"`
+	Longitude *float64 `json:"longitude" validate:"required,longitude"`
+	Capacity  int      `json:"capacity" validate:"required,gt=0"`
+} // @name CreateStationRequest
+
+// UpdateStationRequest contains the information to update a station
+type UpdateStationRequest struct {
+	Name      *string  `json:"name" validate:"omitempty,max=100"`
+	Latitude  *float64 `json:"latitude" validate:"omitempty,latitude"`
+	Longitude *float64 `json:"longitude" validate:"omitempty,longitude"`
+	Capacity  *int     `json:"capacity" validate:"omitempty,gt=0"`
+} // @name UpdateStationRequest
+
+// DockBikeRequest contains the bike to dock at a station
+type DockBikeRequest struct {
+	BikeID int64 `json:"bike_id" validate:"required,numeric"`
+} // @name DockBikeRequest
+
+// CreateUpdateStationResponse represents the response of creating/updating a station
+type CreateUpdateStationResponse struct {
+	ID      int64  `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+} // @name CreateUpdateStationResponse
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/stations/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrStationFull is returned when docking a bike at a station without free docks
+	ErrStationFull = errors.New("station has no free docks")
+	// ErrStationNotEmpty is returned when deleting a station with docked bikes
+	ErrStationNotEmpty = errors.New("station has docked bikes")
+	// ErrBikeNotDockable is returned when docking a bike that is rented or docked elsewhere
+	ErrBikeNotDockable = errors.New("bike cannot be docked")
+)
+
+// stationsTable lists the columns of a station admins can update
+var stationsTable = database.NewTable("stations", "name", "latitude", "longitude", "capacity")
+
+type StationRepository interface {
+	CreateStation(station models.CreateStationRequest) (int64, error)
+	GetStationByID(stationID int64) (*models.Station, error)
+	GetStationAvailability(stationID int64) (*models.StationAvailability, error)
+	ListStations() (*models.StationList, error)
+	UpdateStation(stationID int64, fieldsToUpdate map[string]interface{}) (int64, error)
+	DeleteStation(stationID int64) error
+	CountDockedBikes(q database.Querier, stationID int64) (int, error)
+	DockBike(stationID int64, bikeID int64) error
+	UndockBike(stationID int64, bikeID int64) error
+}
+
+type stationRepository struct {
+	db database.Database
+}
+
+// New initializes a new empty station repository
+func New(db database.Database) StationRepository {
+	return &stationRepository{db}
+}
+
+// availabilityQuery selects the stations with the counts of their docked and available bikes
+const availabilityQuery = `SELECT s.id, s.name, s.latitude, s.longitude, s.capacity, s.created_at, s.updated_at,
+	COUNT(b.id), COALESCE(SUM(CASE WHEN b.is_available THEN 1 ELSE 0 END), 0)
+	FROM stations s LEFT JOIN bikes b ON b.station_id = s.id`
+
+// CreateStation creates a station in the database
+func (r *stationRepository) CreateStation(station models.CreateStationRequest) (int64, error) {
+	query := "INSERT INTO stations (name, latitude, longitude, capacity) VALUES (?, ?, ?, ?)"
+	result, err := r.db.Exec(query, station.Name, station.Latitude, station.Longitude, station.Capacity)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert station: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %v", err)
+	}
+	return id, nil
+}
+
+// GetStationByID retrieves a station from the database by its id
+func (r *stationRepository) GetStationByID(stationID int64) (*models.Station, error) {
+	query := "SELECT id, name, latitude, longitude, capacity, created_at, updated_at FROM stations WHERE id = ?"
+	var station models.Station
+	err := r.db.QueryRow(query, stationID).Scan(&station.ID, &station.Name, &station.Latitude, &station.Longitude, &station.Capacity, &station.CreatedAt, &station.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &station, nil
+}
+
+// GetStationAvailability retrieves a station with the state of its docks
+func (r *stationRepository) GetStationAvailability(stationID int64) (*models.StationAvailability, error) {
+	query := availabilityQuery + " WHERE s.id = ? GROUP BY s.id"
+	return scanAvailability(r.db.QueryRow(query, stationID))
+}
+
+// ListStations retrieves every station with the state of its docks
+func (r *stationRepository) ListStations() (*models.StationList, error) {
+	query := availabilityQuery + " GROUP BY s.id ORDER BY s.id"
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stations := &models.StationList{Items: make([]*models.StationAvailability, 0)}
+	for rows.Next() {
+		station, err := scanAvailability(rows)
+		if err != nil {
+			return nil, err
+		}
+		stations.Items = append(stations.Items, station)
+	}
+	return stations, rows.Err()
+}
+
+// UpdateStation updates a station in the database by id
+func (r *stationRepository) UpdateStation(stationID int64, fieldsToUpdate map[string]interface{}) (int64, error) {
+	if _, err := stationsTable.Update(r.db, stationID, fieldsToUpdate); err != nil {
+		return 0, err
+	}
+	return stationID, nil
+}
+
+// DeleteStation deletes a station without docked bikes. Past rentals keep the station id
+func (r *stationRepository) DeleteStation(stationID int64) error {
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		docked, err := r.CountDockedBikes(tx, stationID)
+		if err != nil {
+			return err
+		}
+		if docked > 0 {
+			return ErrStationNotEmpty
+		}
+		result, err := tx.Exec("DELETE FROM stations WHERE id = ?", stationID)
+		if err != nil {
+			return fmt.Errorf("failed to delete station: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
+
+// CountDockedBikes returns the number of bikes docked at a station. q is either the database or an ongoing transaction
+func (r *stationRepository) CountDockedBikes(q database.Querier, stationID int64) (int, error) {
+	var count int
+	if err := q.QueryRow("SELECT COUNT(*) FROM bikes WHERE station_id = ?", stationID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count docked bikes: %v", err)
+	}
+	return count, nil
+}
+
+// DockBike docks an available, free-floating bike at a station with free docks and moves it to the station location
+func (r *stationRepository) DockBike(stationID int64, bikeID int64) error {
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		var capacity int
+		var latitude, longitude float64
+		query := "SELECT capacity, latitude, longitude FROM stations WHERE id = ?"
+		if err := tx.QueryRow(query, stationID).Scan(&capacity, &latitude, &longitude); err != nil {
+			return err
+		}
+		docked, err := r.CountDockedBikes(tx, stationID)
+		if err != nil {
+			return err
+		}
+		if docked >= capacity {
+			return ErrStationFull
+		}
+		query = "UPDATE bikes SET station_id = ?, latitude = ?, longitude = ? WHERE id = ? AND is_available = ? AND station_id IS NULL AND deleted_at IS NULL"
+		result, err := tx.Exec(query, stationID, latitude, longitude, bikeID, true)
+		if err != nil {
+			return fmt.Errorf("failed to dock bike: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrBikeNotDockable
+		}
+		return nil
+	})
+}
+
+// UndockBike releases a bike docked at a station, it becomes free-floating at the station location
+func (r *stationRepository) UndockBike(stationID int64, bikeID int64) error {
+	query := "UPDATE bikes SET station_id = NULL WHERE id = ? AND station_id = ?"
+	result, err := r.db.Exec(query, bikeID, stationID)
+	if err != nil {
+		return fmt.Errorf("failed to undock bike: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAvailability scans a row of availabilityQuery
+func scanAvailability(row scanner) (*models.StationAvailability, error) {
+	var station models.StationAvailability
+	err := row.Scan(&station.ID, &station.Name, &station.Latitude, &station.Longitude, &station.Capacity, &station.CreatedAt, &station.UpdatedAt,
+		&station.DockedBikes, &station.AvailableBikes)
+	if err != nil {
+		return nil, err
+	}
+	station.FreeDocks = station.Capacity - station.DockedBikes
+	if station.FreeDocks < 0 {
+		station.FreeDocks = 0
+	}
+	return &station, nil
+}
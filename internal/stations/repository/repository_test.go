@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/stations/models"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocks(t *testing.T) {
+	// GIVEN: a station with a single dock, two available bikes and a rented one
+	t.Setenv("DB_URL", "file:docks_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	repo := New(db)
+	latitude, longitude := 40.4168, -3.7038
+	stationID, err := repo.CreateStation(models.CreateStationRequest{Name: "Sol", Latitude: &latitude, Longitude: &longitude, Capacity: 1})
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute) VALUES
+		(1, 1, 40.42, -3.70, 0.1), (2, 1, 40.42, -3.70, 0.1), (3, 0, 40.42, -3.70, 0.1)`)
+	require.NoError(t, err)
+
+	t.Run("Failure - rented bikes can't be docked", func(t *testing.T) {
+		assert.ErrorIs(t, repo.DockBike(stationID, 3), ErrBikeNotDockable)
+	})
+	t.Run("Failure - bikes can't be docked at unknown stations", func(t *testing.T) {
+		assert.ErrorIs(t, repo.DockBike(stationID+1, 1), sql.ErrNoRows)
+	})
+	t.Run("Success - the docked bike moves to the station", func(t *testing.T) {
+		require.NoError(t, repo.DockBike(stationID, 1))
+		var bikeLatitude, bikeLongitude float64
+		require.NoError(t, db.QueryRow("SELECT latitude, longitude FROM bikes WHERE id = 1").Scan(&bikeLatitude, &bikeLongitude))
+		assert.Equal(t, latitude, bikeLatitude)
+		assert.Equal(t, longitude, bikeLongitude)
+
+		station, err := repo.GetStationAvailability(stationID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, station.DockedBikes)
+		assert.Equal(t, 1, station.AvailableBikes)
+		assert.Zero(t, station.FreeDocks)
+	})
+	t.Run("Failure - full stations don't take more bikes", func(t *testing.T) {
+		assert.ErrorIs(t, repo.DockBike(stationID, 2), ErrStationFull)
+	})
+	t.Run("Failure - stations with docked bikes can't be deleted", func(t *testing.T) {
+		assert.ErrorIs(t, repo.DeleteStation(stationID), ErrStationNotEmpty)
+	})
+	t.Run("Success - the undocked bike frees its dock", func(t *testing.T) {
+		assert.ErrorIs(t, repo.UndockBike(stationID, 2), sql.ErrNoRows, "the bike is not docked at the station")
+		require.NoError(t, repo.UndockBike(stationID, 1))
+		docked, err := repo.CountDockedBikes(db, stationID)
+		require.NoError(t, err)
+		assert.Zero(t, docked)
+		stations, err := repo.ListStations()
+		require.NoError(t, err)
+		require.Len(t, stations.Items, 1)
+		assert.Equal(t, 1, stations.Items[0].FreeDocks)
+	})
+	t.Run("Success - empty stations are deleted", func(t *testing.T) {
+		require.NoError(t, repo.DeleteStation(stationID))
+		assert.ErrorIs(t, repo.DeleteStation(stationID), sql.ErrNoRows)
+	})
+}
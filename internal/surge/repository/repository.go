@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/helpers"
+	"fmt"
+	"time"
+)
+
+type SurgeRepository interface {
+	GetCellActivity(latitude, longitude, cellSize float64, since time.Time) (availableBikes int, recentDemand int, err error)
+}
+
+type surgeRepository struct {
+	db database.Database
+}
+
+// New initializes a new surge repository
+func New(db database.Database) SurgeRepository {
+	return &surgeRepository{db}
+}
+
+// GetCellActivity returns the number of available bikes in the grid cell of cellSize degrees containing the position
+// and the number of rentals started in it since the given time
+func (r *surgeRepository) GetCellActivity(latitude, longitude, cellSize float64, since time.Time) (int, int, error) {
+	row, col := helpers.GetGridCell(latitude, longitude, cellSize)
+	minLat, minLon := float64(row)*cellSize, float64(col)*cellSize
+	maxLat, maxLon := minLat+cellSize, minLon+cellSize
+
+	var availableBikes int
+	query := `SELECT COUNT(*) FROM bikes WHERE is_available = 1 AND deleted_at IS NULL
+		AND latitude >= ? AND latitude < ? AND longitude >= ? AND longitude < ?`
+	if err := r.db.QueryRow(query, minLat, maxLat, minLon, maxLon).Scan(&availableBikes); err != nil {
+		return 0, 0, fmt.Errorf("failed to count available bikes: %v", err)
+	}
+
+	var recentDemand int
+	query = `SELECT COUNT(*) FROM rentals WHERE start_time >= ?
+		AND start_latitude >= ? AND start_latitude < ? AND start_longitude >= ? AND start_longitude < ?`
+	if err := r.db.QueryRow(query, since.UTC(), minLat, maxLat, minLon, maxLon).Scan(&recentDemand); err != nil {
+		return 0, 0, fmt.Errorf("failed to count recent rentals: %v", err)
+	}
+	return availableBikes, recentDemand, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCellActivity(t *testing.T) {
+	// GIVEN: a cell of 0.01 degrees between 40.41,-3.71 and 40.42,-3.70 with two available bikes,
+	// a rented bike, a deleted bike and a bike next door, and rentals started in and out of the cell
+	t.Setenv("DB_URL", "file:cell_activity_test?mode=memory&cache=shared")
+	db := database.New()
+	require.NoError(t, db.Start())
+	defer db.Close()
+	require.NoError(t, db.Migrate())
+	now := time.Now()
+	_, err := db.Exec(`INSERT INTO bikes (id, is_available, latitude, longitude, price_per_minute, deleted_at) VALUES
+		(1, 1, 40.411, -3.709, 0.1, NULL), (2, 1, 40.419, -3.701, 0.1, NULL), (3, 0, 40.415, -3.705, 0.1, NULL),
+		(4, 1, 40.415, -3.705, 0.1, ?), (5, 1, 40.421, -3.705, 0.1, NULL)`, now)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO rentals (user_id, bike_id, start_time, start_latitude, start_longitude, cost) VALUES
+		(1, 3, ?, 40.415, -3.705, 0), (1, 1, ?, 40.415, -3.705, 0), (1, 5, ?, 40.415, -3.695, 0)`,
+		now.Add(-10*time.Minute), now.Add(-2*time.Hour), now.Add(-10*time.Minute))
+	require.NoError(t, err)
+	repo := New(db)
+
+	// WHEN: the activity of the cell in the last hour is counted
+	availableBikes, recentDemand, err := repo.GetCellActivity(40.415, -3.705, 0.01, now.Add(-time.Hour))
+
+	// THEN: only the available bikes and the recent rentals of the cell count
+	require.NoError(t, err)
+	assert.Equal(t, 2, availableBikes)
+	assert.Equal(t, 1, recentDemand)
+}
@@ -0,0 +1,151 @@
+package surge
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCellSize is the size of the grid cells in degrees, about 1km of latitude
+	defaultCellSize = 0.01
+	// defaultWindow is how far back the rentals started in a cell count as demand
+	defaultWindow = time.Hour
+	// defaultMaxMultiplier is the highest multiplier applied to the price per minute of a bike
+	defaultMaxMultiplier = 2.0
+)
+
+// defaultTiers apply 1.25x when there is as much recent demand as available bikes, 1.5x at twice and 2x at three times
+var defaultTiers = []Tier{{Ratio: 1, Multiplier: 1.25}, {Ratio: 2, Multiplier: 1.5}, {Ratio: 3, Multiplier: 2}}
+
+// Tier is the multiplier applied from a ratio of recent demand per available bike
+type Tier struct {
+	Ratio      float64
+	Multiplier float64
+}
+
+// Config holds the dynamic pricing configuration
+type Config struct {
+	// Enabled turns dynamic pricing on. Bikes are rented at their own price per minute otherwise
+	Enabled bool
+	// CellSize is the size, in degrees, of the grid cells availability and demand are measured in
+	CellSize float64
+	// Window is how far back the rentals started in a cell count as demand
+	Window time.Duration
+	// Tiers are the multipliers by ratio of recent demand per available bike, sorted by ratio
+	Tiers []Tier
+	// MaxMultiplier is the ceiling of the multiplier
+	MaxMultiplier float64
+	// MaxPricePerMinute is the ceiling of the surged price per minute, 0 when there is none.
+	// Bikes priced above it are never discounted by it
+	MaxPricePerMinute float64
+}
+
+// ConfigFromEnv reads the dynamic pricing configuration from SURGE_PRICING_ENABLED, SURGE_CELL_SIZE, SURGE_DEMAND_WINDOW,
+// SURGE_TIERS ("ratio:multiplier,..."), SURGE_MAX_MULTIPLIER and SURGE_MAX_PRICE_PER_MINUTE, falling back to defaults
+func ConfigFromEnv() Config {
+	config := Config{CellSize: defaultCellSize, Window: defaultWindow, Tiers: defaultTiers, MaxMultiplier: defaultMaxMultiplier}
+	if value := os.Getenv("SURGE_PRICING_ENABLED"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("invalid SURGE_PRICING_ENABLED %q. Dynamic pricing is disabled", value)
+		}
+		config.Enabled = enabled
+	}
+	if value := os.Getenv("SURGE_CELL_SIZE"); value != "" {
+		cellSize, err := strconv.ParseFloat(value, 64)
+		if err != nil || cellSize <= 0 {
+			log.Printf("invalid SURGE_CELL_SIZE %q. Set to %v as default", value, defaultCellSize)
+		} else {
+			config.CellSize = cellSize
+		}
+	}
+	if value := os.Getenv("SURGE_DEMAND_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			log.Printf("invalid SURGE_DEMAND_WINDOW %q. Set to %v as default", value, defaultWindow)
+		} else {
+			config.Window = window
+		}
+	}
+	if value := os.Getenv("SURGE_TIERS"); value != "" {
+		tiers, err := ParseTiers(value)
+		if err != nil {
+			log.Printf("invalid SURGE_TIERS %q: %v. Set to default", value, err)
+		} else {
+			config.Tiers = tiers
+		}
+	}
+	if value := os.Getenv("SURGE_MAX_MULTIPLIER"); value != "" {
+		maxMultiplier, err := strconv.ParseFloat(value, 64)
+		if err != nil || maxMultiplier < 1 {
+			log.Printf("invalid SURGE_MAX_MULTIPLIER %q. Set to %v as default", value, defaultMaxMultiplier)
+		} else {
+			config.MaxMultiplier = maxMultiplier
+		}
+	}
+	if value := os.Getenv("SURGE_MAX_PRICE_PER_MINUTE"); value != "" {
+		maxPrice, err := strconv.ParseFloat(value, 64)
+		if err != nil || maxPrice < 0 {
+			log.Printf("invalid SURGE_MAX_PRICE_PER_MINUTE %q. No ceiling is applied", value)
+		} else {
+			config.MaxPricePerMinute = maxPrice
+		}
+	}
+	return config
+}
+
+// ParseTiers reads tiers written as "ratio:multiplier" pairs separated by commas, e.g. "1:1.25,2:1.5"
+func ParseTiers(value string) ([]Tier, error) {
+	tiers := make([]Tier, 0)
+	for _, pair := range strings.Split(value, ",") {
+		ratioStr, multiplierStr, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			return nil, fmt.Errorf("tier %q is not ratio:multiplier", pair)
+		}
+		ratio, err := strconv.ParseFloat(ratioStr, 64)
+		if err != nil || ratio < 0 {
+			return nil, fmt.Errorf("invalid ratio %q", ratioStr)
+		}
+		multiplier, err := strconv.ParseFloat(multiplierStr, 64)
+		if err != nil || multiplier < 1 {
+			return nil, fmt.Errorf("invalid multiplier %q, it can't be lower than 1", multiplierStr)
+		}
+		tiers = append(tiers, Tier{Ratio: ratio, Multiplier: multiplier})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Ratio < tiers[j].Ratio })
+	return tiers, nil
+}
+
+// Multiplier returns the multiplier of the highest tier reached by the recent demand per available bike, capped to the ceiling.
+// A cell without available bikes counts as one so the ratio stays finite
+func (c Config) Multiplier(availableBikes, recentDemand int) float64 {
+	if !c.Enabled {
+		return 1
+	}
+	ratio := float64(recentDemand) / float64(max(availableBikes, 1))
+	multiplier := 1.0
+	for _, tier := range c.Tiers {
+		if ratio >= tier.Ratio {
+			multiplier = tier.Multiplier
+		}
+	}
+	if c.MaxMultiplier >= 1 {
+		multiplier = min(multiplier, c.MaxMultiplier)
+	}
+	return multiplier
+}
+
+// PricePerMinute returns the price per minute of a bike with the multiplier applied, capped to the price ceiling
+func (c Config) PricePerMinute(basePrice, multiplier float64) float64 {
+	price := math.Round(basePrice*multiplier*10000) / 10000
+	if c.MaxPricePerMinute > 0 && price > c.MaxPricePerMinute {
+		price = max(basePrice, c.MaxPricePerMinute)
+	}
+	return price
+}
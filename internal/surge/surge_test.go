@@ -0,0 +1,105 @@
+package surge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiplier(t *testing.T) {
+	config := Config{Enabled: true, Tiers: defaultTiers, MaxMultiplier: 1.5}
+
+	testCases := []struct {
+		name               string
+		config             Config
+		availableBikes     int
+		recentDemand       int
+		expectedMultiplier float64
+	}{
+		{
+			name:               "Success - no surge while bikes outnumber demand",
+			config:             config,
+			availableBikes:     10,
+			recentDemand:       5,
+			expectedMultiplier: 1,
+		},
+		{
+			name:               "Success - the highest tier reached applies",
+			config:             config,
+			availableBikes:     4,
+			recentDemand:       5,
+			expectedMultiplier: 1.25,
+		},
+		{
+			name:               "Success - the multiplier is capped to the ceiling",
+			config:             config,
+			availableBikes:     1,
+			recentDemand:       10,
+			expectedMultiplier: 1.5,
+		},
+		{
+			name:               "Success - a cell without bikes counts as one bike",
+			config:             config,
+			availableBikes:     0,
+			recentDemand:       1,
+			expectedMultiplier: 1.25,
+		},
+		{
+			name:               "Success - dynamic pricing disabled",
+			config:             Config{Tiers: defaultTiers, MaxMultiplier: 2},
+			availableBikes:     1,
+			recentDemand:       10,
+			expectedMultiplier: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: the availability and the recent demand of a cell
+			// WHEN: the multiplier is computed
+			multiplier := tc.config.Multiplier(tc.availableBikes, tc.recentDemand)
+			// THEN: it matches the tier reached
+			assert.Equal(t, tc.expectedMultiplier, multiplier)
+		})
+	}
+}
+
+func TestPricePerMinute(t *testing.T) {
+	t.Run("Success - the multiplier applies to the bike price", func(t *testing.T) {
+		// GIVEN: no price ceiling
+		config := Config{Enabled: true}
+		// WHEN: a multiplier is applied
+		// THEN: the price is multiplied
+		assert.Equal(t, 0.25, config.PricePerMinute(0.2, 1.25))
+	})
+	t.Run("Success - the price is capped to the ceiling", func(t *testing.T) {
+		// GIVEN: a price ceiling
+		config := Config{Enabled: true, MaxPricePerMinute: 0.3}
+		// WHEN: the surged price goes over it
+		// THEN: the ceiling applies, but never below the bike price
+		assert.Equal(t, 0.3, config.PricePerMinute(0.2, 2))
+		assert.Equal(t, 0.5, config.PricePerMinute(0.5, 2))
+	})
+}
+
+func TestParseTiers(t *testing.T) {
+	t.Run("Success - tiers are sorted by ratio", func(t *testing.T) {
+		// GIVEN: unsorted tiers
+		// WHEN: they are parsed
+		tiers, err := ParseTiers("2:1.5, 1:1.2")
+		// THEN: they are sorted
+		assert.NoError(t, err)
+		assert.Equal(t, []Tier{{Ratio: 1, Multiplier: 1.2}, {Ratio: 2, Multiplier: 1.5}}, tiers)
+	})
+	t.Run("Failure - multipliers can't discount", func(t *testing.T) {
+		// GIVEN: a multiplier lower than 1
+		// WHEN: it is parsed
+		_, err := ParseTiers("1:0.5")
+		// THEN: it is refused
+		assert.Error(t, err)
+	})
+	t.Run("Failure - malformed tier", func(t *testing.T) {
+		_, err := ParseTiers("1.5")
+		assert.Error(t, err)
+	})
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"bikesRentalAPI/internal/audit"
+	"bikesRentalAPI/internal/database"
 	"bikesRentalAPI/internal/helpers"
 	"bikesRentalAPI/internal/middlewares"
 	"bikesRentalAPI/internal/users/models"
 	"bikesRentalAPI/internal/users/repository"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -26,6 +30,8 @@ type Handler interface {
 	ListAllUsers(w http.ResponseWriter, req *http.Request)
 	UpdateUserDetails(w http.ResponseWriter, req *http.Request)
 	GetUserDetails(w http.ResponseWriter, req *http.Request)
+	DeleteUser(w http.ResponseWriter, req *http.Request)
+	RestoreUser(w http.ResponseWriter, req *http.Request)
 }
 
 type handler struct {
@@ -76,6 +82,10 @@ func (h *handler) RegisterUser(w http.ResponseWriter, req *http.Request) {
 	}
 	id, err := h.UserRepo.CreateUser(newUser)
 	if err != nil {
+		if errors.Is(err, repository.ErrInvalidReferralCode) {
+			http.Error(w, "Invalid referral code", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error creating user: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -108,12 +118,16 @@ func (h *handler) LoginUser(tokenAuth *jwtauth.JWTAuth, w http.ResponseWriter, r
 		return
 	}
 
+	// Logins are audited with the status code telling whether they succeeded, failed ones against the user they tried
+	audit.SetAction(req, "login")
 	auxUser, err := h.UserRepo.GetUserByEmailForAuth(strings.ToLower(credentials.Email))
 	if err != nil {
 		log.Printf("Error getting user by email: %v", err)
 		http.Error(w, "Invalid username or password.", http.StatusUnauthorized)
 		return
 	}
+	audit.SetActor(req, fmt.Sprintf("user:%d", auxUser.GetID()))
+	audit.SetResource(req, "users", strconv.FormatInt(auxUser.GetID(), 10))
 	if !auxUser.CheckPassword(credentials.Password) {
 		http.Error(w, "Invalid username or password.", http.StatusUnauthorized)
 		return
@@ -160,7 +174,7 @@ func (h *handler) GetUserProfile(w http.ResponseWriter, req *http.Request) {
 		http.Error(w, "Error getting user", http.StatusBadRequest)
 		return
 	}
-	helpers.WriteJSON(w, http.StatusOK, user)
+	helpers.WriteJSONWithETag(w, req, http.StatusOK, user.Version, user)
 }
 
 // UpdateUserProfile ...
@@ -202,6 +216,9 @@ func (h *handler) UpdateUserProfile(w http.ResponseWriter, req *http.Request) {
 		http.Error(w, "Error getting user", http.StatusInternalServerError)
 		return
 	}
+	if !helpers.CheckIfMatch(w, req, user.Version) {
+		return
+	}
 
 	// Compare attributes to identify modifications
 	fieldsToUpdate, err := getFieldsToUpdate(&updateUserReq, user)
@@ -211,9 +228,9 @@ func (h *handler) UpdateUserProfile(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// Update user
-	result, err := h.UserRepo.UpdateUser(userId, fieldsToUpdate)
+	result, err := h.UserRepo.UpdateUser(userId, fieldsToUpdate, user.Version)
 	if err != nil {
-		http.Error(w, "Error updating user", http.StatusInternalServerError)
+		h.writeUpdateError(w, err)
 		return
 	}
 	updateUserResp := models.CreateUpdateUserResponse{
@@ -229,8 +246,9 @@ func (h *handler) UpdateUserProfile(w http.ResponseWriter, req *http.Request) {
 
 // ListAllUsers returns a list of all users
 func (h *handler) ListAllUsers(w http.ResponseWriter, req *http.Request) {
-	pageID := req.Context().Value(middlewares.PageIDKey)
-	users, err := h.UserRepo.ListAllUsers(pageID.(int64))
+	page := middlewares.PageFromContext(req.Context())
+	spec := middlewares.QuerySpecFromContext(req.Context())
+	users, err := h.UserRepo.ListAllUsers(page, spec)
 	if err != nil {
 		log.Printf("Error getting available users: %v", err)
 		http.Error(w, "Error getting available users", http.StatusInternalServerError)
@@ -238,13 +256,14 @@ func (h *handler) ListAllUsers(w http.ResponseWriter, req *http.Request) {
 	}
 
 	userListResp := models.UserList{
-		Items:      users.Items,
-		NextPageID: users.NextPageID,
+		Items: users.Items,
+		Page:  users.Page,
 	}
+	userListResp.Page.SetLinks(req.URL)
 	helpers.WriteJSON(w, http.StatusOK, userListResp)
 }
 
-// GetUserDetails retrieves a user from the database based on the user id
+// GetUserDetails retrieves a user from the database based on the user id with its ETag
 func (h *handler) GetUserDetails(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "user_id")
 	userId, err := strconv.ParseInt(userIDStr, 10, 64)
@@ -253,6 +272,59 @@ func (h *handler) GetUserDetails(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	user, err := h.UserRepo.GetUserByID(userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting user: %v", err)
+		http.Error(w, "Error getting user", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSONWithETag(w, req, http.StatusOK, user.Version, user)
+}
+
+// DeleteUser soft deletes a user. Users with an ongoing rental can't be deleted
+func (h *handler) DeleteUser(w http.ResponseWriter, req *http.Request) {
+	userIDStr := chi.URLParam(req, "user_id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read user %s: %v", userIDStr, err), http.StatusBadRequest)
+		return
+	}
+	if err := h.UserRepo.DeleteUser(userID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrUserRenting):
+			http.Error(w, "User has an ongoing rental", http.StatusConflict)
+		default:
+			log.Printf("Error deleting user: %v", err)
+			http.Error(w, "Error deleting user", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreUser restores a deleted user
+func (h *handler) RestoreUser(w http.ResponseWriter, req *http.Request) {
+	userIDStr := chi.URLParam(req, "user_id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read user %s: %v", userIDStr, err), http.StatusBadRequest)
+		return
+	}
+	if err := h.UserRepo.RestoreUser(userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Deleted user not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error restoring user: %v", err)
+		http.Error(w, "Error restoring user", http.StatusInternalServerError)
+		return
+	}
+	user, err := h.UserRepo.GetUserByID(userID)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
 		http.Error(w, "Error getting user", http.StatusInternalServerError)
@@ -293,10 +365,17 @@ func (h *handler) UpdateUserDetails(w http.ResponseWriter, req *http.Request) {
 
 	user, err := h.UserRepo.GetUserByID(userID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
 		log.Printf("Error getting user: %v", err)
 		http.Error(w, "Error getting user", http.StatusInternalServerError)
 		return
 	}
+	if !helpers.CheckIfMatch(w, req, user.Version) {
+		return
+	}
 
 	// Compare attributes to identify modifications
 	fieldsToUpdate, err := getFieldsToUpdate(updateUserReq, user)
@@ -305,10 +384,12 @@ func (h *handler) UpdateUserDetails(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// The profile values are erased from the audit log when the user is anonymized
+	audit.SetChanges(req, user, fieldsToUpdate)
 	// Update user
-	result, err := h.UserRepo.UpdateUser(userID, fieldsToUpdate)
+	result, err := h.UserRepo.UpdateUser(userID, fieldsToUpdate, user.Version)
 	if err != nil {
-		http.Error(w, "Error updating user", http.StatusInternalServerError)
+		h.writeUpdateError(w, err)
 		return
 	}
 	updateUserResp := models.CreateUpdateUserResponse{
@@ -322,6 +403,18 @@ func (h *handler) UpdateUserDetails(w http.ResponseWriter, req *http.Request) {
 // ------ Utils ---------------------------
 // ----------------------------------------
 
+// writeUpdateError writes the response of a failed update of a user
+func (h *handler) writeUpdateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrVersionConflict):
+		http.Error(w, "The user was changed since it was read", http.StatusPreconditionFailed)
+	default:
+		http.Error(w, "Error updating user", http.StatusInternalServerError)
+	}
+}
+
 // getFieldsToUpdate ...
 func getFieldsToUpdate(updateUserReq *models.UpdateUserRequest, user *models.User) (map[string]interface{}, error) {
 	// TODO move this to utils and add support for interface{} to be used across domains
@@ -3,7 +3,9 @@ package handlers
 import (
 	"bikesRentalAPI/internal/helpers"
 	"bikesRentalAPI/internal/users/models"
+	"bikesRentalAPI/internal/users/repository"
 	"bikesRentalAPI/internal/users/repository/mocks"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/assert"
@@ -204,6 +207,21 @@ func TestRegisterUser(t *testing.T) {
 			expectedHttpCode:    http.StatusInternalServerError,
 			expectedResponseMsg: "Error creating user",
 		},
+		{
+			name: "Failure - RegisterUser receives a request with an unknown referral code. Handler returns error 400",
+			createuserReq: models.CreateUserRequest{
+				Email:        testEmail,
+				Password:     testPsw,
+				FirstName:    "test",
+				LastName:     "test",
+				ReferralCode: "UNKNOWN1",
+			},
+			expectedRepoError:   nil,
+			mockCreateUser:      true,
+			mockedErrror:        repository.ErrInvalidReferralCode,
+			expectedHttpCode:    http.StatusBadRequest,
+			expectedResponseMsg: "Invalid referral code",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -344,3 +362,57 @@ func TestUpdateUserDetails(t *testing.T) {
 	// WHEN: the request is made
 	// THEN: the user details should be updated
 }
+
+func TestDeleteUser(t *testing.T) {
+	// GIVEN a mocked user repository
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockUsersRepo := mocks.NewMockUserRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		userID           string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name:             "Success - the user is deleted",
+			userID:           "2",
+			mockCalls:        func() { mockUsersRepo.EXPECT().DeleteUser(int64(2)).Return(nil) },
+			expectedHttpCode: http.StatusNoContent,
+		},
+		{
+			name:             "Failure - the user doesn't exist or is already deleted",
+			userID:           "2",
+			mockCalls:        func() { mockUsersRepo.EXPECT().DeleteUser(int64(2)).Return(sql.ErrNoRows) },
+			expectedHttpCode: http.StatusNotFound,
+		},
+		{
+			name:             "Failure - the user has an ongoing rental",
+			userID:           "2",
+			mockCalls:        func() { mockUsersRepo.EXPECT().DeleteUser(int64(2)).Return(repository.ErrUserRenting) },
+			expectedHttpCode: http.StatusConflict,
+		},
+		{
+			name:             "Failure - invalid user id",
+			userID:           "abc",
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockCalls()
+			// GIVEN a request to delete a user
+			req := httptest.NewRequest(http.MethodDelete, "/admin/users/"+tc.userID, nil)
+			rr := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Delete("/admin/users/{user_id}", New(mockUsersRepo).DeleteUser)
+			// WHEN the request is made
+			router.ServeHTTP(rr, req)
+			// THEN The expected status code should be returned
+			assert.Equal(t, tc.expectedHttpCode, rr.Code)
+		})
+	}
+}
@@ -40,6 +40,18 @@ func (m *MockHandler) EXPECT() *MockHandlerMockRecorder {
 	return m.recorder
 }
 
+// DeleteUser mocks base method.
+func (m *MockHandler) DeleteUser(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteUser", w, req)
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockHandlerMockRecorder) DeleteUser(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockHandler)(nil).DeleteUser), w, req)
+}
+
 // GetUserDetails mocks base method.
 func (m *MockHandler) GetUserDetails(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
@@ -100,6 +112,18 @@ func (mr *MockHandlerMockRecorder) RegisterUser(w, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockHandler)(nil).RegisterUser), w, req)
 }
 
+// RestoreUser mocks base method.
+func (m *MockHandler) RestoreUser(w http.ResponseWriter, req *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RestoreUser", w, req)
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockHandlerMockRecorder) RestoreUser(w, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockHandler)(nil).RestoreUser), w, req)
+}
+
 // UpdateUserDetails mocks base method.
 func (m *MockHandler) UpdateUserDetails(w http.ResponseWriter, req *http.Request) {
 	m.ctrl.T.Helper()
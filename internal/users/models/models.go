@@ -2,6 +2,7 @@ package models
 
 import (
 	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/pagination"
 	"fmt"
 	"time"
 )
@@ -13,8 +14,13 @@ type User struct {
 	HashedPassword string    `json:"hashed_password,omitempty"`
 	FirstName      *string   `json:"first_name,omitempty"`
 	LastName       *string   `json:"last_name,omitempty"`
+	ReferralCode   *string   `json:"referral_code,omitempty"`
 	CreatedAt      time.Time `json:"created_at,omitempty"`
 	UpdatedAt      time.Time `json:"updated_at,omitempty"`
+	// When the user was deleted, listed to admins with include_deleted=true
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// The version of the user, changed by every update. Sent as its ETag
+	Version int64 `json:"-"`
 } // @name User
 
 // FullName returns the full name of the user
@@ -83,6 +89,8 @@ type CreateUserRequest struct {
 	Password  string `json:"password" validate:"required"`
 	FirstName string `json:"first_name" validate:"required,max=50"`
 	LastName  string `json:"last_name" validate:"required,max=50"`
+	// The referral code of the user who referred the new user
+	ReferralCode string `json:"referral_code" validate:"omitempty,alphanum,max=20"`
 } // @name CreateUserRequest
 
 type UpdateUserRequest struct {
@@ -114,6 +122,6 @@ type Claims struct {
 type UserList struct {
 	// The list of users
 	Items []*User `json:"items"`
-	// The id to query the next page
-	NextPageID int64 `json:"next_page_id"`
+	// The pagination of the list
+	Page pagination.Info `json:"page"`
 } // @name UserList
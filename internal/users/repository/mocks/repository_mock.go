@@ -10,6 +10,8 @@
 package mocks
 
 import (
+	middlewares "bikesRentalAPI/internal/middlewares"
+	pagination "bikesRentalAPI/internal/pagination"
 	models "bikesRentalAPI/internal/users/models"
 	reflect "reflect"
 
@@ -54,6 +56,20 @@ func (mr *MockUserRepositoryMockRecorder) CreateUser(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), arg0)
 }
 
+// DeleteUser mocks base method.
+func (m *MockUserRepository) DeleteUser(userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserRepositoryMockRecorder) DeleteUser(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepository)(nil).DeleteUser), userID)
+}
+
 // GetUserByEmailForAuth mocks base method.
 func (m *MockUserRepository) GetUserByEmailForAuth(arg0 string) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -99,32 +115,61 @@ func (mr *MockUserRepositoryMockRecorder) IsEmailUnique(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmailUnique", reflect.TypeOf((*MockUserRepository)(nil).IsEmailUnique), arg0)
 }
 
+// IsUserActive mocks base method.
+func (m *MockUserRepository) IsUserActive(userID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserActive", userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserActive indicates an expected call of IsUserActive.
+func (mr *MockUserRepositoryMockRecorder) IsUserActive(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserActive", reflect.TypeOf((*MockUserRepository)(nil).IsUserActive), userID)
+}
+
 // ListAllUsers mocks base method.
-func (m *MockUserRepository) ListAllUsers(arg0 int64) (*models.UserList, error) {
+func (m *MockUserRepository) ListAllUsers(arg0 *pagination.Page, arg1 *middlewares.QuerySpec) (*models.UserList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAllUsers", arg0)
+	ret := m.ctrl.Call(m, "ListAllUsers", arg0, arg1)
 	ret0, _ := ret[0].(*models.UserList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListAllUsers indicates an expected call of ListAllUsers.
-func (mr *MockUserRepositoryMockRecorder) ListAllUsers(arg0 any) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) ListAllUsers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllUsers", reflect.TypeOf((*MockUserRepository)(nil).ListAllUsers), arg0, arg1)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserRepository) RestoreUser(userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserRepositoryMockRecorder) RestoreUser(userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllUsers", reflect.TypeOf((*MockUserRepository)(nil).ListAllUsers), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserRepository)(nil).RestoreUser), userID)
 }
 
 // UpdateUser mocks base method.
-func (m *MockUserRepository) UpdateUser(userID int64, fieldsToUpdate map[string]any) (int64, error) {
+func (m *MockUserRepository) UpdateUser(userID int64, fieldsToUpdate map[string]any, version int64) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", userID, fieldsToUpdate)
+	ret := m.ctrl.Call(m, "UpdateUser", userID, fieldsToUpdate, version)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserRepositoryMockRecorder) UpdateUser(userID, fieldsToUpdate any) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) UpdateUser(userID, fieldsToUpdate, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), userID, fieldsToUpdate)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), userID, fieldsToUpdate, version)
 }
@@ -3,23 +3,55 @@ package repository
 import (
 	"bikesRentalAPI/internal/database"
 	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/middlewares"
+	"bikesRentalAPI/internal/pagination"
 	"bikesRentalAPI/internal/users/models"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
-const (
-	// pageSize is the number of items to return in a page, 10 as default.
-	pageSize = 10
+// ListQuery is the whitelist of the filters and sort fields of the admin user list
+var ListQuery = middlewares.QueryWhitelist{
+	Filters: map[string]middlewares.FilterField{
+		"email":        {Column: "email", Type: middlewares.FieldString, Operator: middlewares.OpContains},
+		"first_name":   {Column: "first_name", Type: middlewares.FieldString, Operator: middlewares.OpContains},
+		"last_name":    {Column: "last_name", Type: middlewares.FieldString, Operator: middlewares.OpContains},
+		"created_from": {Column: "created_at", Type: middlewares.FieldTime, Operator: middlewares.OpGreaterOrEqual},
+		"created_to":   {Column: "created_at", Type: middlewares.FieldTime, Operator: middlewares.OpLessOrEqual},
+		"include_deleted": {
+			Conditions: map[string]string{"false": "deleted_at IS NULL", "true": ""},
+			Default:    "false",
+		},
+	},
+	Sorts: map[string]string{"id": "id", "email": "email", "created_at": "created_at"},
+}
+
+var (
+	// ErrInvalidReferralCode is returned when registering with a referral code no user has
+	ErrInvalidReferralCode = errors.New("invalid referral code")
+	// ErrUserRenting is returned when deleting a user with an ongoing rental
+	ErrUserRenting = errors.New("user has an ongoing rental")
 )
 
+// usersTable lists the columns of a user the users and admins can update
+var usersTable = database.NewTable("users", "email", "first_name", "last_name").Versioned().SoftDeleted()
+
 type UserRepository interface {
 	CreateUser(models.CreateUserRequest) (int64, error)
 	GetUserByEmailForAuth(string) (*models.User, error)
 	GetUserByID(int64) (*models.User, error)
-	UpdateUser(userID int64, fieldsToUpdate map[string]interface{}) (int64, error)
-	ListAllUsers(int64) (*models.UserList, error)
+	IsUserActive(userID int64) (bool, error)
+	UpdateUser(userID int64, fieldsToUpdate map[string]interface{}, version int64) (int64, error)
+	ListAllUsers(*pagination.Page, *middlewares.QuerySpec) (*models.UserList, error)
 	IsEmailUnique(string) (bool, error)
+	DeleteUser(userID int64) error
+	RestoreUser(userID int64) error
 }
 
 type userRepository struct {
@@ -31,27 +63,53 @@ func New(db database.Database) UserRepository {
 	return &userRepository{db}
 }
 
-// CreateUser inserts a new user into the database
+// CreateUser inserts a new user into the database with its own referral code.
+// The user who gave the referral code, if any, is recorded as the referrer
 func (r *userRepository) CreateUser(user models.CreateUserRequest) (int64, error) {
 	hashedPsw, err := helpers.GetHashPassword(user.Password)
 	if err != nil {
 		return 0, fmt.Errorf("failed to hash password: %v", err)
 	}
-	result, err := r.db.Exec("INSERT INTO users (email, hashed_password, first_name, last_name) VALUES (?, ?, ?, ?)", user.Email, hashedPsw, user.FirstName, user.LastName)
+	referralCode, err := generateReferralCode()
 	if err != nil {
-		return 0, fmt.Errorf("failed to insert user: %v", err)
+		return 0, fmt.Errorf("failed to generate referral code: %v", err)
 	}
-	id, err := result.LastInsertId()
+	var id int64
+	err = r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		var referredBy *int64
+		if user.ReferralCode != "" {
+			var referrerID int64
+			// Deleted and anonymized users can't refer anyone
+			err := tx.QueryRow("SELECT id FROM users WHERE referral_code = ? AND deleted_at IS NULL", strings.ToUpper(user.ReferralCode)).Scan(&referrerID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrInvalidReferralCode
+				}
+				return fmt.Errorf("failed to get referrer: %v", err)
+			}
+			referredBy = &referrerID
+		}
+		query := "INSERT INTO users (email, hashed_password, first_name, last_name, referral_code, referred_by) VALUES (?, ?, ?, ?, ?, ?)"
+		result, err := tx.Exec(query, user.Email, hashedPsw, user.FirstName, user.LastName, referralCode, referredBy)
+		if err != nil {
+			return fmt.Errorf("failed to insert user: %v", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to get last insert id: %v", err)
+		return 0, err
 	}
 	return id, nil
 }
 
-// GetUserByEmailForAuth retrieves a user from the database by email
+// GetUserByEmailForAuth retrieves a user from the database by email. Deleted users can't log in
 func (r *userRepository) GetUserByEmailForAuth(email string) (*models.User, error) {
 	var user models.User
-	query := "SELECT id, email, hashed_password, first_name, last_name FROM users WHERE email = ?"
+	query := "SELECT id, email, hashed_password, first_name, last_name FROM users WHERE email = ? AND deleted_at IS NULL"
 	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.HashedPassword, &user.FirstName, &user.LastName)
 	if err != nil {
 		return &user, err
@@ -69,51 +127,43 @@ func (r *userRepository) IsEmailUnique(email string) (bool, error) {
 	return count == 0, nil
 }
 
-// GetUserByID retrieves a user from the database by id
+// GetUserByID retrieves a user from the database by id. Deleted users are not found
 func (r *userRepository) GetUserByID(id int64) (*models.User, error) {
 	var user models.User
-	quwery := "SELECT id, email, first_name, last_name, created_at, updated_at FROM users WHERE id = ?"
-	err := r.db.QueryRow(quwery, id).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	quwery := "SELECT id, email, first_name, last_name, referral_code, created_at, updated_at, version FROM users WHERE id = ? AND deleted_at IS NULL"
+	err := r.db.QueryRow(quwery, id).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.ReferralCode, &user.CreatedAt, &user.UpdatedAt, &user.Version)
 	if err != nil {
 		return &user, err
 	}
 	return &user, nil
 }
 
-// UpdateUser updates a user in the database by id. Returns the id of the updated user. If no fields are updated, returns 0 and an error
-func (r *userRepository) UpdateUser(userID int64, fieldsToUpdate map[string]interface{}) (int64, error) {
-	var setFields []string
-	var args []interface{}
-
-	for field, value := range fieldsToUpdate {
-		setFields = append(setFields, fmt.Sprintf("%s = ?", field))
-		args = append(args, value)
-	}
-	args = append(args, userID)
-
-	query := fmt.Sprintf("UPDATE users SET %s WHERE id = ?", strings.Join(setFields, ", "))
-	stmt, err := r.db.Prepare(query)
-	if err != nil {
-		return 0, fmt.Errorf("failed to prepare update statement: %v", err)
-	}
-	defer stmt.Close()
-
-	result, err := stmt.Exec(args...)
+// IsUserActive tells whether a user exists and is not deleted
+func (r *userRepository) IsUserActive(userID int64) (bool, error) {
+	var active bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE id = ? AND deleted_at IS NULL)", userID).Scan(&active)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute update statement: %v", err)
+		return false, fmt.Errorf("failed to check user: %v", err)
 	}
+	return active, nil
+}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get last insert id: %v", err)
+// UpdateUser updates a user in the database by id if they are still at the version they were read at. Returns the id of the updated user.
+// If no fields are updated, returns 0 and an error, database.ErrVersionConflict when the user was changed since
+func (r *userRepository) UpdateUser(userID int64, fieldsToUpdate map[string]interface{}, version int64) (int64, error) {
+	if _, err := usersTable.UpdateVersion(r.db, userID, fieldsToUpdate, version); err != nil {
+		return 0, err
 	}
-	return id, nil
+	return userID, nil
 }
 
-// ListAllUsers retrieves all bikes from the database
-func (u *userRepository) ListAllUsers(PageID int64) (*models.UserList, error) {
-	query := "SELECT id, email, first_name, last_name, created_at, updated_at FROM bikes WHERE id > ? ORDER BY id LIMIT ?"
-	rows, err := u.db.Query(query, PageID, pageSize)
+// ListAllUsers retrieves the users matching the filters of the spec from the database, in its sort order
+func (u *userRepository) ListAllUsers(page *pagination.Page, spec *middlewares.QuerySpec) (*models.UserList, error) {
+	where, args := spec.Where()
+	keyset, keysetArgs, orderBy := page.Keyset(spec.SortColumn, spec.Descending)
+	query := fmt.Sprintf("SELECT id, email, first_name, last_name, created_at, updated_at, deleted_at, %s FROM users WHERE %s AND %s ORDER BY %s LIMIT ?",
+		pagination.SortKey(spec.SortColumn), where, keyset, orderBy)
+	rows, err := u.db.Query(query, append(append(args, keysetArgs...), page.FetchLimit())...)
 	if err != nil {
 		return nil, err
 	}
@@ -121,16 +171,68 @@ func (u *userRepository) ListAllUsers(PageID int64) (*models.UserList, error) {
 
 	users := &models.UserList{}
 	userList := make([]*models.User, 0)
+	pageRows := make([]pagination.Row, 0)
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		var row pagination.Row
+		if err := rows.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &row.Key); err != nil {
 			return nil, err
 		}
+		row.ID = user.ID
 		userList = append(userList, &user)
+		pageRows = append(pageRows, row)
 	}
-	if len(userList) == pageSize {
-		users.NextPageID = userList[len(userList)-1].ID
+	users.Items, users.Page = pagination.Paginate(page, userList, pageRows)
+	if page.WithTotal {
+		var total int64
+		if err := u.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM users WHERE %s", where), args...).Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to count users: %v", err)
+		}
+		users.Page.Total = &total
 	}
-	users.Items = userList
 	return users, nil
 }
+
+// DeleteUser soft deletes a user, who can no longer log in nor be listed. Users with an ongoing rental can't be deleted
+func (r *userRepository) DeleteUser(userID int64) error {
+	return r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		result, err := tx.Exec("UPDATE users SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL", now, now, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return sql.ErrNoRows
+		}
+		var renting bool
+		query := "SELECT EXISTS (SELECT 1 FROM rentals WHERE user_id = ? AND end_time IS NULL)"
+		if err := tx.QueryRow(query, userID).Scan(&renting); err != nil {
+			return fmt.Errorf("failed to check ongoing rentals: %v", err)
+		}
+		if renting {
+			return ErrUserRenting
+		}
+		return nil
+	})
+}
+
+// RestoreUser restores a deleted user. Anonymized users can't be restored
+func (r *userRepository) RestoreUser(userID int64) error {
+	result, err := r.db.Exec("UPDATE users SET deleted_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL", time.Now().UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// generateReferralCode returns a random 8 characters code to share with other users
+func generateReferralCode() (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(buf), nil
+}
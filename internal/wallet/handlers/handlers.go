@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/wallet/models"
+	"bikesRentalAPI/internal/wallet/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// defaultStatementLimit is the number of wallet movements returned when no limit is given
+	defaultStatementLimit = 50
+	// maxStatementLimit is the highest number of wallet movements returned at once
+	maxStatementLimit = 200
+)
+
+// Handler is the interface for wallet handlers
+type Handler interface {
+	GetWallet(w http.ResponseWriter, req *http.Request)        // Get the balance and statement of the user
+	TopUpWallet(w http.ResponseWriter, req *http.Request)      // Buy credit with the default payment method
+	GetUserWallet(w http.ResponseWriter, req *http.Request)    // Get the balance and statement of any user
+	AdjustUserWallet(w http.ResponseWriter, req *http.Request) // Credit or debit the wallet of any user
+}
+
+type handler struct {
+	WalletRepo repository.WalletRepository
+	validator  *validator.Validate
+}
+
+// New returns a new wallet handler
+func New(walletRepository repository.WalletRepository) Handler {
+	validator := validator.New(validator.WithRequiredStructEnabled())
+	handler := &handler{
+		WalletRepo: walletRepository,
+		validator:  validator,
+	}
+	return handler
+}
+
+// GetWallet returns the balance of the user and the latest movements of the wallet
+func (h *handler) GetWallet(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	h.writeWallet(w, req, userID)
+}
+
+// TopUpWallet charges the amount on the default payment method of the user and credits it to the wallet
+func (h *handler) TopUpWallet(w http.ResponseWriter, req *http.Request) {
+	userID, err := helpers.GetUserIDFromRequest(req)
+	if err != nil {
+		log.Printf("Error getting user id from jwt.claims: %v", err)
+		http.Error(w, "Error getting user id", http.StatusBadRequest)
+		return
+	}
+	var topUpReq models.TopUpRequest
+	if !h.parseRequest(w, req, &topUpReq) {
+		return
+	}
+	response, err := h.WalletRepo.TopUp(userID, helpers.ToMinorUnits(topUpReq.Amount))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPaymentMethodRequired):
+			http.Error(w, "A payment method is required to top up the wallet", http.StatusPaymentRequired)
+		case errors.Is(err, repository.ErrPaymentDeclined):
+			http.Error(w, "Top-up payment declined", http.StatusPaymentRequired)
+		default:
+			log.Printf("Error topping up wallet: %v", err)
+			http.Error(w, "Error topping up wallet", http.StatusInternalServerError)
+		}
+		return
+	}
+	helpers.WriteJSON(w, http.StatusCreated, response)
+}
+
+// -------------------------------
+// ------ Admin access only ------
+// -------------------------------
+
+// GetUserWallet returns the balance of a user and the latest movements of the wallet
+func (h *handler) GetUserWallet(w http.ResponseWriter, req *http.Request) {
+	userID, ok := parseUserID(w, req)
+	if !ok {
+		return
+	}
+	h.writeWallet(w, req, userID)
+}
+
+// AdjustUserWallet credits (positive amount) or debits (negative amount) the wallet of a user. A reason is required
+func (h *handler) AdjustUserWallet(w http.ResponseWriter, req *http.Request) {
+	userID, ok := parseUserID(w, req)
+	if !ok {
+		return
+	}
+	var adjustmentReq models.AdjustmentRequest
+	if !h.parseRequest(w, req, &adjustmentReq) {
+		return
+	}
+	amount := helpers.ToMinorUnits(adjustmentReq.Amount)
+	if amount == 0 {
+		http.Error(w, "Amount must be at least one cent", http.StatusBadRequest)
+		return
+	}
+	response, err := h.WalletRepo.Adjust(userID, amount, adjustmentReq.Reason, helpers.GetAdminFromRequest(req))
+	if err != nil {
+		log.Printf("Error adjusting wallet: %v", err)
+		http.Error(w, "Error adjusting wallet", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusCreated, response)
+}
+
+// writeWallet writes the wallet of a user with the number of movements given by the 'limit' query parameter
+func (h *handler) writeWallet(w http.ResponseWriter, req *http.Request, userID int64) {
+	limit := defaultStatementLimit
+	if limitStr := req.URL.Query().Get("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > maxStatementLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxStatementLimit), http.StatusBadRequest)
+			return
+		}
+		limit = value
+	}
+	wallet, err := h.WalletRepo.GetWallet(userID, limit)
+	if err != nil {
+		log.Printf("Error getting wallet: %v", err)
+		http.Error(w, "Error getting wallet", http.StatusInternalServerError)
+		return
+	}
+	helpers.WriteJSON(w, http.StatusOK, wallet)
+}
+
+// parseRequest reads and validates the body request. It writes the error response when it fails
+func (h *handler) parseRequest(w http.ResponseWriter, req *http.Request, dest interface{}) bool {
+	body, err := helpers.ParseBody(req.Body)
+	if err != nil {
+		http.Error(w, "Error parsing body request", http.StatusBadRequest)
+		return false
+	}
+	if err := json.Unmarshal(body, dest); err != nil {
+		http.Error(w, "Error unmarshalling body request", http.StatusBadRequest)
+		return false
+	}
+	if err := h.validator.Struct(dest); err != nil {
+		errors := err.(validator.ValidationErrors)
+		http.Error(w, fmt.Sprintf("Validation errors: %s", errors), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// parseUserID reads the 'user_id' URL parameter. It writes the error response when it fails
+func parseUserID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	userIDStr := chi.URLParam(req, "user_id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't read %s: %v", userIDStr, err), http.StatusBadRequest)
+		return 0, false
+	}
+	return userID, true
+}
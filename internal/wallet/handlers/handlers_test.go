@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bikesRentalAPI/internal/wallet/models"
+	"bikesRentalAPI/internal/wallet/repository"
+	"bikesRentalAPI/internal/wallet/repository/mocks"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// withUser returns the request authenticated as the user
+func withUser(t *testing.T, req *http.Request, userID string) *http.Request {
+	token, _, err := jwtauth.New("HS256", []byte("secret"), nil).Encode(map[string]interface{}{"sub": userID})
+	require.NoError(t, err)
+	return req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+}
+
+func TestTopUpWallet(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockWalletRepo := mocks.NewMockWalletRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		body             string
+		repoErr          error
+		expectedHttpCode int
+	}{
+		{name: "Success - the amount is credited in cents", body: `{"amount":10.25}`, expectedHttpCode: http.StatusCreated},
+		{name: "Failure - the amount is over the top-up limit", body: `{"amount":501}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the amount is negative", body: `{"amount":-5}`, expectedHttpCode: http.StatusBadRequest},
+		{name: "Failure - the rider has no payment method", body: `{"amount":10.25}`, repoErr: repository.ErrPaymentMethodRequired, expectedHttpCode: http.StatusPaymentRequired},
+		{name: "Failure - the payment is declined", body: `{"amount":10.25}`, repoErr: repository.ErrPaymentDeclined, expectedHttpCode: http.StatusPaymentRequired},
+		{name: "Failure - the top-up can't be stored", body: `{"amount":10.25}`, repoErr: errors.New("database is locked"), expectedHttpCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider topping up the wallet
+			if tc.expectedHttpCode != http.StatusBadRequest {
+				var response *models.WalletTransactionResponse
+				if tc.repoErr == nil {
+					response = &models.WalletTransactionResponse{TransactionID: 1, Balance: 10.25}
+				}
+				mockWalletRepo.EXPECT().TopUp(int64(2), int64(1025)).Return(response, tc.repoErr)
+			}
+			req := withUser(t, httptest.NewRequest(http.MethodPost, "/users/wallet/top-up", strings.NewReader(tc.body)), "2")
+			rec := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockWalletRepo).TopUpWallet(rec, req)
+			// THEN: the failures of the payment are told apart
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestGetWallet(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockWalletRepo := mocks.NewMockWalletRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		query            string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - the latest movements are returned by default",
+			mockCalls: func() {
+				mockWalletRepo.EXPECT().GetWallet(int64(2), defaultStatementLimit).Return(&models.Wallet{UserID: 2}, nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:  "Success - the number of movements is limited",
+			query: "?limit=5",
+			mockCalls: func() {
+				mockWalletRepo.EXPECT().GetWallet(int64(2), 5).Return(&models.Wallet{UserID: 2}, nil)
+			},
+			expectedHttpCode: http.StatusOK,
+		},
+		{
+			name:             "Failure - the limit is over the highest limit",
+			query:            "?limit=201",
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: a rider getting the wallet
+			tc.mockCalls()
+			req := withUser(t, httptest.NewRequest(http.MethodGet, "/users/wallet"+tc.query, nil), "2")
+			rec := httptest.NewRecorder()
+			// WHEN: the request is made
+			New(mockWalletRepo).GetWallet(rec, req)
+			// THEN: the statement is capped
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
+
+func TestAdjustUserWallet(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockWalletRepo := mocks.NewMockWalletRepository(mockCtrl)
+
+	testCases := []struct {
+		name             string
+		body             string
+		mockCalls        func()
+		expectedHttpCode int
+	}{
+		{
+			name: "Success - the debit is recorded by the admin",
+			body: `{"amount":-3.5,"reason":"Damaged bike"}`,
+			mockCalls: func() {
+				mockWalletRepo.EXPECT().Adjust(int64(2), int64(-350), "Damaged bike", "admin").
+					Return(&models.WalletTransactionResponse{TransactionID: 1, Balance: -3.5}, nil)
+			},
+			expectedHttpCode: http.StatusCreated,
+		},
+		{
+			name:             "Failure - the reason is required",
+			body:             `{"amount":5}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+		{
+			name:             "Failure - the amount rounds to zero cents",
+			body:             `{"amount":0.001,"reason":"Rounding"}`,
+			mockCalls:        func() {},
+			expectedHttpCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// GIVEN: an admin adjusting the wallet of user 2
+			tc.mockCalls()
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/2/wallet/adjustments", strings.NewReader(tc.body))
+			req.SetBasicAuth("admin", "password")
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Post("/admin/users/{user_id}/wallet/adjustments", New(mockWalletRepo).AdjustUserWallet)
+			// WHEN: the request is made
+			router.ServeHTTP(rec, req)
+			// THEN: adjustments need a reason and at least a cent
+			assert.Equal(t, tc.expectedHttpCode, rec.Code)
+		})
+	}
+}
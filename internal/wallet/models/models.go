@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// TransactionKind is the reason money moved between ledger accounts
+type TransactionKind string
+
+const (
+	// TransactionKindTopUp is credit bought by a rider with a payment method
+	TransactionKindTopUp TransactionKind = "top_up"
+	// TransactionKindRental is the cost of a rental debited from the wallet of a rider
+	TransactionKindRental TransactionKind = "rental"
+	// TransactionKindAdjustment is credit given or taken by an administrator, or promotional credit
+	TransactionKindAdjustment TransactionKind = "adjustment"
+)
+
+// System ledger accounts. Every wallet movement has its counterpart in one of them
+const (
+	// AccountCash holds the money received through the payment provider
+	AccountCash = "cash"
+	// AccountRevenue holds the rental costs paid with wallet credit
+	AccountRevenue = "revenue"
+	// AccountAdjustments holds the credit given or taken by administrators and the promotional credit
+	AccountAdjustments = "adjustments"
+)
+
+// Entry is the amount, in cents, moved in or out of an account by a transaction.
+// The entries of a transaction always sum to zero
+type Entry struct {
+	AccountCode string
+	Amount      int64
+}
+
+// Transaction is a balanced movement of money between ledger accounts
+type Transaction struct {
+	Kind      TransactionKind
+	UserID    int64
+	RentalID  *int64
+	Reference *string
+	Reason    *string
+	CreatedBy *string
+	Entries   []Entry
+}
+
+// StatementLine is a movement of the wallet of a user
+type StatementLine struct {
+	TransactionID int64           `json:"transaction_id"`
+	Kind          TransactionKind `json:"kind"`
+	Amount        float64         `json:"amount"`
+	BalanceAfter  float64         `json:"balance_after"`
+	RentalID      *int64          `json:"rental_id,omitempty"`
+	Reason        *string         `json:"reason,omitempty"`
+	CreatedBy     *string         `json:"created_by,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+} // @name StatementLine
+
+// Wallet contains the balance of a user and the latest movements of the wallet
+type Wallet struct {
+	UserID  int64   `json:"user_id"`
+	Balance float64 `json:"balance"`
+	// The latest movements, most recent first
+	Statement []*StatementLine `json:"statement"`
+} // @name Wallet
+
+// TopUpRequest contains the amount of credit bought with the default payment method
+type TopUpRequest struct {
+	Amount float64 `json:"amount" validate:"required,gt=0,lte=500"`
+} // @name TopUpRequest
+
+// AdjustmentRequest contains credit given (positive amount) or taken (negative amount) by an administrator
+type AdjustmentRequest struct {
+	Amount float64 `json:"amount" validate:"required,ne=0"`
+	Reason string  `json:"reason" validate:"required,max=255"`
+} // @name AdjustmentRequest
+
+// WalletTransactionResponse contains the ledger transaction recorded and the resulting balance
+type WalletTransactionResponse struct {
+	TransactionID int64   `json:"transaction_id"`
+	Balance       float64 `json:"balance"`
+	Message       string  `json:"message"`
+} // @name WalletTransactionResponse
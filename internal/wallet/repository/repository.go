@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/database"
+	"bikesRentalAPI/internal/helpers"
+	"bikesRentalAPI/internal/payments"
+	paymentsrepository "bikesRentalAPI/internal/payments/repository"
+	"bikesRentalAPI/internal/wallet/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var (
+	// ErrUnbalancedTransaction is returned when the entries of a transaction don't sum to zero
+	ErrUnbalancedTransaction = errors.New("unbalanced ledger transaction")
+	// ErrPaymentMethodRequired is returned when topping up without a default payment method
+	ErrPaymentMethodRequired = errors.New("payment method required")
+	// ErrPaymentDeclined is returned when the payment provider refuses to charge a top-up
+	ErrPaymentDeclined = errors.New("top-up payment declined")
+)
+
+type WalletRepository interface {
+	GetBalance(q database.Querier, userID int64) (int64, error)
+	GetWallet(userID int64, limit int) (*models.Wallet, error)
+	Post(q database.Querier, transaction *models.Transaction) (int64, error)
+	DebitRental(q database.Querier, userID int64, rentalID int64, amount int64) (int64, error)
+	GetRentalPaidAmount(q database.Querier, userID int64, rentalID int64) (int64, error)
+	RefundRental(q database.Querier, userID int64, rentalID int64, amount int64, reason string, createdBy string) (int64, error)
+	ChargeRental(q database.Querier, userID int64, rentalID int64, amount int64, reason string, createdBy string) (int64, error)
+	TopUp(userID int64, amount int64) (*models.WalletTransactionResponse, error)
+	Adjust(userID int64, amount int64, reason string, createdBy string) (*models.WalletTransactionResponse, error)
+	Credit(q database.Querier, userID int64, amount int64, reason string, createdBy string) (int64, error)
+}
+
+type walletRepository struct {
+	db          database.Database
+	paymentRepo paymentsrepository.PaymentRepository
+	provider    payments.PaymentProvider
+}
+
+// New initializes a new wallet repository. Top-ups are charged through the payment provider
+func New(db database.Database, paymentRepo paymentsrepository.PaymentRepository, provider payments.PaymentProvider) WalletRepository {
+	return &walletRepository{
+		db:          db,
+		paymentRepo: paymentRepo,
+		provider:    provider,
+	}
+}
+
+// walletAccountCode returns the code of the ledger account holding the wallet of a user
+func walletAccountCode(userID int64) string {
+	return fmt.Sprintf("wallet:%d", userID)
+}
+
+// GetBalance returns the balance of the wallet of a user in cents. q is either the database or an ongoing transaction
+func (r *walletRepository) GetBalance(q database.Querier, userID int64) (int64, error) {
+	query := `SELECT COALESCE(SUM(e.amount), 0) FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id WHERE a.code = ?`
+	var balance int64
+	if err := q.QueryRow(query, walletAccountCode(userID)).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to get wallet balance: %v", err)
+	}
+	return balance, nil
+}
+
+// GetWallet returns the balance of a user and the latest limit movements of the wallet
+func (r *walletRepository) GetWallet(userID int64, limit int) (*models.Wallet, error) {
+	balance, err := r.GetBalance(r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT t.id, t.kind, e.amount, t.rental_id, t.reason, t.created_by, t.created_at
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE a.code = ? ORDER BY e.id DESC LIMIT ?`
+	rows, err := r.db.Query(query, walletAccountCode(userID), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet statement: %v", err)
+	}
+	defer rows.Close()
+
+	wallet := &models.Wallet{
+		UserID:    userID,
+		Balance:   helpers.FromMinorUnits(balance),
+		Statement: make([]*models.StatementLine, 0),
+	}
+	// The statement is read backwards from the current balance
+	running := balance
+	for rows.Next() {
+		var line models.StatementLine
+		var amount int64
+		err := rows.Scan(&line.TransactionID, &line.Kind, &amount, &line.RentalID, &line.Reason, &line.CreatedBy, &line.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		line.Amount = helpers.FromMinorUnits(amount)
+		line.BalanceAfter = helpers.FromMinorUnits(running)
+		running -= amount
+		wallet.Statement = append(wallet.Statement, &line)
+	}
+	return wallet, rows.Err()
+}
+
+// Post records a balanced transaction in the ledger. q is either the database or an ongoing transaction
+func (r *walletRepository) Post(q database.Querier, transaction *models.Transaction) (int64, error) {
+	if err := validateEntries(transaction.Entries); err != nil {
+		return 0, err
+	}
+	accountIDs := make([]int64, len(transaction.Entries))
+	for i, entry := range transaction.Entries {
+		accountID, err := r.getAccountID(q, entry.AccountCode)
+		if err != nil {
+			return 0, err
+		}
+		accountIDs[i] = accountID
+	}
+
+	query := "INSERT INTO ledger_transactions (kind, user_id, rental_id, reference, reason, created_by) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := q.Exec(query, transaction.Kind, transaction.UserID, transaction.RentalID, transaction.Reference, transaction.Reason, transaction.CreatedBy)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert ledger transaction: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %v", err)
+	}
+	for i, entry := range transaction.Entries {
+		_, err := q.Exec("INSERT INTO ledger_entries (transaction_id, account_id, amount) VALUES (?, ?, ?)", id, accountIDs[i], entry.Amount)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert ledger entry: %v", err)
+		}
+	}
+	return id, nil
+}
+
+// DebitRental moves the cost of a rental, in cents, from the wallet of the user to the revenue account
+func (r *walletRepository) DebitRental(q database.Querier, userID int64, rentalID int64, amount int64) (int64, error) {
+	return r.Post(q, &models.Transaction{
+		Kind:     models.TransactionKindRental,
+		UserID:   userID,
+		RentalID: &rentalID,
+		Entries: []models.Entry{
+			{AccountCode: walletAccountCode(userID), Amount: -amount},
+			{AccountCode: models.AccountRevenue, Amount: amount},
+		},
+	})
+}
+
+// GetRentalPaidAmount returns the cost of a rental, in cents, paid with the wallet of the user minus what was already refunded
+func (r *walletRepository) GetRentalPaidAmount(q database.Querier, userID int64, rentalID int64) (int64, error) {
+	query := `SELECT COALESCE(SUM(e.amount), 0) FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE a.code = ? AND t.rental_id = ?`
+	var amount int64
+	if err := q.QueryRow(query, walletAccountCode(userID), rentalID).Scan(&amount); err != nil {
+		return 0, fmt.Errorf("failed to get rental wallet amount: %v", err)
+	}
+	return -amount, nil
+}
+
+// RefundRental gives back part of the cost of a rental, in cents, from the revenue account to the wallet of the user
+func (r *walletRepository) RefundRental(q database.Querier, userID int64, rentalID int64, amount int64, reason string, createdBy string) (int64, error) {
+	return r.Post(q, &models.Transaction{
+		Kind:      models.TransactionKindAdjustment,
+		UserID:    userID,
+		RentalID:  &rentalID,
+		Reason:    &reason,
+		CreatedBy: &createdBy,
+		Entries: []models.Entry{
+			{AccountCode: models.AccountRevenue, Amount: -amount},
+			{AccountCode: walletAccountCode(userID), Amount: amount},
+		},
+	})
+}
+
+// ChargeRental debits an increase of the cost of a rental, in cents, from the wallet of the user to the revenue account.
+// The balance goes negative when the wallet doesn't cover it
+func (r *walletRepository) ChargeRental(q database.Querier, userID int64, rentalID int64, amount int64, reason string, createdBy string) (int64, error) {
+	return r.Post(q, &models.Transaction{
+		Kind:      models.TransactionKindAdjustment,
+		UserID:    userID,
+		RentalID:  &rentalID,
+		Reason:    &reason,
+		CreatedBy: &createdBy,
+		Entries: []models.Entry{
+			{AccountCode: walletAccountCode(userID), Amount: -amount},
+			{AccountCode: models.AccountRevenue, Amount: amount},
+		},
+	})
+}
+
+// TopUp charges the amount, in cents, on the default payment method of the user and credits it to the wallet
+func (r *walletRepository) TopUp(userID int64, amount int64) (*models.WalletTransactionResponse, error) {
+	method, err := r.paymentRepo.GetDefaultPaymentMethod(userID)
+	if err != nil {
+		if errors.Is(err, paymentsrepository.ErrPaymentMethodNotFound) {
+			return nil, ErrPaymentMethodRequired
+		}
+		return nil, fmt.Errorf("failed to get payment method: %v", err)
+	}
+
+	ctx := context.Background()
+	charge := helpers.FromMinorUnits(amount)
+	reference, err := r.provider.Authorize(ctx, method.Token, charge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+	}
+	if err := r.provider.Capture(ctx, reference, charge); err != nil {
+		if voidErr := r.provider.Void(ctx, reference); voidErr != nil {
+			log.Printf("Error voiding top-up authorization %s: %v", reference, voidErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+	}
+
+	id, err := r.postInTransaction(ctx, &models.Transaction{
+		Kind:      models.TransactionKindTopUp,
+		UserID:    userID,
+		Reference: &reference,
+		Entries: []models.Entry{
+			{AccountCode: models.AccountCash, Amount: -amount},
+			{AccountCode: walletAccountCode(userID), Amount: amount},
+		},
+	})
+	if err != nil {
+		// The credit could not be recorded, give the money back
+		if refundErr := r.provider.Refund(ctx, reference, charge); refundErr != nil {
+			log.Printf("Error refunding top-up %s: %v", reference, refundErr)
+		}
+		return nil, err
+	}
+	return r.transactionResponse(userID, id, "Wallet topped up successfully")
+}
+
+// Adjust credits (positive amount) or debits (negative amount) the wallet of a user, in cents, on behalf of an administrator
+func (r *walletRepository) Adjust(userID int64, amount int64, reason string, createdBy string) (*models.WalletTransactionResponse, error) {
+	var id int64
+	err := r.db.Transaction(context.Background(), func(tx *sql.Tx) error {
+		var err error
+		id, err = r.Credit(tx, userID, amount, reason, createdBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.transactionResponse(userID, id, "Wallet adjusted successfully")
+}
+
+// Credit moves an amount, in cents, from the adjustments account to the wallet of a user, e.g. promotional credit.
+// A negative amount debits the wallet. q is either the database or an ongoing transaction
+func (r *walletRepository) Credit(q database.Querier, userID int64, amount int64, reason string, createdBy string) (int64, error) {
+	return r.Post(q, &models.Transaction{
+		Kind:      models.TransactionKindAdjustment,
+		UserID:    userID,
+		Reason:    &reason,
+		CreatedBy: &createdBy,
+		Entries: []models.Entry{
+			{AccountCode: models.AccountAdjustments, Amount: -amount},
+			{AccountCode: walletAccountCode(userID), Amount: amount},
+		},
+	})
+}
+
+// postInTransaction records a balanced transaction and its entries atomically
+func (r *walletRepository) postInTransaction(ctx context.Context, transaction *models.Transaction) (int64, error) {
+	var id int64
+	err := r.db.Transaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		id, err = r.Post(tx, transaction)
+		return err
+	})
+	return id, err
+}
+
+// transactionResponse returns the recorded transaction with the new balance of the user
+func (r *walletRepository) transactionResponse(userID int64, transactionID int64, message string) (*models.WalletTransactionResponse, error) {
+	balance, err := r.GetBalance(r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.WalletTransactionResponse{
+		TransactionID: transactionID,
+		Balance:       helpers.FromMinorUnits(balance),
+		Message:       message,
+	}, nil
+}
+
+// getAccountID returns the id of a ledger account. Wallet accounts are created the first time they are used
+func (r *walletRepository) getAccountID(q database.Querier, code string) (int64, error) {
+	var id int64
+	err := q.QueryRow("SELECT id FROM ledger_accounts WHERE code = ?", code).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get ledger account: %v", err)
+	}
+	var userID int64
+	if _, err := fmt.Sscanf(code, "wallet:%d", &userID); err != nil {
+		return 0, fmt.Errorf("unknown ledger account %q", code)
+	}
+	result, err := q.Exec("INSERT INTO ledger_accounts (code, kind, user_id) VALUES (?, 'wallet', ?)", code, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create wallet account: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// validateEntries checks a transaction moves money between at least two accounts and its entries sum to zero
+func validateEntries(entries []models.Entry) error {
+	if len(entries) < 2 {
+		return fmt.Errorf("%w: at least two entries are required", ErrUnbalancedTransaction)
+	}
+	var sum int64
+	for _, entry := range entries {
+		if entry.Amount == 0 {
+			return fmt.Errorf("%w: entries can't be zero", ErrUnbalancedTransaction)
+		}
+		sum += entry.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("%w: entries sum to %d", ErrUnbalancedTransaction, sum)
+	}
+	return nil
+}
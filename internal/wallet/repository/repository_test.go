@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"bikesRentalAPI/internal/wallet/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []models.Entry
+		wantErr bool
+	}{
+		{
+			name: "Success - entries sum to zero",
+			entries: []models.Entry{
+				{AccountCode: models.AccountCash, Amount: -500},
+				{AccountCode: walletAccountCode(1), Amount: 500},
+			},
+		},
+		{
+			name: "Success - a transaction can move money between more than two accounts",
+			entries: []models.Entry{
+				{AccountCode: walletAccountCode(1), Amount: -500},
+				{AccountCode: models.AccountRevenue, Amount: 300},
+				{AccountCode: models.AccountAdjustments, Amount: 200},
+			},
+		},
+		{
+			name: "Failure - entries don't sum to zero",
+			entries: []models.Entry{
+				{AccountCode: models.AccountCash, Amount: -500},
+				{AccountCode: walletAccountCode(1), Amount: 400},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Failure - a single entry can't be balanced",
+			entries: []models.Entry{{AccountCode: walletAccountCode(1), Amount: 500}},
+			wantErr: true,
+		},
+		{
+			name: "Failure - zero entries are rejected",
+			entries: []models.Entry{
+				{AccountCode: models.AccountCash, Amount: 0},
+				{AccountCode: walletAccountCode(1), Amount: 0},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN: the entries of a transaction
+			// WHEN: the entries are validated
+			err := validateEntries(tt.entries)
+			// THEN: only balanced transactions are accepted
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnbalancedTransaction)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}